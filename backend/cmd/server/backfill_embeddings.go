@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jjckrbbt/chimera/backend/internal/connections"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/rag"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// runBackfillEmbeddings embeds items that were ingested before their report type had embed_content,
+// or its title and body embeddings, configured, building the text from the report type's source
+// columns. It reads DATABASE_URL,
+// EMBEDDING_SERVICE_URL and EMBEDDING_NORMALIZE like the server. Progress is logged per batch; when
+// a run fails or is interrupted it prints the --after-id to resume from.
+func runBackfillEmbeddings(args []string) int {
+	flags := flag.NewFlagSet("backfill-embeddings", flag.ContinueOnError)
+	configDir := flags.String("config-dir", configDirFromEnv(), "directory holding ingestion configs")
+	reportType := flags.String("report-type", "", "report type whose item type and embed_content to use")
+	afterID := flags.Int64("after-id", 0, "resume after this item id, as printed by an earlier run")
+	batchSize := flags.Int("batch-size", processing.DefaultBackfillBatchSize, "items to read per batch")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *reportType == "" {
+		fmt.Fprintln(os.Stderr, "backfill-embeddings requires --report-type")
+		flags.Usage()
+		return 2
+	}
+	if *batchSize <= 0 {
+		fmt.Fprintln(os.Stderr, "--batch-size must be positive")
+		return 2
+	}
+	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	embeddingURL := strings.TrimSpace(os.Getenv("EMBEDDING_SERVICE_URL"))
+	if databaseURL == "" || embeddingURL == "" {
+		fmt.Fprintln(os.Stderr, "backfill-embeddings requires DATABASE_URL and EMBEDDING_SERVICE_URL")
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	configLoader, err := processing.NewConfigLoader(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load ingestion configs: %v\n", err)
+		return 1
+	}
+	ingestionConfig, found := configLoader.GetConfig(*reportType)
+	if !found {
+		fmt.Fprintf(os.Stderr, "No ingestion config found for report type %s\n", *reportType)
+		return 1
+	}
+	if ingestionConfig.EmbedContent == nil {
+		fmt.Fprintf(os.Stderr, "Report type %s has no embed_content configured\n", *reportType)
+		return 1
+	}
+
+	dbClient, err := connections.ConnectDB(databaseURL, logger.With("component", "database_connector"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer dbClient.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ragService := rag.NewRAGService(embeddingURL, os.Getenv("EMBEDDING_NORMALIZE") == "true", "", "", true, nil, logger)
+	result, err := processing.BackfillEmbeddings(ctx, repository.New(dbClient.Pool), ingestionConfig.EmbedContent, ragService.GetEmbedding, processing.BackfillOptions{
+		ItemType:  ingestionConfig.ItemType,
+		AfterID:   *afterID,
+		BatchSize: int32(*batchSize),
+	}, logger.With("report_type", *reportType))
+	fmt.Fprintf(os.Stderr, "Embedded %d items, skipped %d without text to embed\n", result.Embedded, result.Skipped)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Backfill stopped: %v\nResume with --after-id %d\n", err, result.LastID)
+		return 1
+	}
+	return 0
+}
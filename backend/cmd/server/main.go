@@ -8,13 +8,16 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/jjckrbbt/chimera/backend/internal/api"
+	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
 	"github.com/jjckrbbt/chimera/backend/internal/config"
 	"github.com/jjckrbbt/chimera/backend/internal/connections"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
 	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
 	"github.com/jjckrbbt/chimera/backend/internal/logger"
 	"github.com/jjckrbbt/chimera/backend/internal/processing"
@@ -51,6 +54,18 @@ func slogPanicRecoverMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
 }
 
 func main() {
+	// Subcommands run offline tools instead of the server.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate-configs":
+			os.Exit(runValidateConfigs(os.Args[2:], os.Stdout, os.Stderr))
+		case "process-file":
+			os.Exit(runProcessFile(os.Args[2:], os.Stdout, os.Stderr))
+		case "backfill-embeddings":
+			os.Exit(runBackfillEmbeddings(os.Args[2:]))
+		}
+	}
+
 	// 1. Load application configuration FIRST.
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -70,10 +85,10 @@ func main() {
 	defer sentry.Flush(2 * time.Second)
 
 	// 3. Initialize the Logger.
-	logger.InitLogger(cfg.AppEnv)
+	logger.InitLogger(cfg.AppEnv, cfg.LogLevel)
 	appLogger := logger.L() // Get the configured logger instance
 
-	appLogger.Info("Application starting up...", "environment", cfg.AppEnv)
+	appLogger.Info("Application starting up...", "environment", cfg.AppEnv, "log_level", logger.Level().String())
 
 	// 4. Connect to the Database.
 	dbClient, err := connections.ConnectDB(cfg.DatabaseURL, appLogger.With("component", "database_connector"))
@@ -85,26 +100,37 @@ func main() {
 	appLogger.Info("Database connection established.")
 
 	ctx := context.Background()
-	gcsClient, err := storage.NewClient(ctx)
-	if err != nil {
-		appLogger.Error("Failed to create GCS client on startup", slog.Any("error", err))
-		os.Exit(1)
+	var fileStore filestore.Store
+	if cfg.StorageBackend == filestore.BackendLocal {
+		fileStore, err = filestore.NewLocalStore(cfg.LocalStorageDir)
+		if err != nil {
+			appLogger.Error("Failed to create local file store on startup", slog.Any("error", err))
+			os.Exit(1)
+		}
+		appLogger.Info("Local file store initialized.", "dir", cfg.LocalStorageDir)
+	} else {
+		gcsClient, err := storage.NewClient(ctx)
+		if err != nil {
+			appLogger.Error("Failed to create GCS client on startup", slog.Any("error", err))
+			os.Exit(1)
+		}
+		fileStore = filestore.NewGCSStore(gcsClient, cfg.GCSBucketName)
+		appLogger.Info("GCS client initialized.")
 	}
-	appLogger.Info("GCS client initialized.")
 
 	// 5. Initialize Core Application Components.
 	platformQuerier := repository.New(dbClient.Pool)
 
 	apiLogger := appLogger.With("service", "api_handlers")
 
-	ingestionService, err := ingestion.NewService(platformQuerier, gcsClient, cfg, apiLogger)
+	ingestionService, err := ingestion.NewService(platformQuerier, fileStore, cfg, apiLogger)
 	if err != nil {
 		appLogger.Error("Failed to initialize ingestion service", slog.Any("error", err))
 		os.Exit(1)
 	}
 	appLogger.Info("Ingestion service initialized.")
 
-	configLoader, err := processing.NewConfigLoader("./backend/configs")
+	configLoader, err := processing.NewConfigLoader(cfg.ConfigDir)
 	if err != nil {
 		appLogger.Error("Failed to load configs", slog.Any("error", err))
 		os.Exit(1)
@@ -112,17 +138,33 @@ func main() {
 	appLogger.Info("catalyst Config Loader initialized.")
 
 	processorLogger := appLogger.With("service", "catalyst_data_processor")
-	processingService := processing.NewService(ingestionService, configLoader, platformQuerier, gcsClient, processorLogger, cfg, dbClient.Pool)
-	ragService := rag.NewRAGService(cfg.EMBEDDING_SERVICE_URL, cfg.AIAPIKey, cfg.LLMURL, apiLogger)
+	processingService := processing.NewService(ingestionService, configLoader, platformQuerier, fileStore, processorLogger, cfg, dbClient.Pool)
+	llmPrices, err := rag.LoadPriceTable(filepath.Join(cfg.ConfigDir, "llm", "pricing.yaml"))
+	if err != nil {
+		appLogger.Error("Failed to load LLM price table", slog.Any("error", err))
+		os.Exit(1)
+	}
+	ragService := rag.NewRAGService(cfg.EMBEDDING_SERVICE_URL, cfg.NormalizeEmbeddings, cfg.AIAPIKey, cfg.LLMURL, cfg.UseStubLLM, llmPrices, apiLogger)
 	appLogger.Info("Processing service initialized.")
+	if cfg.UseStubLLM {
+		appLogger.Warn("LLM stub mode is enabled; RAG responses are canned and no AI API calls will be made.")
+	}
 
 	fetcherRegistry := api.NewFetcherRegistry()
 
 	// Initialize your HTTP API handlers.
 
-	itemHandler := api.NewItemHandler(platformQuerier, dbClient.Pool, apiLogger, fetcherRegistry)
-	uploadHandler := api.NewUploadHandler(ingestionService, processingService, ragService, configLoader, apiLogger)
-	triageHandler := api.NewTriageHandler(dbClient.Pool, platformQuerier, apiLogger)
+	itemHandler := api.NewItemHandler(platformQuerier, dbClient.Pool, apiLogger, fetcherRegistry, cfg.DefaultItemStatus, configLoader, cfg.PageSizes)
+	ingestionPauses := ingestion.NewPauseList()
+	uploadHandler := api.NewUploadHandler(ingestionService, processingService, ragService, configLoader, ingestionPauses, cfg.PageSizes, apiLogger)
+	triageHandler := api.NewTriageHandler(dbClient.Pool, platformQuerier, cfg.PageSizes, apiLogger)
+	adminHandler := api.NewAdminHandler(configLoader, ingestionPauses, apiLogger)
+	searchHandler := api.NewSearchHandler(platformQuerier, ragService, configLoader, apiLogger)
+	insuranceHandler, err := api.NewInsuranceHandler(dbClient.Pool, insurance.New(dbClient.Pool), platformQuerier, cfg.ConfigDir, cfg.NormalizeEmbeddings, cfg.AIAPIKey, cfg.LLMURL, cfg.UseStubLLM, llmPrices, cfg.PageSizes, apiLogger)
+	if err != nil {
+		appLogger.Error("Failed to initialize insurance handler", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	appLogger.Info("API handlers initialized.")
 
@@ -148,15 +190,16 @@ func main() {
 	// --- Auth Middleware Setup ---
 	apiGroup := e.Group("/api")
 
-	if cfg.AppEnv == "development" {
-		appLogger.Warn("!!!!!!!!!! AUTHENTICATION MIDDLEWARE IS DISABLED IN DEVELOPMENT MODE !!!!!!!!!!")
+	if cfg.AuthDisabled() {
+		// LoadConfig only allows this branch when CHIMERA_ALLOW_INSECURE=true.
+		appLogger.Warn("!!!!!!!!!! AUTHENTICATION MIDDLEWARE IS DISABLED IN DEVELOPMENT MODE !!!!!!!!!!", "allow_insecure", cfg.AllowInsecure)
 		// This is our mock middleware for local development.
 		apiGroup.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 			return func(c echo.Context) error {
 				// Hardcode a user ID for development. User ID 1 is usually the first admin.
 				const devUserID int64 = 1
 				// Create a new context with the hardcoded user ID.
-				ctxWithUser := context.WithValue(c.Request().Context(), "userID", devUserID)
+				ctxWithUser := api.WithUserID(c.Request().Context(), devUserID)
 				// Set the new context on the request.
 				c.SetRequest(c.Request().WithContext(ctxWithUser))
 
@@ -173,7 +216,14 @@ func main() {
 			os.Exit(1)
 		}
 		//		apiGroup.Use(authMiddleware.ValidateRequest)
+		// Until the identity provider middleware is wired in, no request carries a user, so
+		// ItemScopeMiddleware gives every request the zero ItemScope and item reads fail closed.
+		appLogger.Warn("No identity provider middleware is wired in: item endpoints return no items until it is", "app_env", cfg.AppEnv)
 	}
+	// Item scopes are resolved once per request from the authenticated user, so every handler
+	// filters items the same way. Without an authenticated user the scope is empty and no items
+	// are visible.
+	apiGroup.Use(api.ItemScopeMiddleware(platformQuerier, apiLogger))
 	// --- End Auth Middleware Setup ---
 	// Request Logger Middleware (For consistent request logging)
 	// This logs basic request info using our slog instance.
@@ -224,7 +274,7 @@ func main() {
 		reqLogger := appLogger.With("request_id", c.Get("requestID")) // Retrieve request ID from context
 		reqLogger.InfoContext(c.Request().Context(), "Health check requested", "ip", c.RealIP())
 
-		if err := dbClient.Ping(); err != nil {
+		if err := dbClient.Ping(c.Request().Context()); err != nil {
 			reqLogger.ErrorContext(c.Request().Context(), "Database ping failed during health check", slog.Any("error", err))
 
 			sentry.CaptureException(err)
@@ -234,19 +284,41 @@ func main() {
 		return c.String(http.StatusOK, "OK") // Return string response for success
 	})
 
+	// Request timeouts cancel the request context and return 503. Uploads and RAG calls get
+	// the longer deadline; CRUD routes get the shorter one.
+	crudTimeout := api.RequestTimeout(cfg.RequestTimeout)
+	longTimeout := api.RequestTimeout(cfg.LongRequestTimeout)
+
 	//Upload group
-	apiGroup.POST("/upload/:reportType", uploadHandler.HandleUpload)
+	uploadRoutes := apiGroup.Group("/upload", longTimeout)
+	uploadRoutes.POST("/:reportType", uploadHandler.HandleUpload)
+	uploadRoutes.POST("/:reportType/preview", uploadHandler.HandlePreview)
+	uploadRoutes.POST("/:reportType/signed-url", uploadHandler.HandleCreateSignedUpload)
+	uploadRoutes.POST("/:reportType/register", uploadHandler.HandleRegisterUpload)
 
 	// Triage group
-	triageHandler.RegisterRoutes(apiGroup)
+	triageHandler.RegisterRoutes(apiGroup.Group("", crudTimeout))
+
+	// Admin group. Admin endpoints also require the admin permission.
+	requireAdmin := api.RequirePermission(platformQuerier, api.AdminPermission, apiLogger)
+	adminHandler.RegisterRoutes(apiGroup.Group("/admin", crudTimeout, requireAdmin))
+
+	// Search group
+	searchHandler.RegisterRoutes(apiGroup.Group("", crudTimeout))
+
+	// Insurance group
+	insuranceHandler.RegisterRoutes(apiGroup.Group("/insurance", crudTimeout))
+	insuranceHandler.RegisterQueryRoutes(apiGroup.Group("/insurance", longTimeout))
+	insuranceHandler.RegisterAdminRoutes(apiGroup.Group("/admin/insurance", longTimeout, requireAdmin))
 
 	//Items group
-	itemRoutes := apiGroup.Group("/items")
+	itemRoutes := apiGroup.Group("/items", crudTimeout)
 	itemRoutes.GET("", itemHandler.HandleGetItems)
-	itemRoutes.GET("/:id", itemHandler.HandleGetItems)
+	itemRoutes.GET("/:id", itemHandler.HandleGetItemByID)
 	itemRoutes.GET("/history/:id", itemHandler.HandleGetHistory)
 	itemRoutes.POST("", itemHandler.HandleCreateItem)
 	itemRoutes.PATCH("/:id", itemHandler.HandleUpdateItem)
+	itemRoutes.PUT("/:id", itemHandler.HandleReplaceItem)
 
 	//Dashbord group
 	//	apiGroup.GET("/dashboard", dashboardHandler.HandleGetDashboardStats)
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// offlineQuerier stands in for the database when processing a file locally. The items table is
+// treated as empty, so exists_in_items validation fails for every row.
+type offlineQuerier struct {
+	repository.Querier
+}
+
+func (offlineQuerier) ItemExistsByBusinessKey(ctx context.Context, arg repository.ItemExistsByBusinessKeyParams) (int32, error) {
+	return 0, nil
+}
+
+// noopEmbedder returns an empty embedding, so embed_content columns are read without calling the
+// embedding service.
+func noopEmbedder(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+// processedFileItem is the readable form of an item built from the file.
+type processedFileItem struct {
+	ItemType         string          `json:"item_type"`
+	Scope            string          `json:"scope"`
+	BusinessKey      string          `json:"business_key"`
+	Status           string          `json:"status"`
+	CustomProperties json.RawMessage `json:"custom_properties"`
+}
+
+type processedFileOutput struct {
+	ReportType         string                 `json:"report_type"`
+	Items              []processedFileItem    `json:"items"`
+	TriageRows         []processing.TriageRow `json:"triage_rows"`
+	BlankRowsDiscarded int                    `json:"blank_rows_discarded"`
+}
+
+// runProcessFile runs one file through a report type's ingestion config and prints the items and
+// triage rows it would produce as JSON, without touching the database, GCS or the embedding service.
+func runProcessFile(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("process-file", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configDir := flags.String("config-dir", configDirFromEnv(), "directory holding ingestion configs")
+	reportType := flags.String("report-type", "", "report type whose ingestion config to use")
+	filePath := flags.String("file", "", "CSV or JSONL file to process")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *reportType == "" || *filePath == "" {
+		fmt.Fprintln(stderr, "process-file requires --report-type and --file")
+		flags.Usage()
+		return 2
+	}
+
+	// Row-level logging goes to stderr so stdout stays valid JSON.
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	configLoader, err := processing.NewConfigLoader(*configDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load ingestion configs: %v\n", err)
+		return 1
+	}
+	ingestionConfig, found := configLoader.GetConfig(*reportType)
+	if !found {
+		fmt.Fprintf(stderr, "No ingestion config found for report type %s\n", *reportType)
+		return 1
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to open file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	result, err := processing.NewGenericProcessor(ingestionConfig).Process(context.Background(), file, offlineQuerier{}, noopEmbedder)
+	if err != nil {
+		fmt.Fprintf(stderr, "Processing failed: %v\n", err)
+		return 1
+	}
+
+	output := processedFileOutput{
+		ReportType:         *reportType,
+		Items:              make([]processedFileItem, 0, len(result.SuccessfulItems)),
+		TriageRows:         result.TriageRows,
+		BlankRowsDiscarded: result.BlankRowsDiscarded,
+	}
+	if output.TriageRows == nil {
+		output.TriageRows = []processing.TriageRow{}
+	}
+	for _, item := range result.SuccessfulItems {
+		output.Items = append(output.Items, processedFileItem{
+			ItemType:         string(item.ItemType),
+			Scope:            item.Scope.String,
+			BusinessKey:      item.BusinessKey.String,
+			Status:           string(item.Status),
+			CustomProperties: item.CustomProperties,
+		})
+	}
+
+	if err := writeJSON(stdout, output); err != nil {
+		fmt.Fprintf(stderr, "Failed to write result: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunProcessFile(t *testing.T) {
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runProcessFile(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	configDir := testConfigDir(t)
+	writeFile := func(t *testing.T, contents string) string {
+		path := filepath.Join(t.TempDir(), "policyholders.csv")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+		return path
+	}
+
+	t.Run("Prints the items and triage rows a file would produce", func(t *testing.T) {
+		file := writeFile(t, "PolicyHolder_ID,PolicyHolder_Name,State\nPH-1,Jane Doe,TX\nPH-2,John Roe,\n,,\n")
+		code, stdout, stderr := run("--config-dir", configDir, "--report-type", "TEST_POLICYHOLDERS", "--file", file)
+		require.Equal(t, 0, code, stderr)
+
+		var output processedFileOutput
+		require.NoError(t, json.Unmarshal([]byte(stdout), &output), "stdout is only the JSON result")
+		assert.Equal(t, "TEST_POLICYHOLDERS", output.ReportType)
+		require.Len(t, output.Items, 1)
+		item := output.Items[0]
+		assert.Equal(t, "POLICYHOLDER", item.ItemType)
+		assert.Equal(t, "TX", item.Scope)
+		assert.Equal(t, "PH-1", item.BusinessKey)
+		assert.JSONEq(t, `"Jane Doe"`, string(mustField(t, item.CustomProperties, "PolicyHolder_Name")))
+		require.Len(t, output.TriageRows, 1)
+		assert.Contains(t, output.TriageRows[0].FailureReason, "State")
+		assert.Equal(t, 1, output.BlankRowsDiscarded)
+	})
+
+	t.Run("Prints empty lists rather than null", func(t *testing.T) {
+		file := writeFile(t, "PolicyHolder_ID,PolicyHolder_Name,State\nPH-1,Jane Doe,TX\n")
+		code, stdout, stderr := run("--config-dir", configDir, "--report-type", "TEST_POLICYHOLDERS", "--file", file)
+		require.Equal(t, 0, code, stderr)
+		assert.Contains(t, stdout, `"triage_rows": []`)
+	})
+
+	t.Run("Requires a report type and a file", func(t *testing.T) {
+		code, stdout, stderr := run("--config-dir", configDir, "--report-type", "TEST_POLICYHOLDERS")
+		assert.Equal(t, 2, code)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "process-file requires --report-type and --file")
+		assert.Contains(t, stderr, "-report-type string", "the usage is printed")
+	})
+
+	t.Run("Rejects unknown flags", func(t *testing.T) {
+		code, _, stderr := run("--dry-run")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "flag provided but not defined: -dry-run")
+	})
+
+	t.Run("Fails on an unknown report type", func(t *testing.T) {
+		code, stdout, stderr := run("--config-dir", configDir, "--report-type", "NOPE", "--file", writeFile(t, "a\n1\n"))
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "No ingestion config found for report type NOPE")
+	})
+
+	t.Run("Fails on a missing file", func(t *testing.T) {
+		code, _, stderr := run("--config-dir", configDir, "--report-type", "TEST_POLICYHOLDERS", "--file", filepath.Join(t.TempDir(), "missing.csv"))
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "Failed to open file: ")
+	})
+
+	t.Run("Fails when the file can't be processed", func(t *testing.T) {
+		code, stdout, stderr := run("--config-dir", configDir, "--report-type", "TEST_POLICYHOLDERS", "--file", writeFile(t, "PolicyHolder_ID\nPH-1\n"))
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "Processing failed: ")
+	})
+}
+
+// mustField returns one field of a JSON object.
+func mustField(t *testing.T, object json.RawMessage, field string) json.RawMessage {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(object, &fields))
+	require.Contains(t, fields, field)
+	return fields[field]
+}
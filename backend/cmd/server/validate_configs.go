@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/jjckrbbt/chimera/backend/internal/api"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/rag"
+)
+
+// defaultConfigDir matches config.LoadConfig's default, which the subcommands can't call because it
+// requires the database and identity provider settings.
+const defaultConfigDir = "./backend/configs"
+
+// runValidateConfigs loads and validates the ingestion configs, LLM price table, page sizes and app
+// prompt templates under the config directory without connecting to the database or GCS. It prints every
+// failure to stderr and returns the process exit code, so CI can gate deploys on it.
+func runValidateConfigs(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate-configs", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configDir := flags.String("config-dir", configDirFromEnv(), "directory holding ingestion configs and prompt templates")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// The loaders log each file they read; only the result matters here.
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"ingestion configs", func() error {
+			_, err := processing.NewConfigLoader(*configDir)
+			return err
+		}},
+		{"LLM price table", func() error {
+			_, err := rag.LoadPriceTable(filepath.Join(*configDir, "llm", "pricing.yaml"))
+			return err
+		}},
+		{"page sizes", func() error {
+			_, err := config.LoadPageSizes(filepath.Join(*configDir, "page_sizes.yaml"))
+			return err
+		}},
+		{"insurance app config", func() error {
+			return api.ValidateInsuranceConfig(*configDir)
+		}},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if err := check.run(); err != nil {
+			failed++
+			fmt.Fprintf(stderr, "FAIL %s: %v\n", check.name, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "ok   %s\n", check.name)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stderr, "%d of %d config checks failed in %s\n", failed, len(checks), *configDir)
+		return 1
+	}
+	fmt.Fprintf(stdout, "All configs in %s are valid\n", *configDir)
+	return 0
+}
+
+// configDirFromEnv returns CONFIG_DIR, or the repository default when it isn't set.
+func configDirFromEnv() string {
+	if dir := os.Getenv("CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return defaultConfigDir
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repoConfigDir is the repository's config directory, relative to this package.
+const repoConfigDir = "../../configs"
+
+const testIngestionConfig = `
+report_type: "TEST_POLICYHOLDERS"
+item_type: "POLICYHOLDER"
+scope_field: "State"
+business_key:
+  - "PolicyHolder_ID"
+column_mappings:
+  - csv_header: "PolicyHolder_ID"
+    json_field: "PolicyHolder_ID"
+    validation:
+      required: true
+  - csv_header: "PolicyHolder_Name"
+    json_field: "PolicyHolder_Name"
+    validation:
+      required: true
+  - csv_header: "State"
+    json_field: "State"
+    validation:
+      required: true
+`
+
+// testConfigDir copies the repository's configs with the ingestion configs swapped for
+// testIngestionConfig, so the tests don't depend on the shipped report types.
+func testConfigDir(t *testing.T) string {
+	t.Helper()
+	configDir := t.TempDir()
+	require.NoError(t, os.CopyFS(configDir, os.DirFS(repoConfigDir)))
+	ingestionDir := filepath.Join(configDir, "apps", "insurance", "ingestion")
+	require.NoError(t, os.RemoveAll(ingestionDir))
+	require.NoError(t, os.MkdirAll(ingestionDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(ingestionDir, "policyholders.yaml"), []byte(testIngestionConfig), 0o644))
+	return configDir
+}
+
+func TestRunValidateConfigs(t *testing.T) {
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runValidateConfigs(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("Passes valid configs", func(t *testing.T) {
+		configDir := testConfigDir(t)
+		code, stdout, stderr := run("--config-dir", configDir)
+		assert.Equal(t, 0, code, stderr)
+		assert.Contains(t, stdout, "ok   ingestion configs")
+		assert.Contains(t, stdout, "ok   insurance app config")
+		assert.Contains(t, stdout, "All configs in "+configDir+" are valid")
+	})
+
+	t.Run("Reports each failing check and runs the rest", func(t *testing.T) {
+		configDir := testConfigDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "apps", "insurance", "ingestion", "broken.yaml"), []byte("report_type: BROKEN\n"), 0o644))
+		require.NoError(t, os.Remove(filepath.Join(configDir, "llm", "pricing.yaml")))
+
+		code, stdout, stderr := run("--config-dir", configDir)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "FAIL ingestion configs: ")
+		assert.Contains(t, stderr, "FAIL LLM price table: ")
+		assert.Contains(t, stderr, "2 of 4 config checks failed in "+configDir)
+		assert.Contains(t, stdout, "ok   page sizes")
+		assert.NotContains(t, stdout, "are valid")
+	})
+
+	t.Run("Reads the config directory from CONFIG_DIR", func(t *testing.T) {
+		configDir := testConfigDir(t)
+		t.Setenv("CONFIG_DIR", configDir)
+		code, stdout, stderr := run()
+		assert.Equal(t, 0, code, stderr)
+		assert.Contains(t, stdout, "All configs in "+configDir+" are valid")
+	})
+
+	t.Run("Rejects unknown flags", func(t *testing.T) {
+		code, _, stderr := run("--config", testConfigDir(t))
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "flag provided but not defined: -config")
+	})
+}
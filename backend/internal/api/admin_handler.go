@@ -0,0 +1,149 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
+	"github.com/jjckrbbt/chimera/backend/internal/logger"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminHandler exposes operational endpoints for administering a running server.
+type AdminHandler struct {
+	configLoader *processing.ConfigLoader
+	pauses       *ingestion.PauseList
+	logger       *slog.Logger
+}
+
+// NewAdminHandler creates a new instance of the AdminHandler.
+func NewAdminHandler(cl *processing.ConfigLoader, pauses *ingestion.PauseList, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		configLoader: cl,
+		pauses:       pauses,
+		logger:       logger.With("component", "admin_handler"),
+	}
+}
+
+// LogLevelRequest defines the structure for changing the log level at runtime.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResponse reports the current log level.
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// ConfigSummary describes a single loaded ingestion configuration.
+type ConfigSummary struct {
+	ReportType string `json:"report_type"`
+	ItemType   string `json:"item_type"`
+	Format     string `json:"format"`
+}
+
+// ConfigStatusResponse reports which ingestion configurations the server has loaded.
+type ConfigStatusResponse struct {
+	Count    int             `json:"count"`
+	Configs  []ConfigSummary `json:"configs"`
+	LoadedAt time.Time       `json:"loaded_at"`
+}
+
+// RegisterRoutes registers the admin endpoints on the given group.
+func (h *AdminHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/log-level", h.HandleGetLogLevel)
+	g.PUT("/log-level", h.HandleSetLogLevel)
+	g.GET("/configs/status", h.HandleGetConfigStatus)
+	g.GET("/ingestion/paused", h.HandleListPausedReportTypes)
+	g.PUT("/ingestion/paused/:reportType", h.HandlePauseReportType)
+	g.DELETE("/ingestion/paused/:reportType", h.HandleResumeReportType)
+}
+
+// PauseReportTypeRequest is the optional body of a pause request.
+type PauseReportTypeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// HandleListPausedReportTypes lists the report types whose uploads are currently refused.
+func (h *AdminHandler) HandleListPausedReportTypes(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.pauses.List())
+}
+
+// HandlePauseReportType stops accepting uploads for a report type, e.g. while its feed is broken.
+// Jobs that are already processing are not affected.
+func (h *AdminHandler) HandlePauseReportType(c echo.Context) error {
+	ctx := c.Request().Context()
+	reportType := c.Param("reportType")
+	if _, found := h.configLoader.GetConfig(reportType); !found {
+		return echo.NewHTTPError(http.StatusNotFound, "Unknown report type")
+	}
+	var req PauseReportTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	userID, _ := UserIDFromContext(ctx)
+	paused := h.pauses.Pause(reportType, req.Reason, userID)
+	h.logger.WarnContext(ctx, "Ingestion paused for report type", "reportType", reportType, "reason", req.Reason, "paused_by", userID)
+	return c.JSON(http.StatusOK, paused)
+}
+
+// HandleResumeReportType accepts uploads for a paused report type again.
+func (h *AdminHandler) HandleResumeReportType(c echo.Context) error {
+	ctx := c.Request().Context()
+	reportType := c.Param("reportType")
+	if !h.pauses.Resume(reportType) {
+		return echo.NewHTTPError(http.StatusNotFound, "Report type is not paused")
+	}
+	h.logger.WarnContext(ctx, "Ingestion resumed for report type", "reportType", reportType)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandleGetConfigStatus lists the loaded ingestion configurations and when they were loaded,
+// so operators can confirm a config deploy took effect.
+func (h *AdminHandler) HandleGetConfigStatus(c echo.Context) error {
+	reportTypes := h.configLoader.ReportTypes()
+	resp := ConfigStatusResponse{
+		Count:    len(reportTypes),
+		Configs:  make([]ConfigSummary, 0, len(reportTypes)),
+		LoadedAt: h.configLoader.LoadedAt(),
+	}
+	for _, reportType := range reportTypes {
+		config, _ := h.configLoader.GetConfig(reportType)
+		format := config.Format
+		if format == "" {
+			format = processing.FormatCSV
+		}
+		resp.Configs = append(resp.Configs, ConfigSummary{
+			ReportType: config.ReportType,
+			ItemType:   config.ItemType,
+			Format:     format,
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// HandleGetLogLevel returns the current minimum log level.
+func (h *AdminHandler) HandleGetLogLevel(c echo.Context) error {
+	return c.JSON(http.StatusOK, LogLevelResponse{Level: logger.Level().String()})
+}
+
+// HandleSetLogLevel changes the minimum log level without restarting the server.
+func (h *AdminHandler) HandleSetLogLevel(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req LogLevelRequest
+	if err := c.Bind(&req); err != nil || req.Level == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: level is required")
+	}
+
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		h.logger.WarnContext(ctx, "Rejected log level change", "requested_level", req.Level, "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	h.logger.WarnContext(ctx, "Log level changed at runtime", "previous_level", previous.String(), "new_level", logger.Level().String())
+	return c.JSON(http.StatusOK, LogLevelResponse{Level: logger.Level().String()})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePauseAndResumeReportType(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	configDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "ingestion"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "ingestion", "claims.yaml"), []byte(uploadFlowConfig), 0o644))
+	configLoader, err := processing.NewConfigLoader(configDir)
+	require.NoError(t, err)
+	pauses := ingestion.NewPauseList()
+	handler := NewAdminHandler(configLoader, pauses, logger)
+	uploads := NewUploadHandler(nil, nil, nil, configLoader, pauses, nil, logger)
+
+	call := func(t *testing.T, method, reportType, body string, handle func(echo.Context) error) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(method, "/admin/ingestion/paused/"+reportType, strings.NewReader(body)).WithContext(WithUserID(context.Background(), 7))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("reportType")
+		c.SetParamValues(reportType)
+		return rec, handle(c)
+	}
+	pause := func(t *testing.T, reportType, body string) (*httptest.ResponseRecorder, error) {
+		return call(t, http.MethodPut, reportType, body, handler.HandlePauseReportType)
+	}
+	resume := func(t *testing.T, reportType string) (*httptest.ResponseRecorder, error) {
+		return call(t, http.MethodDelete, reportType, "", handler.HandleResumeReportType)
+	}
+	listPaused := func(t *testing.T) []ingestion.PausedReportType {
+		rec, err := call(t, http.MethodGet, "", "", handler.HandleListPausedReportTypes)
+		require.NoError(t, err)
+		var paused []ingestion.PausedReportType
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &paused))
+		return paused
+	}
+	assertStatus := func(t *testing.T, err error, status int) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, status, httpErr.Code, httpErr.Message)
+	}
+
+	t.Run("Pauses a report type and refuses its uploads", func(t *testing.T) {
+		rec, err := pause(t, uploadFlowReportType, `{"reason": "feed is broken"}`)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var paused ingestion.PausedReportType
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &paused))
+		assert.Equal(t, uploadFlowReportType, paused.ReportType)
+		assert.Equal(t, "feed is broken", paused.Reason)
+		assert.EqualValues(t, 7, paused.PausedBy)
+
+		err = uploads.checkNotPaused(context.Background(), uploadFlowReportType)
+		assertStatus(t, err, http.StatusServiceUnavailable)
+		assert.Contains(t, err.(*echo.HTTPError).Message, "feed is broken")
+	})
+
+	t.Run("Pausing twice keeps one entry with the latest reason", func(t *testing.T) {
+		_, err := pause(t, uploadFlowReportType, `{"reason": "still broken"}`)
+		require.NoError(t, err)
+		paused := listPaused(t)
+		require.Len(t, paused, 1)
+		assert.Equal(t, "still broken", paused[0].Reason)
+	})
+
+	t.Run("Rejects an unknown report type", func(t *testing.T) {
+		_, err := pause(t, "NOT_CONFIGURED", "")
+		assertStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("Resumes a paused report type", func(t *testing.T) {
+		rec, err := resume(t, uploadFlowReportType)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, listPaused(t))
+		assert.NoError(t, uploads.checkNotPaused(context.Background(), uploadFlowReportType))
+	})
+
+	t.Run("Rejects resuming a report type that isn't paused", func(t *testing.T) {
+		_, err := resume(t, uploadFlowReportType)
+		assertStatus(t, err, http.StatusNotFound)
+	})
+}
@@ -2,7 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
 )
 
 // This middleware is a placeholder to be replaced with an actual OIDC/JWT implementation.
@@ -26,3 +32,53 @@ func NewAuthMiddleware() func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// contextKey is the type of this package's request context keys. Being unexported, it can't
+// collide with keys set by other packages.
+type contextKey int
+
+const (
+	userIDContextKey contextKey = iota
+	itemScopeContextKey
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user's ID. The auth middleware sets it.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user's ID set by the auth middleware, if any.
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDContextKey).(int64)
+	return userID, ok
+}
+
+// AdminPermission gates the /admin endpoints: runtime log level, config status, ingestion pauses,
+// conversation replay and template reloads.
+const AdminPermission = "admin:access"
+
+// RequirePermission only lets a request through when the authenticated user is an admin or holds
+// permission through one of their roles. Requests without a user get 401, and users without the
+// permission get 403. It must run after the auth middleware.
+func RequirePermission(q repository.Querier, permission string, logger *slog.Logger) echo.MiddlewareFunc {
+	logger = logger.With("component", "permission_middleware", "permission", permission)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			userID, ok := UserIDFromContext(ctx)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+			}
+			allowed, err := q.UserHasPermission(ctx, repository.UserHasPermissionParams{Action: permission, UserID: userID})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				logger.ErrorContext(ctx, "Failed to check user permission", "error", err, "user_id", userID)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve user permissions")
+			}
+			if !allowed {
+				logger.WarnContext(ctx, "Rejected request without the required permission", "user_id", userID, "path", c.Path())
+				return echo.NewHTTPError(http.StatusForbidden, "This endpoint requires the "+permission+" permission")
+			}
+			return next(c)
+		}
+	}
+}
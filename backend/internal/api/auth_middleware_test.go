@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// permissionQuerier answers permission checks; other Querier methods are not expected to be called.
+type permissionQuerier struct {
+	repository.Querier
+	allowed bool
+	err     error
+	got     repository.UserHasPermissionParams
+}
+
+func (q *permissionQuerier) UserHasPermission(ctx context.Context, arg repository.UserHasPermissionParams) (bool, error) {
+	q.got = arg
+	return q.allowed, q.err
+}
+
+func TestRequirePermission(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	run := func(t *testing.T, q *permissionQuerier, ctx context.Context) (bool, error) {
+		called := false
+		handler := RequirePermission(q, AdminPermission, logger)(func(c echo.Context) error {
+			called = true
+			return c.NoContent(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodPut, "/admin/log-level", nil).WithContext(ctx)
+		err := handler(echo.New().NewContext(req, httptest.NewRecorder()))
+		return called, err
+	}
+	withUser := WithUserID(context.Background(), 7)
+	assertStatus := func(t *testing.T, err error, status int) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, status, httpErr.Code)
+	}
+
+	t.Run("Lets holders of the permission through", func(t *testing.T) {
+		q := &permissionQuerier{allowed: true}
+		called, err := run(t, q, withUser)
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, repository.UserHasPermissionParams{Action: AdminPermission, UserID: 7}, q.got)
+	})
+
+	t.Run("Rejects users without the permission with 403", func(t *testing.T) {
+		called, err := run(t, &permissionQuerier{allowed: false}, withUser)
+		assertStatus(t, err, http.StatusForbidden)
+		assert.False(t, called)
+	})
+
+	t.Run("Rejects unknown users with 403", func(t *testing.T) {
+		called, err := run(t, &permissionQuerier{err: pgx.ErrNoRows}, withUser)
+		assertStatus(t, err, http.StatusForbidden)
+		assert.False(t, called)
+	})
+
+	t.Run("Rejects requests without an authenticated user with 401", func(t *testing.T) {
+		called, err := run(t, &permissionQuerier{allowed: true}, context.Background())
+		assertStatus(t, err, http.StatusUnauthorized)
+		assert.False(t, called)
+	})
+
+	t.Run("Fails when the permission cannot be checked", func(t *testing.T) {
+		_, err := run(t, &permissionQuerier{err: errors.New("connection reset")}, withUser)
+		assertStatus(t, err, http.StatusInternalServerError)
+	})
+}
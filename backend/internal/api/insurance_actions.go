@@ -0,0 +1,171 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
+)
+
+// Action types the insurance synthesizer may emit and the frontend knows how to render.
+const (
+	// ActionTextResponse shows its string payload as the assistant's answer.
+	ActionTextResponse = "text_response"
+	// ActionRenderTable shows the retrieved claims in the claims table. The synthesizer's payload is
+	// true; the handler replaces it with a RenderTablePayload.
+	ActionRenderTable = "render_table"
+	// ActionOpenDetailDrawer opens the single retrieved claim in the detail drawer; its payload is true.
+	ActionOpenDetailDrawer = "open_detail_drawer"
+	// ActionClarify asks the user its string payload instead of answering.
+	ActionClarify = "clarify"
+)
+
+// validateActions checks the synthesizer's actions against the action schema, so the frontend only
+// receives action types it can render. A well-formed action is kept as is. An unknown type with a
+// text payload is coerced to a text_response; any other malformed action is dropped. Each coerced
+// or dropped action is described in the returned diagnostics. A render_table or open_detail_drawer
+// with a false payload is a valid decision not to show anything and is dropped silently.
+func validateActions(actions []ActionPlan) ([]ActionPlan, []string) {
+	valid := make([]ActionPlan, 0, len(actions))
+	var diagnostics []string
+	for i, action := range actions {
+		switch action.Type {
+		case ActionTextResponse, ActionClarify:
+			text, ok := action.Payload.(string)
+			if !ok || strings.TrimSpace(text) == "" {
+				diagnostics = append(diagnostics, fmt.Sprintf("action %d (%s): payload must be a non-empty string, got %s", i, action.Type, describePayload(action.Payload)))
+				continue
+			}
+			valid = append(valid, ActionPlan{Type: action.Type, Payload: strings.TrimSpace(text)})
+		case ActionRenderTable, ActionOpenDetailDrawer:
+			wanted, ok := action.Payload.(bool)
+			if !ok {
+				diagnostics = append(diagnostics, fmt.Sprintf("action %d (%s): payload must be a boolean, got %s", i, action.Type, describePayload(action.Payload)))
+				continue
+			}
+			if wanted {
+				valid = append(valid, action)
+			}
+		default:
+			if text, ok := action.Payload.(string); ok && strings.TrimSpace(text) != "" {
+				diagnostics = append(diagnostics, fmt.Sprintf("action %d: unknown type '%s' coerced to %s", i, action.Type, ActionTextResponse))
+				valid = append(valid, ActionPlan{Type: ActionTextResponse, Payload: strings.TrimSpace(text)})
+				continue
+			}
+			diagnostics = append(diagnostics, fmt.Sprintf("action %d: unknown type '%s' dropped", i, action.Type))
+		}
+	}
+	return valid, diagnostics
+}
+
+// describePayload names a payload's JSON type for diagnostics, without echoing its contents.
+func describePayload(payload interface{}) string {
+	switch payload := payload.(type) {
+	case nil:
+		return "null"
+	case string:
+		if strings.TrimSpace(payload) == "" {
+			return "an empty string"
+		}
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", payload)
+	}
+}
+
+// clarifyAction returns the synthesizer's clarify action, {"type": "clarify", "payload": "<question>"},
+// when it asked the user a clarifying question instead of answering. The question replaces every
+// other action, so a guessed table or drawer is never shown alongside it. actions must already have
+// passed validateActions.
+func clarifyAction(actions []ActionPlan) (Action, bool) {
+	for _, action := range actions {
+		if action.Type == ActionClarify {
+			return Action{Type: ActionClarify, Payload: action.Payload}, true
+		}
+	}
+	return Action{}, false
+}
+
+// renderTablePageSize is how many claims a render_table action carries. The frontend fetches later
+// pages from GET /claims as the user pages through them.
+const renderTablePageSize = 25
+
+// RenderTablePayload is the payload the frontend receives with a render_table action: the first page
+// of the retrieved claims and the query to fetch more.
+type RenderTablePayload struct {
+	Claims   interface{} `json:"claims"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	HasMore  bool        `json:"has_more"`
+	// Query is the query string for GET /claims that reproduces the retrieval's filters; add page and
+	// limit to fetch any page.
+	Query string `json:"query,omitempty"`
+	// NextQuery is Query with the page and limit of the next page, set when HasMore.
+	NextQuery string `json:"next_query,omitempty"`
+}
+
+// renderTablePayload pages the retrieved claims for a render_table action. limited reports that the
+// retrieval was capped, so there are more matching claims than were retrieved. withScores keeps the
+// claims' normalized similarity scores, as GET /claims does with include_scores, and the queries ask
+// for them too. Claims data of an unknown shape, such as a replayed conversation's stored JSON, is
+// sent as a single page.
+func renderTablePayload(claimsData interface{}, claimsQuery url.Values, limited, withScores bool) RenderTablePayload {
+	payload := RenderTablePayload{Claims: claimsData, Page: 1, PageSize: renderTablePageSize}
+	switch claims := claimsData.(type) {
+	case []insurance.ListClaimsWithVectorRow:
+		page, hasMore := firstPage(claims)
+		scored := make([]scoredClaim, 0, len(page))
+		for _, row := range page {
+			scored = append(scored, newScoredClaim(row, withScores))
+		}
+		payload.Claims, payload.HasMore = scored, hasMore
+	case []insurance.ListClaimsWithoutVectorRow:
+		page, hasMore := firstPage(claims)
+		unscored := make([]unscoredClaim, 0, len(page))
+		for _, row := range page {
+			unscored = append(unscored, unscoredClaim{ListClaimsWithoutVectorRow: row})
+		}
+		payload.Claims, payload.HasMore = unscored, hasMore
+	default:
+		return payload
+	}
+	payload.HasMore = payload.HasMore || limited
+	if claimsQuery == nil {
+		return payload
+	}
+	query := url.Values{}
+	for key, values := range claimsQuery {
+		query[key] = values
+	}
+	if withScores {
+		query.Set("include_scores", "true")
+	}
+	payload.Query = query.Encode()
+	if payload.HasMore {
+		next := url.Values{}
+		for key, values := range query {
+			next[key] = values
+		}
+		next.Set("page", "2")
+		next.Set("limit", strconv.Itoa(renderTablePageSize))
+		payload.NextQuery = next.Encode()
+	}
+	return payload
+}
+
+func firstPage[T any](rows []T) ([]T, bool) {
+	if len(rows) > renderTablePageSize {
+		return rows[:renderTablePageSize], true
+	}
+	return rows, false
+}
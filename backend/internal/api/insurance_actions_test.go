@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
+	"github.com/jjckrbbt/chimera/backend/internal/rag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateActions(t *testing.T) {
+	t.Run("Keeps well-formed actions", func(t *testing.T) {
+		actions, diagnostics := validateActions([]ActionPlan{
+			{Type: ActionTextResponse, Payload: " Two claims are open. "},
+			{Type: ActionRenderTable, Payload: true},
+			{Type: ActionOpenDetailDrawer, Payload: false},
+		})
+		assert.Empty(t, diagnostics)
+		assert.Equal(t, []ActionPlan{
+			{Type: ActionTextResponse, Payload: "Two claims are open."},
+			{Type: ActionRenderTable, Payload: true},
+		}, actions)
+	})
+
+	t.Run("Coerces an unknown type with text to a text_response", func(t *testing.T) {
+		actions, diagnostics := validateActions([]ActionPlan{{Type: "show_chart", Payload: "Claims rose 10% this month."}})
+		assert.Equal(t, []ActionPlan{{Type: ActionTextResponse, Payload: "Claims rose 10% this month."}}, actions)
+		assert.Equal(t, []string{"action 0: unknown type 'show_chart' coerced to text_response"}, diagnostics)
+	})
+
+	t.Run("Drops malformed payloads with a diagnostic", func(t *testing.T) {
+		actions, diagnostics := validateActions([]ActionPlan{
+			{Type: ActionTextResponse, Payload: map[string]interface{}{"text": "hi"}},
+			{Type: ActionTextResponse, Payload: "  "},
+			{Type: ActionRenderTable, Payload: "yes"},
+			{Type: ActionOpenDetailDrawer, Payload: nil},
+			{Type: ActionClarify, Payload: []interface{}{"Which one?"}},
+			{Type: "show_chart", Payload: 3.0},
+			{Type: "", Payload: nil},
+		})
+		assert.Empty(t, actions)
+		assert.Equal(t, []string{
+			"action 0 (text_response): payload must be a non-empty string, got an object",
+			"action 1 (text_response): payload must be a non-empty string, got an empty string",
+			"action 2 (render_table): payload must be a boolean, got a string",
+			"action 3 (open_detail_drawer): payload must be a boolean, got null",
+			"action 4 (clarify): payload must be a non-empty string, got an array",
+			"action 5: unknown type 'show_chart' dropped",
+			"action 6: unknown type '' dropped",
+		}, diagnostics)
+	})
+}
+
+func TestClarifyAction(t *testing.T) {
+	t.Run("Returns the clarifying question in place of other actions", func(t *testing.T) {
+		actions, _ := validateActions([]ActionPlan{
+			{Type: ActionTextResponse, Payload: "Here are the big claims."},
+			{Type: ActionClarify, Payload: " Which amount counts as big? "},
+			{Type: ActionRenderTable, Payload: true},
+		})
+		action, ok := clarifyAction(actions)
+		require.True(t, ok)
+		assert.Equal(t, Action{Type: ActionClarify, Payload: "Which amount counts as big?"}, action)
+	})
+
+	t.Run("Ignores a clarify action without a question", func(t *testing.T) {
+		actions, _ := validateActions([]ActionPlan{{Type: ActionClarify, Payload: ""}, {Type: ActionClarify, Payload: true}})
+		_, ok := clarifyAction(actions)
+		assert.False(t, ok)
+		_, ok = clarifyAction([]ActionPlan{{Type: ActionTextResponse, Payload: "Hello"}})
+		assert.False(t, ok)
+	})
+}
+
+func TestRenderTablePayload(t *testing.T) {
+	claims := make([]insurance.ListClaimsWithoutVectorRow, 30)
+	for i := range claims {
+		claims[i].ID = int64(i + 1)
+	}
+	query := url.Values{"status": {"Open"}, "sort_by": {"claim_amount"}}
+
+	t.Run("Sends the first page with the query for the next", func(t *testing.T) {
+		payload := renderTablePayload(claims, query, false, false)
+		page, ok := payload.Claims.([]unscoredClaim)
+		require.True(t, ok)
+		assert.Len(t, page, renderTablePageSize)
+		assert.Equal(t, int64(1), page[0].ID)
+		assert.True(t, payload.HasMore)
+		assert.Equal(t, 1, payload.Page)
+		assert.Equal(t, "sort_by=claim_amount&status=Open", payload.Query)
+		assert.Equal(t, "limit=25&page=2&sort_by=claim_amount&status=Open", payload.NextQuery)
+		assert.NotContains(t, query, "page", "the retrieval's query is not modified")
+	})
+
+	t.Run("Has no next page when every claim fits", func(t *testing.T) {
+		payload := renderTablePayload(claims[:3], query, false, false)
+		assert.Len(t, payload.Claims, 3)
+		assert.False(t, payload.HasMore)
+		assert.Empty(t, payload.NextQuery)
+	})
+
+	t.Run("Has more when the retrieval was capped", func(t *testing.T) {
+		payload := renderTablePayload(claims[:3], query, true, false)
+		assert.True(t, payload.HasMore)
+		assert.Equal(t, "limit=25&page=2&sort_by=claim_amount&status=Open", payload.NextQuery)
+	})
+
+	t.Run("Keeps normalized scores and asks for them on later pages", func(t *testing.T) {
+		scored := []insurance.ListClaimsWithVectorRow{{ID: 1, SimilarityScore: 0.2}}
+		payload := renderTablePayload(scored, query, true, true)
+		page, ok := payload.Claims.([]scoredClaim)
+		require.True(t, ok)
+		require.NotNil(t, page[0].SimilarityScore)
+		assert.InDelta(t, 0.9, *page[0].SimilarityScore, 1e-9)
+		assert.Equal(t, "include_scores=true&sort_by=claim_amount&status=Open", payload.Query)
+
+		payload = renderTablePayload(scored, query, false, false)
+		assert.Nil(t, payload.Claims.([]scoredClaim)[0].SimilarityScore)
+	})
+
+	t.Run("Sends claims data of another shape as one page", func(t *testing.T) {
+		payload := renderTablePayload([]interface{}{"stored"}, nil, false, false)
+		assert.Equal(t, []interface{}{"stored"}, payload.Claims)
+		assert.False(t, payload.HasMore)
+	})
+}
+
+func TestClaimsFiltersQuery(t *testing.T) {
+	filters, err := claimsFilterArgs(rag.ToolArgs{"status": "Open", "min_amount": 10000.5, "semantic_search_query": "water damage"})
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"status":                {"Open"},
+		"min_amount":            {"10000.5"},
+		"semantic_search_query": {"water damage"},
+	}, filters.query())
+}
@@ -6,17 +6,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/pdf"
+	"github.com/jjckrbbt/chimera/backend/internal/rag"
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
 	"github.com/labstack/echo/v4"
 	"github.com/pgvector/pgvector-go"
@@ -24,13 +35,17 @@ import (
 )
 
 // --- Structs for RAG pipeline ---
-type ChatMessage struct {
-	Sender  string `json:"sender"`
-	Content string `json:"content"`
-}
+// ChatMessage is a turn of the conversation history; it is the RAG pipeline's message type so the
+// history can be checked and redacted by the rag package.
+type ChatMessage = rag.ChatMessage
 type InsuranceQueryRequest struct {
-	Question string        `json:"question"`
-	History  []ChatMessage `json:"history"`
+	Question       string        `json:"question"`
+	History        []ChatMessage `json:"history"`
+	ConversationID string        `json:"conversation_id,omitempty"`
+	// Language is the language the answer should be written in; see rag.ResolveLanguage.
+	Language string `json:"language,omitempty"`
+	// Explain returns the retrieved sources with an LLM-written rationale for each match.
+	Explain bool `json:"explain,omitempty"`
 }
 type PlannerResponse struct {
 	ToolCalls []ToolCall `json:"tool_calls"`
@@ -40,16 +55,58 @@ type ToolCall struct {
 	Arguments map[string]interface{} `json:"arguments"`
 }
 type InsuranceContext struct {
-	ClaimsData      interface{}
-	KnowledgeChunks []SearchResult
-	Comments        []SearchResult
+	ClaimsData      interface{}    `json:"claims_data"`
+	KnowledgeChunks []SearchResult `json:"knowledge_chunks"`
+	Comments        []SearchResult `json:"comments"`
+	// LimitedTools maps each tool that found more results than its cap to that cap.
+	LimitedTools map[string]int `json:"limited_tools,omitempty"`
+	// ClaimsQuery holds the get_claims_data filters as GET /claims query parameters, so a rendered
+	// table can page through the rest of the results.
+	ClaimsQuery url.Values `json:"claims_query,omitempty"`
+	// NoDataRetrieved is set when the planner chose no tools, as for a greeting, so the synthesizer
+	// answers from the conversation rather than from empty results.
+	NoDataRetrieved bool `json:"no_data_retrieved,omitempty"`
+	// IgnoredArguments maps each tool that ran without some of its arguments, because they were
+	// invalid, to why they were left out, so the synthesizer doesn't present the results as filtered.
+	IgnoredArguments map[string]string `json:"ignored_arguments,omitempty"`
+	// UnavailableTools lists the critical tools that failed even when retried, so the synthesizer
+	// can say which data is missing rather than answer as if there were none.
+	UnavailableTools []string `json:"unavailable_tools,omitempty"`
+}
+
+// ignoreArguments records that tool ran without the arguments err describes.
+func ignoreArguments(insuranceCtx *InsuranceContext, tool string, err error) {
+	if insuranceCtx.IgnoredArguments == nil {
+		insuranceCtx.IgnoredArguments = make(map[string]string)
+	}
+	insuranceCtx.IgnoredArguments[tool] = strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// limitResults caps a tool's results at its configured limit, recording the tool in LimitedTools
+// when results were dropped.
+func limitResults[T any](insuranceCtx *InsuranceContext, limits rag.ToolLimits, tool string, results []T) []T {
+	limit := limits.Limit(tool)
+	results, truncated := rag.CapResults(results, limit)
+	if truncated {
+		if insuranceCtx.LimitedTools == nil {
+			insuranceCtx.LimitedTools = make(map[string]int)
+		}
+		insuranceCtx.LimitedTools[tool] = limit
+	}
+	return results
 }
+
 type SynthesizerTemplateData struct {
-	UserQuestion    string
-	History         []ChatMessage
-	ClaimsData      interface{}
-	KnowledgeChunks []SearchResult
-	Comments        []SearchResult
+	UserQuestion     string
+	History          []ChatMessage
+	ClaimsData       interface{}
+	KnowledgeChunks  []SearchResult
+	Comments         []SearchResult
+	LimitedTools     map[string]int
+	NoDataRetrieved  bool
+	IgnoredArguments map[string]string
+	UnavailableTools []string
+	Language         string
 }
 type ActionPlan struct {
 	Type    string      `json:"type"`
@@ -83,30 +140,118 @@ type LLMResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage rag.TokenUsage `json:"usage"`
 }
+
+// SearchResult is a knowledge chunk or comment found by a search. SimilarityScore is normalized to
+// 0-1, higher is closer, and is omitted from responses unless ?include_scores=true.
 type SearchResult struct {
 	Source          string                 `json:"source"`
 	Text            string                 `json:"text"`
-	SimilarityScore float32                `json:"similarityScore"`
+	SimilarityScore *float64               `json:"similarityScore,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata"`
+	Explanation     *SearchExplanation     `json:"explanation,omitempty"`
 }
 type InsuranceHandler struct {
-	queries             *insurance.Queries
-	platformQuerier     repository.Querier
+	db              TxDB
+	queries         insurance.Querier
+	platformQuerier repository.Querier
+	// txQueries binds platform queries to a transaction started on db.
+	txQueries           func(tx pgx.Tx) repository.Querier
 	httpClient          *http.Client
 	embeddingServiceURL string
-	plannerTemplate     *template.Template
-	synthesizerTemplate *template.Template
+	normalizeEmbeddings bool
+	appDir              string
+	templatesMu         sync.RWMutex
+	templates           *insuranceTemplates
+	claimWorkflow       *insurance.ClaimWorkflow
 	openAIAPIKey        string
 	LLMURL              string
+	useStubLLM          bool
+	llmPrices           rag.PriceTable
+	redactor            *rag.Redactor
+	toolLimits          rag.ToolLimits
+	pageSizes           config.PageSizes
 	logger              *slog.Logger
 }
+
+const (
+	maxBulkClaimUpdate = 500
+
+	// adjusterAssignedField is the claim custom property holding the assigned adjuster's name, and
+	// adjusterAssignedUserIDField the ID of the user it names. Claims assigned by name alone, such
+	// as those loaded from files, have no user ID.
+	adjusterAssignedField       = "Adjuster_Assigned"
+	adjusterAssignedUserIDField = "Adjuster_Assigned_User_ID"
+
+	// insuranceRAGContext tags the conversation turns answered by this handler.
+	insuranceRAGContext = "insurance"
+)
+
 type UpdateClaimRequest struct {
 	BusinessStatus string `json:"business_status"`
 }
+type AssignClaimRequest struct {
+	UserID           *int64 `json:"user_id,omitempty"`
+	AdjusterAssigned string `json:"adjuster_assigned,omitempty"`
+}
+type ClaimExport struct {
+	Claim         insurance.GetClaimDetailsRow        `json:"claim"`
+	StatusHistory []ClaimStatusEvent                  `json:"status_history"`
+	Comments      []repository.ListCommentsForItemRow `json:"comments"`
+	ExportedAt    time.Time                           `json:"exported_at"`
+}
+
+// ClaimStatusEvent is a status history entry in a claim export. EventData is embedded as the
+// event's JSON object rather than base64-encoded like a []byte.
+type ClaimStatusEvent struct {
+	EventID        int64              `json:"event_id"`
+	EventType      string             `json:"event_type"`
+	EventTimestamp pgtype.Timestamptz `json:"event_timestamp"`
+	EventData      json.RawMessage    `json:"event_data"`
+	UserName       pgtype.Text        `json:"user_name"`
+}
+type PolicyholderSummaryResponse struct {
+	TotalPolicyholders int64                                        `json:"total_policyholders"`
+	TotalClaims        int64                                        `json:"total_claims"`
+	TotalClaimExposure decimal.Decimal                              `json:"total_claim_exposure"`
+	Segments           []insurance.SummarizePolicyholderSegmentsRow `json:"segments"`
+}
+type ReplayedTurn struct {
+	TurnID         int64             `json:"turn_id"`
+	Question       string            `json:"question"`
+	AskedAt        time.Time         `json:"asked_at"`
+	OriginalAnswer json.RawMessage   `json:"original_answer"`
+	ReplayedAnswer *QueryApiResponse `json:"replayed_answer,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+type ConversationReplayResponse struct {
+	ConversationID string         `json:"conversation_id"`
+	Turns          []ReplayedTurn `json:"turns"`
+	// Usage is what the replay cost; OriginalUsage is what the stored turns cost when first asked.
+	Usage         rag.UsageSummary `json:"usage"`
+	OriginalUsage rag.UsageSummary `json:"original_usage"`
+}
+type BulkUpdateClaimsRequest struct {
+	ClaimIDs       []int64 `json:"claim_ids"`
+	BusinessStatus string  `json:"business_status"`
+}
+type BulkClaimResult struct {
+	ClaimID int64  `json:"claim_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+type BulkUpdateClaimsResponse struct {
+	UpdatedCount int               `json:"updated_count"`
+	FailedCount  int               `json:"failed_count"`
+	Results      []BulkClaimResult `json:"results"`
+}
 type CreateCommentRequest struct {
 	CommentText string `json:"comment_text"`
 }
+type UpdateCommentRequest struct {
+	CommentText string `json:"comment_text"`
+}
 type EmbeddingRequest struct {
 	Text string `json:"text"`
 }
@@ -114,46 +259,212 @@ type EmbeddingResponse struct {
 	Embedding []float32 `json:"embedding"`
 }
 
-func NewInsuranceHandler(q *insurance.Queries, pq repository.Querier, apiKey string, LLMURL string, logger *slog.Logger) (*InsuranceHandler, error) {
-	funcMap := template.FuncMap{
-		"marshal": func(v interface{}) (string, error) {
-			if v == nil {
-				return "[]", nil
-			}
-			a, err := json.Marshal(v)
-			if err != nil {
-				return "", err
-			}
-			return string(a), nil
-		},
-	}
-	plannerTmpl, err := template.New("insurance_planner_prompt.tmpl").Funcs(funcMap).ParseFiles("backend/configs/apps/insurance/prompts/insurance_planner_prompt.tmpl")
+// insuranceTemplates are the templates the handler parses from disk, along with the planner's
+// few-shot examples. They are replaced as a set when reloaded, so a request never mixes old and new
+// templates.
+type insuranceTemplates struct {
+	planner         *template.Template
+	synthesizer     *template.Template
+	export          *template.Template
+	explanation     *template.Template
+	plannerExamples []rag.PlannerExample
+}
+
+// promptFuncMap holds the helpers available to the planner and synthesizer prompt templates.
+var promptFuncMap = template.FuncMap{
+	"marshal": func(v interface{}) (string, error) {
+		if v == nil {
+			return "[]", nil
+		}
+		a, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(a), nil
+	},
+}
+
+// loadInsuranceTemplates parses the prompt and export templates and the planner examples under appDir.
+func loadInsuranceTemplates(appDir string) (*insuranceTemplates, error) {
+	plannerTmpl, err := template.New("insurance_planner_prompt.tmpl").Funcs(promptFuncMap).ParseFiles(filepath.Join(appDir, "prompts", "insurance_planner_prompt.tmpl"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse insurance planner template: %w", err)
 	}
-	synthesizerTmpl, err := template.New("synthesizer_prompt.tmpl").Funcs(funcMap).ParseFiles("backend/configs/apps/insurance/prompts/synthesizer_prompt.tmpl")
+	synthesizerTmpl, err := template.New("synthesizer_prompt.tmpl").Funcs(promptFuncMap).ParseFiles(filepath.Join(appDir, "prompts", "synthesizer_prompt.tmpl"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse insurance synthesizer template: %w", err)
 	}
+	exportTmpl, err := template.New("claim_export.tmpl").Funcs(exportFuncMap).ParseFiles(filepath.Join(appDir, "templates", "claim_export.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse claim export template: %w", err)
+	}
+	explanationTmpl, err := template.New("explanation_prompt.tmpl").ParseFiles(filepath.Join(appDir, "prompts", "explanation_prompt.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse insurance explanation template: %w", err)
+	}
+	plannerExamples, err := rag.LoadPlannerExamples(filepath.Join(appDir, "prompts", "planner_examples.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	return &insuranceTemplates{planner: plannerTmpl, synthesizer: synthesizerTmpl, export: exportTmpl, explanation: explanationTmpl, plannerExamples: plannerExamples}, nil
+}
+
+// ValidateInsuranceConfig loads everything NewInsuranceHandler reads from the apps/insurance directory
+// under configDir and reports every file that fails to parse or validate.
+func ValidateInsuranceConfig(configDir string) error {
+	appDir := filepath.Join(configDir, "apps", "insurance")
+	var errs []error
+	if _, err := loadInsuranceTemplates(appDir); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := insurance.LoadClaimWorkflow(filepath.Join(appDir, "workflow", "claim_status.yaml")); err != nil {
+		errs = append(errs, fmt.Errorf("failed to load insurance claim workflow: %w", err))
+	}
+	if _, err := rag.LoadRedactor(filepath.Join(appDir, "redaction.yaml")); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := rag.LoadToolLimits(filepath.Join(appDir, "tool_limits.yaml")); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// NewInsuranceHandler creates the insurance handler, loading its prompt templates, export template,
+// claim workflow, PII redaction settings and tool result limits from the apps/insurance directory under configDir.
+// Its claim, policyholder and comment lists are paged by pageSizes. When useStubLLM is true, LLM calls
+// return canned responses instead of calling the AI API.
+func NewInsuranceHandler(db TxDB, q *insurance.Queries, pq repository.Querier, configDir string, normalizeEmbeddings bool, apiKey string, LLMURL string, useStubLLM bool, prices rag.PriceTable, pageSizes config.PageSizes, logger *slog.Logger) (*InsuranceHandler, error) {
+	appDir := filepath.Join(configDir, "apps", "insurance")
+	templates, err := loadInsuranceTemplates(appDir)
+	if err != nil {
+		return nil, err
+	}
+	claimWorkflow, err := insurance.LoadClaimWorkflow(filepath.Join(appDir, "workflow", "claim_status.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load insurance claim workflow: %w", err)
+	}
+	redactor, err := rag.LoadRedactor(filepath.Join(appDir, "redaction.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	toolLimits, err := rag.LoadToolLimits(filepath.Join(appDir, "tool_limits.yaml"))
+	if err != nil {
+		return nil, err
+	}
 	return &InsuranceHandler{
+		db:                  db,
 		queries:             q,
 		platformQuerier:     pq,
+		txQueries:           func(tx pgx.Tx) repository.Querier { return repository.New(tx) },
 		httpClient:          &http.Client{Timeout: 30 * time.Second},
 		embeddingServiceURL: "http://embedding-service:5001/embed",
-		plannerTemplate:     plannerTmpl,
-		synthesizerTemplate: synthesizerTmpl,
+		normalizeEmbeddings: normalizeEmbeddings,
+		appDir:              appDir,
+		templates:           templates,
+		claimWorkflow:       claimWorkflow,
 		openAIAPIKey:        apiKey,
 		LLMURL:              LLMURL,
+		useStubLLM:          useStubLLM,
+		llmPrices:           prices,
+		redactor:            redactor,
+		toolLimits:          toolLimits,
+		pageSizes:           pageSizes,
 		logger:              logger.With("component", "insurance_handler"),
 	}, nil
 }
+
+// currentTemplates returns the templates in use. Callers should fetch them once per request.
+func (h *InsuranceHandler) currentTemplates() *insuranceTemplates {
+	h.templatesMu.RLock()
+	defer h.templatesMu.RUnlock()
+	return h.templates
+}
+
+// ReloadTemplates re-parses the prompt and export templates from disk and swaps them in. If any
+// template fails to parse, the templates in use are kept and the error is returned.
+func (h *InsuranceHandler) ReloadTemplates() error {
+	templates, err := loadInsuranceTemplates(h.appDir)
+	if err != nil {
+		return err
+	}
+	h.templatesMu.Lock()
+	defer h.templatesMu.Unlock()
+	h.templates = templates
+	return nil
+}
+
+// HandleReloadTemplates reloads the templates from disk so prompt changes take effect without a restart.
+func (h *InsuranceHandler) HandleReloadTemplates(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := h.ReloadTemplates(); err != nil {
+		h.logger.WarnContext(ctx, "Failed to reload insurance templates", "error", err)
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	h.logger.InfoContext(ctx, "Reloaded insurance templates", "dir", h.appDir)
+	return c.JSON(http.StatusOK, map[string]interface{}{"reloaded_at": time.Now()})
+}
+
+// RegisterRoutes registers the insurance claim, comment and policyholder endpoints on the given group.
+func (h *InsuranceHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/claims", h.HandleListClaims)
+	g.POST("/claims/bulk-status", h.HandleBulkUpdateClaims)
+	g.GET("/claims/:id", h.HandleGetClaimDetails)
+	g.PATCH("/claims/:id", h.HandleUpdateClaim)
+	g.GET("/claims/:id/history", h.HandleGetClaimStatusHistory)
+	g.POST("/claims/:id/assign", h.HandleAssignClaim)
+	g.GET("/claims/:id/export", h.HandleExportClaim)
+	g.GET("/claims/:id/comments", h.HandleListComments)
+	g.POST("/claims/:id/comments", h.HandleCreateComment)
+	g.PATCH("/claims/:id/comments/:commentId", h.HandleUpdateComment)
+	g.DELETE("/claims/:id/comments/:commentId", h.HandleDeleteComment)
+	g.GET("/comments/search", h.HandleSearchComments)
+	g.GET("/policyholders", h.HandleListPolicyholders)
+	g.GET("/policyholders/summary", h.HandleGetPolicyholderSummary)
+}
+
+// RegisterAdminRoutes registers the conversation replay and template reload endpoints. Replay
+// calls the LLM once per turn, so the group belongs behind the longer request timeout.
+func (h *InsuranceHandler) RegisterAdminRoutes(g *echo.Group) {
+	g.POST("/conversations/:id/replay", h.HandleReplayConversation)
+	g.POST("/templates/reload", h.HandleReloadTemplates)
+}
+
+// RegisterQueryRoutes registers the RAG query endpoint. It is kept apart from RegisterRoutes so
+// it can sit behind the longer request timeout.
+func (h *InsuranceHandler) RegisterQueryRoutes(g *echo.Group) {
+	g.POST("/query", h.HandleInsuranceQuery)
+}
+
+// claimSortColumns are the sort_by values the claims list queries understand. After any requested
+// sort, claims are ordered by date_of_loss descending, or by similarity for a semantic search.
+var claimSortColumns = []string{"claim_amount", "date_of_loss"}
+
+// validateClaimsSort checks sort_by and sort_direction against the values the claims list queries
+// accept and returns them lowercased. Both may be empty to use the default order; a sort_by
+// without a direction sorts descending, and a direction without a sort_by is rejected.
+func validateClaimsSort(sortBy, sortDirection string) (string, string, error) {
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	sortDirection = strings.ToLower(strings.TrimSpace(sortDirection))
+	if sortBy != "" && !slices.Contains(claimSortColumns, sortBy) {
+		return "", "", fmt.Errorf("'sort_by' must be one of %s", strings.Join(claimSortColumns, ", "))
+	}
+	if sortDirection != "" && sortDirection != "asc" && sortDirection != "desc" {
+		return "", "", fmt.Errorf("'sort_direction' must be asc or desc")
+	}
+	switch {
+	case sortBy == "" && sortDirection != "":
+		return "", "", fmt.Errorf("'sort_direction' requires 'sort_by'")
+	case sortBy != "" && sortDirection == "":
+		sortDirection = "desc"
+	}
+	return sortBy, sortDirection, nil
+}
+
 func (h *InsuranceHandler) HandleListClaims(c echo.Context) error {
 	ctx := c.Request().Context()
 	reqLogger := h.logger.With("request_id", c.Get("requestID"))
-	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 32)
-	if limit <= 0 {
-		limit = 50
-	}
+	pageSize := h.pageSizes.For(config.PageSizeClaims)
+	limit := int64(pageSize.Limit(c.QueryParam("limit")))
 	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 32)
 	if page <= 0 {
 		page = 1
@@ -161,20 +472,39 @@ func (h *InsuranceHandler) HandleListClaims(c echo.Context) error {
 	offset := (page - 1) * limit
 
 	var results interface{}
+	var totalCount int64
 	var err error
 	searchQuery := c.QueryParam("semantic_search_query")
-
-	parseAmount := func(amountStr string) pgtype.Numeric {
-		if amountStr == "" {
-			return pgtype.Numeric{Valid: false}
+	adjusterAssigned := c.QueryParam("adjuster_assigned")
+	// assigned_to_me matches the claims assigned to the caller's user ID, so it doesn't depend on
+	// their display name staying the same.
+	var adjusterUserID pgtype.Int8
+	if c.QueryParam("assigned_to_me") == "true" {
+		userID, ok := UserIDFromContext(ctx)
+		if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
 		}
-		d, err := decimal.NewFromString(amountStr)
+		adjusterUserID = pgtype.Int8{Int64: userID, Valid: true}
+	}
+	var breachedSLADays []byte
+	if c.QueryParam("sla_breached") == "true" {
+		breachedSLADays, err = h.claimWorkflow.SLADaysJSON()
 		if err != nil {
-			return pgtype.Numeric{Valid: false}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to encode SLA thresholds")
 		}
-		num := new(pgtype.Numeric)
-		_ = num.Scan(d.String())
-		return *num
+	}
+
+	sortBy, sortDirection, err := validateClaimsSort(c.QueryParam("sort_by"), c.QueryParam("sort_direction"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Query parameter "+err.Error())
+	}
+	minAmount, err := repository.ParseNumeric(c.QueryParam("min_amount"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Query parameter 'min_amount' must be a number")
+	}
+	maxAmount, err := repository.ParseNumeric(c.QueryParam("max_amount"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Query parameter 'max_amount' must be a number")
 	}
 
 	if searchQuery != "" {
@@ -188,27 +518,58 @@ func (h *InsuranceHandler) HandleListClaims(c echo.Context) error {
 			Offset:           int32(offset),
 			SearchEmbedding:  pgvector.NewVector(embedding),
 			ClaimID:          pgtype.Text{String: c.QueryParam("claim_id"), Valid: c.QueryParam("claim_id") != ""},
-			AdjusterAssigned: pgtype.Text{String: c.QueryParam("adjuster_assigned"), Valid: c.QueryParam("adjuster_assigned") != ""},
+			AdjusterAssigned: pgtype.Text{String: adjusterAssigned, Valid: adjusterAssigned != ""},
 			Status:           pgtype.Text{String: c.QueryParam("status"), Valid: c.QueryParam("status") != ""},
 			PolicyNumber:     pgtype.Text{String: c.QueryParam("policy_number"), Valid: c.QueryParam("policy_number") != ""},
-			MinAmount:        parseAmount(c.QueryParam("min_amount")),
-			MaxAmount:        parseAmount(c.QueryParam("max_amount")),
+			BreachedSlaDays:  breachedSLADays,
+			AdjusterUserID:   adjusterUserID,
+			SortBy:           sortBy,
+			SortDirection:    sortDirection,
+			MinAmount:        minAmount,
+			MaxAmount:        maxAmount,
+		}
+		totalCount, err = h.queries.CountClaimsWithVector(ctx, insurance.CountClaimsWithVectorParams{
+			SearchEmbedding:  params.SearchEmbedding,
+			ClaimID:          params.ClaimID,
+			MinAmount:        params.MinAmount,
+			MaxAmount:        params.MaxAmount,
+			AdjusterAssigned: params.AdjusterAssigned,
+			Status:           params.Status,
+			PolicyNumber:     params.PolicyNumber,
+			BreachedSlaDays:  params.BreachedSlaDays,
+			AdjusterUserID:   params.AdjusterUserID,
+		})
+		if err == nil {
+			results, err = h.queries.ListClaimsWithVector(ctx, params)
 		}
-		results, err = h.queries.ListClaimsWithVector(ctx, params)
 	} else {
 		params := insurance.ListClaimsWithoutVectorParams{
 			Limit:            int32(limit),
 			Offset:           int32(offset),
 			ClaimID:          pgtype.Text{String: c.QueryParam("claim_id"), Valid: c.QueryParam("claim_id") != ""},
-			AdjusterAssigned: pgtype.Text{String: c.QueryParam("adjuster_assigned"), Valid: c.QueryParam("adjuster_assigned") != ""},
+			AdjusterAssigned: pgtype.Text{String: adjusterAssigned, Valid: adjusterAssigned != ""},
 			Status:           pgtype.Text{String: c.QueryParam("status"), Valid: c.QueryParam("status") != ""},
 			PolicyNumber:     pgtype.Text{String: c.QueryParam("policy_number"), Valid: c.QueryParam("policy_number") != ""},
-			SortBy:           c.QueryParam("sort_by"),
-			SortDirection:    c.QueryParam("sort_direction"),
-			MinAmount:        parseAmount(c.QueryParam("min_amount")),
-			MaxAmount:        parseAmount(c.QueryParam("max_amount")),
+			BreachedSlaDays:  breachedSLADays,
+			AdjusterUserID:   adjusterUserID,
+			SortBy:           sortBy,
+			SortDirection:    sortDirection,
+			MinAmount:        minAmount,
+			MaxAmount:        maxAmount,
+		}
+		totalCount, err = h.queries.CountClaimsWithoutVector(ctx, insurance.CountClaimsWithoutVectorParams{
+			ClaimID:          params.ClaimID,
+			MinAmount:        params.MinAmount,
+			MaxAmount:        params.MaxAmount,
+			AdjusterAssigned: params.AdjusterAssigned,
+			Status:           params.Status,
+			PolicyNumber:     params.PolicyNumber,
+			BreachedSlaDays:  params.BreachedSlaDays,
+			AdjusterUserID:   params.AdjusterUserID,
+		})
+		if err == nil {
+			results, err = h.queries.ListClaimsWithoutVector(ctx, params)
 		}
-		results, err = h.queries.ListClaimsWithoutVector(ctx, params)
 	}
 	if err != nil {
 		h.logger.ErrorContext(ctx, "Failed to list insurance claims", "error", err)
@@ -218,18 +579,47 @@ func (h *InsuranceHandler) HandleListClaims(c echo.Context) error {
 	switch v := results.(type) {
 	case []insurance.ListClaimsWithVectorRow:
 		claimsCount = len(v)
+		withScores := includeScores(c)
+		claims := make([]claimWithVectorSLA, 0, len(v))
+		for _, row := range v {
+			claims = append(claims, claimWithVectorSLA{newScoredClaim(row, withScores), h.slaBreached(row.BusinessStatus, row.AgeDays)})
+		}
+		results = claims
 	case []insurance.ListClaimsWithoutVectorRow:
 		claimsCount = len(v)
-	}
-	h.logger.InfoContext(ctx, "Successfully retrieved claims list", "count", claimsCount)
-	return c.JSON(http.StatusOK, results)
+		claims := make([]claimWithSLA, 0, len(v))
+		for _, row := range v {
+			claims = append(claims, claimWithSLA{unscoredClaim{ListClaimsWithoutVectorRow: row}, h.slaBreached(row.BusinessStatus, row.AgeDays)})
+		}
+		results = claims
+	}
+	h.logger.InfoContext(ctx, "Successfully retrieved claims list", "count", claimsCount, "total_count", totalCount)
+	return c.JSON(http.StatusOK, PaginatedItemsResponse{
+		TotalCount: totalCount,
+		Data:       results,
+		Limit:      int(limit),
+		MaxLimit:   pageSize.Max,
+	})
+}
+
+// claimWithSLA and claimWithVectorSLA add the computed sla_breached flag to a claim list row.
+type claimWithSLA struct {
+	unscoredClaim
+	SLABreached bool `json:"sla_breached"`
 }
+type claimWithVectorSLA struct {
+	scoredClaim
+	SLABreached bool `json:"sla_breached"`
+}
+
+func (h *InsuranceHandler) slaBreached(status string, ageDays pgtype.Int4) bool {
+	return ageDays.Valid && h.claimWorkflow.SLABreached(status, ageDays.Int32)
+}
+
 func (h *InsuranceHandler) HandleListPolicyholders(c echo.Context) error {
 	ctx := c.Request().Context()
-	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 32)
-	if limit <= 0 {
-		limit = 50
-	}
+	pageSize := h.pageSizes.For(config.PageSizePolicyholders)
+	limit := int64(pageSize.Limit(c.QueryParam("limit")))
 	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 32)
 	if page <= 0 {
 		page = 1
@@ -241,13 +631,61 @@ func (h *InsuranceHandler) HandleListPolicyholders(c echo.Context) error {
 		State:         pgtype.Text{String: c.QueryParam("state"), Valid: c.QueryParam("state") != ""},
 		CustomerLevel: pgtype.Text{String: c.QueryParam("customer_level"), Valid: c.QueryParam("customer_level") != ""},
 	}
+	totalCount, err := h.queries.CountPolicyholders(ctx, insurance.CountPolicyholdersParams{
+		State:         params.State,
+		CustomerLevel: params.CustomerLevel,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to count policyholders", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve policyholders")
+	}
 	policyholders, err := h.queries.ListPolicyholders(ctx, params)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "Failed to list policyholders", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve policyholders")
 	}
-	h.logger.InfoContext(ctx, "Successfully retrieved policyholders list", "count", len(policyholders))
-	return c.JSON(http.StatusOK, policyholders)
+	if policyholders == nil {
+		policyholders = []insurance.VwPolicyholder{}
+	}
+	h.logger.InfoContext(ctx, "Successfully retrieved policyholders list", "count", len(policyholders), "total_count", totalCount)
+	return c.JSON(http.StatusOK, PaginatedItemsResponse{
+		TotalCount: totalCount,
+		Data:       policyholders,
+		Limit:      int(limit),
+		MaxLimit:   pageSize.Max,
+	})
+}
+
+// HandleGetPolicyholderSummary returns policyholder counts and total claim exposure grouped by
+// state and customer level, with portfolio-wide totals, for the portfolio dashboard.
+func (h *InsuranceHandler) HandleGetPolicyholderSummary(c echo.Context) error {
+	ctx := c.Request().Context()
+	segments, err := h.queries.SummarizePolicyholderSegments(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to summarize policyholders", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve policyholder summary")
+	}
+	response := PolicyholderSummaryResponse{
+		TotalClaimExposure: decimal.Zero,
+		Segments:           segments,
+	}
+	if response.Segments == nil {
+		response.Segments = []insurance.SummarizePolicyholderSegmentsRow{}
+	}
+	for _, segment := range segments {
+		response.TotalPolicyholders += segment.PolicyholderCount
+		response.TotalClaims += segment.ClaimCount
+		response.TotalClaimExposure = response.TotalClaimExposure.Add(numericToDecimal(segment.TotalClaimExposure))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// numericToDecimal converts a pgtype.Numeric to a decimal.Decimal, treating NULL as zero.
+func numericToDecimal(n pgtype.Numeric) decimal.Decimal {
+	if !n.Valid || n.Int == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(n.Int, n.Exp)
 }
 func (h *InsuranceHandler) HandleGetClaimDetails(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -276,6 +714,7 @@ func (h *InsuranceHandler) HandleGetClaimStatusHistory(c echo.Context) error {
 	}
 	type HistoryResponse struct {
 		ID             int64           `json:"ID"`
+		EventType      string          `json:"event_type"`
 		EventTimestamp time.Time       `json:"event_timestamp"`
 		EventData      json.RawMessage `json:"event_data"`
 		UserName       pgtype.Text     `json:"user_name"`
@@ -284,6 +723,7 @@ func (h *InsuranceHandler) HandleGetClaimStatusHistory(c echo.Context) error {
 	for i, event := range history {
 		response[i] = HistoryResponse{
 			ID:             event.EventID,
+			EventType:      event.EventType,
 			EventTimestamp: event.EventTimestamp.Time,
 			EventData:      event.EventData,
 			UserName:       event.UserName,
@@ -301,143 +741,764 @@ func (h *InsuranceHandler) HandleUpdateClaim(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
-	var userID int64 = 1 // Placeholder for auth
-	existingItem, err := h.platformQuerier.GetItemForUpdate(ctx, id)
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	// The claim is locked from the status read to the change event, so two concurrent updates
+	// can't both pass the workflow check against the same old status.
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	if err := h.updateClaimStatus(ctx, h.txQueries(tx), id, req.BusinessStatus, userID); err != nil {
+		// Release the claim's lock before the rejected transition is recorded outside the
+		// transaction.
+		_ = tx.Rollback(ctx)
+		var transitionErr *insurance.InvalidTransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			h.recordRejectedTransition(ctx, id, transitionErr, userID)
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, transitionErr.Error())
+		case errors.Is(err, errClaimNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Item not found")
+		case errors.Is(err, errClaimEvent):
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create audit event for claim update")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update claim")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandleAssignClaim sets a claim's adjuster and records a CLAIM_ASSIGNED event. The body names
+// the adjuster by user_id or by adjuster_assigned; an empty body assigns the claim to the caller.
+// Assigning a user stores their user ID next to their name, which assigned_to_me filters on; a
+// name alone clears it.
+func (h *InsuranceHandler) HandleAssignClaim(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
+	}
+	var req AssignClaimRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	caller, err := h.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	adjuster := strings.TrimSpace(req.AdjusterAssigned)
+	assignedUserID := pgtype.Int8{}
+	switch {
+	case req.UserID != nil:
+		assignee, err := h.platformQuerier.GetUserByID(ctx, *req.UserID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusBadRequest, "Assignee user not found")
+			}
+			h.logger.ErrorContext(ctx, "Failed to fetch assignee", "error", err, "user_id", *req.UserID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to assign claim")
+		}
+		adjuster = adjusterName(assignee)
+		assignedUserID = pgtype.Int8{Int64: assignee.ID, Valid: true}
+	case adjuster == "":
+		adjuster = adjusterName(caller)
+		assignedUserID = pgtype.Int8{Int64: caller.ID, Valid: true}
+	}
+
+	// The claim stays locked from the read to the assignment event, so a concurrent change can't be
+	// overwritten or left without its event.
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.txQueries(tx)
+
+	existingItem, err := qtx.GetItemForUpdate(ctx, id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Item not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Item not found")
+		}
+		h.logger.ErrorContext(ctx, "Failed to fetch item", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to assign claim")
 	}
 	var customProps map[string]interface{}
 	if err := json.Unmarshal(existingItem.CustomProperties, &customProps); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to parse existing item properties")
 	}
-	oldStatus := customProps["Status"]
-	customProps["Status"] = req.BusinessStatus
+	oldAdjuster := customProps[adjusterAssignedField]
+	customProps[adjusterAssignedField] = adjuster
+	if assignedUserID.Valid {
+		// Stored as text, the form the claims queries compare it in.
+		customProps[adjusterAssignedUserIDField] = strconv.FormatInt(assignedUserID.Int64, 10)
+	} else {
+		delete(customProps, adjusterAssignedUserIDField)
+	}
 	updatedCustomProps, err := json.Marshal(customProps)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to serialize updated properties")
 	}
-	updateParams := repository.UpdateItemParams{
+	_, err = qtx.UpdateItem(ctx, repository.UpdateItemParams{
 		ID:               id,
 		Scope:            existingItem.Scope,
 		Status:           existingItem.Status,
 		CustomProperties: updatedCustomProps,
-	}
-	_, err = h.platformQuerier.UpdateItem(ctx, updateParams)
+	})
 	if err != nil {
-		h.logger.ErrorContext(ctx, "Failed to update item", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update claim")
+		h.logger.ErrorContext(ctx, "Failed to update item", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to assign claim")
+	}
+	eventData := map[string]interface{}{"old_adjuster": oldAdjuster, "new_adjuster": adjuster}
+	if assignedUserID.Valid {
+		eventData["assigned_user_id"] = assignedUserID.Int64
 	}
-	eventData := map[string]interface{}{"old_status": oldStatus, "new_status": req.BusinessStatus}
 	eventDataJSON, _ := json.Marshal(eventData)
-	eventParams := repository.CreateItemEventParams{
+	_, err = qtx.CreateItemEvent(ctx, repository.CreateItemEventParams{
 		ItemID:    id,
-		EventType: "CLAIM_STATUS_CHANGED",
+		EventType: "CLAIM_ASSIGNED",
 		EventData: eventDataJSON,
-		CreatedBy: userID,
-	}
-	_, err = h.platformQuerier.CreateItemEvent(ctx, eventParams)
+		CreatedBy: caller.ID,
+	})
 	if err != nil {
-		h.logger.ErrorContext(ctx, "Failed to create status change event", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create audit event for claim update")
+		h.logger.ErrorContext(ctx, "Failed to create assignment event", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create audit event for claim assignment")
 	}
-	return c.NoContent(http.StatusNoContent)
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	response := map[string]interface{}{"id": id, "adjuster_assigned": adjuster}
+	if assignedUserID.Valid {
+		response["adjuster_user_id"] = assignedUserID.Int64
+	}
+	return c.JSON(http.StatusOK, response)
 }
-func (h *InsuranceHandler) HandleListComments(c echo.Context) error {
-	ctx := c.Request().Context()
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
+
+// currentUser loads the authenticated user from the request context.
+func (h *InsuranceHandler) currentUser(ctx context.Context) (repository.User, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return repository.User{}, echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
 	}
-	comments, err := h.platformQuerier.ListCommentsForItem(ctx, id)
+	user, err := h.platformQuerier.GetUserByID(ctx, userID)
 	if err != nil {
-		h.logger.ErrorContext(ctx, "Failed to list comments", "error", err, "item_id", id)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve comments")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.User{}, echo.NewHTTPError(http.StatusUnauthorized, "Unknown user")
+		}
+		h.logger.ErrorContext(ctx, "Failed to fetch current user", "error", err, "user_id", userID)
+		return repository.User{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve current user")
 	}
-	return c.JSON(http.StatusOK, comments)
+	return user, nil
 }
-func (h *InsuranceHandler) HandleCreateComment(c echo.Context) error {
+
+// adjusterName is the value stored in a claim's Adjuster_Assigned field for a user: their
+// display name, or their email when no display name is set. It is for display; the user is
+// identified by the Adjuster_Assigned_User_ID field.
+func adjusterName(user repository.User) string {
+	if user.DisplayName.Valid && user.DisplayName.String != "" {
+		return user.DisplayName.String
+	}
+	return user.Email
+}
+
+// HandleExportClaim bundles a claim's details, status history and comments into one document for
+// handing the claim off. format=json (the default) returns the bundle as JSON; format=pdf renders
+// it through the claim export template into a PDF.
+func (h *InsuranceHandler) HandleExportClaim(c echo.Context) error {
 	ctx := c.Request().Context()
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
 	}
-	var req CreateCommentRequest
-	if err := c.Bind(&req); err != nil || req.CommentText == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: comment_text is required")
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
 	}
-	var userID int64 = 1 // Placeholder for auth
-	params := repository.CreateCommentParams{
-		ItemID:  id,
-		Comment: req.CommentText,
-		UserID:  userID,
+	if format != "json" && format != "pdf" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Query parameter 'format' must be 'json' or 'pdf'")
 	}
-	newComment, err := h.platformQuerier.CreateComment(ctx, params)
+
+	export, err := h.buildClaimExport(ctx, id)
 	if err != nil {
-		h.logger.ErrorContext(ctx, "Failed to create comment", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save comment")
+		return err
 	}
-	embedding, err := h.getEmbedding(ctx, newComment.Comment)
-	if err != nil {
-		h.logger.WarnContext(ctx, "Failed to generate embedding for comment", "error", err, "comment_id", newComment.ID)
-	} else {
-		updateEmbeddingParams := repository.SetCommentEmbeddingParams{
-			ID:        newComment.ID,
-			Embedding: pgvector.NewVector(embedding),
-		}
-		err = h.platformQuerier.SetCommentEmbedding(ctx, updateEmbeddingParams)
-		if err != nil {
-			h.logger.ErrorContext(ctx, "Failed to save embedding for comment", "error", err, "comment_id", newComment.ID)
-		}
+	filename := fmt.Sprintf("claim-%d.%s", id, format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "json" {
+		return c.JSON(http.StatusOK, export)
 	}
-	return c.JSON(http.StatusCreated, newComment)
+
+	var body bytes.Buffer
+	if err := h.currentTemplates().export.Execute(&body, export); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to render claim export", "error", err, "claim_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to render claim export")
+	}
+	return c.Blob(http.StatusOK, "application/pdf", pdf.FromText(body.String()))
 }
-func (h *InsuranceHandler) getEmbedding(ctx context.Context, textToEmbed string) ([]float32, error) {
-	reqBody, err := json.Marshal(EmbeddingRequest{Text: textToEmbed})
+
+// buildClaimExport gathers everything in a claim package. History and comments are returned
+// oldest first so the package reads chronologically.
+func (h *InsuranceHandler) buildClaimExport(ctx context.Context, id int64) (*ClaimExport, error) {
+	claim, err := h.queries.GetClaimDetails(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Claim not found")
+		}
+		h.logger.ErrorContext(ctx, "Failed to get claim details", "error", err, "claim_id", id)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve claim details")
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", h.embeddingServiceURL, bytes.NewBuffer(reqBody))
+	history, err := h.queries.GetClaimStatusHistory(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+		h.logger.ErrorContext(ctx, "Failed to get claim status history", "error", err, "claim_id", id)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve claim status history")
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := h.httpClient.Do(req)
+	commentCount, err := h.platformQuerier.CountCommentsForItem(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call embedding service: %w", err)
+		h.logger.ErrorContext(ctx, "Failed to count comments", "error", err, "item_id", id)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve comments")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embedding service returned non-OK status %d: %s", resp.StatusCode, string(bodyBytes))
+	comments, err := h.platformQuerier.ListCommentsForItem(ctx, repository.ListCommentsForItemParams{
+		ItemID:    id,
+		PageLimit: int32(commentCount),
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to list comments", "error", err, "item_id", id)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve comments")
+	}
+	statusHistory := make([]ClaimStatusEvent, 0, len(history))
+	for _, event := range slices.Backward(history) {
+		statusHistory = append(statusHistory, ClaimStatusEvent{
+			EventID:        event.EventID,
+			EventType:      event.EventType,
+			EventTimestamp: event.EventTimestamp,
+			EventData:      event.EventData,
+			UserName:       event.UserName,
+		})
 	}
-	var embeddingResp EmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	slices.Reverse(comments)
+	if comments == nil {
+		comments = []repository.ListCommentsForItemRow{}
 	}
-	return embeddingResp.Embedding, nil
+	return &ClaimExport{
+		Claim:         claim,
+		StatusHistory: statusHistory,
+		Comments:      comments,
+		ExportedAt:    time.Now().UTC(),
+	}, nil
 }
-func (h *InsuranceHandler) callLLM(ctx context.Context, prompt string, useJSONMode bool) (string, error) {
-	h.logger.InfoContext(ctx, "Executing LLM call", "prompt", prompt)
-	apiKey := h.openAIAPIKey
-	if apiKey == "" {
-		return "", fmt.Errorf("OpenAI key is not configured on the handler")
+
+// exportFuncMap formats the nullable database types used by the claim export template.
+var exportFuncMap = template.FuncMap{
+	"text": func(t pgtype.Text) string {
+		return t.String
+	},
+	"date": func(d pgtype.Date) string {
+		if !d.Valid {
+			return ""
+		}
+		return d.Time.Format("2006-01-02")
+	},
+	"timestamp": func(v interface{}) string {
+		switch t := v.(type) {
+		case pgtype.Timestamptz:
+			if !t.Valid {
+				return ""
+			}
+			return t.Time.UTC().Format("2006-01-02 15:04 MST")
+		case time.Time:
+			return t.UTC().Format("2006-01-02 15:04 MST")
+		}
+		return ""
+	},
+	"amount": func(n pgtype.Numeric) string {
+		if !n.Valid {
+			return ""
+		}
+		return numericToDecimal(n).StringFixed(2)
+	},
+}
+
+// HandleBulkUpdateClaims moves a batch of claims to the same business status in one transaction.
+// Each claim is updated under its own savepoint, so one bad ID is reported in the per-claim
+// results without undoing the others. A claim listed more than once is updated and reported once.
+func (h *InsuranceHandler) HandleBulkUpdateClaims(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
 	}
-	payload := LLMRequestBody{
-		Model: "gpt-4o",
-		Messages: []Message{
-			{Role: "user", Content: prompt},
-		},
+	var req BulkUpdateClaimsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
-	if useJSONMode {
-		payload.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	seen := make(map[int64]bool, len(req.ClaimIDs))
+	req.ClaimIDs = slices.DeleteFunc(req.ClaimIDs, func(id int64) bool {
+		duplicate := seen[id]
+		seen[id] = true
+		return duplicate
+	})
+	if len(req.ClaimIDs) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "claim_ids must not be empty")
 	}
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal OpenAI request: %w", err)
+	if len(req.ClaimIDs) > maxBulkClaimUpdate {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("claim_ids must not contain more than %d IDs", maxBulkClaimUpdate))
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", h.LLMURL, bytes.NewBuffer(payloadBytes))
+	if !h.claimWorkflow.IsKnownStatus(req.BusinessStatus) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid business_status %q", req.BusinessStatus))
+	}
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return "", fmt.Errorf("failed to create OpenAI request: %w", err)
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]BulkClaimResult, 0, len(req.ClaimIDs))
+	updated := 0
+	for _, id := range req.ClaimIDs {
+		result := BulkClaimResult{ClaimID: id}
+		savepoint, err := tx.Begin(ctx)
+		if err == nil {
+			err = h.updateClaimStatus(ctx, h.txQueries(savepoint), id, req.BusinessStatus, userID)
+			if err == nil {
+				err = savepoint.Commit(ctx)
+			} else {
+				_ = savepoint.Rollback(ctx)
+			}
+		}
+		if err != nil {
+			var transitionErr *insurance.InvalidTransitionError
+			if errors.As(err, &transitionErr) {
+				h.recordRejectedTransition(ctx, id, transitionErr, userID)
+			}
+			result.Error = bulkClaimErrorMessage(err)
+		} else {
+			result.Success = true
+			updated++
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	h.logger.InfoContext(ctx, "Bulk updated claim status", "status", req.BusinessStatus, "requested", len(req.ClaimIDs), "updated", updated)
+	return c.JSON(http.StatusOK, BulkUpdateClaimsResponse{
+		UpdatedCount: updated,
+		FailedCount:  len(results) - updated,
+		Results:      results,
+	})
+}
+
+var (
+	errClaimNotFound = errors.New("claim not found")
+	errClaimEvent    = errors.New("failed to create claim status event")
+)
+
+// updateClaimStatus sets the business status of a claim and records a CLAIM_STATUS_CHANGED event.
+func (h *InsuranceHandler) updateClaimStatus(ctx context.Context, q repository.Querier, id int64, status string, userID int64) error {
+	existingItem, err := q.GetItemForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errClaimNotFound
+		}
+		h.logger.ErrorContext(ctx, "Failed to fetch item", "error", err, "item_id", id)
+		return err
+	}
+	var customProps map[string]interface{}
+	if err := json.Unmarshal(existingItem.CustomProperties, &customProps); err != nil {
+		return fmt.Errorf("failed to parse existing item properties: %w", err)
+	}
+	oldStatus := customProps["Status"]
+	currentStatus, _ := oldStatus.(string)
+	if err := h.claimWorkflow.CheckTransition(currentStatus, status); err != nil {
+		return err
+	}
+	customProps["Status"] = status
+	updatedCustomProps, err := json.Marshal(customProps)
+	if err != nil {
+		return fmt.Errorf("failed to serialize updated properties: %w", err)
+	}
+	updateParams := repository.UpdateItemParams{
+		ID:               id,
+		Scope:            existingItem.Scope,
+		Status:           existingItem.Status,
+		CustomProperties: updatedCustomProps,
+	}
+	_, err = q.UpdateItem(ctx, updateParams)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to update item", "error", err, "item_id", id)
+		return err
+	}
+	eventData := map[string]interface{}{"old_status": oldStatus, "new_status": status}
+	eventDataJSON, _ := json.Marshal(eventData)
+	eventParams := repository.CreateItemEventParams{
+		ItemID:    id,
+		EventType: "CLAIM_STATUS_CHANGED",
+		EventData: eventDataJSON,
+		CreatedBy: userID,
+	}
+	_, err = q.CreateItemEvent(ctx, eventParams)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create status change event", "error", err, "item_id", id)
+		return fmt.Errorf("%w: %v", errClaimEvent, err)
+	}
+	return nil
+}
+
+// recordRejectedTransition records an attempted status change the workflow refused, so invalid
+// transitions show up in the claim's history next to the changes that were made. It writes outside any surrounding transaction because
+// the attempt happened whether or not the rest of the request commits. Failures are only logged.
+func (h *InsuranceHandler) recordRejectedTransition(ctx context.Context, id int64, transitionErr *insurance.InvalidTransitionError, userID int64) {
+	eventData := map[string]interface{}{"old_status": transitionErr.From, "attempted_status": transitionErr.To}
+	eventDataJSON, _ := json.Marshal(eventData)
+	_, err := h.platformQuerier.CreateItemEvent(ctx, repository.CreateItemEventParams{
+		ItemID:    id,
+		EventType: "CLAIM_STATUS_TRANSITION_REJECTED",
+		EventData: eventDataJSON,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record rejected status transition", "error", err, "item_id", id)
+	}
+}
+
+func bulkClaimErrorMessage(err error) string {
+	var transitionErr *insurance.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return transitionErr.Error()
+	}
+	switch {
+	case errors.Is(err, errClaimNotFound):
+		return "Claim not found"
+	case errors.Is(err, errClaimEvent):
+		return "Failed to create audit event for claim update"
+	default:
+		return "Failed to update claim"
+	}
+}
+
+// CommentPageResponse is one page of a claim's comments, oldest first. NextCursor is the before
+// value that loads the page of older comments, and is null on the last page.
+type CommentPageResponse struct {
+	TotalCount int64                               `json:"total_count"`
+	Data       []repository.ListCommentsForItemRow `json:"data"`
+	Limit      int                                 `json:"limit"`
+	MaxLimit   int                                 `json:"max_limit"`
+	NextCursor *int64                              `json:"next_cursor"`
+}
+
+// HandleListComments returns one page of a claim's comments. The first page holds the most recent
+// comments (the comments page size, 50 by default, or the limit query param); passing a page's
+// next_cursor as the before query param loads the comments older than it. Each page is listed
+// oldest first, so the UI can prepend older pages to what it shows.
+func (h *InsuranceHandler) HandleListComments(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
+	}
+	pageSize := h.pageSizes.For(config.PageSizeComments)
+	limit := pageSize.Limit(c.QueryParam("limit"))
+	var before pgtype.Int8
+	if raw := c.QueryParam("before"); raw != "" {
+		before.Int64, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid before cursor")
+		}
+		before.Valid = true
+	}
+	totalCount, err := h.platformQuerier.CountCommentsForItem(ctx, id)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to count comments", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve comments")
+	}
+	// One comment past the page tells whether there are older ones to load.
+	comments, err := h.platformQuerier.ListCommentsForItem(ctx, repository.ListCommentsForItemParams{
+		ItemID:    id,
+		BeforeID:  before,
+		PageLimit: int32(limit + 1),
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to list comments", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve comments")
+	}
+	var nextCursor *int64
+	if len(comments) > limit {
+		comments = comments[:limit]
+		oldest := comments[limit-1].ID
+		nextCursor = &oldest
+	}
+	slices.Reverse(comments)
+	if comments == nil {
+		comments = []repository.ListCommentsForItemRow{}
+	}
+	return c.JSON(http.StatusOK, CommentPageResponse{
+		TotalCount: totalCount,
+		Data:       comments,
+		Limit:      limit,
+		MaxLimit:   pageSize.Max,
+		NextCursor: nextCursor,
+	})
+}
+func (h *InsuranceHandler) HandleCreateComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
+	}
+	var req CreateCommentRequest
+	if err := c.Bind(&req); err != nil || req.CommentText == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: comment_text is required")
+	}
+	var userID int64 = 1 // Placeholder for auth
+	params := repository.CreateCommentParams{
+		ItemID:  id,
+		Comment: req.CommentText,
+		UserID:  userID,
+	}
+	newComment, err := h.platformQuerier.CreateComment(ctx, params)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create comment", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save comment")
+	}
+	h.embedComment(ctx, newComment.ID, newComment.Comment)
+	return c.JSON(http.StatusCreated, newComment)
+}
+
+// HandleUpdateComment edits the text of a comment on a claim and records a COMMENT_EDITED event
+// on the claim in the same transaction. The old embedding is cleared with the edit and re-generated
+// afterwards, so semantic search never matches the old text. Only the comment's author may edit it.
+func (h *InsuranceHandler) HandleUpdateComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID, commentID, err := parseCommentPath(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateCommentRequest
+	if err := c.Bind(&req); err != nil || req.CommentText == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: comment_text is required")
+	}
+	userID, err := h.commentAuthor(ctx, itemID, commentID)
+	if err != nil {
+		return err
+	}
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.txQueries(tx)
+
+	updated, err := qtx.UpdateComment(ctx, repository.UpdateCommentParams{
+		ID:      commentID,
+		ItemID:  itemID,
+		Comment: req.CommentText,
+		UserID:  userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Comment not found")
+		}
+		h.logger.ErrorContext(ctx, "Failed to update comment", "error", err, "comment_id", commentID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update comment")
+	}
+	eventData := map[string]interface{}{
+		"comment_id":  commentID,
+		"old_comment": updated.PreviousComment,
+		"new_comment": updated.Comment,
+	}
+	if err := h.recordCommentEvent(ctx, qtx, itemID, "COMMENT_EDITED", eventData, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	h.embedComment(ctx, updated.ID, updated.Comment)
+	return c.JSON(http.StatusOK, updated)
+}
+
+// HandleDeleteComment soft deletes a comment so it no longer shows in listings or search,
+// and records a COMMENT_DELETED event on the claim in the same transaction. The row is kept for
+// the audit trail.
+// Only the comment's author may delete it.
+func (h *InsuranceHandler) HandleDeleteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID, commentID, err := parseCommentPath(c)
+	if err != nil {
+		return err
+	}
+	userID, err := h.commentAuthor(ctx, itemID, commentID)
+	if err != nil {
+		return err
+	}
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.txQueries(tx)
+
+	deleted, err := qtx.SoftDeleteComment(ctx, repository.SoftDeleteCommentParams{
+		ID:     commentID,
+		ItemID: itemID,
+		UserID: userID,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to delete comment", "error", err, "comment_id", commentID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete comment")
+	}
+	if deleted == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Comment not found")
+	}
+	eventData := map[string]interface{}{"comment_id": commentID}
+	if err := h.recordCommentEvent(ctx, qtx, itemID, "COMMENT_DELETED", eventData, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// commentAuthor returns the caller's user ID when they wrote the comment. Only a comment's author
+// may edit or delete it: other callers get 403, unauthenticated ones 401, and a missing comment 404.
+func (h *InsuranceHandler) commentAuthor(ctx context.Context, itemID, commentID int64) (int64, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return 0, echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	authorID, err := h.platformQuerier.GetCommentAuthor(ctx, repository.GetCommentAuthorParams{ID: commentID, ItemID: itemID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, echo.NewHTTPError(http.StatusNotFound, "Comment not found")
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to look up comment author", "error", err, "comment_id", commentID)
+		return 0, echo.NewHTTPError(http.StatusInternalServerError, "Failed to look up comment")
+	}
+	if authorID != userID {
+		h.logger.WarnContext(ctx, "Rejected change to another user's comment", "comment_id", commentID, "author_id", authorID, "user_id", userID)
+		return 0, echo.NewHTTPError(http.StatusForbidden, "Only the comment's author can change it")
+	}
+	return userID, nil
+}
+
+// parseCommentPath reads the claim and comment IDs from the :id and :commentId path params.
+func parseCommentPath(c echo.Context) (int64, int64, error) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid claim ID format")
+	}
+	commentID, err := strconv.ParseInt(c.Param("commentId"), 10, 64)
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid comment ID format")
+	}
+	return itemID, commentID, nil
+}
+
+func (h *InsuranceHandler) recordCommentEvent(ctx context.Context, q repository.Querier, itemID int64, eventType string, eventData map[string]interface{}, userID int64) error {
+	eventDataJSON, _ := json.Marshal(eventData)
+	_, err := q.CreateItemEvent(ctx, repository.CreateItemEventParams{
+		ItemID:    itemID,
+		EventType: eventType,
+		EventData: eventDataJSON,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create comment event", "error", err, "event_type", eventType)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create audit event for comment")
+	}
+	return nil
+}
+
+// embedComment generates and stores the embedding for a comment. Failures are logged but not
+// returned; the comment is still saved and will simply be missing from semantic search.
+func (h *InsuranceHandler) embedComment(ctx context.Context, commentID int64, text string) {
+	embedding, err := h.getEmbedding(ctx, text)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Failed to generate embedding for comment", "error", err, "comment_id", commentID)
+		return
+	}
+	updateEmbeddingParams := repository.SetCommentEmbeddingParams{
+		ID:        commentID,
+		Embedding: pgvector.NewVector(embedding),
+	}
+	if err := h.platformQuerier.SetCommentEmbedding(ctx, updateEmbeddingParams); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to save embedding for comment", "error", err, "comment_id", commentID)
+	}
+}
+func (h *InsuranceHandler) getEmbedding(ctx context.Context, textToEmbed string) ([]float32, error) {
+	reqBody, err := json.Marshal(EmbeddingRequest{Text: textToEmbed})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", h.embeddingServiceURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call embedding service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embedding service returned non-OK status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	var embeddingResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if err := rag.ValidateEmbedding(embeddingResp.Embedding); err != nil {
+		return nil, err
+	}
+	if h.normalizeEmbeddings {
+		return rag.NormalizeL2(embeddingResp.Embedding), nil
+	}
+	return embeddingResp.Embedding, nil
+}
+func (h *InsuranceHandler) callLLM(ctx context.Context, prompt string, useJSONMode bool) (string, error) {
+	if h.useStubLLM {
+		h.logger.DebugContext(ctx, "Returning stub LLM response", "prompt_length", len(prompt))
+		return rag.StubLLMResponse(prompt), nil
+	}
+	h.logger.InfoContext(ctx, "Executing LLM call", "prompt", prompt)
+	apiKey := h.openAIAPIKey
+	if apiKey == "" {
+		return "", fmt.Errorf("OpenAI key is not configured on the handler")
+	}
+	payload := LLMRequestBody{
+		Model: "gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: prompt},
+		},
+	}
+	if useJSONMode {
+		payload.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OpenAI request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", h.LLMURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to create OpenAI request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
@@ -454,6 +1515,7 @@ func (h *InsuranceHandler) callLLM(ctx context.Context, prompt string, useJSONMo
 	if err := json.NewDecoder(resp.Body).Decode(&llmResponse); err != nil {
 		return "", fmt.Errorf("failed to decode OpenAI response: %w", err)
 	}
+	rag.RecordUsage(ctx, payload.Model, llmResponse.Usage)
 	if len(llmResponse.Choices) == 0 {
 		return "", fmt.Errorf("no choices returned from OpenAI")
 	}
@@ -471,34 +1533,243 @@ func (h *InsuranceHandler) HandleInsuranceQuery(c echo.Context) error {
 	if req.Question == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "field 'question' is required")
 	}
-	plan, err := h.getExecutionPlan(ctx, req.Question, req.History)
+	if pattern, found := rag.DetectPromptInjection(req.Question); found {
+		h.logger.WarnContext(ctx, "Rejected insurance question matching a prompt-injection pattern", "pattern", pattern)
+		return echo.NewHTTPError(http.StatusBadRequest, "The question looks like an attempt to change the assistant's instructions. Please rephrase it as a question about your claims.")
+	}
+	if pattern, index, found := rag.DetectHistoryInjection(req.History); found {
+		h.logger.WarnContext(ctx, "Rejected insurance history matching a prompt-injection pattern", "pattern", pattern, "message_index", index)
+		return echo.NewHTTPError(http.StatusBadRequest, "A message in the conversation history looks like an attempt to change the assistant's instructions. Please start a new conversation.")
+	}
+	language, err := rag.ResolveLanguage(req.Language)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "field 'language': "+err.Error())
+	}
+	req.Language = language
+	conversationID := uuid.New()
+	if req.ConversationID != "" {
+		parsed, err := uuid.Parse(req.ConversationID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "field 'conversation_id' must be a UUID")
+		}
+		conversationID = parsed
+		if err := h.checkConversationOwner(ctx, conversationID); err != nil {
+			return err
+		}
+	}
+	usage := rag.NewUsageTracker(h.llmPrices)
+	ctx = rag.WithUsageTracker(ctx, usage)
+	// The planner and synthesizer only see the question and history with PII masked; the planned
+	// tool arguments get the original values back before the tools run.
+	redactions := rag.NewRedactionMap()
+	question, history := h.redactPrompt(req.Question, req.History, redactions)
+	plan, err := h.getExecutionPlan(ctx, question, history)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "RAG Error: Failed to get execution plan", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Error planning query")
 	}
-	contextData, err := h.getContextFromPlan(ctx, plan)
-	if err != nil {
+	for i := range plan {
+		plan[i].Arguments = redactions.RestoreArguments(plan[i].Arguments)
+	}
+	contextData := &InsuranceContext{NoDataRetrieved: true}
+	if len(plan) == 0 {
+		h.logger.InfoContext(ctx, "Planner chose no tools, synthesizing answer without retrieved data")
+	} else if contextData, err = h.getContextFromPlan(ctx, plan); err != nil {
 		h.logger.ErrorContext(ctx, "RAG Error: Failed to execute plan", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Error executing plan")
 	}
-	finalApiResponse, err := h.synthesizeAnswer(ctx, c, req.Question, req.History, contextData)
+	finalApiResponse, err := h.synthesizeAnswer(ctx, c, question, history, language, contextData, redactions)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "RAG Error: Failed to synthesize answer", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Error synthesizing answer")
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{"answer": finalApiResponse})
+	summary := usage.Summary()
+	h.logger.InfoContext(ctx, "Insurance query LLM usage", "llm_calls", summary.Calls, "prompt_tokens", summary.PromptTokens,
+		"completion_tokens", summary.CompletionTokens, "total_tokens", summary.TotalTokens, "estimated_cost_usd", summary.EstimatedCostUSD)
+	h.recordConversationTurn(ctx, conversationID, req, contextData, finalApiResponse, summary)
+	response := map[string]interface{}{"answer": finalApiResponse, "usage": summary, "conversation_id": conversationID.String()}
+	if conversationUsage, err := h.platformQuerier.GetConversationUsage(ctx, pgtype.UUID{Bytes: conversationID, Valid: true}); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to total conversation usage", "error", err, "conversation_id", conversationID)
+	} else {
+		response["conversation_usage"] = rag.UsageSummary{
+			Calls:            int(conversationUsage.LlmCalls),
+			PromptTokens:     int(conversationUsage.PromptTokens),
+			CompletionTokens: int(conversationUsage.CompletionTokens),
+			TotalTokens:      int(conversationUsage.TotalTokens),
+			EstimatedCostUSD: conversationUsage.EstimatedCostUsd,
+		}
+	}
+	if req.Explain {
+		h.addRationales(ctx, req.Question, resultPointers(contextData.KnowledgeChunks, contextData.Comments))
+		if !includeScores(c) {
+			withoutScores(contextData.KnowledgeChunks)
+			withoutScores(contextData.Comments)
+		}
+		response["sources"] = map[string]interface{}{"knowledge_chunks": contextData.KnowledgeChunks, "comments": contextData.Comments}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// checkConversationOwner returns a 403 unless the conversation was started by the caller. An ID
+// with no stored turns starts a new conversation.
+func (h *InsuranceHandler) checkConversationOwner(ctx context.Context, conversationID uuid.UUID) error {
+	owner, err := h.platformQuerier.GetConversationOwner(ctx, pgtype.UUID{Bytes: conversationID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to look up conversation owner", "error", err, "conversation_id", conversationID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load conversation")
+	}
+	// Turns stored without a user can only be continued without one.
+	userID, ok := UserIDFromContext(ctx)
+	if owner.Valid != ok || owner.Int64 != userID {
+		return echo.NewHTTPError(http.StatusForbidden, "The conversation belongs to another user")
+	}
+	return nil
+}
+
+// recordConversationTurn stores an answered query with the context it was answered from and the
+// LLM usage it took, so it can be replayed later and its conversation's usage totalled. Failures
+// are logged and never fail the query itself.
+func (h *InsuranceHandler) recordConversationTurn(ctx context.Context, conversationID uuid.UUID, req InsuranceQueryRequest, contextData *InsuranceContext, answer QueryApiResponse, usage rag.UsageSummary) {
+	history := req.History
+	if history == nil {
+		history = []ChatMessage{}
+	}
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to marshal conversation history", "error", err)
+		return
+	}
+	contextJSON, err := json.Marshal(contextData)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to marshal retrieved context", "error", err)
+		return
+	}
+	answerJSON, err := json.Marshal(answer)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to marshal answer", "error", err)
+		return
+	}
+	userID, ok := UserIDFromContext(ctx)
+	_, err = h.platformQuerier.CreateConversationTurn(ctx, repository.CreateConversationTurnParams{
+		ConversationID:   pgtype.UUID{Bytes: conversationID, Valid: true},
+		RagContext:       insuranceRAGContext,
+		UserID:           pgtype.Int8{Int64: userID, Valid: ok},
+		Question:         req.Question,
+		History:          historyJSON,
+		RetrievedContext: contextJSON,
+		Answer:           answerJSON,
+		Language:         req.Language,
+		LlmCalls:         int32(usage.Calls),
+		PromptTokens:     int32(usage.PromptTokens),
+		CompletionTokens: int32(usage.CompletionTokens),
+		TotalTokens:      int32(usage.TotalTokens),
+		EstimatedCostUsd: usage.EstimatedCostUSD,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to store conversation turn", "error", err, "conversation_id", conversationID)
+	}
+}
+
+// HandleReplayConversation re-runs the synthesizer with the current templates over every stored
+// turn of a conversation, feeding it the context retrieved when the turn was first answered, and
+// returns the original and replayed answers side by side. Stored claims data is replayed as plain
+// JSON, so drawer actions that need a typed claim row are not reproduced.
+func (h *InsuranceHandler) HandleReplayConversation(c echo.Context) error {
+	ctx := c.Request().Context()
+	reqLogger := h.logger.With("request_id", c.Get("requestID"))
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid conversation ID")
+	}
+
+	turns, err := h.platformQuerier.ListConversationTurns(ctx, pgtype.UUID{Bytes: conversationID, Valid: true})
+	if err != nil {
+		reqLogger.ErrorContext(ctx, "Failed to list conversation turns", "error", err, "conversation_id", conversationID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load conversation")
+	}
+	if len(turns) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Conversation not found")
+	}
+
+	usage := rag.NewUsageTracker(h.llmPrices)
+	ctx = rag.WithUsageTracker(ctx, usage)
+	replayed := make([]ReplayedTurn, 0, len(turns))
+	var originalUsage rag.UsageSummary
+	for _, turn := range turns {
+		originalUsage.Calls += int(turn.LlmCalls)
+		originalUsage.PromptTokens += int(turn.PromptTokens)
+		originalUsage.CompletionTokens += int(turn.CompletionTokens)
+		originalUsage.TotalTokens += int(turn.TotalTokens)
+		originalUsage.EstimatedCostUSD += turn.EstimatedCostUsd
+		result := ReplayedTurn{
+			TurnID:         turn.ID,
+			Question:       turn.Question,
+			AskedAt:        turn.CreatedAt.Time,
+			OriginalAnswer: json.RawMessage(turn.Answer),
+		}
+		answer, err := h.replayTurn(ctx, c, turn)
+		if err != nil {
+			reqLogger.WarnContext(ctx, "Failed to replay conversation turn", "error", err, "turn_id", turn.ID)
+			result.Error = err.Error()
+		} else {
+			result.ReplayedAnswer = &answer
+		}
+		replayed = append(replayed, result)
+	}
+
+	reqLogger.InfoContext(ctx, "Replayed conversation", "conversation_id", conversationID, "turns", len(replayed))
+	return c.JSON(http.StatusOK, ConversationReplayResponse{
+		ConversationID: conversationID.String(),
+		Turns:          replayed,
+		Usage:          usage.Summary(),
+		OriginalUsage:  originalUsage,
+	})
+}
+
+func (h *InsuranceHandler) replayTurn(ctx context.Context, c echo.Context, turn repository.ConversationTurn) (QueryApiResponse, error) {
+	if turn.RagContext != insuranceRAGContext {
+		return QueryApiResponse{}, fmt.Errorf("turn was answered by the %q context, which cannot be replayed here", turn.RagContext)
+	}
+	var history []ChatMessage
+	if err := json.Unmarshal(turn.History, &history); err != nil {
+		return QueryApiResponse{}, fmt.Errorf("failed to read stored history: %w", err)
+	}
+	var contextData InsuranceContext
+	if err := json.Unmarshal(turn.RetrievedContext, &contextData); err != nil {
+		return QueryApiResponse{}, fmt.Errorf("failed to read stored context: %w", err)
+	}
+	// The language was resolved before it was stored; resolving it again keeps unexpected values
+	// out of the prompt.
+	language, err := rag.ResolveLanguage(turn.Language)
+	if err != nil {
+		return QueryApiResponse{}, fmt.Errorf("stored language: %w", err)
+	}
+	redactions := rag.NewRedactionMap()
+	question, history := h.redactPrompt(turn.Question, history, redactions)
+	return h.synthesizeAnswer(ctx, c, question, history, language, &contextData, redactions)
 }
 func (h *InsuranceHandler) getExecutionPlan(ctx context.Context, question string, history []ChatMessage) ([]ToolCall, error) {
 	type PlannerTemplateData struct {
 		UserQuestion string
 		History      []ChatMessage
+		Examples     []rag.PlannerExample
+		Tools        []rag.PlannerTool
+		ToolsPrompt  string
 	}
+	templates := h.currentTemplates()
+	tools := h.plannerTools()
 	templateData := PlannerTemplateData{
 		UserQuestion: question,
 		History:      history,
+		Examples:     templates.plannerExamples,
+		Tools:        tools,
+		ToolsPrompt:  rag.FormatPlannerTools(tools),
 	}
 	var promptBuffer bytes.Buffer
-	if err := h.plannerTemplate.Execute(&promptBuffer, templateData); err != nil {
+	if err := templates.planner.Execute(&promptBuffer, templateData); err != nil {
 		return nil, fmt.Errorf("failed to execute planner template: %w", err)
 	}
 	llmResponseContent, err := h.callLLM(ctx, promptBuffer.String(), true)
@@ -513,278 +1784,398 @@ func (h *InsuranceHandler) getExecutionPlan(ctx context.Context, question string
 	}
 	return plannerResponse.ToolCalls, nil
 }
+
+// claimsFilters are the get_claims_data tool's arguments, converted to query parameters.
+type claimsFilters struct {
+	searchQuery      string
+	claimID          pgtype.Text
+	adjusterAssigned pgtype.Text
+	status           pgtype.Text
+	policyNumber     pgtype.Text
+	sortBy           string
+	sortDirection    string
+	minAmount        pgtype.Numeric
+	maxAmount        pgtype.Numeric
+}
+
+// claimsFilterArgs converts the get_claims_data arguments to filters. An invalid argument is left
+// out of the filters rather than failing the call; the returned error names every argument that
+// was left out. An invalid sort leaves both sort arguments out, so claims come in the default order.
+func claimsFilterArgs(args rag.ToolArgs) (claimsFilters, error) {
+	var f claimsFilters
+	var errs []error
+	collect := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var err error
+	f.searchQuery, err = args.String("semantic_search_query", "")
+	collect(err)
+	f.claimID, err = args.Text("claim_id")
+	collect(err)
+	f.adjusterAssigned, err = args.Text("adjuster_assigned")
+	collect(err)
+	f.status, err = args.Text("status")
+	collect(err)
+	f.policyNumber, err = args.Text("policy_number")
+	collect(err)
+	f.sortBy, err = args.String("sort_by", "")
+	collect(err)
+	f.sortDirection, err = args.String("sort_direction", "")
+	collect(err)
+	f.minAmount, err = args.Numeric("min_amount")
+	collect(err)
+	f.maxAmount, err = args.Numeric("max_amount")
+	collect(err)
+	f.sortBy, f.sortDirection, err = validateClaimsSort(f.sortBy, f.sortDirection)
+	collect(err)
+	return f, errors.Join(errs...)
+}
+
+// query returns the filters as query parameters for GET /claims, which applies them the same way.
+func (f claimsFilters) query() url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("semantic_search_query", f.searchQuery)
+	set("claim_id", f.claimID.String)
+	set("adjuster_assigned", f.adjusterAssigned.String)
+	set("status", f.status.String)
+	set("policy_number", f.policyNumber.String)
+	set("sort_by", f.sortBy)
+	set("sort_direction", f.sortDirection)
+	if f.minAmount.Valid {
+		set("min_amount", numericToDecimal(f.minAmount).String())
+	}
+	if f.maxAmount.Valid {
+		set("max_amount", numericToDecimal(f.maxAmount).String())
+	}
+	return query
+}
+
+// getContextFromPlan runs the plan's tools and gathers their results. A critical tool that fails
+// is retried once, and listed in UnavailableTools if it fails again.
 func (h *InsuranceHandler) getContextFromPlan(ctx context.Context, plan []ToolCall) (*InsuranceContext, error) {
 	var insuranceCtx InsuranceContext
 	reqLogger := h.logger.With("plan_execution", true)
 
+	tools := h.tools()
 	for _, toolCall := range plan {
-		switch toolCall.ToolName {
-		case "get_claims_data":
-			var claimsData interface{}
-			var err error
-			// Helper functions for safe argument parsing
-			getStringArg := func(key string) string {
-				if val, ok := toolCall.Arguments[key]; ok {
-					if strVal, ok := val.(string); ok {
-						return strVal
-					}
-				}
-				return ""
-			}
-			parseAmount := func(amountVal interface{}) pgtype.Numeric {
-				if amountVal == nil {
-					return pgtype.Numeric{Valid: false}
-				}
-				var amountStr string
-				switch v := amountVal.(type) {
-				case string:
-					amountStr = v
-				case float64:
-					amountStr = fmt.Sprintf("%.2f", v)
-				default:
-					return pgtype.Numeric{Valid: false}
-				}
-				if amountStr == "" {
-					return pgtype.Numeric{Valid: false}
-				}
-				d, err := decimal.NewFromString(amountStr)
-				if err != nil {
-					return pgtype.Numeric{Valid: false}
-				}
-				num := new(pgtype.Numeric)
-				_ = num.Scan(d.String())
-				return *num
+		err := h.runPlanTool(ctx, &insuranceCtx, toolCall, reqLogger)
+		if err != nil && tools[toolCall.ToolName].Critical {
+			reqLogger.WarnContext(ctx, "Critical tool failed, retrying it once", "tool_name", toolCall.ToolName, "attempt", 1)
+			err = h.runPlanTool(ctx, &insuranceCtx, toolCall, reqLogger)
+		}
+		switch {
+		case err == nil:
+			// A later call of the same tool that succeeds makes its data available after all.
+			insuranceCtx.UnavailableTools = slices.DeleteFunc(insuranceCtx.UnavailableTools, func(name string) bool { return name == toolCall.ToolName })
+		case tools[toolCall.ToolName].Critical:
+			reqLogger.ErrorContext(ctx, "Critical tool failed again, its data will be reported as unavailable", "tool_name", toolCall.ToolName, "attempt", 2)
+			if !slices.Contains(insuranceCtx.UnavailableTools, toolCall.ToolName) {
+				insuranceCtx.UnavailableTools = append(insuranceCtx.UnavailableTools, toolCall.ToolName)
 			}
+		}
+	}
 
-			searchQuery := getStringArg("semantic_search_query")
-			if searchQuery != "" {
-				embedding, embErr := h.getEmbedding(ctx, searchQuery)
-				if embErr != nil {
-					reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", embErr)
-					continue
-				}
-				params := insurance.ListClaimsWithVectorParams{
-					Limit:            100,
-					Offset:           0,
-					SearchEmbedding:  pgvector.NewVector(embedding),
-					ClaimID:          pgtype.Text{String: getStringArg("claim_id"), Valid: getStringArg("claim_id") != ""},
-					AdjusterAssigned: pgtype.Text{String: getStringArg("adjuster_assigned"), Valid: getStringArg("adjuster_assigned") != ""},
-					Status:           pgtype.Text{String: getStringArg("status"), Valid: getStringArg("status") != ""},
-					PolicyNumber:     pgtype.Text{String: getStringArg("policy_number"), Valid: getStringArg("policy_number") != ""},
-					MinAmount:        parseAmount(toolCall.Arguments["min_amount"]),
-					MaxAmount:        parseAmount(toolCall.Arguments["max_amount"]),
-				}
-				claims, vectorErr := h.queries.ListClaimsWithVector(ctx, params)
-				claimsData = claims
-				err = vectorErr
-			} else {
-				params := insurance.ListClaimsWithoutVectorParams{
-					Limit:            100,
-					Offset:           0,
-					ClaimID:          pgtype.Text{String: getStringArg("claim_id"), Valid: getStringArg("claim_id") != ""},
-					AdjusterAssigned: pgtype.Text{String: getStringArg("adjuster_assigned"), Valid: getStringArg("adjuster_assigned") != ""},
-					Status:           pgtype.Text{String: getStringArg("status"), Valid: getStringArg("status") != ""},
-					PolicyNumber:     pgtype.Text{String: getStringArg("policy_number"), Valid: getStringArg("policy_number") != ""},
-					SortBy:           getStringArg("sort_by"),
-					SortDirection:    getStringArg("sort_direction"),
-					MinAmount:        parseAmount(toolCall.Arguments["min_amount"]),
-					MaxAmount:        parseAmount(toolCall.Arguments["max_amount"]),
-				}
-				claims, nonVectorErr := h.queries.ListClaimsWithoutVector(ctx, params)
-				claimsData = claims
-				err = nonVectorErr
-			}
-			if err != nil {
-				reqLogger.ErrorContext(ctx, "Failed to execute 'get_claims_data' tool", "error", err)
-				continue
-			}
+	// Overlapping chunks and repeated searches return near-identical text; keep only the closest
+	// match of each so duplicates don't crowd the synthesizer's context.
+	resultText := func(r SearchResult) string { return r.Text }
+	chunkCount, commentCount := len(insuranceCtx.KnowledgeChunks), len(insuranceCtx.Comments)
+	insuranceCtx.KnowledgeChunks = rag.DedupeNearDuplicates(insuranceCtx.KnowledgeChunks, resultText, func(a, b SearchResult) bool {
+		return a.score() > b.score()
+	}, rag.DefaultDuplicateThreshold)
+	insuranceCtx.Comments = rag.DedupeNearDuplicates(insuranceCtx.Comments, resultText, nil, rag.DefaultDuplicateThreshold)
+	if dropped := chunkCount + commentCount - len(insuranceCtx.KnowledgeChunks) - len(insuranceCtx.Comments); dropped > 0 {
+		reqLogger.InfoContext(ctx, "Dropped near-duplicate search results", "dropped", dropped)
+	}
+	if len(insuranceCtx.LimitedTools) > 0 {
+		reqLogger.InfoContext(ctx, "Tool results were truncated at their limits", "limited_tools", insuranceCtx.LimitedTools)
+	}
+	return &insuranceCtx, nil
+}
 
-			var claimsCount int
-			switch v := claimsData.(type) {
-			case []insurance.ListClaimsWithVectorRow:
-				claimsCount = len(v)
-			case []insurance.ListClaimsWithoutVectorRow:
-				claimsCount = len(v)
+// runPlanTool runs one planned tool call, adding its results to insuranceCtx. It returns an error
+// when the tool could not fetch its data, so critical tools can be retried; invalid arguments are
+// logged and skipped, as retrying them would fail the same way.
+func (h *InsuranceHandler) runPlanTool(ctx context.Context, insuranceCtx *InsuranceContext, toolCall ToolCall, reqLogger *slog.Logger) error {
+	switch toolCall.ToolName {
+	case "get_claims_data":
+		filters, argErr := claimsFilterArgs(rag.ToolArgs(toolCall.Arguments))
+		if argErr != nil {
+			reqLogger.WarnContext(ctx, "Ignoring invalid arguments for get_claims_data", "error", argErr)
+			ignoreArguments(insuranceCtx, toolCall.ToolName, argErr)
+		}
+		// One row past the cap is fetched so a result set that exactly fills it isn't flagged.
+		claimsLimit := int32(h.toolLimits.Limit(toolCall.ToolName)) + 1
+		var claimsData interface{}
+		var err error
+		if filters.searchQuery != "" {
+			embedding, embErr := h.getEmbedding(ctx, filters.searchQuery)
+			if embErr != nil {
+				reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", embErr)
+				return embErr
 			}
-			insuranceCtx.ClaimsData = claimsData
-			reqLogger.InfoContext(ctx, "Executed tool: get_claims_data", "results_found", claimsCount)
-
-		case "search_knowledge_base":
-			getStringArg := func(key string) string {
-				if val, ok := toolCall.Arguments[key]; ok {
-					if strVal, ok := val.(string); ok {
-						return strVal
-					}
-				}
-				return ""
+			params := insurance.ListClaimsWithVectorParams{
+				Limit:            claimsLimit,
+				Offset:           0,
+				SearchEmbedding:  pgvector.NewVector(embedding),
+				ClaimID:          filters.claimID,
+				AdjusterAssigned: filters.adjusterAssigned,
+				Status:           filters.status,
+				PolicyNumber:     filters.policyNumber,
+				SortBy:           filters.sortBy,
+				SortDirection:    filters.sortDirection,
+				MinAmount:        filters.minAmount,
+				MaxAmount:        filters.maxAmount,
 			}
-			searchQuery := getStringArg("search_query")
-			if searchQuery == "" {
-				reqLogger.WarnContext(ctx, "Missing 'search_query' argument for search_knowledge_base")
-				continue
+			claims, vectorErr := h.queries.ListClaimsWithVector(ctx, params)
+			claimsData = limitResults(insuranceCtx, h.toolLimits, toolCall.ToolName, claims)
+			err = vectorErr
+		} else {
+			params := insurance.ListClaimsWithoutVectorParams{
+				Limit:            claimsLimit,
+				Offset:           0,
+				ClaimID:          filters.claimID,
+				AdjusterAssigned: filters.adjusterAssigned,
+				Status:           filters.status,
+				PolicyNumber:     filters.policyNumber,
+				SortBy:           filters.sortBy,
+				SortDirection:    filters.sortDirection,
+				MinAmount:        filters.minAmount,
+				MaxAmount:        filters.maxAmount,
 			}
-			embedding, err := h.getEmbedding(ctx, searchQuery)
+			claims, nonVectorErr := h.queries.ListClaimsWithoutVector(ctx, params)
+			claimsData = limitResults(insuranceCtx, h.toolLimits, toolCall.ToolName, claims)
+			err = nonVectorErr
+		}
+		if err != nil {
+			reqLogger.ErrorContext(ctx, "Failed to execute 'get_claims_data' tool", "error", err)
+			return err
+		}
+
+		var claimsCount int
+		switch v := claimsData.(type) {
+		case []insurance.ListClaimsWithVectorRow:
+			claimsCount = len(v)
+		case []insurance.ListClaimsWithoutVectorRow:
+			claimsCount = len(v)
+		}
+		insuranceCtx.ClaimsData = claimsData
+		insuranceCtx.ClaimsQuery = filters.query()
+		reqLogger.InfoContext(ctx, "Executed tool: get_claims_data", "results_found", claimsCount)
+
+	case "search_knowledge_base":
+		searchQuery, argErr := rag.ToolArgs(toolCall.Arguments).String("search_query", "")
+		if argErr != nil || searchQuery == "" {
+			reqLogger.WarnContext(ctx, "Missing or invalid 'search_query' argument for search_knowledge_base", "error", argErr)
+			return nil
+		}
+		embedding, err := h.getEmbedding(ctx, searchQuery)
+		if err != nil {
+			reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", err)
+			return err
+		}
+		pgVec := pgvector.NewVector(embedding)
+
+		knowledgeChunks, err1 := h.queries.SearchKnowledgeChunks(ctx, insurance.SearchKnowledgeChunksParams{
+			Embedding: pgVec,
+			Limit:     int32(h.toolLimits.Limit(toolCall.ToolName)) + 1,
+		})
+		if err1 != nil {
+			reqLogger.ErrorContext(ctx, "Failed to search knowledge chunks", "error", err1)
+			return err1
+		}
+		knowledgeChunks = limitResults(insuranceCtx, h.toolLimits, toolCall.ToolName, knowledgeChunks)
+
+		var enrichedResults []SearchResult
+		for _, chunk := range knowledgeChunks {
+			metadata, err := decodeMetadata([]byte(chunk.StructuredMetadata.String))
 			if err != nil {
-				reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", err)
-				continue
+				reqLogger.WarnContext(ctx, "Ignoring unreadable structured metadata on knowledge chunk", "source", chunk.Source, "error", err)
 			}
-			pgVec := pgvector.NewVector(embedding)
-
-			knowledgeChunks, err1 := h.queries.SearchKnowledgeChunks(ctx, insurance.SearchKnowledgeChunksParams{
-				Embedding: pgVec,
-				Limit:     5,
-			})
-			if err1 != nil {
-				reqLogger.ErrorContext(ctx, "Failed to search knowledge chunks", "error", err1)
-				continue // Use continue to skip to the next tool call on error
+			enrichedResult := SearchResult{
+				Source:          chunk.Source,
+				Text:            chunk.Text,
+				SimilarityScore: cosineScore(chunk.SimilarityScore),
+				Metadata:        metadata,
 			}
 
-			var enrichedResults []SearchResult
-			for _, chunk := range knowledgeChunks {
-				sourceText, _ := chunk.Source.(string)
-				textValue, _ := chunk.Text.(string)
-				score, _ := chunk.SimilarityScore.(float64)
-				var metadata map[string]interface{}
-				if rawJSON, ok := chunk.StructuredMetadata.([]byte); ok && rawJSON != nil {
-					_ = json.Unmarshal(rawJSON, &metadata)
+			// Chunks ingested with chunk_metadata already carry their enrichment; merge it directly.
+			chunkMetadata, err := decodeMetadata(chunk.ChunkMetadata)
+			if err != nil {
+				reqLogger.WarnContext(ctx, "Ignoring unreadable chunk_metadata on knowledge chunk", "source", chunk.Source, "error", err)
+			}
+			if len(chunkMetadata) > 0 {
+				if enrichedResult.Metadata == nil {
+					enrichedResult.Metadata = make(map[string]interface{}, len(chunkMetadata))
 				}
-
-				enrichedResult := SearchResult{
-					Source:          sourceText,
-					Text:            textValue,
-					SimilarityScore: float32(score),
-					Metadata:        metadata,
-				}
-
-				// Fetch and merge header data if a document_id is present
-				if enrichedResult.Metadata != nil {
-					if docID, ok := enrichedResult.Metadata["document_id"].(string); ok && docID != "" {
-						headerMetadataJSON, err := h.queries.GetDocumentHeader(ctx, docID)
-						if err != nil {
-							reqLogger.WarnContext(ctx, "Could not fetch document header", "doc_id", docID, "error", err)
-						} else if headerMetadataJSON != nil {
-							if rawJSON, ok := headerMetadataJSON.([]byte); ok {
-								var headerMetadata map[string]interface{}
-								if err := json.Unmarshal(rawJSON, &headerMetadata); err == nil {
-									// Ensure metadata map is initialized
-									if enrichedResult.Metadata == nil {
-										enrichedResult.Metadata = make(map[string]interface{})
-									}
-									// Merge header properties into the chunk's metadata
-									for key, value := range headerMetadata {
-										enrichedResult.Metadata[key] = value
-									}
-								}
-							}
-						}
-					}
+				for key, value := range chunkMetadata {
+					enrichedResult.Metadata[key] = value
 				}
 				enrichedResults = append(enrichedResults, enrichedResult)
+				continue
 			}
-			insuranceCtx.KnowledgeChunks = append(insuranceCtx.KnowledgeChunks, enrichedResults...)
 
-		case "search_comments":
-			getStringArg := func(key string) string {
-				if val, ok := toolCall.Arguments[key]; ok {
-					if strVal, ok := val.(string); ok {
-						return strVal
+			// Legacy chunks: fetch and merge header data if a document_id is present
+			if enrichedResult.Metadata != nil {
+				if docID, ok := enrichedResult.Metadata["document_id"].(string); ok && docID != "" {
+					headerMetadataJSON, err := h.queries.GetDocumentHeader(ctx, docID)
+					if err != nil {
+						reqLogger.WarnContext(ctx, "Could not fetch document header", "doc_id", docID, "error", err)
+					} else if headerMetadata, err := decodeMetadata(headerMetadataJSON); err != nil {
+						reqLogger.WarnContext(ctx, "Ignoring unreadable document header", "doc_id", docID, "error", err)
+					} else {
+						// Merge header properties into the chunk's metadata
+						for key, value := range headerMetadata {
+							enrichedResult.Metadata[key] = value
+						}
 					}
 				}
-				return ""
 			}
-			searchQuery := getStringArg("search_query")
-			if searchQuery == "" {
-				reqLogger.WarnContext(ctx, "Missing 'search_query' argument for search_comments")
-				continue
-			}
-			embedding, err := h.getEmbedding(ctx, searchQuery)
-			if err != nil {
-				reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", err)
-				continue
-			}
-			pgVec := pgvector.NewVector(embedding)
+			enrichedResults = append(enrichedResults, enrichedResult)
+		}
+		explainMatches(enrichedResults, searchQuery)
+		insuranceCtx.KnowledgeChunks = append(insuranceCtx.KnowledgeChunks, enrichedResults...)
 
+	case "search_comments":
+		searchQuery, argErr := rag.ToolArgs(toolCall.Arguments).String("search_query", "")
+		if argErr != nil || searchQuery == "" {
+			reqLogger.WarnContext(ctx, "Missing or invalid 'search_query' argument for search_comments", "error", argErr)
+			return nil
+		}
+		commentsLimit := int32(h.toolLimits.Limit(toolCall.ToolName)) + 1
+		// Keyword hits catch exact terms (ticket numbers, names) that the vector search can miss,
+		// so they are fetched even when the embedding service is unavailable.
+		scope := ItemScopeFromContext(ctx)
+		keywordComments, err := h.queries.SearchCommentsKeyword(ctx, insurance.SearchCommentsKeywordParams{
+			SearchQuery: searchQuery,
+			Limit:       commentsLimit,
+			ViewAll:     scope.ViewAll,
+			Scopes:      scope.Scopes,
+		})
+		if err != nil {
+			reqLogger.ErrorContext(ctx, "Failed to keyword search comments", "error", err)
+		}
+		var vectorResults []SearchResult
+		embedding, embErr := h.getEmbedding(ctx, searchQuery)
+		if embErr != nil {
+			reqLogger.ErrorContext(ctx, "Failed to get embedding", "error", embErr)
+		} else {
 			comments, err2 := h.queries.SearchComments(ctx, insurance.SearchCommentsParams{
-				Embedding: pgVec,
-				Limit:     10,
+				Embedding: pgvector.NewVector(embedding),
+				Limit:     commentsLimit,
+				ViewAll:   scope.ViewAll,
+				Scopes:    scope.Scopes,
 			})
 			if err2 != nil {
 				reqLogger.ErrorContext(ctx, "Failed to search comments", "error", err2)
-			} else {
-				var commentResults []SearchResult
-				for _, comment := range comments {
-					score, _ := comment.SimilarityScore.(float64)
-
-					commentMetadata := make(map[string]interface{})
-					if comment.ClaimID.Valid {
-						commentMetadata["claim_id"] = comment.ClaimID.String
-					}
-
-					commentResults = append(commentResults, SearchResult{
-						Source:          comment.Source,
-						Text:            comment.Text,
-						SimilarityScore: float32(score),
-						Metadata:        commentMetadata,
-					})
-				}
-				insuranceCtx.Comments = commentResults
+			}
+			for _, comment := range comments {
+				vectorResults = append(vectorResults, SearchResult{
+					Source:          comment.Source,
+					Text:            comment.Text,
+					SimilarityScore: cosineScore(comment.SimilarityScore),
+					Metadata:        commentMetadata(comment.ClaimID),
+				})
 			}
 		}
+		comments := fuseCommentResults(keywordSearchResults(keywordComments), vectorResults, int(commentsLimit))
+		insuranceCtx.Comments = limitResults(insuranceCtx, h.toolLimits, toolCall.ToolName, comments)
+		explainMatches(insuranceCtx.Comments, searchQuery)
 	}
-	return &insuranceCtx, nil
+	return nil
 }
 
-func (h *InsuranceHandler) synthesizeAnswer(ctx context.Context, c echo.Context, question string, history []ChatMessage, context *InsuranceContext) (QueryApiResponse, error) {
+// synthesizeAnswer writes the answer from the retrieved context. question and history must already
+// be redacted with redactions, which then masks the context as well.
+func (h *InsuranceHandler) synthesizeAnswer(ctx context.Context, c echo.Context, question string, history []ChatMessage, language string, context *InsuranceContext, redactions *rag.RedactionMap) (QueryApiResponse, error) {
 	h.logger.InfoContext(ctx, "Synthesizing final answer from hybrid context...")
+	claimsData, err := h.redactClaimsData(context.ClaimsData, redactions)
+	if err != nil {
+		return QueryApiResponse{}, err
+	}
 	templateData := SynthesizerTemplateData{
-		UserQuestion:    question,
-		History:         history,
-		ClaimsData:      context.ClaimsData,
-		KnowledgeChunks: context.KnowledgeChunks,
-		Comments:        context.Comments,
+		UserQuestion:     question,
+		History:          history,
+		ClaimsData:       claimsData,
+		KnowledgeChunks:  h.redactSearchResults(context.KnowledgeChunks, redactions),
+		Comments:         h.redactSearchResults(context.Comments, redactions),
+		LimitedTools:     context.LimitedTools,
+		NoDataRetrieved:  context.NoDataRetrieved,
+		UnavailableTools: context.UnavailableTools,
+		Language:         language,
+	}
+	// The reasons quote the planned arguments, which had their PII restored before the tools ran.
+	for tool, reason := range context.IgnoredArguments {
+		if templateData.IgnoredArguments == nil {
+			templateData.IgnoredArguments = make(map[string]string, len(context.IgnoredArguments))
+		}
+		templateData.IgnoredArguments[tool] = h.redactor.Redact(reason, redactions)
+	}
+	if redactions.Len() > 0 {
+		h.logger.InfoContext(ctx, "Redacted PII from synthesizer context", "redacted_values", redactions.Len())
 	}
 	var promptBuffer bytes.Buffer
-	if err := h.synthesizerTemplate.Execute(&promptBuffer, templateData); err != nil {
+	if err := h.currentTemplates().synthesizer.Execute(&promptBuffer, templateData); err != nil {
 		return QueryApiResponse{}, fmt.Errorf("failed to execute synthesizer template: %w", err)
 	}
 	llmResponseContent, err := h.callLLM(ctx, promptBuffer.String(), true)
 	if err != nil {
 		return QueryApiResponse{}, err
 	}
+	if h.redactor.RestoresAnswer() {
+		llmResponseContent = redactions.RestoreJSON(llmResponseContent)
+	}
 	var synthResponse SynthesizerResponse
 	if err := json.Unmarshal([]byte(llmResponseContent), &synthResponse); err != nil {
-		return QueryApiResponse{}, fmt.Errorf("failed to unmarshal synthesizer response from LLM: %w. Raw content: %s", err, llmResponseContent)
+		// Rather than failing the request, show the model's raw output as a plain text answer.
+		h.logger.WarnContext(ctx, "Synthesizer returned malformed JSON, falling back to a text response", "error", err, "raw_content", h.redactor.Redact(llmResponseContent, redactions))
+		synthResponse = SynthesizerResponse{Actions: []ActionPlan{{Type: ActionTextResponse, Payload: llmResponseContent}}}
 	}
 	var finalApiResponse QueryApiResponse
 	if synthResponse.Actions == nil {
 		return finalApiResponse, nil
 	}
-	for _, plannedAction := range synthResponse.Actions {
+	actions, diagnostics := validateActions(synthResponse.Actions)
+	if len(diagnostics) > 0 {
+		h.logger.WarnContext(ctx, "Synthesizer returned actions outside the action schema", "diagnostics", diagnostics)
+	}
+	if clarify, ok := clarifyAction(actions); ok {
+		// The answer may have had its PII restored; the log gets the question masked again.
+		h.logger.InfoContext(ctx, "Synthesizer asked a clarifying question", "question", h.redactor.Redact(fmt.Sprint(clarify.Payload), redactions))
+		finalApiResponse.Actions = []Action{clarify}
+		return finalApiResponse, nil
+	}
+	for _, plannedAction := range actions {
 		finalAction := Action{Type: plannedAction.Type}
 		switch plannedAction.Type {
-		case "text_response":
+		case ActionTextResponse:
 			finalAction.Payload = plannedAction.Payload
-		case "render_table":
-			if wantsTable, ok := plannedAction.Payload.(bool); ok && wantsTable {
-				finalAction.Payload = context.ClaimsData
+		case ActionRenderTable:
+			if context.ClaimsData != nil {
+				_, limited := context.LimitedTools["get_claims_data"]
+				finalAction.Payload = renderTablePayload(context.ClaimsData, context.ClaimsQuery, limited, includeScores(c))
 			}
-		case "open_detail_drawer":
-			if wantsDrawer, ok := plannedAction.Payload.(bool); ok && wantsDrawer {
-				var claimID int64
-				if claims, ok := context.ClaimsData.([]insurance.ListClaimsWithVectorRow); ok && len(claims) == 1 {
-					claimID = claims[0].ID
-				} else if claims, ok := context.ClaimsData.([]insurance.ListClaimsWithoutVectorRow); ok && len(claims) == 1 {
-					claimID = claims[0].ID
-				}
-				if claimID > 0 {
-					claimDetails, err := h.queries.GetClaimDetails(ctx, claimID)
-					if err != nil {
-						reqLogger := h.logger.With("request_id", c.Get("requestID"))
-						reqLogger.ErrorContext(ctx, "Failed to get claim details for drawer action", "error", err, "claim_id", claimID)
-						continue
-					}
-					finalAction.Payload = claimDetails
+		case ActionOpenDetailDrawer:
+			var claimID int64
+			if claims, ok := context.ClaimsData.([]insurance.ListClaimsWithVectorRow); ok && len(claims) == 1 {
+				claimID = claims[0].ID
+			} else if claims, ok := context.ClaimsData.([]insurance.ListClaimsWithoutVectorRow); ok && len(claims) == 1 {
+				claimID = claims[0].ID
+			}
+			if claimID > 0 {
+				claimDetails, err := h.queries.GetClaimDetails(ctx, claimID)
+				if err != nil {
+					reqLogger := h.logger.With("request_id", c.Get("requestID"))
+					reqLogger.ErrorContext(ctx, "Failed to get claim details for drawer action", "error", err, "claim_id", claimID)
+					continue
 				}
+				finalAction.Payload = claimDetails
 			}
 		}
 		if finalAction.Payload != nil {
@@ -793,3 +2184,160 @@ func (h *InsuranceHandler) synthesizeAnswer(ctx context.Context, c echo.Context,
 	}
 	return finalApiResponse, nil
 }
+
+// redactPrompt returns the question and a copy of history with PII masked, for the planner and
+// synthesizer prompts. Without a redactor they are returned unchanged.
+func (h *InsuranceHandler) redactPrompt(question string, history []ChatMessage, redactions *rag.RedactionMap) (string, []ChatMessage) {
+	if h.redactor == nil {
+		return question, history
+	}
+	redacted := make([]ChatMessage, len(history))
+	for i, message := range history {
+		message.Content = h.redactor.Redact(message.Content, redactions)
+		redacted[i] = message
+	}
+	return h.redactor.Redact(question, redactions), redacted
+}
+
+// redactClaimsData returns claims data with PII masked, as JSON ready for the synthesizer prompt.
+// Without a redactor the data is returned unchanged.
+func (h *InsuranceHandler) redactClaimsData(claimsData interface{}, redactions *rag.RedactionMap) (interface{}, error) {
+	if h.redactor == nil || claimsData == nil {
+		return claimsData, nil
+	}
+	raw, err := json.Marshal(claimsData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal claims data for redaction: %w", err)
+	}
+	return json.RawMessage(h.redactor.Redact(string(raw), redactions)), nil
+}
+
+// redactSearchResults returns a copy of results with PII masked in their text.
+func (h *InsuranceHandler) redactSearchResults(results []SearchResult, redactions *rag.RedactionMap) []SearchResult {
+	if h.redactor == nil {
+		return results
+	}
+	redacted := make([]SearchResult, len(results))
+	for i, result := range results {
+		result.Text = h.redactor.Redact(result.Text, redactions)
+		redacted[i] = result
+	}
+	return redacted
+}
+
+// decodeMetadata decodes a JSON object column. An empty or null column has no metadata; anything
+// else that is not a JSON object is an error, so the caller can log it rather than silently
+// dropping the metadata.
+func decodeMetadata(raw []byte) (map[string]interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+	return object, nil
+}
+
+// HandleSearchComments runs a keyword search over the live comments on the caller's claims, for
+// exact terms like ticket numbers that the semantic search may not surface. Each result carries
+// its claim_id and an explanation; with explain=true the explanation includes an LLM-written
+// rationale.
+func (h *InsuranceHandler) HandleSearchComments(c echo.Context) error {
+	ctx := c.Request().Context()
+	searchQuery := strings.TrimSpace(c.QueryParam("q"))
+	if searchQuery == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Query parameter 'q' is required")
+	}
+	limit := h.pageSizes.For(config.PageSizeCommentSearch).Limit(c.QueryParam("limit"))
+	scope := ItemScopeFromContext(ctx)
+	comments, err := h.queries.SearchCommentsKeyword(ctx, insurance.SearchCommentsKeywordParams{
+		SearchQuery: searchQuery,
+		Limit:       int32(limit),
+		ViewAll:     scope.ViewAll,
+		Scopes:      scope.Scopes,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to keyword search comments", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to search comments")
+	}
+	results := keywordSearchResults(comments)
+	explainMatches(results, searchQuery)
+	if c.QueryParam("explain") == "true" {
+		h.addRationales(ctx, searchQuery, resultPointers(results))
+	}
+	if !includeScores(c) {
+		withoutScores(results)
+	}
+	return c.JSON(http.StatusOK, results)
+}
+
+func commentMetadata(claimID pgtype.Text) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if claimID.Valid {
+		metadata["claim_id"] = claimID.String
+	}
+	return metadata
+}
+
+func keywordSearchResults(rows []insurance.SearchCommentsKeywordRow) []SearchResult {
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		metadata := commentMetadata(row.ClaimID)
+		metadata["comment_id"] = row.CommentID
+		score := keywordScore(row.Rank)
+		results = append(results, SearchResult{
+			Source:          row.Source,
+			Text:            row.Text,
+			SimilarityScore: &score,
+			Metadata:        metadata,
+		})
+	}
+	return results
+}
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is the usual default.
+const rrfK = 60
+
+// fuseCommentResults merges keyword and vector comment hits with reciprocal rank fusion, so a
+// comment found by both searches ranks above one found by either alone. Comments are matched
+// on claim and text since the vector search does not return comment IDs.
+func fuseCommentResults(keyword, vector []SearchResult, limit int) []SearchResult {
+	type fused struct {
+		result SearchResult
+		score  float64
+		order  int
+	}
+	byKey := make(map[string]*fused)
+	var merged []*fused
+	add := func(results []SearchResult) {
+		for rank, result := range results {
+			claimID, _ := result.Metadata["claim_id"].(string)
+			key := claimID + "\x00" + result.Text
+			entry, ok := byKey[key]
+			if !ok {
+				entry = &fused{result: result, order: len(merged)}
+				byKey[key] = entry
+				merged = append(merged, entry)
+			}
+			entry.score += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+	add(keyword)
+	add(vector)
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].score != merged[j].score {
+			return merged[i].score > merged[j].score
+		}
+		return merged[i].order < merged[j].order
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	results := make([]SearchResult, 0, len(merged))
+	for _, entry := range merged {
+		results = append(results, entry.result)
+	}
+	return results
+}
@@ -0,0 +1,52 @@
+package api
+
+import "github.com/jjckrbbt/chimera/backend/internal/rag"
+
+// plannerTools describes the tools getContextFromPlan runs, for the planner prompt's
+// {{.ToolsPrompt}}. The insurance tools have no per-tool permissions, so every one of them is offered.
+func (h *InsuranceHandler) plannerTools() []rag.PlannerTool {
+	return rag.PlannerTools(h.tools())
+}
+
+// tools returns the tools getContextFromPlan runs by name. The argument schemas must match what
+// claimsFilterArgs and the search tools read. Claims data and the knowledge base are critical:
+// getContextFromPlan retries them once when they fail. search_comments falls back between its
+// keyword and vector searches on its own, so it is not.
+func (h *InsuranceHandler) tools() map[string]rag.Tool {
+	searchQuery := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"search_query": map[string]interface{}{"type": "string", "description": description}},
+			"required":   []string{"search_query"},
+		}
+	}
+	return map[string]rag.Tool{
+		"get_claims_data": {
+			Critical:    true,
+			Description: "Use this tool to get structured data about insurance claims. It supports filtering by specific criteria, semantic search on claim descriptions, and sorting.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"claim_id":              map[string]interface{}{"type": "string", "description": "The unique ID of a single claim to fetch."},
+					"min_amount":            map[string]interface{}{"type": "number", "description": "The minimum claim amount to filter by."},
+					"max_amount":            map[string]interface{}{"type": "number", "description": "The maximum claim amount to filter by."},
+					"semantic_search_query": map[string]interface{}{"type": "string", "description": `A natural language query to search the contents of the claim descriptions. Use this for questions about the *nature* of the claim itself (e.g., "claims involving a bent frame").`},
+					"policy_number":         map[string]interface{}{"type": "string", "description": "The policy number to filter claims by."},
+					"status":                map[string]interface{}{"type": "string", "description": "The business status to filter claims by.", "enum": h.claimWorkflow.Statuses()},
+					"adjuster_assigned":     map[string]interface{}{"type": "string", "description": "The name of the adjuster to filter claims by."},
+					"sort_by":               map[string]interface{}{"type": "string", "description": "The field to sort the results by.", "enum": claimSortColumns},
+					"sort_direction":        map[string]interface{}{"type": "string", "description": `The sort direction. Only valid with sort_by; defaults to "desc".`, "enum": []string{"asc", "desc"}},
+				},
+			},
+		},
+		"search_knowledge_base": {
+			Critical:    true,
+			Description: "Use this tool to find procedural information, definitions, or general knowledge from internal documents like policy guides and claims handling protocols. This is also the primary tool for searching the narrative content of adjuster comments.",
+			Parameters:  searchQuery("A concise search query that summarizes the core information needed."),
+		},
+		"search_comments": {
+			Description: `Use this to search the narrative content of adjuster comments, especially for subjective information, opinions, or details not found in structured data (e.g., "signs of potential fraud," "customer sentiment"). It also matches exact terms, so pass ticket numbers, names, or other identifiers through verbatim.`,
+			Parameters:  searchQuery("A concise search query summarizing the information needed from comments."),
+		},
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Filter operators. The filter query matches these names, so they must not change independently.
+const (
+	FilterOpEqual    = "="
+	FilterOpNotEqual = "!="
+	FilterOpGreater  = ">"
+	FilterOpLess     = "<"
+	FilterOpIn       = "in"
+	FilterOpContains = "contains"
+)
+
+// Limits that keep a filter expression cheap to evaluate.
+const (
+	maxFilterConditions = 10
+	maxFilterInValues   = 50
+)
+
+// FilterCondition is one condition of an item filter. It is passed to the list queries as JSON, so
+// the field, operator and values reach the database as parameters rather than SQL.
+type FilterCondition struct {
+	Field  string   `json:"field"`
+	Op     string   `json:"op"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+	// Number is set when Value is an unquoted number, so numeric properties, and string properties
+	// holding a decimal number, compare as numbers rather than text.
+	Number json.Number `json:"number,omitempty"`
+}
+
+// ItemFilter is a parsed filter expression. An item must match every condition.
+type ItemFilter []FilterCondition
+
+// JSON encodes the filter for the list queries' filters parameter.
+func (f ItemFilter) JSON() []byte {
+	if len(f) == 0 {
+		return []byte(`[]`)
+	}
+	data, _ := json.Marshal([]FilterCondition(f))
+	return data
+}
+
+// ParseItemFilter parses a filter expression over custom_properties, such as
+//
+//	amount > 10000 AND region IN (West, East) AND tags contains urgent
+//
+// Conditions are joined with AND and compare a field with =, !=, >, <, IN or contains. Values are
+// numbers, bare words, or quoted with ' or " when they hold spaces or punctuation. contains matches
+// an array holding the value or a string including it. Only the given fields may be filtered.
+func ParseItemFilter(expr string, fields []string) (ItemFilter, error) {
+	p := &filterParser{tokens: tokenizeFilter(expr)}
+	if err := p.tokenErr(); err != nil {
+		return nil, err
+	}
+	var filter ItemFilter
+	for {
+		condition, err := p.condition(fields)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, condition)
+		if len(filter) > maxFilterConditions {
+			return nil, fmt.Errorf("filter has more than %d conditions", maxFilterConditions)
+		}
+		if p.done() {
+			return filter, nil
+		}
+		if token := p.next(); !token.isKeyword("and") {
+			return nil, fmt.Errorf("expected AND after the condition on '%s', got %s", condition.Field, token)
+		}
+	}
+}
+
+// filterToken is a lexical token of a filter expression.
+type filterToken struct {
+	text   string
+	quoted bool
+	// err is set for a token that could not be read, such as an unterminated quote.
+	err error
+	// end marks the position past the last token.
+	end bool
+}
+
+func (t filterToken) String() string {
+	if t.end {
+		return "end of filter"
+	}
+	return "'" + t.text + "'"
+}
+
+// isWord reports whether t is an unquoted field name or value.
+func (t filterToken) isWord() bool {
+	return !t.quoted && !t.end && t.text != "" && isFilterWordRune([]rune(t.text)[0])
+}
+
+func (t filterToken) isKeyword(keyword string) bool {
+	return !t.quoted && !t.end && strings.EqualFold(t.text, keyword)
+}
+
+func (t filterToken) isSymbol(symbol string) bool {
+	return !t.quoted && !t.end && t.text == symbol
+}
+
+// tokenizeFilter splits expr into words, quoted strings, operators and punctuation.
+func tokenizeFilter(expr string) []filterToken {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			end := slices.Index(runes[i+1:], r)
+			if end < 0 {
+				return append(tokens, filterToken{err: fmt.Errorf("unterminated quote at position %d", i+1)})
+			}
+			tokens = append(tokens, filterToken{text: string(runes[i+1 : i+1+end]), quoted: true})
+			i += end + 2
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, filterToken{text: FilterOpNotEqual})
+			i += 2
+		case strings.ContainsRune("=<>(),", r):
+			tokens = append(tokens, filterToken{text: string(r)})
+			i++
+		case isFilterWordRune(r):
+			start := i
+			for i < len(runes) && isFilterWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[start:i])})
+		default:
+			return append(tokens, filterToken{err: fmt.Errorf("unexpected character '%c' at position %d", r, i+1)})
+		}
+	}
+	return tokens
+}
+
+// isFilterWordRune reports whether r may appear in a field name or an unquoted value.
+func isFilterWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-:/+", r)
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) tokenErr() error {
+	if len(p.tokens) == 0 {
+		return fmt.Errorf("filter is empty")
+	}
+	if last := p.tokens[len(p.tokens)-1]; last.err != nil {
+		return last.err
+	}
+	return nil
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// next returns the next token, or an end token past the end of the expression.
+func (p *filterParser) next() filterToken {
+	if p.done() {
+		return filterToken{end: true}
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token
+}
+
+// condition parses "field op value", "field IN (values)" or "field contains value".
+func (p *filterParser) condition(fields []string) (FilterCondition, error) {
+	fieldToken := p.next()
+	if !fieldToken.isWord() {
+		return FilterCondition{}, fmt.Errorf("expected a field name, got %s", fieldToken)
+	}
+	if !slices.Contains(fields, fieldToken.text) {
+		if len(fields) == 0 {
+			return FilterCondition{}, fmt.Errorf("unknown field '%s'; this item type has no filterable fields", fieldToken.text)
+		}
+		return FilterCondition{}, fmt.Errorf("unknown field '%s'; filterable fields are %s", fieldToken.text, strings.Join(fields, ", "))
+	}
+	condition := FilterCondition{Field: fieldToken.text}
+
+	opToken := p.next()
+	switch {
+	case opToken.isSymbol(FilterOpEqual), opToken.isSymbol(FilterOpNotEqual), opToken.isSymbol(FilterOpGreater), opToken.isSymbol(FilterOpLess):
+		condition.Op = opToken.text
+	case opToken.isKeyword(FilterOpContains):
+		condition.Op = FilterOpContains
+	case opToken.isKeyword(FilterOpIn):
+		condition.Op = FilterOpIn
+		values, err := p.valueList(condition.Field)
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		condition.Values = values
+		return condition, nil
+	default:
+		return FilterCondition{}, fmt.Errorf("expected an operator (=, !=, >, <, IN, contains) after '%s', got %s", condition.Field, opToken)
+	}
+
+	valueToken, err := p.value(condition.Field)
+	if err != nil {
+		return FilterCondition{}, err
+	}
+	condition.Value = valueToken.text
+	if !valueToken.quoted && filterNumber.MatchString(valueToken.text) {
+		condition.Number = json.Number(valueToken.text)
+	}
+	return condition, nil
+}
+
+// valueList parses the parenthesized, comma-separated values of an IN condition.
+func (p *filterParser) valueList(field string) ([]string, error) {
+	if token := p.next(); !token.isSymbol("(") {
+		return nil, fmt.Errorf("expected '(' after IN for '%s', got %s", field, token)
+	}
+	var values []string
+	for {
+		token, err := p.value(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, token.text)
+		if len(values) > maxFilterInValues {
+			return nil, fmt.Errorf("IN for '%s' has more than %d values", field, maxFilterInValues)
+		}
+		switch token := p.next(); {
+		case token.isSymbol(")"):
+			return values, nil
+		case !token.isSymbol(","):
+			return nil, fmt.Errorf("expected ',' or ')' in the IN list for '%s', got %s", field, token)
+		}
+	}
+}
+
+// value parses a quoted or bare value.
+func (p *filterParser) value(field string) (filterToken, error) {
+	token := p.next()
+	if !token.quoted && (!token.isWord() || token.isKeyword("and")) {
+		return filterToken{}, fmt.Errorf("expected a value for '%s', got %s", field, token)
+	}
+	return token, nil
+}
+
+// filterNumber matches the decimal numbers a filter compares numerically.
+var filterNumber = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
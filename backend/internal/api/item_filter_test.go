@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseItemFilter(t *testing.T) {
+	fields := []string{"amount", "region", "tags", "policyholder"}
+
+	t.Run("Parses conditions joined by AND", func(t *testing.T) {
+		filter, err := ParseItemFilter(`amount > 10000 and region IN (West, "East") AND tags contains urgent AND policyholder != 'Kim Lee'`, fields)
+		require.NoError(t, err)
+		assert.Equal(t, ItemFilter{
+			{Field: "amount", Op: FilterOpGreater, Value: "10000", Number: "10000"},
+			{Field: "region", Op: FilterOpIn, Values: []string{"West", "East"}},
+			{Field: "tags", Op: FilterOpContains, Value: "urgent"},
+			{Field: "policyholder", Op: FilterOpNotEqual, Value: "Kim Lee"},
+		}, filter)
+	})
+
+	t.Run("Compares quoted numbers as text", func(t *testing.T) {
+		filter, err := ParseItemFilter(`region = "10000"`, fields)
+		require.NoError(t, err)
+		assert.Empty(t, filter[0].Number)
+
+		filter, err = ParseItemFilter(`amount < -2.5e3`, fields)
+		require.NoError(t, err)
+		assert.Equal(t, "-2.5e3", filter[0].Number.String())
+	})
+
+	t.Run("Encodes the filter as a JSON array", func(t *testing.T) {
+		assert.JSONEq(t, `[]`, string(ItemFilter(nil).JSON()))
+		filter, err := ParseItemFilter(`amount = 5`, fields)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"field": "amount", "op": "=", "value": "5", "number": 5}]`, string(filter.JSON()))
+	})
+
+	for expr, message := range map[string]string{
+		``:                               "filter is empty",
+		`owner = Kim`:                    "unknown field 'owner'; filterable fields are amount, region, tags, policyholder",
+		`amount >= 5`:                    "expected a value for 'amount', got '='",
+		`amount LIKE 5`:                  "expected an operator (=, !=, >, <, IN, contains) after 'amount', got 'LIKE'",
+		`amount = 5 OR region = West`:    "expected AND after the condition on 'amount', got 'OR'",
+		`amount =`:                       "expected a value for 'amount', got end of filter",
+		`region IN West`:                 "expected '(' after IN for 'region', got 'West'",
+		`region IN (West East)`:          "expected ',' or ')' in the IN list for 'region', got 'East'",
+		`region = 'West`:                 "unterminated quote at position 10",
+		`region = West; DROP TABLE x`:    "unexpected character ';' at position 14",
+		`"region" = West`:                "expected a field name, got 'region'",
+		`amount = 5 AND`:                 "expected a field name, got end of filter",
+		`region = West AND amount = AND`: "expected a value for 'amount', got 'AND'",
+	} {
+		t.Run("Rejects "+expr, func(t *testing.T) {
+			_, err := ParseItemFilter(expr, fields)
+			assert.EqualError(t, err, message)
+		})
+	}
+
+	t.Run("Limits the number of conditions", func(t *testing.T) {
+		expr := "amount = 1"
+		for i := 0; i < maxFilterConditions; i++ {
+			expr += " AND amount = 1"
+		}
+		_, err := ParseItemFilter(expr, fields)
+		assert.ErrorContains(t, err, "filter has more than 10 conditions")
+	})
+}
+
+func TestHandleGetItemsFilter(t *testing.T) {
+	registry := NewFetcherRegistry()
+	registry.Register("CUSTOM", func(ctx context.Context, db repository.DBTX, params ListParams) (interface{}, int64, error) {
+		return []string{}, 0, nil
+	})
+	h := NewItemHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), registry, repository.ItemStatusActive, nil, nil)
+
+	list := func(itemType, filter string) error {
+		query := url.Values{"item_type": {itemType}, "filter": {filter}}
+		req := httptest.NewRequest(http.MethodGet, "/items?"+query.Encode(), nil)
+		return h.HandleGetItems(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+	requireBadRequest := func(t *testing.T, err error, message string) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Contains(t, httpErr.Message, message)
+	}
+
+	t.Run("Rejects an unparseable filter", func(t *testing.T) {
+		requireBadRequest(t, list("INSURANCE_CLAIM", "amount >"), "Query parameter 'filter' is invalid")
+	})
+
+	t.Run("Rejects a filter for an item type with its own fetcher", func(t *testing.T) {
+		requireBadRequest(t, list("CUSTOM", "amount > 5"), "not supported for item type 'CUSTOM'")
+	})
+}
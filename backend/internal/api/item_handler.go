@@ -1,42 +1,71 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
 	"github.com/labstack/echo/v4"
 )
 
+// TxDB is a database handle that can also start transactions, such as *pgxpool.Pool.
+type TxDB interface {
+	repository.DBTX
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
 // ItemHandler is a generic handler for the 'items' resource.
 type ItemHandler struct {
-	queries  repository.Querier
-	db       repository.DBTX
-	logger   *slog.Logger
-	registry *FetcherRegistry
+	queries repository.Querier
+	db      TxDB
+	// txQueries binds queries to a transaction started on db.
+	txQueries func(tx pgx.Tx) repository.Querier
+	logger    *slog.Logger
+	registry  *FetcherRegistry
+	// defaultStatus is given to items created without a status.
+	defaultStatus repository.ItemStatus
+	// configLoader supplies the fields each item type may be filtered on.
+	configLoader *processing.ConfigLoader
+	pageSizes    config.PageSizes
 }
 
-// NewItemHandler creates a new instance of the ItemHandler.
-func NewItemHandler(q repository.Querier, db repository.DBTX, logger *slog.Logger, registry *FetcherRegistry) *ItemHandler {
+// NewItemHandler creates a new instance of the ItemHandler. Items created without a status get
+// defaultStatus. Item lists may be filtered on the fields configLoader maps for the item type, and
+// are paged by the items page size in pageSizes.
+func NewItemHandler(q repository.Querier, db TxDB, logger *slog.Logger, registry *FetcherRegistry, defaultStatus repository.ItemStatus, configLoader *processing.ConfigLoader, pageSizes config.PageSizes) *ItemHandler {
 	return &ItemHandler{
-		queries:  q,
-		db:       db,
-		logger:   logger.With("component", "item_handler"),
-		registry: registry,
+		queries:       q,
+		db:            db,
+		txQueries:     func(tx pgx.Tx) repository.Querier { return repository.New(tx) },
+		logger:        logger.With("component", "item_handler"),
+		registry:      registry,
+		defaultStatus: defaultStatus,
+		configLoader:  configLoader,
+		pageSizes:     pageSizes,
 	}
 }
 
 // --- Request & Response Structs ---
 
-// PaginatedItemsResponse defines the structure for paginated item lists.
+// PaginatedItemsResponse defines the structure for paginated item lists. Limit is the page size
+// the request got and MaxLimit the most it may ask for, the resource's configured max.
 type PaginatedItemsResponse struct {
 	TotalCount int64       `json:"total_count"`
 	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	MaxLimit   int         `json:"max_limit"`
 }
 
 // CreateItemRequest defines the structure for creating a new generic item.
@@ -48,16 +77,121 @@ type CreateItemRequest struct {
 	CustomProperties json.RawMessage `json:"custom_properties"`
 }
 
-// UpdateItemRequest defines the structure for updating an item's mutable fields.
+// UpdateItemRequest defines the structure for updating an item's mutable fields. Omitted fields
+// keep their current values.
 type UpdateItemRequest struct {
 	Scope            *string         `json:"scope,omitempty"`
 	Status           *string         `json:"status,omitempty"`
 	CustomProperties json.RawMessage `json:"custom_properties,omitempty"`
+	// ArrayOperations add or remove single values in custom_properties arrays, such as one tag,
+	// without rewriting the other properties. They can't be combined with CustomProperties.
+	ArrayOperations []ArrayOperation `json:"array_operations,omitempty"`
+}
+
+// Operations accepted in UpdateItemRequest.ArrayOperations.
+const (
+	ArrayOperationAdd    = "add"
+	ArrayOperationRemove = "remove"
+)
+
+// ArrayOperation adds Value to, or removes it from, the array under the top-level custom_properties
+// key Path. Value may be any JSON value except null. Each operation is a single UPDATE, so
+// concurrent requests adding tags don't overwrite each other the way a read-modify-write of
+// custom_properties would.
+type ArrayOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (o ArrayOperation) validate() error {
+	if o.Op != ArrayOperationAdd && o.Op != ArrayOperationRemove {
+		return fmt.Errorf("array operation op must be %s or %s, got '%s'", ArrayOperationAdd, ArrayOperationRemove, o.Op)
+	}
+	if o.Path == "" {
+		return errors.New("array operation path is required")
+	}
+	if len(o.Value) == 0 {
+		return fmt.Errorf("array operation on '%s' requires a value", o.Path)
+	}
+	if string(bytes.TrimSpace(o.Value)) == "null" {
+		return fmt.Errorf("array operation on '%s' requires a non-null value", o.Path)
+	}
+	return nil
+}
+
+// ReplaceItemRequest defines the full mutable state of an item. Every field is required, since
+// a replace has no existing value to fall back on.
+type ReplaceItemRequest struct {
+	Scope            string          `json:"scope"`
+	Status           string          `json:"status"`
+	CustomProperties json.RawMessage `json:"custom_properties"`
+}
+
+// validate checks that every field is present and well formed, returning the parsed status.
+func (r ReplaceItemRequest) validate() (repository.ItemStatus, error) {
+	var missing []string
+	if r.Scope == "" {
+		missing = append(missing, "scope")
+	}
+	if r.Status == "" {
+		missing = append(missing, "status")
+	}
+	if len(r.CustomProperties) == 0 {
+		missing = append(missing, "custom_properties")
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	status, err := repository.ParseItemStatus(r.Status)
+	if err != nil {
+		return "", err
+	}
+	if err := processing.ValidateScope(r.Scope); err != nil {
+		return "", err
+	}
+	if err := validateCustomProperties(r.CustomProperties); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// validateCustomProperties checks that raw is a JSON object. Arrays, scalars and null are valid
+// JSON but would break the JSONB lookups and merges that expect custom_properties to be an object.
+func validateCustomProperties(raw json.RawMessage) error {
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &properties); err != nil || properties == nil {
+		return fmt.Errorf("custom_properties must be a JSON object, got %s", describeJSON(raw))
+	}
+	return nil
+}
+
+// describeJSON names the kind of JSON value in raw, for error messages.
+func describeJSON(raw json.RawMessage) string {
+	trimmed := bytes.TrimSpace(raw)
+	if !json.Valid(trimmed) {
+		return "malformed JSON"
+	}
+	switch trimmed[0] {
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 'n':
+		return "null"
+	case 't', 'f':
+		return "a boolean"
+	case '{':
+		return "an object"
+	}
+	return "a number"
 }
 
 // --- Handlers ---
 
-// HandleGetItems retrieves a list of items, filtered by item_type.
+// HandleGetItems retrieves a list of items, filtered by item_type and the caller's scopes. Item
+// types without a registered fetcher are listed straight from the items table, and can be narrowed
+// further with a filter expression over custom_properties (see ParseItemFilter).
 func (h *ItemHandler) HandleGetItems(c echo.Context) error {
 	ctx := c.Request().Context()
 	itemType := c.QueryParam("item_type")
@@ -73,16 +207,25 @@ func (h *ItemHandler) HandleGetItems(c echo.Context) error {
 		return c.JSON(http.StatusNotImplemented, "Lookup by business_key not yet implemented")
 	}
 
-	fetcher, ok := h.registry.Get(itemType)
-	if !ok {
-		h.logger.WarnContext(ctx, "HandleGetItems called with unsupported 'item_type'", "item_type", itemType)
-		return echo.NewHTTPError(http.StatusBadRequest, "Unsupported 'item_type'"+itemType)
+	fetcher, registered := h.registry.Get(itemType)
+	if !registered {
+		fetcher = ScopedItemsFetcher(itemType)
 	}
 
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-	if limit <= 0 {
-		limit = 50
+	var filter ItemFilter
+	if expr := c.QueryParam("filter"); expr != "" {
+		if registered {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter 'filter' is not supported for item type '%s'", itemType))
+		}
+		var err error
+		if filter, err = ParseItemFilter(expr, h.filterFields(itemType)); err != nil {
+			h.logger.WarnContext(ctx, "Rejected invalid item filter", "error", err, "filter", expr)
+			return echo.NewHTTPError(http.StatusBadRequest, "Query parameter 'filter' is invalid: "+err.Error())
+		}
 	}
+
+	pageSize := h.pageSizes.For(config.PageSizeItems)
+	limit := pageSize.Limit(c.QueryParam("limit"))
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	if page <= 0 {
 		page = 1
@@ -92,6 +235,8 @@ func (h *ItemHandler) HandleGetItems(c echo.Context) error {
 	params := ListParams{
 		Limit:  int32(limit),
 		Offset: int32(offset),
+		Scope:  ItemScopeFromContext(ctx),
+		Filter: filter,
 	}
 
 	items, totalCount, err := fetcher(ctx, h.db, params)
@@ -103,11 +248,57 @@ func (h *ItemHandler) HandleGetItems(c echo.Context) error {
 	response := PaginatedItemsResponse{
 		TotalCount: totalCount,
 		Data:       items,
+		Limit:      limit,
+		MaxLimit:   pageSize.Max,
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// filterFields returns the custom_properties fields items of itemType may be filtered on.
+func (h *ItemHandler) filterFields(itemType string) []string {
+	if h.configLoader == nil {
+		return nil
+	}
+	return h.configLoader.FieldsByItemType()[itemType]
+}
+
+// HandleGetItemByID retrieves a single item of any type by its numeric ID. Items outside the
+// caller's scopes are reported as not found, so their existence isn't revealed.
+func (h *ItemHandler) HandleGetItemByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Invalid item ID format provided to get handler", "error", err, "id_param", c.Param("id"))
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID format")
+	}
+	item, err := h.getItemInScope(ctx, h.queries, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// getItemInScope fetches an item within the caller's scopes through q, returning a 404 HTTP error
+// when it does not exist or is out of scope.
+func (h *ItemHandler) getItemInScope(ctx context.Context, q repository.Querier, id int64) (repository.GetItemInScopeRow, error) {
+	scope := ItemScopeFromContext(ctx)
+	item, err := q.GetItemInScope(ctx, repository.GetItemInScopeParams{
+		ID:      id,
+		ViewAll: scope.ViewAll,
+		Scopes:  scope.Scopes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.logger.WarnContext(ctx, "Item not found or outside the caller's scopes", "item_id", id)
+			return item, echo.NewHTTPError(http.StatusNotFound, "Item not found")
+		}
+		h.logger.ErrorContext(ctx, "Failed to retrieve item", "error", err, "item_id", id)
+		return item, echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve item")
+	}
+	return item, nil
+}
+
 // HandleCreateItem creates a new item in the database.
 func (h *ItemHandler) HandleCreateItem(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -117,11 +308,34 @@ func (h *ItemHandler) HandleCreateItem(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
 	}
 
+	status := h.defaultStatus
+	if req.Status != "" {
+		var err error
+		if status, err = repository.ParseItemStatus(req.Status); err != nil {
+			h.logger.WarnContext(ctx, "Rejected item with an unknown status", "status", req.Status)
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+	}
+
+	if req.Scope != "" {
+		if err := processing.ValidateScope(req.Scope); err != nil {
+			h.logger.WarnContext(ctx, "Rejected item with an invalid scope", "error", err)
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+	}
+	if len(req.CustomProperties) == 0 {
+		req.CustomProperties = json.RawMessage(`{}`)
+	}
+	if err := validateCustomProperties(req.CustomProperties); err != nil {
+		h.logger.WarnContext(ctx, "Rejected item with invalid custom_properties", "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+
 	params := repository.CreateItemParams{
 		ItemType:         repository.ItemType(req.ItemType),
 		Scope:            pgtype.Text{String: req.Scope, Valid: req.Scope != ""},
 		BusinessKey:      pgtype.Text{String: req.BusinessKey, Valid: req.BusinessKey != ""},
-		Status:           repository.ItemStatus(req.Status),
+		Status:           status,
 		CustomProperties: []byte(req.CustomProperties),
 	}
 
@@ -135,7 +349,11 @@ func (h *ItemHandler) HandleCreateItem(c echo.Context) error {
 	return c.JSON(http.StatusCreated, newItem)
 }
 
-// HandleUpdateItem updates an existing item's mutable fields.
+// HandleUpdateItem updates an existing item's mutable fields (PATCH). Only the fields present in
+// the request change; see HandleReplaceItem for replace semantics. The field update and any array
+// operations run in one transaction holding the item's row lock, so an operation that fails leaves
+// the item unchanged. Changing custom_properties clears the item's embeddings, which the embedding
+// backfill then regenerates.
 func (h *ItemHandler) HandleUpdateItem(c echo.Context) error {
 	ctx := c.Request().Context()
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -150,7 +368,27 @@ func (h *ItemHandler) HandleUpdateItem(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
 	}
 
-	existingItem, err := h.queries.GetItemForUpdate(ctx, id)
+	for _, op := range req.ArrayOperations {
+		if err := op.validate(); err != nil {
+			h.logger.WarnContext(ctx, "Rejected invalid array operation", "error", err, "item_id", id)
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+	}
+	if len(req.ArrayOperations) > 0 && req.CustomProperties != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: custom_properties and array_operations can't be combined")
+	}
+
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.txQueries(tx)
+
+	// Lock the row before checking its scope, so a concurrent update can't move it out of the
+	// caller's scopes or interleave with the changes below.
+	existingItem, err := qtx.GetItemForUpdate(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			h.logger.WarnContext(ctx, "Attempted to update a non-existent item", "item_id", id)
@@ -159,7 +397,37 @@ func (h *ItemHandler) HandleUpdateItem(c echo.Context) error {
 		h.logger.ErrorContext(ctx, "Failed to retrieve item for update", "error", err, "item_id", id)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve item for update")
 	}
+	if _, err := h.getItemInScope(ctx, qtx, id); err != nil {
+		return err
+	}
+
+	updatedItem := existingItem
+	if req.Scope != nil || req.Status != nil || req.CustomProperties != nil || len(req.ArrayOperations) == 0 {
+		if updatedItem, err = h.updateItemFields(ctx, qtx, existingItem, req); err != nil {
+			return err
+		}
+	}
+	for _, op := range req.ArrayOperations {
+		if updatedItem, err = h.applyArrayOperation(ctx, qtx, id, op); err != nil {
+			return err
+		}
+	}
+	if updatedItem, err = h.clearStaleEmbeddings(ctx, qtx, existingItem.CustomProperties, updatedItem); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	h.logger.InfoContext(ctx, "Successfully updated item", "item_id", updatedItem.ID)
+	return c.JSON(http.StatusOK, updatedItem)
+}
+
+// updateItemFields overlays the scope, status and custom_properties present in req onto
+// existingItem and writes the result with q.
+func (h *ItemHandler) updateItemFields(ctx context.Context, q repository.Querier, existingItem repository.Item, req UpdateItemRequest) (repository.Item, error) {
+	id := existingItem.ID
 	params := repository.UpdateItemParams{
 		ID:               id,
 		Scope:            existingItem.Scope,
@@ -168,24 +436,165 @@ func (h *ItemHandler) HandleUpdateItem(c echo.Context) error {
 	}
 
 	if req.Scope != nil {
+		if err := processing.ValidateScope(*req.Scope); err != nil {
+			h.logger.WarnContext(ctx, "Rejected item update with an invalid scope", "error", err, "item_id", id)
+			return repository.Item{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
 		params.Scope = pgtype.Text{String: *req.Scope, Valid: true}
 	}
 	if req.Status != nil {
-		params.Status = repository.ItemStatus(*req.Status)
+		status, err := repository.ParseItemStatus(*req.Status)
+		if err != nil {
+			h.logger.WarnContext(ctx, "Rejected item update with an unknown status", "item_id", id, "status", *req.Status)
+			return repository.Item{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+		params.Status = status
 	}
 	if req.CustomProperties != nil {
+		if err := validateCustomProperties(req.CustomProperties); err != nil {
+			h.logger.WarnContext(ctx, "Rejected item update with invalid custom_properties", "error", err, "item_id", id)
+			return repository.Item{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
 		// A real implementation would merge JSONB fields, but for now we overwrite.
 		params.CustomProperties = []byte(req.CustomProperties)
 	}
 
-	updatedItem, err := h.queries.UpdateItem(ctx, params)
+	updatedItem, err := q.UpdateItem(ctx, params)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "Failed to update item in database", "error", err, "item_id", id)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update item")
+		return repository.Item{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to update item")
 	}
+	return updatedItem, nil
+}
 
-	h.logger.InfoContext(ctx, "Successfully updated item", "item_id", updatedItem.ID)
-	return c.JSON(http.StatusOK, updatedItem)
+// clearStaleEmbeddings clears the embeddings of item when its custom_properties differ from
+// oldProperties, since they were generated from the old content. It returns the item as stored.
+func (h *ItemHandler) clearStaleEmbeddings(ctx context.Context, q repository.Querier, oldProperties []byte, item repository.Item) (repository.Item, error) {
+	var before, after interface{}
+	if json.Unmarshal(oldProperties, &before) == nil && json.Unmarshal(item.CustomProperties, &after) == nil && reflect.DeepEqual(before, after) {
+		return item, nil
+	}
+	cleared, err := q.ClearItemEmbeddings(ctx, item.ID)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to clear stale item embeddings", "error", err, "item_id", item.ID)
+		return repository.Item{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to update item")
+	}
+	return cleared, nil
+}
+
+// applyArrayOperation runs one array operation as a single UPDATE of the item's custom_properties.
+func (h *ItemHandler) applyArrayOperation(ctx context.Context, q repository.Querier, id int64, op ArrayOperation) (repository.Item, error) {
+	var item repository.Item
+	var err error
+	switch op.Op {
+	case ArrayOperationAdd:
+		item, err = q.AddItemPropertyArrayValue(ctx, repository.AddItemPropertyArrayValueParams{Key: op.Path, Value: op.Value, ID: id})
+	case ArrayOperationRemove:
+		item, err = q.RemoveItemPropertyArrayValue(ctx, repository.RemoveItemPropertyArrayValueParams{Key: op.Path, Value: op.Value, ID: id})
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The item was found in scope, so no match means the key holds something other than an array.
+			h.logger.WarnContext(ctx, "Rejected array operation on a non-array property", "item_id", id, "path", op.Path)
+			return item, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid request body: custom_properties '%s' is not an array", op.Path))
+		}
+		h.logger.ErrorContext(ctx, "Failed to apply array operation", "error", err, "item_id", id, "op", op.Op, "path", op.Path)
+		return item, echo.NewHTTPError(http.StatusInternalServerError, "Failed to update item")
+	}
+	return item, nil
+}
+
+// HandleReplaceItem replaces an item's scope, status and custom_properties with exactly what is
+// sent (PUT), for integrations that own the full item state. Unlike HandleUpdateItem, nothing is
+// carried over from the existing row, so every field is required. The replace is recorded as an
+// ITEM_REPLACED event holding the old and new values; the read, update and event run in one
+// transaction holding the item's row lock, so the event always matches the change it records.
+func (h *ItemHandler) HandleReplaceItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Invalid item ID format provided to replace handler", "error", err, "id_param", c.Param("id"))
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID format")
+	}
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+
+	var req ReplaceItemRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.WarnContext(ctx, "Failed to bind request body for replacing item", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+	status, err := req.validate()
+	if err != nil {
+		h.logger.WarnContext(ctx, "Rejected invalid item replacement", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Could not start db transaction", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start transaction")
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.txQueries(tx)
+
+	// Lock the row before checking its scope, so a concurrent update can neither move it out of the
+	// caller's scopes nor change the old values the event records.
+	existingItem, err := qtx.GetItemForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.logger.WarnContext(ctx, "Attempted to replace a non-existent item", "item_id", id)
+			return echo.NewHTTPError(http.StatusNotFound, "Item not found")
+		}
+		h.logger.ErrorContext(ctx, "Failed to retrieve item for replace", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve item for replace")
+	}
+	if _, err := h.getItemInScope(ctx, qtx, id); err != nil {
+		return err
+	}
+
+	replacedItem, err := qtx.UpdateItem(ctx, repository.UpdateItemParams{
+		ID:               id,
+		Scope:            pgtype.Text{String: req.Scope, Valid: true},
+		Status:           status,
+		CustomProperties: []byte(req.CustomProperties),
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to replace item in database", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to replace item")
+	}
+	if replacedItem, err = h.clearStaleEmbeddings(ctx, qtx, existingItem.CustomProperties, replacedItem); err != nil {
+		return err
+	}
+
+	eventData := map[string]interface{}{
+		"old_scope":             existingItem.Scope.String,
+		"new_scope":             req.Scope,
+		"old_status":            existingItem.Status,
+		"new_status":            status,
+		"old_custom_properties": json.RawMessage(existingItem.CustomProperties),
+		"new_custom_properties": req.CustomProperties,
+	}
+	eventDataJSON, _ := json.Marshal(eventData)
+	_, err = qtx.CreateItemEvent(ctx, repository.CreateItemEventParams{
+		ItemID:    id,
+		EventType: "ITEM_REPLACED",
+		EventData: eventDataJSON,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create replace event", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create audit event for item replace")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.logger.ErrorContext(ctx, "Could not commit db transaction", "error", err, "item_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not commit transaction")
+	}
+	h.logger.InfoContext(ctx, "Successfully replaced item", "item_id", replacedItem.ID)
+	return c.JSON(http.StatusOK, replacedItem)
 }
 
 // HandleGetHistory retrieves the event history for a specific item.
@@ -197,6 +606,9 @@ func (h *ItemHandler) HandleGetHistory(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID format")
 	}
 
+	if _, err := h.getItemInScope(ctx, h.queries, id); err != nil {
+		return err
+	}
 	history, err := h.queries.GetEventsForItem(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
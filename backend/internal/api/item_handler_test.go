@@ -0,0 +1,417 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createItemQuerier records the items it is asked to create.
+type createItemQuerier struct {
+	repository.Querier
+	created []repository.CreateItemParams
+}
+
+func (q *createItemQuerier) CreateItem(ctx context.Context, arg repository.CreateItemParams) (repository.Item, error) {
+	q.created = append(q.created, arg)
+	return repository.Item{ID: int64(len(q.created)), ItemType: arg.ItemType, Status: arg.Status}, nil
+}
+
+func TestHandleCreateItemStatus(t *testing.T) {
+	q := &createItemQuerier{}
+	h := NewItemHandler(q, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusInactive, nil, nil)
+
+	create := func(body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return h.HandleCreateItem(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+
+	t.Run("Uses the default status when none is given", func(t *testing.T) {
+		require.NoError(t, create(`{"item_type": "INSURANCE_CLAIM", "custom_properties": {}}`))
+		assert.Equal(t, repository.ItemStatusInactive, q.created[len(q.created)-1].Status)
+	})
+
+	t.Run("Keeps a supplied status", func(t *testing.T) {
+		require.NoError(t, create(`{"item_type": "INSURANCE_CLAIM", "status": "archived", "custom_properties": {}}`))
+		assert.Equal(t, repository.ItemStatusArchived, q.created[len(q.created)-1].Status)
+	})
+
+	t.Run("Rejects an unknown status", func(t *testing.T) {
+		created := len(q.created)
+		err := create(`{"item_type": "INSURANCE_CLAIM", "status": "pending", "custom_properties": {}}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Contains(t, httpErr.Message, "status must be one of active, inactive, archived")
+		assert.Len(t, q.created, created)
+	})
+}
+
+func TestValidateCustomProperties(t *testing.T) {
+	assert.NoError(t, validateCustomProperties([]byte(`{"claim_id": "C-1"}`)))
+	assert.NoError(t, validateCustomProperties([]byte(`{}`)))
+
+	for raw, kind := range map[string]string{
+		`[{"claim_id": "C-1"}]`: "an array",
+		`"C-1"`:                 "a string",
+		`42`:                    "a number",
+		`true`:                  "a boolean",
+		`null`:                  "null",
+		`{"claim_id":`:          "malformed JSON",
+	} {
+		err := validateCustomProperties([]byte(raw))
+		assert.EqualError(t, err, "custom_properties must be a JSON object, got "+kind, raw)
+	}
+}
+
+func TestHandleCreateItemCustomProperties(t *testing.T) {
+	q := &createItemQuerier{}
+	h := NewItemHandler(q, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+
+	create := func(body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return h.HandleCreateItem(echo.New().NewContext(req, httptest.NewRecorder()))
+	}
+
+	t.Run("Stores an empty object when omitted", func(t *testing.T) {
+		require.NoError(t, create(`{"item_type": "INSURANCE_CLAIM"}`))
+		assert.JSONEq(t, `{}`, string(q.created[len(q.created)-1].CustomProperties))
+	})
+
+	t.Run("Rejects properties that are not an object", func(t *testing.T) {
+		created := len(q.created)
+		err := create(`{"item_type": "INSURANCE_CLAIM", "custom_properties": ["C-1"]}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Contains(t, httpErr.Message, "custom_properties must be a JSON object, got an array")
+		assert.Len(t, q.created, created)
+	})
+}
+
+// fakeTx records whether it was committed or rolled back; other pgx.Tx methods are not expected
+// to be called.
+type fakeTx struct {
+	pgx.Tx
+	committed  bool
+	rolledBack bool
+	savepoints []*fakeTx
+}
+
+// Begin starts a savepoint, kept on tx.savepoints.
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	savepoint := &fakeTx{}
+	tx.savepoints = append(tx.savepoints, savepoint)
+	return savepoint, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if !tx.committed {
+		tx.rolledBack = true
+	}
+	return nil
+}
+
+// fakeTxDB hands out fakeTxs, keeping the last one started.
+type fakeTxDB struct {
+	repository.DBTX
+	tx *fakeTx
+}
+
+func (db *fakeTxDB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	db.tx = &fakeTx{}
+	return db.tx, nil
+}
+
+// replaceItemQuerier serves one in-scope item and records the update, event and cleared
+// embeddings a change writes.
+type replaceItemQuerier struct {
+	scopeQuerier
+	existing repository.Item
+	updated  []repository.UpdateItemParams
+	events   []repository.CreateItemEventParams
+	cleared  []int64
+	eventErr error
+}
+
+func (q *replaceItemQuerier) ClearItemEmbeddings(ctx context.Context, id int64) (repository.Item, error) {
+	q.cleared = append(q.cleared, id)
+	return repository.Item{ID: id}, nil
+}
+
+func (q *replaceItemQuerier) GetItemForUpdate(ctx context.Context, id int64) (repository.Item, error) {
+	return q.existing, nil
+}
+
+func (q *replaceItemQuerier) UpdateItem(ctx context.Context, arg repository.UpdateItemParams) (repository.Item, error) {
+	q.updated = append(q.updated, arg)
+	return repository.Item{ID: arg.ID, Scope: arg.Scope, Status: arg.Status, CustomProperties: arg.CustomProperties}, nil
+}
+
+func (q *replaceItemQuerier) CreateItemEvent(ctx context.Context, arg repository.CreateItemEventParams) (repository.ItemsEvent, error) {
+	if q.eventErr != nil {
+		return repository.ItemsEvent{}, q.eventErr
+	}
+	q.events = append(q.events, arg)
+	return repository.ItemsEvent{}, nil
+}
+
+func TestHandleReplaceItem(t *testing.T) {
+	q := &replaceItemQuerier{
+		scopeQuerier: scopeQuerier{items: map[int64]repository.GetItemInScopeRow{1: {ID: 1}}},
+		existing: repository.Item{
+			ID:               1,
+			Scope:            pgtype.Text{String: "WEST", Valid: true},
+			Status:           repository.ItemStatusActive,
+			CustomProperties: []byte(`{"claim_id": "C-1", "adjuster": "Kim"}`),
+		},
+	}
+	db := &fakeTxDB{}
+	h := NewItemHandler(q, db, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+	h.txQueries = func(tx pgx.Tx) repository.Querier { return q }
+
+	replace := func(id, body string) error {
+		ctx := WithUserID(context.Background(), 7)
+		ctx = WithItemScope(ctx, ItemScope{Scopes: []string{"WEST"}})
+		req := httptest.NewRequest(http.MethodPut, "/items/"+id, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return h.HandleReplaceItem(c)
+	}
+
+	t.Run("Replaces every field and records an event", func(t *testing.T) {
+		require.NoError(t, replace("1", `{"scope": "WEST/BR01", "status": "inactive", "custom_properties": {"claim_id": "C-1"}}`))
+		require.Len(t, q.updated, 1)
+		assert.Equal(t, "WEST/BR01", q.updated[0].Scope.String)
+		assert.Equal(t, repository.ItemStatusInactive, q.updated[0].Status)
+		assert.JSONEq(t, `{"claim_id": "C-1"}`, string(q.updated[0].CustomProperties))
+
+		require.Len(t, q.events, 1)
+		assert.Equal(t, "ITEM_REPLACED", q.events[0].EventType)
+		assert.Equal(t, int64(7), q.events[0].CreatedBy)
+		assert.JSONEq(t, `{
+			"old_scope": "WEST", "new_scope": "WEST/BR01",
+			"old_status": "active", "new_status": "inactive",
+			"old_custom_properties": {"claim_id": "C-1", "adjuster": "Kim"},
+			"new_custom_properties": {"claim_id": "C-1"}
+		}`, string(q.events[0].EventData))
+		assert.Equal(t, []int64{1}, q.cleared, "embeddings of the old properties are cleared")
+		assert.True(t, db.tx.committed)
+	})
+
+	t.Run("Keeps the embeddings when the properties are unchanged", func(t *testing.T) {
+		q.cleared = nil
+		require.NoError(t, replace("1", `{"scope": "WEST", "status": "inactive", "custom_properties": {"adjuster": "Kim", "claim_id": "C-1"}}`))
+		assert.Empty(t, q.cleared)
+	})
+
+	t.Run("Rolls back the update when the event cannot be recorded", func(t *testing.T) {
+		q.eventErr = errors.New("connection reset")
+		defer func() { q.eventErr = nil }()
+		err := replace("1", `{"scope": "WEST", "status": "active", "custom_properties": {}}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+		assert.False(t, db.tx.committed)
+		assert.True(t, db.tx.rolledBack)
+	})
+
+	t.Run("Requires every field", func(t *testing.T) {
+		updated := len(q.updated)
+		err := replace("1", `{"status": "active"}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Contains(t, httpErr.Message, "missing required fields: scope, custom_properties")
+		assert.Len(t, q.updated, updated)
+	})
+
+	t.Run("Rejects a scope with an empty level", func(t *testing.T) {
+		updated := len(q.updated)
+		err := replace("1", `{"scope": "WEST/", "status": "active", "custom_properties": {}}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Len(t, q.updated, updated)
+	})
+
+	t.Run("Rejects an unknown status", func(t *testing.T) {
+		err := replace("1", `{"scope": "WEST", "status": "pending", "custom_properties": {}}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("Reports an out-of-scope item as not found", func(t *testing.T) {
+		updated := len(q.updated)
+		err := replace("2", `{"scope": "WEST", "status": "active", "custom_properties": {}}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.Code)
+		assert.Len(t, q.updated, updated)
+		assert.True(t, db.tx.rolledBack)
+	})
+}
+
+// arrayOpQuerier serves one in-scope item and records the array operations run against it. Keys
+// listed in notArrays hold something other than an array.
+type arrayOpQuerier struct {
+	replaceItemQuerier
+	added     []repository.AddItemPropertyArrayValueParams
+	removed   []repository.RemoveItemPropertyArrayValueParams
+	notArrays map[string]bool
+}
+
+func (q *arrayOpQuerier) AddItemPropertyArrayValue(ctx context.Context, arg repository.AddItemPropertyArrayValueParams) (repository.Item, error) {
+	if q.notArrays[arg.Key] {
+		return repository.Item{}, pgx.ErrNoRows
+	}
+	q.added = append(q.added, arg)
+	return repository.Item{ID: arg.ID, CustomProperties: []byte(`{"tags": ["new", "urgent"]}`)}, nil
+}
+
+func (q *arrayOpQuerier) RemoveItemPropertyArrayValue(ctx context.Context, arg repository.RemoveItemPropertyArrayValueParams) (repository.Item, error) {
+	q.removed = append(q.removed, arg)
+	return repository.Item{ID: arg.ID, CustomProperties: []byte(`{"tags": ["urgent"]}`)}, nil
+}
+
+func TestHandleUpdateItemArrayOperations(t *testing.T) {
+	q := &arrayOpQuerier{
+		replaceItemQuerier: replaceItemQuerier{
+			scopeQuerier: scopeQuerier{items: map[int64]repository.GetItemInScopeRow{1: {ID: 1}}},
+			existing:     repository.Item{ID: 1, CustomProperties: []byte(`{"tags": ["new"], "claim_id": "C-1"}`)},
+		},
+		notArrays: map[string]bool{"claim_id": true},
+	}
+	db := &fakeTxDB{}
+	h := NewItemHandler(q, db, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+	h.txQueries = func(tx pgx.Tx) repository.Querier { return q }
+
+	update := func(body string) error {
+		ctx := WithItemScope(context.Background(), ItemScope{ViewAll: true})
+		req := httptest.NewRequest(http.MethodPatch, "/items/1", strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		return h.HandleUpdateItem(c)
+	}
+	requireBadRequest := func(t *testing.T, err error, message string) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Contains(t, httpErr.Message, message)
+	}
+
+	t.Run("Applies every operation in one transaction without rewriting the other properties", func(t *testing.T) {
+		require.NoError(t, update(`{"array_operations": [
+			{"op": "add", "path": "tags", "value": "urgent"},
+			{"op": "remove", "path": "tags", "value": "new"}
+		]}`))
+		assert.Empty(t, q.updated, "no read-modify-write of custom_properties")
+		require.Len(t, q.added, 1)
+		assert.Equal(t, "tags", q.added[0].Key)
+		assert.JSONEq(t, `"urgent"`, string(q.added[0].Value))
+		require.Len(t, q.removed, 1)
+		assert.JSONEq(t, `"new"`, string(q.removed[0].Value))
+		assert.Equal(t, []int64{1}, q.cleared, "the changed properties need new embeddings")
+		assert.True(t, db.tx.committed)
+	})
+
+	t.Run("Rejects an operation on a property that isn't an array and rolls back the others", func(t *testing.T) {
+		q.added, q.cleared = nil, nil
+		err := update(`{"array_operations": [
+			{"op": "add", "path": "tags", "value": "urgent"},
+			{"op": "add", "path": "claim_id", "value": "C-2"}
+		]}`)
+		requireBadRequest(t, err, "custom_properties 'claim_id' is not an array")
+		assert.Len(t, q.added, 1, "the first operation ran")
+		assert.False(t, db.tx.committed)
+		assert.True(t, db.tx.rolledBack, "and is undone with the failed one")
+	})
+
+	t.Run("Rejects unknown operations and missing values", func(t *testing.T) {
+		requireBadRequest(t, update(`{"array_operations": [{"op": "append", "path": "tags", "value": "x"}]}`), "op must be add or remove")
+		requireBadRequest(t, update(`{"array_operations": [{"op": "add", "path": "tags"}]}`), "requires a value")
+		requireBadRequest(t, update(`{"array_operations": [{"op": "add", "path": "tags", "value": null}]}`), "requires a non-null value")
+		requireBadRequest(t, update(`{"array_operations": [{"op": "add", "value": "x"}]}`), "path is required")
+	})
+
+	t.Run("Rejects operations combined with custom_properties", func(t *testing.T) {
+		err := update(`{"custom_properties": {"tags": []}, "array_operations": [{"op": "add", "path": "tags", "value": "x"}]}`)
+		requireBadRequest(t, err, "can't be combined")
+	})
+}
+
+func TestHandleUpdateItemFields(t *testing.T) {
+	q := &replaceItemQuerier{
+		scopeQuerier: scopeQuerier{items: map[int64]repository.GetItemInScopeRow{1: {ID: 1}}},
+		existing: repository.Item{
+			ID:               1,
+			Scope:            pgtype.Text{String: "WEST", Valid: true},
+			Status:           repository.ItemStatusActive,
+			CustomProperties: []byte(`{"claim_id": "C-1"}`),
+		},
+	}
+	var db *fakeTxDB
+	update := func(id, body string) error {
+		db = &fakeTxDB{}
+		h := NewItemHandler(q, db, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+		h.txQueries = func(tx pgx.Tx) repository.Querier { return q }
+		ctx := WithItemScope(context.Background(), ItemScope{Scopes: []string{"WEST"}})
+		req := httptest.NewRequest(http.MethodPatch, "/items/"+id, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return h.HandleUpdateItem(c)
+	}
+
+	t.Run("Clears the embeddings when the properties change", func(t *testing.T) {
+		q.updated, q.cleared = nil, nil
+		require.NoError(t, update("1", `{"custom_properties": {"claim_id": "C-2"}}`))
+		require.Len(t, q.updated, 1)
+		assert.Equal(t, []int64{1}, q.cleared)
+		assert.True(t, db.tx.committed)
+	})
+
+	t.Run("Keeps the embeddings when only the status changes", func(t *testing.T) {
+		q.updated, q.cleared = nil, nil
+		require.NoError(t, update("1", `{"status": "inactive"}`))
+		require.Len(t, q.updated, 1)
+		assert.Equal(t, repository.ItemStatusInactive, q.updated[0].Status)
+		assert.Empty(t, q.cleared)
+		assert.True(t, db.tx.committed)
+	})
+
+	t.Run("Reports an out-of-scope item as not found", func(t *testing.T) {
+		q.updated = nil
+		err := update("2", `{"status": "inactive"}`)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.Code)
+		assert.Empty(t, q.updated)
+		assert.True(t, db.tx.rolledBack)
+	})
+}
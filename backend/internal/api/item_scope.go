@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// ItemScope is the set of item scopes the caller may read. A scope also covers the composite
+// scopes nested under it, e.g. WEST covers WEST/BR01. The zero value sees no items.
+type ItemScope struct {
+	// ViewAll is set for admins and holders of items:view_all, who see every scope.
+	ViewAll bool
+	Scopes  []string
+}
+
+// WithItemScope returns a copy of ctx carrying scope. ItemScopeMiddleware sets it.
+func WithItemScope(ctx context.Context, scope ItemScope) context.Context {
+	return context.WithValue(ctx, itemScopeContextKey, scope)
+}
+
+// ItemScopeFromContext returns the caller's ItemScope set by ItemScopeMiddleware. Requests it has
+// not run for, or without an authenticated user, get the zero ItemScope and so see no items.
+func ItemScopeFromContext(ctx context.Context) ItemScope {
+	scope, _ := ctx.Value(itemScopeContextKey).(ItemScope)
+	return scope
+}
+
+// ItemScopeMiddleware resolves the authenticated user's item scopes once per request and stores
+// them on the request context, so handlers filter items with ItemScopeFromContext instead of
+// looking up the user's grants themselves. It must run after the auth middleware.
+func ItemScopeMiddleware(q repository.Querier, logger *slog.Logger) echo.MiddlewareFunc {
+	logger = logger.With("component", "item_scope_middleware")
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			userID, ok := UserIDFromContext(ctx)
+			if !ok {
+				return next(c)
+			}
+			row, err := q.GetUserItemScope(ctx, userID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				logger.ErrorContext(ctx, "Failed to resolve user item scopes", "error", err, "user_id", userID)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve user permissions")
+			}
+			scope := ItemScope{ViewAll: row.ViewAll, Scopes: row.Scopes}
+			c.SetRequest(c.Request().WithContext(WithItemScope(ctx, scope)))
+			return next(c)
+		}
+	}
+}
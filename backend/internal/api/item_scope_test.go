@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopeQuerier serves the item scope lookups; other Querier methods are not expected to be called.
+type scopeQuerier struct {
+	repository.Querier
+	userScope repository.GetUserItemScopeRow
+	userErr   error
+	items     map[int64]repository.GetItemInScopeRow
+	gotScope  repository.GetItemInScopeParams
+}
+
+func (q *scopeQuerier) GetUserItemScope(ctx context.Context, id int64) (repository.GetUserItemScopeRow, error) {
+	return q.userScope, q.userErr
+}
+
+func (q *scopeQuerier) GetItemInScope(ctx context.Context, arg repository.GetItemInScopeParams) (repository.GetItemInScopeRow, error) {
+	q.gotScope = arg
+	item, ok := q.items[arg.ID]
+	if !ok {
+		return item, pgx.ErrNoRows
+	}
+	return item, nil
+}
+
+func TestItemScopeMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	run := func(t *testing.T, q *scopeQuerier, ctx context.Context) (ItemScope, error) {
+		var got ItemScope
+		handler := ItemScopeMiddleware(q, logger)(func(c echo.Context) error {
+			got = ItemScopeFromContext(c.Request().Context())
+			return c.NoContent(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/items", nil).WithContext(ctx)
+		err := handler(echo.New().NewContext(req, httptest.NewRecorder()))
+		return got, err
+	}
+	withUser := WithUserID(context.Background(), 7)
+
+	t.Run("Stores the user's scopes", func(t *testing.T) {
+		q := &scopeQuerier{userScope: repository.GetUserItemScopeRow{Scopes: []string{"WEST"}}}
+		scope, err := run(t, q, withUser)
+		require.NoError(t, err)
+		assert.Equal(t, ItemScope{Scopes: []string{"WEST"}}, scope)
+	})
+
+	t.Run("Sees nothing without an authenticated user", func(t *testing.T) {
+		scope, err := run(t, &scopeQuerier{}, context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, ItemScope{}, scope)
+	})
+
+	t.Run("Sees nothing for an unknown user", func(t *testing.T) {
+		scope, err := run(t, &scopeQuerier{userErr: pgx.ErrNoRows}, withUser)
+		require.NoError(t, err)
+		assert.Equal(t, ItemScope{}, scope)
+	})
+
+	t.Run("Fails when the scopes cannot be read", func(t *testing.T) {
+		_, err := run(t, &scopeQuerier{userErr: errors.New("connection reset")}, withUser)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+	})
+}
+
+func TestHandleGetItemByID(t *testing.T) {
+	q := &scopeQuerier{items: map[int64]repository.GetItemInScopeRow{
+		1: {ID: 1, ContentHash: pgtype.Text{String: "9e107d9d372bb6826bd81d3542a419d6", Valid: true}},
+	}}
+	h := NewItemHandler(q, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+	scope := ItemScope{Scopes: []string{"WEST"}}
+
+	get := func(id string) (*httptest.ResponseRecorder, error) {
+		ctx := WithItemScope(context.Background(), scope)
+		req := httptest.NewRequest(http.MethodGet, "/items/"+id, nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return rec, h.HandleGetItemByID(c)
+	}
+
+	t.Run("Returns an item within the caller's scopes", func(t *testing.T) {
+		rec, err := get("1")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"WEST"}, q.gotScope.Scopes)
+		assert.Contains(t, rec.Body.String(), `"content_hash":"9e107d9d372bb6826bd81d3542a419d6"`)
+	})
+
+	t.Run("Reports an out-of-scope item as not found", func(t *testing.T) {
+		_, err := get("2")
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.Code)
+	})
+
+	t.Run("Rejects an ID that is not a number", func(t *testing.T) {
+		_, err := get("C-1")
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+}
+
+// Until the identity provider middleware is wired in, production requests carry no user. Item
+// reads must then query with an empty scope, which matches no rows, rather than fall back to an
+// unscoped query.
+func TestItemReadsWithoutAnAuthenticatedUser(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	q := &scopeQuerier{}
+	h := NewItemHandler(q, nil, logger, NewFetcherRegistry(), repository.ItemStatusActive, nil, nil)
+	handler := ItemScopeMiddleware(q, logger)(h.HandleGetItemByID)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	err := handler(c)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.Code)
+	assert.False(t, q.gotScope.ViewAll)
+	assert.Empty(t, q.gotScope.Scopes)
+}
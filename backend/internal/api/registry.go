@@ -3,23 +3,64 @@ package api
 import (
 	"context"
 	"fmt"
+	"sync"
+
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
 )
 
-// ListParams holds the common pagination parameters
+// ListParams holds the common pagination parameters and the caller's item scopes
 type ListParams struct {
 	Limit  int32
 	Offset int32
+	// Scope is the caller's ItemScope. Fetchers must only return items within it.
+	Scope ItemScope
+	// Filter holds the conditions of the request's filter expression. Only ScopedItemsFetcher
+	// applies it; HandleGetItems rejects filters for item types with a fetcher of their own.
+	Filter ItemFilter
 }
 
 // ItemListFetcher the signature for any function that can fetch a list of items.
 type ItemListFetcher func(ctx context.Context, db repository.DBTX, params ListParams) (interface{}, int64, error)
 
+// ScopedItemsFetcher returns a fetcher that lists items of itemType from the items table, limited
+// to the caller's scopes and filter. It serves item types that have no fetcher of their own.
+func ScopedItemsFetcher(itemType string) ItemListFetcher {
+	return func(ctx context.Context, db repository.DBTX, params ListParams) (interface{}, int64, error) {
+		q := repository.New(db)
+		totalCount, err := q.CountItemsInScope(ctx, repository.CountItemsInScopeParams{
+			ItemType: itemType,
+			ViewAll:  params.Scope.ViewAll,
+			Scopes:   params.Scope.Scopes,
+			Filters:  params.Filter.JSON(),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		items, err := q.ListItemsInScope(ctx, repository.ListItemsInScopeParams{
+			ItemType:     itemType,
+			ViewAll:      params.Scope.ViewAll,
+			Scopes:       params.Scope.Scopes,
+			Filters:      params.Filter.JSON(),
+			ResultOffset: params.Offset,
+			ResultLimit:  params.Limit,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if items == nil {
+			items = []repository.ListItemsInScopeRow{}
+		}
+		return items, totalCount, nil
+	}
+}
+
 // ItemRegistry is the signature for any function that can fetch a list of items.
 var ItemRegistry = make(map[string]ItemListFetcher)
 
-// FetcherRegistry holds a map of the item types to their corresponding fetcher functions
+// FetcherRegistry holds a map of the item types to their corresponding fetcher functions.
+// It is safe for concurrent use, so fetchers may be registered or replaced while requests are being served.
 type FetcherRegistry struct {
+	mu       sync.RWMutex
 	fetchers map[string]ItemListFetcher
 }
 
@@ -30,16 +71,28 @@ func NewFetcherRegistry() *FetcherRegistry {
 	}
 }
 
-// Register add a fetcher function to the registry for a given item type
+// Register add a fetcher function to the registry for a given item type.
+// It may be called after startup; registering an item type twice panics, use Update to replace a fetcher.
 func (r *FetcherRegistry) Register(itemType string, fetcher ItemListFetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, exists := r.fetchers[itemType]; exists {
 		panic(fmt.Sprintf("Fetcher for item type '%s' is already registered", itemType))
 	}
 	r.fetchers[itemType] = fetcher
 }
 
+// Update registers fetcher for the item type, replacing any fetcher already registered for it.
+func (r *FetcherRegistry) Update(itemType string, fetcher ItemListFetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetchers[itemType] = fetcher
+}
+
 // Get retrieves a fetcher function from the registry for  given item type
 func (r *FetcherRegistry) Get(itemType string) (ItemListFetcher, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	fetcher, found := r.fetchers[itemType]
 	return fetcher, found
 }
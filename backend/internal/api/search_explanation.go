@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/jjckrbbt/chimera/backend/internal/rag"
+)
+
+// explanationSnippetLen is roughly how many characters of context a highlighted snippet keeps.
+const explanationSnippetLen = 240
+
+// SearchExplanation tells an analyst why a result matched: its score, the part of its text that
+// shares terms with the query (matches wrapped in **), and, when asked for, a one-line rationale
+// written by the LLM.
+type SearchExplanation struct {
+	Score     *float64 `json:"score,omitempty"`
+	Highlight string   `json:"highlight"`
+	Rationale string   `json:"rationale,omitempty"`
+}
+
+// explainMatches attaches a score and highlighted snippet to each result.
+func explainMatches(results []SearchResult, query string) {
+	terms := queryTerms(query)
+	for i := range results {
+		results[i].Explanation = &SearchExplanation{
+			Score:     results[i].SimilarityScore,
+			Highlight: highlightSnippet(results[i].Text, terms),
+		}
+	}
+}
+
+// queryTerms splits a query into lower-cased words, dropping very short ones that would highlight
+// noise like "a" or "of".
+func queryTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// highlightSnippet returns the window of text around the first query term it contains, with every
+// occurrence of a term wrapped in **. Semantic matches may share no words with the query, in which
+// case the start of the text is returned unmarked.
+func highlightSnippet(text string, terms []string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lower-casing changed the length (rare Unicode cases); fall back to the plain snippet.
+		return snippet(text)
+	}
+
+	type match struct{ start, end int }
+	var matches []match
+	for i := 0; i < len(lower); {
+		matched := 0
+		if i == 0 || !isWordRune(lower[i-1]) {
+			for _, term := range terms {
+				t := []rune(term)
+				if i+len(t) <= len(lower) && string(lower[i:i+len(t)]) == term && len(t) > matched {
+					matched = len(t)
+				}
+			}
+		}
+		if matched > 0 {
+			matches = append(matches, match{i, i + matched})
+			i += matched
+			continue
+		}
+		i++
+	}
+
+	start, end := 0, len(runes)
+	if len(matches) > 0 {
+		start = max(0, matches[0].start-explanationSnippetLen/4)
+	}
+	if end-start > explanationSnippetLen {
+		end = start + explanationSnippetLen
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		b.WriteString(string(runes[pos:m.start]))
+		b.WriteString("**" + string(runes[m.start:m.end]) + "**")
+		pos = m.end
+	}
+	b.WriteString(string(runes[pos:end]))
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// explanationTemplateData is the data for the explanation prompt template.
+type explanationTemplateData struct {
+	Query   string
+	Results []explanationResult
+}
+
+type explanationResult struct {
+	Index  int
+	Text   string
+	Source string
+}
+
+// addRationales asks the LLM for a one-line rationale per result and adds it to the result's
+// explanation. The query and result text are redacted before they are sent. Failures are logged
+// and leave the results without rationales.
+func (h *InsuranceHandler) addRationales(ctx context.Context, query string, results []*SearchResult) {
+	if len(results) == 0 {
+		return
+	}
+	redactions := rag.NewRedactionMap()
+	data := explanationTemplateData{Query: h.redactor.Redact(query, redactions)}
+	for i, result := range results {
+		data.Results = append(data.Results, explanationResult{
+			Index:  i,
+			Text:   h.redactor.Redact(result.Text, redactions),
+			Source: result.Source,
+		})
+	}
+	var prompt bytes.Buffer
+	if err := h.currentTemplates().explanation.Execute(&prompt, data); err != nil {
+		h.logger.WarnContext(ctx, "Failed to execute explanation template", "error", err)
+		return
+	}
+	content, err := h.callLLM(ctx, prompt.String(), true)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Failed to generate search result rationales", "error", err)
+		return
+	}
+	var response struct {
+		Rationales []struct {
+			Index     int    `json:"index"`
+			Rationale string `json:"rationale"`
+		} `json:"rationales"`
+	}
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		h.logger.WarnContext(ctx, "Explanation LLM returned malformed JSON", "error", err, "raw_content", content)
+		return
+	}
+	for _, r := range response.Rationales {
+		if r.Index < 0 || r.Index >= len(results) {
+			continue
+		}
+		result := results[r.Index]
+		if result.Explanation == nil {
+			result.Explanation = &SearchExplanation{Score: result.SimilarityScore}
+		}
+		result.Explanation.Rationale = strings.TrimSpace(redactions.Restore(r.Rationale))
+	}
+}
+
+// resultPointers returns pointers to the results of each slice, in order, so they can be explained together.
+func resultPointers(groups ...[]SearchResult) []*SearchResult {
+	var pointers []*SearchResult
+	for _, group := range groups {
+		for i := range group {
+			pointers = append(pointers, &group[i])
+		}
+	}
+	return pointers
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"splits on spaces and punctuation", "Roof leak, in the attic?", []string{"roof", "leak", "the", "attic"}},
+		{"lower-cases", "ROOF Leak", []string{"roof", "leak"}},
+		{"keeps non-ASCII letters", "Schäden am Dach", []string{"schäden", "dach"}},
+		{"drops words under three letters", "ticket #42-ABC", []string{"ticket", "abc"}},
+		{"empty query", "", nil},
+		{"only short words", "a, of", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, queryTerms(tt.query))
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	longPrefix := strings.Repeat("a", 100) + " "
+	longSuffix := strings.Repeat("b", 300)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"marks every term", "Roof leak after the storm; the roof was patched", []string{"roof", "leak"}, "**Roof** **leak** after the storm; the **roof** was patched"},
+		{"keeps the text's case", "WATER damage", []string{"water"}, "**WATER** damage"},
+		{"matches at word starts only", "Preroof check, roof ok", []string{"roof"}, "Preroof check, **roof** ok"},
+		{"matches word prefixes", "Roofing quote", []string{"roof"}, "**Roof**ing quote"},
+		{"prefers the longest term", "Rooftop unit", []string{"roof", "rooftop"}, "**Rooftop** unit"},
+		{"matches non-ASCII words", "Schäden am Dach", []string{"schäden"}, "**Schäden** am Dach"},
+		{"matches letters whose lower case is shorter in bytes", "İSTANBUL office", []string{"istanbul"}, "**İSTANBUL** office"},
+		{"leaves text without matches unmarked", "Hail damage to siding", []string{"roof"}, "Hail damage to siding"},
+		{"leaves text unmarked without terms", "Hail damage to siding", nil, "Hail damage to siding"},
+		{"empty text", "", []string{"roof"}, ""},
+		{"windows long text around the first match", longPrefix + "roof " + longSuffix, []string{"roof"},
+			"..." + strings.Repeat("a", 59) + " **roof** " + strings.Repeat("b", 175) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, highlightSnippet(tt.text, tt.terms))
+		})
+	}
+}
+
+func TestAddRationales(t *testing.T) {
+	var prompts []string
+	reply := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body LLMRequestBody
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		prompts = append(prompts, body.Messages[0].Content)
+		content, err := json.Marshal(reply)
+		require.NoError(t, err)
+		w.Write([]byte(`{"choices": [{"message": {"content": ` + string(content) + `}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	h, err := NewInsuranceHandler(nil, nil, &conversationQuerier{}, "../../configs", false, "test-key", server.URL, false, nil, config.DefaultPageSizes(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	results := func() []SearchResult {
+		score := 0.8
+		return []SearchResult{
+			{Source: "Comment", Text: "Called jane@example.com about the roof", SimilarityScore: &score},
+			{Source: "Comment", Text: "Hail damage to siding"},
+		}
+	}
+
+	t.Run("Adds each rationale to its result with PII restored", func(t *testing.T) {
+		prompts = nil
+		reply = `{"rationales": [
+			{"index": 0, "rationale": " Mentions [REDACTED_EMAIL_1] and the roof. "},
+			{"index": 1, "rationale": "Describes storm damage."},
+			{"index": 7, "rationale": "Out of range."}
+		]}`
+		explained := results()
+		h.addRationales(context.Background(), "Roof claims for jane@example.com", resultPointers(explained))
+
+		require.Len(t, prompts, 1)
+		assert.NotContains(t, prompts[0], "jane@example.com", "the query and results are redacted")
+		require.NotNil(t, explained[0].Explanation)
+		assert.Equal(t, "Mentions jane@example.com and the roof.", explained[0].Explanation.Rationale)
+		assert.Equal(t, explained[0].SimilarityScore, explained[0].Explanation.Score)
+		require.NotNil(t, explained[1].Explanation)
+		assert.Equal(t, "Describes storm damage.", explained[1].Explanation.Rationale)
+	})
+
+	t.Run("Keeps the highlight of an explained result", func(t *testing.T) {
+		reply = `{"rationales": [{"index": 1, "rationale": "Describes storm damage."}]}`
+		explained := results()
+		explainMatches(explained, "siding")
+		h.addRationales(context.Background(), "siding", resultPointers(explained))
+		assert.Equal(t, "Hail damage to **siding**", explained[1].Explanation.Highlight)
+		assert.Equal(t, "Describes storm damage.", explained[1].Explanation.Rationale)
+		assert.Empty(t, explained[0].Explanation.Rationale)
+	})
+
+	t.Run("Leaves the results alone when the LLM reply is malformed", func(t *testing.T) {
+		reply = `not json`
+		explained := results()
+		h.addRationales(context.Background(), "roof", resultPointers(explained))
+		assert.Nil(t, explained[0].Explanation)
+		assert.Nil(t, explained[1].Explanation)
+	})
+
+	t.Run("Makes no LLM call without results", func(t *testing.T) {
+		prompts = nil
+		h.addRationales(context.Background(), "roof", nil)
+		assert.Empty(t, prompts)
+	})
+}
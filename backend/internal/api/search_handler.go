@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/pgvector/pgvector-go"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	searchSnippetLen   = 300
+)
+
+// Embedder generates the embedding for a piece of text.
+type Embedder interface {
+	GetEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// Searcher runs the search-everything query. *repository.Queries implements it.
+type Searcher interface {
+	SearchEverything(ctx context.Context, arg repository.SearchEverythingParams) ([]repository.SearchEverythingRow, error)
+}
+
+// SearchHandler serves the search-everything endpoint, which runs one vector search across all
+// embedded item types and comments.
+type SearchHandler struct {
+	queries      Searcher
+	embedder     Embedder
+	configLoader *processing.ConfigLoader
+	logger       *slog.Logger
+}
+
+// SearchRequest is the body of POST /api/search. ItemTypes optionally narrows the search, and
+// EmbeddingField searches items by a named embedding ("title" or "body") instead of the combined one.
+// Metric overrides the distance metric; by default it is the one configured for the searched item
+// types, or cosine. Named embeddings are only searched by cosine.
+type SearchRequest struct {
+	Query          string   `json:"query"`
+	ItemTypes      []string `json:"item_types,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+	EmbeddingField string   `json:"embedding_field,omitempty"`
+	Metric         string   `json:"metric,omitempty"`
+}
+
+// SearchHit is one ranked result. SourceType is "item" or "comment"; for comments, ItemID is the
+// item the comment belongs to. Metric is the distance metric the search ranked by, and Score is the
+// similarity under it normalized to 0-1, higher is closer; it is set only with ?include_scores=true.
+type SearchHit struct {
+	SourceType  string                 `json:"source_type"`
+	ID          int64                  `json:"id"`
+	ItemID      int64                  `json:"item_id"`
+	ItemType    string                 `json:"item_type"`
+	Scope       string                 `json:"scope,omitempty"`
+	BusinessKey string                 `json:"business_key,omitempty"`
+	Snippet     string                 `json:"snippet"`
+	Score       *float64               `json:"score,omitempty"`
+	Metric      string                 `json:"metric"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+// NewSearchHandler creates a new instance of the SearchHandler.
+func NewSearchHandler(q Searcher, embedder Embedder, cl *processing.ConfigLoader, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{
+		queries:      q,
+		embedder:     embedder,
+		configLoader: cl,
+		logger:       logger.With("component", "search_handler"),
+	}
+}
+
+// RegisterRoutes registers the search endpoint on the given group.
+func (h *SearchHandler) RegisterRoutes(g *echo.Group) {
+	g.POST("/search", h.HandleSearch)
+}
+
+// HandleSearch embeds the query once and returns the closest items and comments across every
+// item type the caller is allowed to see, ranked together and tagged by source type.
+func (h *SearchHandler) HandleSearch(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req SearchRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "query is required")
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSearchLimit
+	}
+	if req.Limit > maxSearchLimit {
+		req.Limit = maxSearchLimit
+	}
+	if req.EmbeddingField != "" && !processing.IsEmbeddingField(req.EmbeddingField) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("embedding_field must be '%s' or '%s'", processing.EmbeddingFieldTitle, processing.EmbeddingFieldBody))
+	}
+	if req.Metric == "" {
+		req.Metric = h.defaultMetric(req.ItemTypes)
+		if req.EmbeddingField != "" {
+			req.Metric = processing.DistanceMetricCosine
+		}
+	}
+	if !processing.IsDistanceMetric(req.Metric) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("metric must be '%s', '%s' or '%s'", processing.DistanceMetricCosine, processing.DistanceMetricL2, processing.DistanceMetricInnerProduct))
+	}
+	if req.EmbeddingField != "" && req.Metric != processing.DistanceMetricCosine {
+		// The title and body embeddings only have cosine indexes.
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("embedding_field searches only support the '%s' metric", processing.DistanceMetricCosine))
+	}
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+
+	embedding, err := h.embedder.GetEmbedding(ctx, req.Query)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to embed search query", "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to process search query")
+	}
+	rows, err := h.queries.SearchEverything(ctx, repository.SearchEverythingParams{
+		UserID:         userID,
+		Embedding:      pgvector.NewVector(embedding),
+		ItemTypes:      req.ItemTypes,
+		ResultLimit:    int32(req.Limit),
+		EmbeddingField: req.EmbeddingField,
+		Metric:         req.Metric,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to run search", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to run search")
+	}
+
+	embedFields := h.configLoader.EmbedFieldsByItemType()
+	withScores := includeScores(c)
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		var properties map[string]interface{}
+		if err := json.Unmarshal(row.Properties, &properties); err != nil {
+			h.logger.WarnContext(ctx, "Skipping search hit with unreadable properties", "error", err, "source_type", row.SourceType, "id", row.ID)
+			continue
+		}
+		hit := SearchHit{
+			SourceType:  row.SourceType,
+			ID:          row.ID,
+			ItemID:      row.ItemID,
+			ItemType:    row.ItemType,
+			Scope:       row.Scope.String,
+			BusinessKey: row.BusinessKey.String,
+			Metric:      req.Metric,
+			Properties:  properties,
+		}
+		if withScores {
+			score := normalizedScore(req.Metric, row.Distance)
+			hit.Score = &score
+		}
+		if row.SourceType == "comment" {
+			hit.Snippet = snippet(fmt.Sprint(properties["comment"]))
+			hit.Properties = nil
+		} else {
+			hit.Snippet = snippet(joinFields(properties, embedFields[row.ItemType]))
+		}
+		hits = append(hits, hit)
+	}
+	h.logger.InfoContext(ctx, "Search completed", "results", len(hits), "metric", req.Metric, "user_id", userID)
+	return c.JSON(http.StatusOK, hits)
+}
+
+// defaultMetric returns the distance metric configured for the searched item types when they all
+// agree, and cosine otherwise, including when every item type is searched.
+func (h *SearchHandler) defaultMetric(itemTypes []string) string {
+	if len(itemTypes) == 0 {
+		return processing.DistanceMetricCosine
+	}
+	configured := h.configLoader.DistanceMetricByItemType()
+	metric := ""
+	for _, itemType := range itemTypes {
+		m := configured[itemType]
+		if m == "" {
+			m = processing.DistanceMetricCosine
+		}
+		if metric != "" && m != metric {
+			return processing.DistanceMetricCosine
+		}
+		metric = m
+	}
+	return metric
+}
+
+// joinFields concatenates the given properties, the same text an item's embedding was built from.
+func joinFields(properties map[string]interface{}, fields []string) string {
+	var parts []string
+	for _, field := range fields {
+		if val, ok := properties[field]; ok && val != nil {
+			parts = append(parts, fmt.Sprint(val))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func snippet(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= searchSnippetLen {
+		return string(runes)
+	}
+	return string(runes[:searchSnippetLen]) + "..."
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const searchTestConfig = `
+report_type: "SEARCH_TEST_GUIDES"
+item_type: "KNOWLEDGE_CHUNK"
+scope_field: "region"
+distance_metric: "l2"
+business_key:
+  - "guide_id"
+column_mappings:
+  - csv_header: "guide_id"
+    json_field: "guide_id"
+  - csv_header: "title"
+    json_field: "title"
+  - csv_header: "body"
+    json_field: "body"
+  - csv_header: "region"
+    json_field: "region"
+embed_content:
+  source_columns: ["title", "body"]
+`
+
+// fakeSearcher records the search it is asked to run and returns rows.
+type fakeSearcher struct {
+	params []repository.SearchEverythingParams
+	rows   []repository.SearchEverythingRow
+	err    error
+}
+
+func (s *fakeSearcher) SearchEverything(ctx context.Context, arg repository.SearchEverythingParams) ([]repository.SearchEverythingRow, error) {
+	s.params = append(s.params, arg)
+	return s.rows, s.err
+}
+
+// fakeEmbedder records the texts it embeds and returns a fixed embedding.
+type fakeEmbedder struct {
+	texts []string
+	err   error
+}
+
+func (e *fakeEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	e.texts = append(e.texts, text)
+	return []float32{0.1, 0.2}, e.err
+}
+
+func TestHandleSearch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	configDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "ingestion"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "ingestion", "guides.yaml"), []byte(searchTestConfig), 0o644))
+	configLoader, err := processing.NewConfigLoader(configDir)
+	require.NoError(t, err)
+
+	search := func(t *testing.T, searcher *fakeSearcher, embedder *fakeEmbedder, query, body string) (*httptest.ResponseRecorder, error) {
+		h := NewSearchHandler(searcher, embedder, configLoader, logger)
+		req := httptest.NewRequest(http.MethodPost, "/search?"+query, strings.NewReader(body)).WithContext(WithUserID(context.Background(), 7))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return rec, h.HandleSearch(echo.New().NewContext(req, rec))
+	}
+	assertStatus := func(t *testing.T, err error, status int) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, status, httpErr.Code, httpErr.Message)
+	}
+
+	t.Run("Embeds the query once and searches as the caller", func(t *testing.T) {
+		searcher, embedder := &fakeSearcher{}, &fakeEmbedder{}
+		rec, err := search(t, searcher, embedder, "", `{"query": "  hail damage  ", "item_types": ["KNOWLEDGE_CHUNK"]}`)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+
+		assert.Equal(t, []string{"hail damage"}, embedder.texts)
+		require.Len(t, searcher.params, 1)
+		params := searcher.params[0]
+		assert.EqualValues(t, 7, params.UserID, "results are scoped to the caller's grants")
+		assert.Equal(t, []float32{0.1, 0.2}, params.Embedding.Slice())
+		assert.Equal(t, []string{"KNOWLEDGE_CHUNK"}, params.ItemTypes)
+		assert.EqualValues(t, defaultSearchLimit, params.ResultLimit)
+		assert.Equal(t, processing.DistanceMetricL2, params.Metric, "the item type's configured metric is the default")
+	})
+
+	t.Run("Searches every item type by cosine unless asked otherwise", func(t *testing.T) {
+		searcher := &fakeSearcher{}
+		_, err := search(t, searcher, &fakeEmbedder{}, "", `{"query": "hail", "limit": 500}`)
+		require.NoError(t, err)
+		assert.Equal(t, processing.DistanceMetricCosine, searcher.params[0].Metric)
+		assert.EqualValues(t, maxSearchLimit, searcher.params[0].ResultLimit)
+
+		_, err = search(t, searcher, &fakeEmbedder{}, "", `{"query": "hail", "embedding_field": "title", "item_types": ["KNOWLEDGE_CHUNK"]}`)
+		require.NoError(t, err)
+		assert.Equal(t, processing.EmbeddingFieldTitle, searcher.params[1].EmbeddingField)
+		assert.Equal(t, processing.DistanceMetricCosine, searcher.params[1].Metric, "named embeddings are searched by cosine")
+	})
+
+	t.Run("Tags items and comments in the order searched", func(t *testing.T) {
+		searcher := &fakeSearcher{rows: []repository.SearchEverythingRow{
+			{SourceType: "item", ID: 1, ItemID: 1, ItemType: "KNOWLEDGE_CHUNK", Scope: pgtype.Text{String: "WEST", Valid: true},
+				BusinessKey: pgtype.Text{String: "G-1", Valid: true}, Properties: []byte(`{"title": "Hail", "body": "Inspect the roof", "region": "WEST"}`), Distance: 0},
+			{SourceType: "comment", ID: 9, ItemID: 4, ItemType: "INSURANCE_CLAIM", Properties: []byte(`{"comment": "Hail dented the hood"}`), Distance: 3},
+			{SourceType: "item", ID: 2, ItemType: "KNOWLEDGE_CHUNK", Properties: []byte(`not json`)},
+		}}
+		rec, err := search(t, searcher, &fakeEmbedder{}, "include_scores=true", `{"query": "hail", "metric": "l2"}`)
+		require.NoError(t, err)
+
+		var hits []SearchHit
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hits))
+		require.Len(t, hits, 2, "hits with unreadable properties are skipped")
+		assert.Equal(t, "item", hits[0].SourceType)
+		assert.Equal(t, "Hail Inspect the roof", hits[0].Snippet, "item snippets are the embedded text")
+		assert.Equal(t, "G-1", hits[0].BusinessKey)
+		assert.Equal(t, "WEST", hits[0].Properties["region"])
+		require.NotNil(t, hits[0].Score)
+		assert.Equal(t, 1.0, *hits[0].Score)
+
+		assert.Equal(t, "comment", hits[1].SourceType)
+		assert.EqualValues(t, 4, hits[1].ItemID)
+		assert.Equal(t, "Hail dented the hood", hits[1].Snippet)
+		assert.Nil(t, hits[1].Properties)
+		require.NotNil(t, hits[1].Score)
+		assert.Equal(t, 0.25, *hits[1].Score)
+		assert.Equal(t, processing.DistanceMetricL2, hits[1].Metric)
+	})
+
+	t.Run("Leaves scores out unless asked", func(t *testing.T) {
+		searcher := &fakeSearcher{rows: []repository.SearchEverythingRow{{SourceType: "comment", ID: 9, Properties: []byte(`{"comment": "Hail"}`)}}}
+		rec, err := search(t, searcher, &fakeEmbedder{}, "", `{"query": "hail"}`)
+		require.NoError(t, err)
+		assert.NotContains(t, rec.Body.String(), `"score"`)
+	})
+
+	t.Run("Truncates long snippets", func(t *testing.T) {
+		assert.Equal(t, strings.Repeat("é", searchSnippetLen)+"...", snippet(strings.Repeat("é", searchSnippetLen+1)))
+		assert.Equal(t, "short", snippet("  short "))
+	})
+
+	t.Run("Rejects invalid requests", func(t *testing.T) {
+		for _, tt := range []struct {
+			body string
+			want string
+		}{
+			{`{"query": "   "}`, "query is required"},
+			{`{"query": "hail", "embedding_field": "summary"}`, "embedding_field must be"},
+			{`{"query": "hail", "metric": "manhattan"}`, "metric must be"},
+			{`{"query": "hail", "embedding_field": "body", "metric": "l2"}`, "embedding_field searches only support"},
+		} {
+			searcher := &fakeSearcher{}
+			_, err := search(t, searcher, &fakeEmbedder{}, "", tt.body)
+			assertStatus(t, err, http.StatusBadRequest)
+			assert.ErrorContains(t, err, tt.want)
+			assert.Empty(t, searcher.params)
+		}
+	})
+
+	t.Run("Requires a user", func(t *testing.T) {
+		h := NewSearchHandler(&fakeSearcher{}, &fakeEmbedder{}, configLoader, logger)
+		req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(`{"query": "hail"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		assertStatus(t, h.HandleSearch(echo.New().NewContext(req, httptest.NewRecorder())), http.StatusUnauthorized)
+	})
+
+	t.Run("Fails when the query can't be embedded or searched", func(t *testing.T) {
+		_, err := search(t, &fakeSearcher{}, &fakeEmbedder{err: errors.New("embedding service down")}, "", `{"query": "hail"}`)
+		assertStatus(t, err, http.StatusBadGateway)
+
+		_, err = search(t, &fakeSearcher{err: errors.New("connection refused")}, &fakeEmbedder{}, "", `{"query": "hail"}`)
+		assertStatus(t, err, http.StatusInternalServerError)
+	})
+}
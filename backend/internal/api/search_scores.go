@@ -0,0 +1,88 @@
+package api
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/labstack/echo/v4"
+)
+
+// includeScores reports whether the request asked for similarity scores with ?include_scores=true.
+// Search endpoints omit scores by default.
+func includeScores(c echo.Context) bool {
+	include, _ := strconv.ParseBool(c.QueryParam("include_scores"))
+	return include
+}
+
+// normalizedScore turns a vector distance under metric into a similarity from 0 to 1, higher is
+// closer: 1 - distance/2 for cosine, whose distance runs from 0 to 2; (1 + inner product)/2 for
+// inner_product, whose embeddings are unit length, so the product runs from -1 to 1; and
+// 1 / (1 + distance) for l2. pgvector's <#> returns the negative inner product, so it is negated back.
+func normalizedScore(metric string, distance float64) float64 {
+	var score float64
+	switch metric {
+	case processing.DistanceMetricInnerProduct:
+		score = (1 - distance) / 2
+	case processing.DistanceMetricL2:
+		score = 1 / (1 + distance)
+	default:
+		score = 1 - distance/2
+	}
+	return math.Min(math.Max(score, 0), 1)
+}
+
+// keywordScore turns a full-text ts_rank into a score from 0 to 1. ts_rank has no upper bound, so
+// rank / (1 + rank) keeps the ordering while fitting the range of vector scores.
+func keywordScore(rank float32) float64 {
+	r := math.Max(float64(rank), 0)
+	return r / (1 + r)
+}
+
+// cosineScore returns the normalized score of a cosine distance.
+func cosineScore(distance float64) *float64 {
+	score := normalizedScore(processing.DistanceMetricCosine, distance)
+	return &score
+}
+
+// score returns the result's similarity score, or 0 when it has none.
+func (r SearchResult) score() float64 {
+	if r.SimilarityScore == nil {
+		return 0
+	}
+	return *r.SimilarityScore
+}
+
+// withoutScores clears the scores of results, including those of their explanations, for a
+// response that did not ask for them.
+func withoutScores(results []SearchResult) {
+	for i := range results {
+		results[i].SimilarityScore = nil
+		if results[i].Explanation != nil {
+			results[i].Explanation.Score = nil
+		}
+	}
+}
+
+// scoredClaim is a claim found by semantic search. Its similarity_score replaces the row's raw
+// cosine distance with the normalized score, and is omitted unless scores were requested.
+type scoredClaim struct {
+	insurance.ListClaimsWithVectorRow
+	SimilarityScore *float64 `json:"similarity_score,omitempty"`
+}
+
+// unscoredClaim is a claim listed without semantic search. Its similarity_score replaces the row's
+// always-null column, so it is omitted the same way as an unrequested score.
+type unscoredClaim struct {
+	insurance.ListClaimsWithoutVectorRow
+	SimilarityScore *float64 `json:"similarity_score,omitempty"`
+}
+
+func newScoredClaim(row insurance.ListClaimsWithVectorRow, include bool) scoredClaim {
+	claim := scoredClaim{ListClaimsWithVectorRow: row}
+	if include {
+		claim.SimilarityScore = cosineScore(row.SimilarityScore)
+	}
+	return claim
+}
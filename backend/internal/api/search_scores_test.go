@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/apps/insurance"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizedScore(t *testing.T) {
+	tests := []struct {
+		metric   string
+		distance float64
+		want     float64
+	}{
+		{processing.DistanceMetricCosine, 0, 1},
+		{processing.DistanceMetricCosine, 1, 0.5},
+		{processing.DistanceMetricCosine, 2, 0},
+		{"", 0.5, 0.75},
+		{processing.DistanceMetricInnerProduct, -1, 1},
+		{processing.DistanceMetricInnerProduct, 0, 0.5},
+		{processing.DistanceMetricInnerProduct, 1.2, 0},
+		{processing.DistanceMetricL2, 0, 1},
+		{processing.DistanceMetricL2, 3, 0.25},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, normalizedScore(tt.metric, tt.distance), 1e-9, "%s distance %v", tt.metric, tt.distance)
+	}
+	assert.Equal(t, 0.0, keywordScore(0))
+	assert.Equal(t, 0.5, keywordScore(1))
+	assert.Less(t, keywordScore(0.1), keywordScore(0.2))
+}
+
+func TestScoredClaimJSON(t *testing.T) {
+	row := insurance.ListClaimsWithVectorRow{ID: 7, SimilarityScore: 0.5}
+
+	raw, err := json.Marshal(claimWithVectorSLA{scoredClaim: newScoredClaim(row, true)})
+	require.NoError(t, err)
+	var claim map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &claim))
+	assert.Equal(t, 0.75, claim["similarity_score"], "the normalized score replaces the raw distance")
+
+	raw, err = json.Marshal(claimWithVectorSLA{scoredClaim: newScoredClaim(row, false)})
+	require.NoError(t, err)
+	claim = nil
+	require.NoError(t, json.Unmarshal(raw, &claim))
+	assert.NotContains(t, claim, "similarity_score")
+
+	raw, err = json.Marshal(claimWithSLA{unscoredClaim: unscoredClaim{ListClaimsWithoutVectorRow: insurance.ListClaimsWithoutVectorRow{ID: 8}}})
+	require.NoError(t, err)
+	claim = nil
+	require.NoError(t, json.Unmarshal(raw, &claim))
+	assert.NotContains(t, claim, "similarity_score")
+	assert.Equal(t, float64(8), claim["id"])
+}
+
+func TestWithoutScores(t *testing.T) {
+	results := keywordSearchResults([]insurance.SearchCommentsKeywordRow{{Text: "Roof leak", Rank: 1}})
+	explainMatches(results, "roof")
+	require.NotNil(t, results[0].SimilarityScore)
+	assert.Equal(t, 0.5, *results[0].Explanation.Score)
+
+	withoutScores(results)
+	raw, err := json.Marshal(results)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "similarityScore")
+	assert.NotContains(t, string(raw), `"score"`)
+}
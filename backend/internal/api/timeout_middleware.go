@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RequestTimeout cancels the request context after timeout. If the deadline passes before the
+// handler finishes, the client receives a 503 even when the handler wrapped the cancellation in
+// its own error (e.g. a 500 from a failed DB call).
+func RequestTimeout(timeout time.Duration) echo.MiddlewareFunc {
+	return middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+		Timeout: timeout,
+		ErrorHandler: func(err error, c echo.Context) error {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request().Context().Err(), context.DeadlineExceeded) {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "Request timed out").SetInternal(err)
+			}
+			return err
+		},
+	})
+}
@@ -5,48 +5,48 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
 	"github.com/jjckrbbt/chimera/backend/internal/repository" // Use your project's import path
 	"github.com/labstack/echo/v4"
 )
 
 type TriageHandler struct {
-	db      *pgxpool.Pool
-	queries *repository.Queries
-	logger  *slog.Logger
+	db        *pgxpool.Pool
+	queries   *repository.Queries
+	pageSizes config.PageSizes
+	logger    *slog.Logger
 }
 
-// NewTriageHandler creates a new instance of the TriageHandler.
-func NewTriageHandler(db *pgxpool.Pool, queries *repository.Queries, logger *slog.Logger) *TriageHandler {
+// NewTriageHandler creates a new instance of the TriageHandler. Ingestion jobs are paged by the
+// ingestion_jobs page size in pageSizes.
+func NewTriageHandler(db *pgxpool.Pool, queries *repository.Queries, pageSizes config.PageSizes, logger *slog.Logger) *TriageHandler {
 	return &TriageHandler{
-		db:      db,
-		queries: queries,
-		logger:  logger.With("component", "triage_handler"),
+		db:        db,
+		queries:   queries,
+		pageSizes: pageSizes,
+		logger:    logger.With("component", "triage_handler"),
 	}
 }
 
 func (h *TriageHandler) RegisterRoutes(g *echo.Group) {
 	g.GET("/ingestion-jobs", h.listIngestionJobs)
 	g.GET("/ingestion-jobs/:jobId/errors", h.getIngestionErrors)
+	g.GET("/ingestion-stats", h.getIngestionStats)
 	g.PATCH("/ingestion-errors/:errorId", h.updateIngestionError)
 }
 
 func (h *TriageHandler) listIngestionJobs(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	limitStr := c.QueryParam("limit")
-	offsetStr := c.QueryParam("offset")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
+	pageSize := h.pageSizes.For(config.PageSizeIngestionJobs)
+	limit := pageSize.Limit(c.QueryParam("limit"))
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
 	if err != nil || offset < 0 {
 		offset = 0
 	}
@@ -56,14 +56,75 @@ func (h *TriageHandler) listIngestionJobs(c echo.Context) error {
 		Offset: int32(offset),
 	}
 
+	totalCount, err := h.queries.CountIngestionJobs(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to count ingestion jobs", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get ingestion jobs").SetInternal(err)
+	}
+
 	jobs, err := h.queries.ListIngestionJobs(ctx, params)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to list ingestion jobs", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get ingestion jobs").SetInternal(err)
 	}
+	if jobs == nil {
+		jobs = []repository.ListIngestionJobsRow{}
+	}
+
+	h.logger.InfoContext(ctx, "successfully retrieved ingestion jobs", "count", len(jobs), "total_count", totalCount, "limit", limit, "offset", offset)
+	return c.JSON(http.StatusOK, PaginatedItemsResponse{
+		TotalCount: totalCount,
+		Data:       jobs,
+		Limit:      limit,
+		MaxLimit:   pageSize.Max,
+	})
+}
+
+// defaultIngestionStatsWindow is how far back getIngestionStats looks when no 'since' is given.
+const defaultIngestionStatsWindow = 30 * 24 * time.Hour
+
+// IngestionStatsSeries is the per-job stats time series of one report type.
+type IngestionStatsSeries struct {
+	ReportType string                                `json:"report_type"`
+	Points     []repository.ListIngestionJobStatsRow `json:"points"`
+}
+
+// getIngestionStats returns per-job ingestion stats as a time series per report type. The
+// optional 'report_type' narrows it to one type and 'since' (RFC 3339) sets the start, which
+// defaults to 30 days ago.
+func (h *TriageHandler) getIngestionStats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	since := time.Now().Add(-defaultIngestionStatsWindow)
+	if sinceStr := c.QueryParam("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid 'since' timestamp, expected RFC 3339")
+		}
+		since = parsed
+	}
+	reportType := c.QueryParam("report_type")
+
+	rows, err := h.queries.ListIngestionJobStats(ctx, repository.ListIngestionJobStatsParams{
+		Since:      pgtype.Timestamptz{Time: since, Valid: true},
+		ReportType: pgtype.Text{String: reportType, Valid: reportType != ""},
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to list ingestion job stats", "error", err, "report_type", reportType)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get ingestion stats").SetInternal(err)
+	}
+
+	// Rows are ordered by report type, so each series is a contiguous run.
+	series := []IngestionStatsSeries{}
+	for _, row := range rows {
+		if len(series) == 0 || series[len(series)-1].ReportType != row.ReportType {
+			series = append(series, IngestionStatsSeries{ReportType: row.ReportType})
+		}
+		last := &series[len(series)-1]
+		last.Points = append(last.Points, row)
+	}
 
-	h.logger.InfoContext(ctx, "successfully retrieved ingestion jobs", "count", len(jobs), "limit", limit, "offset", offset)
-	return c.JSON(http.StatusOK, jobs)
+	return c.JSON(http.StatusOK, series)
 }
 
 func (h *TriageHandler) getIngestionErrors(c echo.Context) error {
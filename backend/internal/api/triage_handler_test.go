@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsDB serves ingestion job stats rows, recording the arguments of the query.
+type statsDB struct {
+	repository.DBTX
+	rows []repository.ListIngestionJobStatsRow
+	err  error
+	args []interface{}
+}
+
+func (d *statsDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	d.args = args
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &statsRows{rows: d.rows}, nil
+}
+
+type statsRows struct {
+	emptyRows
+	rows []repository.ListIngestionJobStatsRow
+	next int
+}
+
+func (r *statsRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *statsRows) Scan(dest ...interface{}) error {
+	row := r.rows[r.next-1]
+	*dest[0].(*pgtype.UUID) = row.JobID
+	*dest[1].(*string) = row.ReportType
+	*dest[2].(*string) = row.Status
+	*dest[3].(*int32) = row.RowsTotal
+	*dest[4].(*int32) = row.RowsUpserted
+	*dest[5].(*int32) = row.RowsTriaged
+	*dest[6].(*int32) = row.RowsBlank
+	*dest[7].(*int64) = row.DurationMs
+	*dest[8].(*pgtype.Timestamptz) = row.RecordedAt
+	*dest[9].(*float64) = row.TriageRate
+	return nil
+}
+
+func TestGetIngestionStats(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	get := func(t *testing.T, db *statsDB, query string) (*httptest.ResponseRecorder, error) {
+		h := NewTriageHandler(nil, repository.New(db), config.DefaultPageSizes(), logger)
+		req := httptest.NewRequest(http.MethodGet, "/ingestion-stats?"+query, nil)
+		rec := httptest.NewRecorder()
+		return rec, h.getIngestionStats(echo.New().NewContext(req, rec))
+	}
+	point := func(reportType string, rowsTotal int32) repository.ListIngestionJobStatsRow {
+		return repository.ListIngestionJobStatsRow{ReportType: reportType, Status: "COMPLETED", RowsTotal: rowsTotal}
+	}
+
+	t.Run("Groups the points into a series per report type", func(t *testing.T) {
+		db := &statsDB{rows: []repository.ListIngestionJobStatsRow{point("claims", 10), point("claims", 20), point("policies", 5)}}
+		rec, err := get(t, db, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var series []IngestionStatsSeries
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &series))
+		require.Len(t, series, 2)
+		assert.Equal(t, "claims", series[0].ReportType)
+		require.Len(t, series[0].Points, 2)
+		assert.EqualValues(t, 10, series[0].Points[0].RowsTotal)
+		assert.EqualValues(t, 20, series[0].Points[1].RowsTotal)
+		assert.Equal(t, "policies", series[1].ReportType)
+		require.Len(t, series[1].Points, 1)
+	})
+
+	t.Run("Defaults to the last 30 days of every report type", func(t *testing.T) {
+		db := &statsDB{}
+		rec, err := get(t, db, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `[]`, rec.Body.String(), "no stats is an empty list, not null")
+
+		require.Len(t, db.args, 2)
+		since := db.args[0].(pgtype.Timestamptz)
+		assert.WithinDuration(t, time.Now().Add(-defaultIngestionStatsWindow), since.Time, time.Minute)
+		assert.False(t, db.args[1].(pgtype.Text).Valid)
+	})
+
+	t.Run("Narrows to a report type since a given time", func(t *testing.T) {
+		db := &statsDB{}
+		_, err := get(t, db, "report_type=claims&since=2026-01-02T03:04:05Z")
+		require.NoError(t, err)
+		require.Len(t, db.args, 2)
+		assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), db.args[0].(pgtype.Timestamptz).Time)
+		assert.Equal(t, pgtype.Text{String: "claims", Valid: true}, db.args[1])
+	})
+
+	t.Run("Rejects a malformed since", func(t *testing.T) {
+		db := &statsDB{}
+		_, err := get(t, db, "since=yesterday")
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Nil(t, db.args, "nothing is queried")
+	})
+
+	t.Run("Fails when the stats can't be listed", func(t *testing.T) {
+		_, err := get(t, &statsDB{err: errors.New("connection refused")}, "")
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+	})
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
+	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uploadFlowReportType = "INTEGRATION_TEST_CLAIMS"
+
+const uploadFlowConfig = `
+report_type: "INTEGRATION_TEST_CLAIMS"
+item_type: "KNOWLEDGE_CHUNK"
+scope_field: "region"
+business_key:
+  - "claim_id"
+column_mappings:
+  - csv_header: "claim_id"
+    json_field: "claim_id"
+    validation:
+      required: true
+  - csv_header: "amount"
+    json_field: "amount"
+    validation:
+      required: true
+      regex: '^\d+$'
+  - csv_header: "region"
+    json_field: "region"
+    attempts:
+      - transforms: ["trim_space", "to_uppercase"]
+`
+
+// TestUploadProcessTriageFlow drives a CSV upload through HandleUpload, RunJob and triage against a
+// real database, with uploads kept in a local file store. It needs a Postgres with the platform
+// migrations applied (make migrate-up-platform) and is skipped unless TEST_DATABASE_URL points at it.
+func TestUploadProcessTriageFlow(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	// Uploads are attributed to user 1 until the handler reads the user from the request.
+	_, err = pool.Exec(ctx, `INSERT INTO users (id, auth_provider_subject, email) VALUES (1, 'integration-test', 'integration-test@example.com') ON CONFLICT DO NOTHING`)
+	require.NoError(t, err)
+
+	configDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "ingestion"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "ingestion", "claims.yaml"), []byte(uploadFlowConfig), 0o644))
+	configLoader, err := processing.NewConfigLoader(configDir)
+	require.NoError(t, err)
+
+	store, err := filestore.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	cfg := &config.Config{StorageBackend: filestore.BackendLocal}
+	queries := repository.New(pool)
+	ingestionService, err := ingestion.NewService(queries, store, cfg, logger)
+	require.NoError(t, err)
+	processingService := processing.NewService(ingestionService, configLoader, queries, store, logger, cfg, pool)
+	handler := NewUploadHandler(ingestionService, processingService, nil, configLoader, ingestion.NewPauseList(), nil, logger)
+
+	// Claim IDs are unique per run so the test can share a database with earlier runs.
+	run := uuid.NewString()[:8]
+	good1, good2, bad := "IT-"+run+"-1", "IT-"+run+"-2", "IT-"+run+"-3"
+	csvData := "claim_id,amount,region\n" +
+		good1 + ",1200, west\n" +
+		good2 + ",800,east\n" +
+		bad + ",not-a-number,west\n"
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("report_file", "claims.csv")
+	require.NoError(t, err)
+	_, err = io.WriteString(part, csvData)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload/"+uploadFlowReportType+"?sync=true", &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("reportType")
+	c.SetParamValues(uploadFlowReportType)
+
+	require.NoError(t, handler.HandleUpload(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var summary ingestion.JobSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summary))
+
+	t.Run("Job finishes with issues", func(t *testing.T) {
+		assert.Equal(t, "COMPLETE_WITH_ISSUES", summary.Job.Status)
+		assert.EqualValues(t, 2, summary.Job.ProcessedRows.Int32)
+		assert.False(t, summary.Job.Degraded)
+	})
+
+	t.Run("Bad row is sent to triage", func(t *testing.T) {
+		require.Len(t, summary.TriageRows, 1)
+		triage := summary.TriageRows[0]
+		assert.Contains(t, string(triage.OriginalRowData), bad)
+		assert.Contains(t, triage.ReasonForFailure, "amount")
+	})
+
+	t.Run("Good rows are saved as items", func(t *testing.T) {
+		for _, claimID := range []string{good1, good2} {
+			var scope string
+			err := pool.QueryRow(ctx, `SELECT scope FROM items WHERE item_type = 'KNOWLEDGE_CHUNK' AND business_key = $1`, claimID).Scan(&scope)
+			require.NoError(t, err, claimID)
+			assert.Equal(t, map[string]string{good1: "WEST", good2: "EAST"}[claimID], scope)
+		}
+		var count int
+		require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM items WHERE business_key = $1`, bad).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("Uploaded file is kept in the file store", func(t *testing.T) {
+		exists, err := store.Exists(ctx, summary.Job.SourceUri.String)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Job is listed with the total job count", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ingestion-jobs?limit=1", nil)
+		rec := httptest.NewRecorder()
+		require.NoError(t, NewTriageHandler(pool, queries, nil, logger).listIngestionJobs(echo.New().NewContext(req, rec)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var page struct {
+			TotalCount int64                             `json:"total_count"`
+			Data       []repository.ListIngestionJobsRow `json:"data"`
+			Limit      int                               `json:"limit"`
+			MaxLimit   int                               `json:"max_limit"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Len(t, page.Data, 1)
+		assert.GreaterOrEqual(t, page.TotalCount, int64(1))
+		assert.Equal(t, 1, page.Limit)
+		assert.Equal(t, 100, page.MaxLimit, "the default ingestion_jobs max applies without a page sizes config")
+	})
+}
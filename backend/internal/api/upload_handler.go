@@ -2,14 +2,21 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
 	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
 	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
 	"github.com/jjckrbbt/chimera/backend/internal/processing"
 	"github.com/jjckrbbt/chimera/backend/internal/rag"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
 	"github.com/labstack/echo/v4"
 )
 
@@ -19,27 +26,56 @@ type UploadHandler struct {
 	processingService *processing.Service
 	ragService        *rag.RAGService
 	configLoader      *processing.ConfigLoader
+	pauses            *ingestion.PauseList
+	pageSizes         config.PageSizes
 	logger            *slog.Logger
 }
 
 // NewUploadHandler creates a new instance of the UploadHandler.
-func NewUploadHandler(is *ingestion.Service, ps *processing.Service, ragSvc *rag.RAGService, cl *processing.ConfigLoader, logger *slog.Logger) *UploadHandler {
+// Uploads for report types paused in pauses are refused, and previews are sized by the preview page size in pageSizes.
+func NewUploadHandler(is *ingestion.Service, ps *processing.Service, ragSvc *rag.RAGService, cl *processing.ConfigLoader, pauses *ingestion.PauseList, pageSizes config.PageSizes, logger *slog.Logger) *UploadHandler {
 	return &UploadHandler{
 		ingestionService:  is,
 		processingService: ps,
 		ragService:        ragSvc,
 		configLoader:      cl,
+		pauses:            pauses,
+		pageSizes:         pageSizes,
 		logger:            logger,
 	}
 }
 
+// checkNotPaused returns a 503 error when ingestion for reportType has been paused by an admin.
+func (h *UploadHandler) checkNotPaused(ctx context.Context, reportType string) error {
+	paused, found := h.pauses.IsPaused(reportType)
+	if !found {
+		return nil
+	}
+	h.logger.WarnContext(ctx, "Rejected upload for paused report type", "reportType", reportType, "reason", paused.Reason)
+	message := fmt.Sprintf("Ingestion for report type %s is paused", reportType)
+	if paused.Reason != "" {
+		message += ": " + paused.Reason
+	}
+	return echo.NewHTTPError(http.StatusServiceUnavailable, message+". Please try again later.")
+}
+
+// maxSyncUploadRows caps the data rows of files that may be processed synchronously with
+// ?sync=true. Larger files, and files whose rows can't be counted up front, are processed in the
+// background as usual.
+const maxSyncUploadRows = 500
+
 // HandleUpload receives a file, starts an ingestion job, and triggers async processing.
+// With ?sync=true, files of at most maxSyncUploadRows data rows are processed before responding
+// and the response carries the finished job and the rows sent to triage.
 func (h *UploadHandler) HandleUpload(c echo.Context) error {
 	ctx := c.Request().Context()
 	// NOTE: In a real app, you would get the user ID from the JWT in the context.
 	// For this demo, we can hardcode it or leave it as 0.
 	var userID int64 = 1
 	reportType := c.Param("reportType")
+	if err := h.checkNotPaused(ctx, reportType); err != nil {
+		return err
+	}
 
 	file, err := c.FormFile("report_file")
 	if err != nil {
@@ -52,7 +88,37 @@ func (h *UploadHandler) HandleUpload(c echo.Context) error {
 	}
 	defer src.Close()
 
-	// 1. Start the ingestion job (uploads to GCS, creates DB record)
+	// 1. Reject files whose content doesn't match the formats the report type expects
+	if config, found := h.configLoader.GetConfig(reportType); found {
+		head := make([]byte, processing.SniffLen)
+		n, err := io.ReadFull(src, head)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+		}
+		format := processing.DetectFormat(head[:n])
+		if !config.AcceptsFormat(format) {
+			h.logger.WarnContext(ctx, "Rejected upload with unsupported file format", "reportType", reportType, "detected_format", format, "filename", file.Filename)
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+				fmt.Sprintf("File format '%s' is not accepted for report type %s; expected one of: %s", format, reportType, strings.Join(config.ExpectedFormats(), ", ")))
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+		}
+	}
+
+	// 2. Small files may be processed in the request so the uploader sees errors immediately
+	processNow := false
+	if c.QueryParam("sync") == "true" {
+		var rows int
+		if processNow, rows, err = withinSyncRowLimit(src); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+		}
+		if !processNow {
+			h.logger.InfoContext(ctx, "File too large for synchronous processing, processing in background", "reportType", reportType, "rows_counted", rows, "max_sync_rows", maxSyncUploadRows)
+		}
+	}
+
+	// 3. Start the ingestion job (uploads to GCS, creates DB record)
 	job, err := h.ingestionService.StartJob(ctx, src, file.Filename, reportType, userID)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "Failed to start ingestion job", "error", err)
@@ -60,18 +126,188 @@ func (h *UploadHandler) HandleUpload(c echo.Context) error {
 	}
 	h.logger.InfoContext(ctx, "Successfully started ingestion job, queueing for processing", "job_id", job.ID)
 
-	// 2. Determine which embedding function (if any) to use for this job
-	var embedder interfaces.EmbedderFunc
+	// 4a. Process small files in the request
+	if processNow {
+		return h.processSynchronously(c, job, reportType)
+	}
+
+	// 4b. Trigger processing and return an immediate success response
+	h.startProcessing(ctx, job, reportType)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// withinSyncRowLimit reports whether src has at most maxSyncUploadRows data rows, reading no further
+// than the row past the limit, and rewinds src. Files that can't be previewed, such as xlsx
+// workbooks, don't count as within the limit; rows is how many data rows were counted.
+func withinSyncRowLimit(src io.ReadSeeker) (within bool, rows int, err error) {
+	preview, previewErr := processing.PreviewFile(src, maxSyncUploadRows+1)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return false, 0, err
+	}
+	if previewErr != nil {
+		return false, 0, nil
+	}
+	return len(preview.Rows) <= maxSyncUploadRows, len(preview.Rows), nil
+}
+
+// processSynchronously runs the processing job in the request and responds with its summary.
+func (h *UploadHandler) processSynchronously(c echo.Context, job *repository.IngestionJob, reportType string) error {
+	ctx := c.Request().Context()
+	jobID := uuid.UUID(job.ID.Bytes)
+	h.processingService.RunJob(ctx, jobID, reportType, job.SourceUri.String, h.embedderFor(ctx, reportType))
+
+	summary, err := h.ingestionService.GetJobSummary(ctx, jobID)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get summary of synchronously processed job", "error", err, "job_id", jobID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "File was processed, but its results could not be loaded.")
+	}
+	return c.JSON(http.StatusOK, summary)
+}
+
+// HandlePreview returns the header and first N data rows of an uploaded file without storing it
+// or creating a job. N comes from the "rows" query parameter, defaulting to and capped by the
+// preview page size (10 and 100 unless configured).
+func (h *UploadHandler) HandlePreview(c echo.Context) error {
+	ctx := c.Request().Context()
+	reportType := c.Param("reportType")
+
+	rowsParam := c.QueryParam("rows")
+	if parsed, err := strconv.Atoi(rowsParam); rowsParam != "" && (err != nil || parsed <= 0) {
+		return echo.NewHTTPError(http.StatusBadRequest, "rows must be a positive integer")
+	}
+	rows := h.pageSizes.For(config.PageSizePreview).Limit(rowsParam)
+
+	file, err := c.FormFile("report_file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "report_file is required")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to open uploaded file")
+	}
+	defer src.Close()
+
+	preview, err := processing.PreviewFile(src, rows)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Failed to preview uploaded file", "reportType", reportType, "filename", file.Filename, "error", err)
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("Could not preview file: %v", err))
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// SignedUploadRequest is the body for requesting a direct-to-GCS upload URL.
+type SignedUploadRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Resumable   bool   `json:"resumable"`
+}
+
+// RegisterUploadRequest is the body for registering a file uploaded via a signed URL.
+type RegisterUploadRequest struct {
+	JobID    string `json:"job_id"`
+	Filename string `json:"filename"`
+}
+
+// HandleCreateSignedUpload issues a signed URL so large files can be uploaded straight to GCS
+// without streaming through the API.
+func (h *UploadHandler) HandleCreateSignedUpload(c echo.Context) error {
+	ctx := c.Request().Context()
+	reportType := c.Param("reportType")
+	if err := h.checkNotPaused(ctx, reportType); err != nil {
+		return err
+	}
+
+	var req SignedUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Filename == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "filename is required")
+	}
+	if _, found := h.configLoader.GetConfig(reportType); !found {
+		return echo.NewHTTPError(http.StatusBadRequest, "Unknown report type")
+	}
+
+	upload, err := h.ingestionService.CreateSignedUpload(ctx, req.Filename, reportType, req.ContentType, req.Resumable)
+	if errors.Is(err, ingestion.ErrSignedUploadUnsupported) {
+		return echo.NewHTTPError(http.StatusNotImplemented, "Signed uploads are not available with this storage backend; use the regular upload endpoint.")
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create signed upload URL", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create upload URL.")
+	}
+
+	return c.JSON(http.StatusOK, upload)
+}
+
+// HandleRegisterUpload registers a file that was uploaded via a signed URL as an ingestion job
+// and triggers async processing. The file's format is checked as in HandleUpload, and each upload
+// can only be registered once.
+func (h *UploadHandler) HandleRegisterUpload(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	reportType := c.Param("reportType")
+	if err := h.checkNotPaused(ctx, reportType); err != nil {
+		return err
+	}
+
+	var req RegisterUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	jobID, err := uuid.Parse(req.JobID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job_id")
+	}
+	if req.Filename == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "filename is required")
+	}
 	config, found := h.configLoader.GetConfig(reportType)
 	if !found {
-		h.logger.WarnContext(ctx, "No ingestion config found for reportType, processing will likely fail", "reportType", reportType)
-	} else {
-		if config.EmbedContent != nil {
-			embedder = h.getEmbedding
+		return echo.NewHTTPError(http.StatusBadRequest, "Unknown report type")
+	}
+
+	head, err := h.ingestionService.ReadUploadHead(ctx, jobID, req.Filename, reportType, processing.SniffLen)
+	if errors.Is(err, ingestion.ErrUploadNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Uploaded file not found; complete the upload before registering it")
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to read uploaded file", "error", err, "job_id", jobID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	if format := processing.DetectFormat(head); !config.AcceptsFormat(format) {
+		h.logger.WarnContext(ctx, "Rejected upload with unsupported file format", "reportType", reportType, "detected_format", format, "filename", req.Filename)
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("File format '%s' is not accepted for report type %s; expected one of: %s", format, reportType, strings.Join(config.ExpectedFormats(), ", ")))
+	}
+
+	job, err := h.ingestionService.RegisterUpload(ctx, jobID, req.Filename, reportType, userID)
+	if err != nil {
+		if errors.Is(err, ingestion.ErrUploadNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Uploaded file not found; complete the upload before registering it")
+		}
+		if errors.Is(err, ingestion.ErrUploadAlreadyRegistered) {
+			return echo.NewHTTPError(http.StatusConflict, "This upload has already been registered")
 		}
+		h.logger.ErrorContext(ctx, "Failed to register uploaded file", "error", err, "job_id", jobID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not start file processing.")
 	}
+	h.logger.InfoContext(ctx, "Registered direct upload, queueing for processing", "job_id", job.ID)
+
+	h.startProcessing(ctx, job, reportType)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// startProcessing picks the embedder for the report type and runs the processing job in the background.
+func (h *UploadHandler) startProcessing(ctx context.Context, job *repository.IngestionJob, reportType string) {
+	embedder := h.embedderFor(ctx, reportType)
 
-	// 3. Trigger the processing service in a background goroutine
+	// Trigger the processing service in a background goroutine
 	go h.processingService.RunJob(
 		context.Background(),
 		uuid.UUID(job.ID.Bytes),
@@ -79,9 +315,19 @@ func (h *UploadHandler) HandleUpload(c echo.Context) error {
 		job.SourceUri.String,
 		embedder,
 	)
+}
 
-	// 4. Return an immediate success response
-	return c.JSON(http.StatusAccepted, job)
+// embedderFor determines which embedding function (if any) to use for a job of reportType.
+func (h *UploadHandler) embedderFor(ctx context.Context, reportType string) interfaces.EmbedderFunc {
+	config, found := h.configLoader.GetConfig(reportType)
+	if !found {
+		h.logger.WarnContext(ctx, "No ingestion config found for reportType, processing will likely fail", "reportType", reportType)
+		return nil
+	}
+	if config.EmbedContent != nil {
+		return h.getEmbedding
+	}
+	return nil
 }
 
 func (h *UploadHandler) getEmbedding(ctx context.Context, text string) ([]float32, error) {
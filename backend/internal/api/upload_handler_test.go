@@ -0,0 +1,311 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
+	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
+	"github.com/jjckrbbt/chimera/backend/internal/processing"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateJobQuerier fails every job insert with a duplicate key error; other Querier methods
+// are not expected to be called.
+type duplicateJobQuerier struct {
+	repository.Querier
+}
+
+func (duplicateJobQuerier) CreateIngestionJob(ctx context.Context, arg repository.CreateIngestionJobParams) (repository.IngestionJob, error) {
+	return repository.IngestionJob{}, &pgconn.PgError{Code: "23505"}
+}
+
+func TestHandleRegisterUpload(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	configDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "ingestion"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "ingestion", "claims.yaml"), []byte(uploadFlowConfig), 0o644))
+	configLoader, err := processing.NewConfigLoader(configDir)
+	require.NoError(t, err)
+	store, err := filestore.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ingestionService, err := ingestion.NewService(duplicateJobQuerier{}, store, &config.Config{StorageBackend: filestore.BackendLocal}, logger)
+	require.NoError(t, err)
+	handler := NewUploadHandler(ingestionService, nil, nil, configLoader, ingestion.NewPauseList(), nil, logger)
+
+	// upload stores content where a signed upload URL for the job would have put it.
+	upload := func(t *testing.T, content string) uuid.UUID {
+		jobID := uuid.New()
+		w, err := store.NewWriter(ctx, "raw-reports/"+uploadFlowReportType+"/"+jobID.String()+"-/claims.csv")
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return jobID
+	}
+	register := func(ctx context.Context, reportType string, jobID uuid.UUID) error {
+		body := `{"job_id": "` + jobID.String() + `", "filename": "claims.csv"}`
+		req := httptest.NewRequest(http.MethodPost, "/upload/"+reportType+"/register", strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("reportType")
+		c.SetParamValues(reportType)
+		return handler.HandleRegisterUpload(c)
+	}
+	asUser := WithUserID(ctx, 7)
+	assertStatus := func(t *testing.T, err error, status int) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, status, httpErr.Code, httpErr.Message)
+	}
+
+	t.Run("Requires a user", func(t *testing.T) {
+		assertStatus(t, register(ctx, uploadFlowReportType, upload(t, "claim_id,amount,region\n")), http.StatusUnauthorized)
+	})
+
+	t.Run("Rejects an unknown report type", func(t *testing.T) {
+		assertStatus(t, register(asUser, "NOT_CONFIGURED", upload(t, "claim_id,amount,region\n")), http.StatusBadRequest)
+	})
+
+	t.Run("Rejects a missing upload", func(t *testing.T) {
+		assertStatus(t, register(asUser, uploadFlowReportType, uuid.New()), http.StatusNotFound)
+	})
+
+	t.Run("Rejects a file in a format the report type doesn't accept", func(t *testing.T) {
+		assertStatus(t, register(asUser, uploadFlowReportType, upload(t, `{"claim_id": "1"}`+"\n")), http.StatusUnsupportedMediaType)
+	})
+
+	t.Run("Rejects an upload that is already registered", func(t *testing.T) {
+		assertStatus(t, register(asUser, uploadFlowReportType, upload(t, "claim_id,amount,region\n1,2,west\n")), http.StatusConflict)
+	})
+}
+
+// jobQuerier keeps ingestion jobs in memory. Jobs may be updated from a processing goroutine, so
+// access is guarded by mu.
+type jobQuerier struct {
+	repository.Querier
+	mu   sync.Mutex
+	jobs map[pgtype.UUID]repository.IngestionJob
+}
+
+func (q *jobQuerier) CreateIngestionJob(ctx context.Context, arg repository.CreateIngestionJobParams) (repository.IngestionJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := repository.IngestionJob{ID: arg.ID, ItemType: arg.ItemType, Status: arg.Status, SourceUri: arg.SourceUri}
+	q.jobs[arg.ID] = job
+	return job, nil
+}
+
+func (q *jobQuerier) UpdateIngestionJobStatus(ctx context.Context, arg repository.UpdateIngestionJobStatusParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.jobs[arg.ID]
+	job.Status, job.ErrorDetails = arg.Status, arg.ErrorDetails
+	q.jobs[arg.ID] = job
+	return nil
+}
+
+func (q *jobQuerier) GetIngestionJob(ctx context.Context, id pgtype.UUID) (repository.IngestionJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.jobs[id], nil
+}
+
+func (q *jobQuerier) GetIngestionErrorsByJobID(ctx context.Context, jobID pgtype.UUID) ([]repository.IngestionError, error) {
+	return nil, nil
+}
+
+func (q *jobQuerier) status(id pgtype.UUID) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.jobs[id].Status
+}
+
+// execOnlyDB accepts every statement run with Exec, such as recording job stats; other DBTX
+// methods are not expected to be called.
+type execOnlyDB struct {
+	repository.DBTX
+}
+
+func (execOnlyDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func TestHandleUploadSync(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	configDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "ingestion"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "ingestion", "claims.yaml"), []byte(uploadFlowConfig), 0o644))
+	configLoader, err := processing.NewConfigLoader(configDir)
+	require.NoError(t, err)
+	store, err := filestore.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	cfg := &config.Config{StorageBackend: filestore.BackendLocal}
+	queries := &jobQuerier{jobs: map[pgtype.UUID]repository.IngestionJob{}}
+	ingestionService, err := ingestion.NewService(queries, store, cfg, logger)
+	require.NoError(t, err)
+	processingService := processing.NewService(ingestionService, configLoader, repository.New(execOnlyDB{}), store, logger, cfg, nil)
+	handler := NewUploadHandler(ingestionService, processingService, nil, configLoader, ingestion.NewPauseList(), nil, logger)
+
+	uploadFile := func(t *testing.T, reportType, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("report_file", "claims.csv")
+		require.NoError(t, err)
+		_, err = io.WriteString(part, content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/upload/"+reportType+"?sync=true", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("reportType")
+		c.SetParamValues(reportType)
+		require.NoError(t, handler.HandleUpload(c))
+		return rec
+	}
+
+	t.Run("Processes a file within the row limit before responding", func(t *testing.T) {
+		rec := uploadFile(t, uploadFlowReportType, "claim_id,amount,region\n")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var summary ingestion.JobSummary
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summary))
+		assert.Equal(t, "NO_DATA", summary.Job.Status, "the response carries the finished job")
+		assert.NotNil(t, summary.TriageRows)
+		assert.Empty(t, summary.TriageRows)
+	})
+
+	t.Run("Processes a file over the row limit in the background", func(t *testing.T) {
+		// The report type has no ingestion config, so background processing fails without saving anything.
+		content := "claim_id,amount,region\n" + strings.Repeat("1,2,west\n", maxSyncUploadRows+1)
+		rec := uploadFile(t, "NOT_CONFIGURED", content)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+		var job repository.IngestionJob
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, "UPLOADED", job.Status)
+		assert.Eventually(t, func() bool { return queries.status(job.ID) == "FAILED" }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestWithinSyncRowLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		within  bool
+		rows    int
+	}{
+		{"a few rows", "claim_id,amount\n1,2\n3,4\n", true, 2},
+		{"exactly the limit", "claim_id,amount\n" + strings.Repeat("1,2\n", maxSyncUploadRows), true, maxSyncUploadRows},
+		{"one row over the limit", "claim_id,amount\n" + strings.Repeat("1,2\n", maxSyncUploadRows+1), false, maxSyncUploadRows + 1},
+		{"a file that can't be previewed", "PK\x03\x04 not really a workbook", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := strings.NewReader(tt.content)
+			within, rows, err := withinSyncRowLimit(src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.within, within)
+			assert.Equal(t, tt.rows, rows)
+
+			rest, err := io.ReadAll(src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, string(rest), "the file is rewound")
+		})
+	}
+}
+
+func TestHandlePreview(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Without an ingestion service, storing the file or creating a job would panic.
+	handler := NewUploadHandler(nil, nil, nil, nil, ingestion.NewPauseList(), config.DefaultPageSizes(), logger)
+
+	preview := func(t *testing.T, query, content string) (*httptest.ResponseRecorder, error) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		if content != "" {
+			part, err := form.CreateFormFile("report_file", "claims.csv")
+			require.NoError(t, err)
+			_, err = io.WriteString(part, content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/upload/"+uploadFlowReportType+"/preview?"+query, &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("reportType")
+		c.SetParamValues(uploadFlowReportType)
+		return rec, handler.HandlePreview(c)
+	}
+	assertStatus := func(t *testing.T, err error, status int) {
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, status, httpErr.Code, httpErr.Message)
+	}
+	csvData := "claim_id,amount\nC-1,100\nC-2,200\nC-3,300\n"
+
+	t.Run("Returns the headers and the requested number of rows", func(t *testing.T) {
+		rec, err := preview(t, "rows=2", csvData)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"format": "csv", "headers": ["claim_id", "amount"], "rows": [["C-1", "100"], ["C-2", "200"]]}`, rec.Body.String())
+	})
+
+	t.Run("Caps the rows at the preview page size", func(t *testing.T) {
+		var content strings.Builder
+		content.WriteString("claim_id\n")
+		for i := 0; i < 150; i++ {
+			fmt.Fprintf(&content, "C-%d\n", i)
+		}
+		rec, err := preview(t, "rows=1000", content.String())
+		require.NoError(t, err)
+		var body processing.FilePreview
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Len(t, body.Rows, config.DefaultPageSizes().For(config.PageSizePreview).Max)
+	})
+
+	t.Run("Rejects a rows value that isn't a positive integer", func(t *testing.T) {
+		for _, rows := range []string{"0", "-1", "ten"} {
+			_, err := preview(t, "rows="+rows, csvData)
+			assertStatus(t, err, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Requires a file", func(t *testing.T) {
+		_, err := preview(t, "", "")
+		assertStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("Reports a file it can't preview", func(t *testing.T) {
+		_, err := preview(t, "", "claim_id,note\nC-1,\"unterminated\n")
+		assertStatus(t, err, http.StatusUnprocessableEntity)
+		assert.ErrorContains(t, err, "Could not preview file")
+	})
+}
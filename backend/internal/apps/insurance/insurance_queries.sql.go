@@ -12,6 +12,115 @@ import (
 	"github.com/pgvector/pgvector-go"
 )
 
+const countClaimsWithVector = `-- name: CountClaimsWithVector :one
+SELECT COUNT(*)
+FROM vw_insurance_claims
+WHERE
+    ($2::text IS NULL OR claim_id = $2)
+AND ($3::decimal IS NULL OR claim_amount >= $3)
+AND ($4::decimal IS NULL OR claim_amount <= $4)
+AND ($5::text IS NULL OR adjuster_assigned = $5)
+AND ($6::text IS NULL OR business_status = $6)
+AND ($7::text IS NULL OR policy_number = $7)
+AND ($8::jsonb IS NULL OR (CURRENT_DATE - date_of_loss) > ($8::jsonb->>business_status)::int)
+AND ($9::bigint IS NULL OR EXISTS (SELECT 1 FROM items ai WHERE ai.id = vw_insurance_claims.id AND ai.custom_properties->>'Adjuster_Assigned_User_ID' = $9::bigint::text))
+AND (embedding <=> $1::vector) < 0.5
+`
+
+type CountClaimsWithVectorParams struct {
+	SearchEmbedding  pgvector.Vector `json:"search_embedding"`
+	ClaimID          pgtype.Text     `json:"claim_id"`
+	MinAmount        pgtype.Numeric  `json:"min_amount"`
+	MaxAmount        pgtype.Numeric  `json:"max_amount"`
+	AdjusterAssigned pgtype.Text     `json:"adjuster_assigned"`
+	Status           pgtype.Text     `json:"status"`
+	PolicyNumber     pgtype.Text     `json:"policy_number"`
+	BreachedSlaDays  []byte          `json:"breached_sla_days"`
+	AdjusterUserID   pgtype.Int8     `json:"adjuster_user_id"`
+}
+
+// Counts the claims ListClaimsWithVector pages through.
+func (q *Queries) CountClaimsWithVector(ctx context.Context, arg CountClaimsWithVectorParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countClaimsWithVector,
+		arg.SearchEmbedding,
+		arg.ClaimID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AdjusterAssigned,
+		arg.Status,
+		arg.PolicyNumber,
+		arg.BreachedSlaDays,
+		arg.AdjusterUserID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countClaimsWithoutVector = `-- name: CountClaimsWithoutVector :one
+SELECT COUNT(*)
+FROM vw_insurance_claims
+WHERE
+    ($1::text IS NULL OR claim_id = $1)
+AND ($2::decimal IS NULL OR claim_amount >= $2)
+AND ($3::decimal IS NULL OR claim_amount <= $3)
+AND ($4::text IS NULL OR adjuster_assigned = $4)
+AND ($5::text IS NULL OR business_status = $5)
+AND ($6::text IS NULL OR policy_number = $6)
+AND ($7::jsonb IS NULL OR (CURRENT_DATE - date_of_loss) > ($7::jsonb->>business_status)::int)
+AND ($8::bigint IS NULL OR EXISTS (SELECT 1 FROM items ai WHERE ai.id = vw_insurance_claims.id AND ai.custom_properties->>'Adjuster_Assigned_User_ID' = $8::bigint::text))
+`
+
+type CountClaimsWithoutVectorParams struct {
+	ClaimID          pgtype.Text    `json:"claim_id"`
+	MinAmount        pgtype.Numeric `json:"min_amount"`
+	MaxAmount        pgtype.Numeric `json:"max_amount"`
+	AdjusterAssigned pgtype.Text    `json:"adjuster_assigned"`
+	Status           pgtype.Text    `json:"status"`
+	PolicyNumber     pgtype.Text    `json:"policy_number"`
+	BreachedSlaDays  []byte         `json:"breached_sla_days"`
+	AdjusterUserID   pgtype.Int8    `json:"adjuster_user_id"`
+}
+
+// Counts the claims ListClaimsWithoutVector pages through.
+func (q *Queries) CountClaimsWithoutVector(ctx context.Context, arg CountClaimsWithoutVectorParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countClaimsWithoutVector,
+		arg.ClaimID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AdjusterAssigned,
+		arg.Status,
+		arg.PolicyNumber,
+		arg.BreachedSlaDays,
+		arg.AdjusterUserID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPolicyholders = `-- name: CountPolicyholders :one
+SELECT COUNT(*)
+FROM vw_policyholders
+WHERE
+    state = COALESCE($1, state)
+AND
+    customer_level = COALESCE($2, customer_level)
+`
+
+type CountPolicyholdersParams struct {
+	State         pgtype.Text `json:"state"`
+	CustomerLevel pgtype.Text `json:"customer_level"`
+}
+
+// Counts the policyholders ListPolicyholders pages through.
+func (q *Queries) CountPolicyholders(ctx context.Context, arg CountPolicyholdersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countPolicyholders, arg.State, arg.CustomerLevel)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getClaimDetails = `-- name: GetClaimDetails :one
 SELECT
     c.id, c.item_type, c.claim_id, c.policy_number, c.system_status, c.created_at, c.updated_at,
@@ -75,21 +184,23 @@ func (q *Queries) GetClaimDetails(ctx context.Context, id int64) (GetClaimDetail
 
 const getClaimStatusHistory = `-- name: GetClaimStatusHistory :many
 SELECT
-    ie.id AS event_id, ie.created_at AS event_timestamp, ie.event_data, u.display_name AS user_name
+    ie.id AS event_id, ie.event_type, ie.created_at AS event_timestamp, ie.event_data, u.display_name AS user_name
 FROM items_events ie
 JOIN users u ON ie.created_by = u.id
-WHERE ie.item_id = $1 AND ie.event_type = 'CLAIM_STATUS_CHANGED'
+WHERE ie.item_id = $1 AND ie.event_type IN ('CLAIM_STATUS_CHANGED', 'CLAIM_STATUS_TRANSITION_REJECTED')
 ORDER BY ie.created_at DESC
 `
 
 type GetClaimStatusHistoryRow struct {
 	EventID        int64              `json:"event_id"`
+	EventType      string             `json:"event_type"`
 	EventTimestamp pgtype.Timestamptz `json:"event_timestamp"`
 	EventData      []byte             `json:"event_data"`
 	UserName       pgtype.Text        `json:"user_name"`
 }
 
-// Fetches the business status change history for a specific claim item
+// Fetches the business status change history for a specific claim item, including the
+// transitions the workflow rejected
 func (q *Queries) GetClaimStatusHistory(ctx context.Context, itemID int64) ([]GetClaimStatusHistoryRow, error) {
 	rows, err := q.db.Query(ctx, getClaimStatusHistory, itemID)
 	if err != nil {
@@ -101,6 +212,7 @@ func (q *Queries) GetClaimStatusHistory(ctx context.Context, itemID int64) ([]Ge
 		var i GetClaimStatusHistoryRow
 		if err := rows.Scan(
 			&i.EventID,
+			&i.EventType,
 			&i.EventTimestamp,
 			&i.EventData,
 			&i.UserName,
@@ -117,7 +229,7 @@ func (q *Queries) GetClaimStatusHistory(ctx context.Context, itemID int64) ([]Ge
 
 const getDocumentHeader = `-- name: GetDocumentHeader :one
 SELECT
-    custom_properties->'metadata'->'source_custom_properties' as structured_metadata
+    (custom_properties->'metadata'->'source_custom_properties')::jsonb as structured_metadata
 FROM items
 WHERE
     item_type = 'KNOWLEDGE_CHUNK'
@@ -129,9 +241,9 @@ LIMIT 1
 `
 
 // Fetches the header chunk's source_custom_properties for a given document ID.
-func (q *Queries) GetDocumentHeader(ctx context.Context, documentID string) (interface{}, error) {
+func (q *Queries) GetDocumentHeader(ctx context.Context, documentID string) ([]byte, error) {
 	row := q.db.QueryRow(ctx, getDocumentHeader, documentID)
-	var structured_metadata interface{}
+	var structured_metadata []byte
 	err := row.Scan(&structured_metadata)
 	return structured_metadata, err
 }
@@ -141,7 +253,8 @@ SELECT
     id, item_type, claim_id, policy_number, system_status, created_at, updated_at,
     policyholder_id, claim_type, date_of_loss, description_of_loss, claim_amount,
     business_status, adjuster_assigned,
-    (embedding <=> $1::vector) as similarity_score
+    (embedding <=> $1::vector)::float8 as similarity_score,
+    (CURRENT_DATE - date_of_loss) AS age_days
 FROM vw_insurance_claims
 WHERE
     ($2::text IS NULL OR claim_id = $2)
@@ -150,9 +263,16 @@ AND ($4::decimal IS NULL OR claim_amount <= $4)
 AND ($5::text IS NULL OR adjuster_assigned = $5)
 AND ($6::text IS NULL OR business_status = $6)
 AND ($7::text IS NULL OR policy_number = $7)
+AND ($8::jsonb IS NULL OR (CURRENT_DATE - date_of_loss) > ($8::jsonb->>business_status)::int)
+AND ($13::bigint IS NULL OR EXISTS (SELECT 1 FROM items ai WHERE ai.id = vw_insurance_claims.id AND ai.custom_properties->>'Adjuster_Assigned_User_ID' = $13::bigint::text))
 AND (embedding <=> $1::vector) < 0.5
-ORDER BY similarity_score ASC
-LIMIT $9 OFFSET $8
+ORDER BY
+    CASE WHEN $11::text = 'claim_amount' AND $12::text = 'asc' THEN claim_amount END ASC,
+    CASE WHEN $11::text = 'claim_amount' AND $12::text = 'desc' THEN claim_amount END DESC,
+    CASE WHEN $11::text = 'date_of_loss' AND $12::text = 'asc' THEN date_of_loss END ASC,
+    CASE WHEN $11::text = 'date_of_loss' AND $12::text = 'desc' THEN date_of_loss END DESC,
+    similarity_score ASC
+LIMIT $10 OFFSET $9
 `
 
 type ListClaimsWithVectorParams struct {
@@ -163,8 +283,12 @@ type ListClaimsWithVectorParams struct {
 	AdjusterAssigned pgtype.Text     `json:"adjuster_assigned"`
 	Status           pgtype.Text     `json:"status"`
 	PolicyNumber     pgtype.Text     `json:"policy_number"`
+	BreachedSlaDays  []byte          `json:"breached_sla_days"`
 	Offset           int32           `json:"offset"`
 	Limit            int32           `json:"limit"`
+	SortBy           string          `json:"sort_by"`
+	SortDirection    string          `json:"sort_direction"`
+	AdjusterUserID   pgtype.Int8     `json:"adjuster_user_id"`
 }
 
 type ListClaimsWithVectorRow struct {
@@ -182,10 +306,11 @@ type ListClaimsWithVectorRow struct {
 	ClaimAmount       pgtype.Numeric     `json:"claim_amount"`
 	BusinessStatus    string             `json:"business_status"`
 	AdjusterAssigned  string             `json:"adjuster_assigned"`
-	SimilarityScore   interface{}        `json:"similarity_score"`
+	SimilarityScore   float64            `json:"similarity_score"`
+	AgeDays           pgtype.Int4        `json:"age_days"`
 }
 
-// Fetches and sorts claims by semantic similarity.
+// Fetches claims by semantic similarity, sorted by sort_by when given and by similarity otherwise.
 func (q *Queries) ListClaimsWithVector(ctx context.Context, arg ListClaimsWithVectorParams) ([]ListClaimsWithVectorRow, error) {
 	rows, err := q.db.Query(ctx, listClaimsWithVector,
 		arg.SearchEmbedding,
@@ -195,8 +320,12 @@ func (q *Queries) ListClaimsWithVector(ctx context.Context, arg ListClaimsWithVe
 		arg.AdjusterAssigned,
 		arg.Status,
 		arg.PolicyNumber,
+		arg.BreachedSlaDays,
 		arg.Offset,
 		arg.Limit,
+		arg.SortBy,
+		arg.SortDirection,
+		arg.AdjusterUserID,
 	)
 	if err != nil {
 		return nil, err
@@ -221,6 +350,7 @@ func (q *Queries) ListClaimsWithVector(ctx context.Context, arg ListClaimsWithVe
 			&i.BusinessStatus,
 			&i.AdjusterAssigned,
 			&i.SimilarityScore,
+			&i.AgeDays,
 		); err != nil {
 			return nil, err
 		}
@@ -237,7 +367,8 @@ SELECT
     id, item_type, claim_id, policy_number, system_status, created_at, updated_at,
     policyholder_id, claim_type, date_of_loss, description_of_loss, claim_amount,
     business_status, adjuster_assigned,
-    NULL::float8 as similarity_score
+    NULL::float8 as similarity_score,
+    (CURRENT_DATE - date_of_loss) AS age_days
 FROM vw_insurance_claims
 WHERE
     ($1::text IS NULL OR claim_id = $1)
@@ -246,11 +377,15 @@ AND ($3::decimal IS NULL OR claim_amount <= $3)
 AND ($4::text IS NULL OR adjuster_assigned = $4)
 AND ($5::text IS NULL OR business_status = $5)
 AND ($6::text IS NULL OR policy_number = $6)
+AND ($7::jsonb IS NULL OR (CURRENT_DATE - date_of_loss) > ($7::jsonb->>business_status)::int)
+AND ($12::bigint IS NULL OR EXISTS (SELECT 1 FROM items ai WHERE ai.id = vw_insurance_claims.id AND ai.custom_properties->>'Adjuster_Assigned_User_ID' = $12::bigint::text))
 ORDER BY
-    CASE WHEN $7::text = 'claim_amount' AND $8::text = 'asc' THEN claim_amount END ASC,
-    CASE WHEN $7::text = 'claim_amount' AND $8::text = 'desc' THEN claim_amount END DESC,
+    CASE WHEN $8::text = 'claim_amount' AND $9::text = 'asc' THEN claim_amount END ASC,
+    CASE WHEN $8::text = 'claim_amount' AND $9::text = 'desc' THEN claim_amount END DESC,
+    CASE WHEN $8::text = 'date_of_loss' AND $9::text = 'asc' THEN date_of_loss END ASC,
+    CASE WHEN $8::text = 'date_of_loss' AND $9::text = 'desc' THEN date_of_loss END DESC,
     date_of_loss DESC
-LIMIT $10 OFFSET $9
+LIMIT $11 OFFSET $10
 `
 
 type ListClaimsWithoutVectorParams struct {
@@ -260,10 +395,12 @@ type ListClaimsWithoutVectorParams struct {
 	AdjusterAssigned pgtype.Text    `json:"adjuster_assigned"`
 	Status           pgtype.Text    `json:"status"`
 	PolicyNumber     pgtype.Text    `json:"policy_number"`
+	BreachedSlaDays  []byte         `json:"breached_sla_days"`
 	SortBy           string         `json:"sort_by"`
 	SortDirection    string         `json:"sort_direction"`
 	Offset           int32          `json:"offset"`
 	Limit            int32          `json:"limit"`
+	AdjusterUserID   pgtype.Int8    `json:"adjuster_user_id"`
 }
 
 type ListClaimsWithoutVectorRow struct {
@@ -282,6 +419,7 @@ type ListClaimsWithoutVectorRow struct {
 	BusinessStatus    string             `json:"business_status"`
 	AdjusterAssigned  string             `json:"adjuster_assigned"`
 	SimilarityScore   pgtype.Float8      `json:"similarity_score"`
+	AgeDays           pgtype.Int4        `json:"age_days"`
 }
 
 // Fetches a paginated and filtered list of insurance claims without vector search.
@@ -293,10 +431,12 @@ func (q *Queries) ListClaimsWithoutVector(ctx context.Context, arg ListClaimsWit
 		arg.AdjusterAssigned,
 		arg.Status,
 		arg.PolicyNumber,
+		arg.BreachedSlaDays,
 		arg.SortBy,
 		arg.SortDirection,
 		arg.Offset,
 		arg.Limit,
+		arg.AdjusterUserID,
 	)
 	if err != nil {
 		return nil, err
@@ -321,6 +461,7 @@ func (q *Queries) ListClaimsWithoutVector(ctx context.Context, arg ListClaimsWit
 			&i.BusinessStatus,
 			&i.AdjusterAssigned,
 			&i.SimilarityScore,
+			&i.AgeDays,
 		); err != nil {
 			return nil, err
 		}
@@ -396,10 +537,15 @@ SELECT
     'Comment' AS source,
     comment::TEXT AS text,
     i.business_key AS claim_id,
-    c.embedding <=> $1::vector AS similarity_score
+    (c.embedding <=> $1::vector)::float8 AS similarity_score
 FROM comments c
 JOIN items i ON c.item_id = i.id
 WHERE c.embedding IS NOT NULL
+  AND c.deleted_at IS NULL
+  AND ($3::BOOLEAN OR EXISTS (
+    SELECT 1 FROM unnest($4::TEXT[]) AS s(scope)
+    WHERE i.scope = s.scope OR starts_with(i.scope, s.scope || '/')
+  ))
 ORDER BY similarity_score ASC
 LIMIT $2
 `
@@ -407,18 +553,25 @@ LIMIT $2
 type SearchCommentsParams struct {
 	Embedding pgvector.Vector `json:"embedding"`
 	Limit     int32           `json:"limit"`
+	ViewAll   bool            `json:"view_all"`
+	Scopes    []string        `json:"scopes"`
 }
 
 type SearchCommentsRow struct {
 	Source          string      `json:"source"`
 	Text            string      `json:"text"`
 	ClaimID         pgtype.Text `json:"claim_id"`
-	SimilarityScore interface{} `json:"similarity_score"`
+	SimilarityScore float64     `json:"similarity_score"`
 }
 
-// Searches comments semantically.
+// Searches semantically the comments on claims within the caller's scopes.
 func (q *Queries) SearchComments(ctx context.Context, arg SearchCommentsParams) ([]SearchCommentsRow, error) {
-	rows, err := q.db.Query(ctx, searchComments, arg.Embedding, arg.Limit)
+	rows, err := q.db.Query(ctx, searchComments,
+		arg.Embedding,
+		arg.Limit,
+		arg.ViewAll,
+		arg.Scopes,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -442,19 +595,90 @@ func (q *Queries) SearchComments(ctx context.Context, arg SearchCommentsParams)
 	return items, nil
 }
 
+const searchCommentsKeyword = `-- name: SearchCommentsKeyword :many
+SELECT
+    c.id AS comment_id,
+    'Comment' AS source,
+    c.comment::TEXT AS text,
+    i.business_key AS claim_id,
+    ts_rank(to_tsvector('english', c.comment), websearch_to_tsquery('english', $1::TEXT)) AS rank
+FROM comments c
+JOIN items i ON c.item_id = i.id
+WHERE c.deleted_at IS NULL
+  AND (
+    to_tsvector('english', c.comment) @@ websearch_to_tsquery('english', $1::TEXT)
+    OR c.comment ILIKE '%' || replace(replace(replace($1::TEXT, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'
+  )
+  AND ($3::BOOLEAN OR EXISTS (
+    SELECT 1 FROM unnest($4::TEXT[]) AS s(scope)
+    WHERE i.scope = s.scope OR starts_with(i.scope, s.scope || '/')
+  ))
+ORDER BY rank DESC, c.created_at DESC
+LIMIT $2
+`
+
+type SearchCommentsKeywordParams struct {
+	SearchQuery string   `json:"search_query"`
+	Limit       int32    `json:"limit"`
+	ViewAll     bool     `json:"view_all"`
+	Scopes      []string `json:"scopes"`
+}
+
+type SearchCommentsKeywordRow struct {
+	CommentID int64       `json:"comment_id"`
+	Source    string      `json:"source"`
+	Text      string      `json:"text"`
+	ClaimID   pgtype.Text `json:"claim_id"`
+	Rank      float32     `json:"rank"`
+}
+
+// Searches by keyword the comments on claims within the caller's scopes, matching full-text terms
+// or the exact substring. LIKE wildcards in the query match literally.
+func (q *Queries) SearchCommentsKeyword(ctx context.Context, arg SearchCommentsKeywordParams) ([]SearchCommentsKeywordRow, error) {
+	rows, err := q.db.Query(ctx, searchCommentsKeyword,
+		arg.SearchQuery,
+		arg.Limit,
+		arg.ViewAll,
+		arg.Scopes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCommentsKeywordRow
+	for rows.Next() {
+		var i SearchCommentsKeywordRow
+		if err := rows.Scan(
+			&i.CommentID,
+			&i.Source,
+			&i.Text,
+			&i.ClaimID,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchKnowledgeChunks = `-- name: SearchKnowledgeChunks :many
 SELECT
     (
     COALESCE(custom_properties->>'metadata.section', 'General Information') ||
     ' from ' ||
     COALESCE(custom_properties->>'metadata.document_name', 'Unknown Document')
-    ) AS source,
-    COALESCE((custom_properties->>'chunk_text')::TEXT, '') AS text,
-    embedding <=> $1::vector AS similarity_score,
-    custom_properties->>'metadata.source_custom_properties' AS structured_metadata
+    )::TEXT AS source,
+    COALESCE((custom_properties->>'chunk_text')::TEXT, '')::TEXT AS text,
+    (embedding <=> $1::vector)::float8 AS similarity_score,
+    (custom_properties->>'metadata.source_custom_properties')::TEXT AS structured_metadata,
+    (custom_properties->'chunk_metadata')::jsonb AS chunk_metadata
 FROM items
 WHERE
-    item_type = 'KNOWLEDGE_CHUNK' AND embedding IS NOT NULL
+    item_type = 'KNOWLEDGE_CHUNK' AND status = 'active' AND embedding IS NOT NULL
 ORDER BY similarity_score ASC
 LIMIT $2
 `
@@ -465,13 +689,14 @@ type SearchKnowledgeChunksParams struct {
 }
 
 type SearchKnowledgeChunksRow struct {
-	Source             interface{} `json:"source"`
-	Text               interface{} `json:"text"`
-	SimilarityScore    interface{} `json:"similarity_score"`
-	StructuredMetadata interface{} `json:"structured_metadata"`
+	Source             string      `json:"source"`
+	Text               string      `json:"text"`
+	SimilarityScore    float64     `json:"similarity_score"`
+	StructuredMetadata pgtype.Text `json:"structured_metadata"`
+	ChunkMetadata      []byte      `json:"chunk_metadata"`
 }
 
-// Searches semantically the knowledge base
+// Searches semantically the active knowledge base, leaving out chunks archived by a re-ingest
 func (q *Queries) SearchKnowledgeChunks(ctx context.Context, arg SearchKnowledgeChunksParams) ([]SearchKnowledgeChunksRow, error) {
 	rows, err := q.db.Query(ctx, searchKnowledgeChunks, arg.Embedding, arg.Limit)
 	if err != nil {
@@ -486,6 +711,55 @@ func (q *Queries) SearchKnowledgeChunks(ctx context.Context, arg SearchKnowledge
 			&i.Text,
 			&i.SimilarityScore,
 			&i.StructuredMetadata,
+			&i.ChunkMetadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const summarizePolicyholderSegments = `-- name: SummarizePolicyholderSegments :many
+SELECT
+    p.state,
+    p.customer_level,
+    COUNT(DISTINCT p.policyholder_id) AS policyholder_count,
+    COUNT(c.id) AS claim_count,
+    COALESCE(SUM(c.claim_amount), 0)::decimal AS total_claim_exposure
+FROM vw_policyholders p
+LEFT JOIN vw_insurance_claims c ON c.policyholder_id = p.policyholder_id
+GROUP BY p.state, p.customer_level
+ORDER BY p.state, p.customer_level
+`
+
+type SummarizePolicyholderSegmentsRow struct {
+	State              pgtype.Text    `json:"state"`
+	CustomerLevel      string         `json:"customer_level"`
+	PolicyholderCount  int64          `json:"policyholder_count"`
+	ClaimCount         int64          `json:"claim_count"`
+	TotalClaimExposure pgtype.Numeric `json:"total_claim_exposure"`
+}
+
+// Counts policyholders and sums their claim exposure per state and customer level.
+func (q *Queries) SummarizePolicyholderSegments(ctx context.Context) ([]SummarizePolicyholderSegmentsRow, error) {
+	rows, err := q.db.Query(ctx, summarizePolicyholderSegments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SummarizePolicyholderSegmentsRow
+	for rows.Next() {
+		var i SummarizePolicyholderSegmentsRow
+		if err := rows.Scan(
+			&i.State,
+			&i.CustomerLevel,
+			&i.PolicyholderCount,
+			&i.ClaimCount,
+			&i.TotalClaimExposure,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,76 @@
+package insurance
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchCommentsKeyword checks that LIKE wildcards in a keyword search match literally and that
+// only comments on claims within the given scopes are found. It needs a Postgres with the platform
+// migrations applied and is skipped unless TEST_DATABASE_URL points at it.
+func TestSearchCommentsKeyword(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	q := New(tx)
+
+	run := uuid.NewString()
+	region, otherRegion := "search-test-"+run, "other-"+run
+	var userID int64
+	require.NoError(t, tx.QueryRow(ctx, `INSERT INTO users (auth_provider_subject, email) VALUES ($1, $1 || '@example.com') RETURNING id`, run).Scan(&userID))
+	for _, comment := range []struct{ scope, key, text string }{
+		{region, "percent", "Applied the 50% deductible"},
+		{region, "digits", "Applied the 500 deductible"},
+		{region, "underscore", "Escalated under ticket_42"},
+		{region + "/BR01", "letter", "Escalated under ticketX42"},
+		{otherRegion, "other", "Applied the 50% deductible"},
+	} {
+		var itemID int64
+		require.NoError(t, tx.QueryRow(ctx, `INSERT INTO items (item_type, scope, business_key, status, custom_properties) VALUES ('INSURANCE_CLAIM', $1, $2, 'active', '{}') RETURNING id`,
+			comment.scope, comment.key+"-"+run).Scan(&itemID))
+		_, err := tx.Exec(ctx, `INSERT INTO comments (item_id, comment, user_id) VALUES ($1, $2, $3)`, itemID, comment.text, userID)
+		require.NoError(t, err)
+	}
+
+	search := func(t *testing.T, query string, scopes ...string) []string {
+		t.Helper()
+		rows, err := q.SearchCommentsKeyword(ctx, SearchCommentsKeywordParams{
+			SearchQuery: query,
+			Limit:       10,
+			Scopes:      scopes,
+		})
+		require.NoError(t, err)
+		var texts []string
+		for _, row := range rows {
+			texts = append(texts, row.Text)
+		}
+		return texts
+	}
+
+	t.Run("Matches LIKE wildcards literally", func(t *testing.T) {
+		assert.Equal(t, []string{"Applied the 50% deductible"}, search(t, "50%", region))
+		assert.Equal(t, []string{"Escalated under ticket_42"}, search(t, "ticket_42", region))
+	})
+
+	t.Run("Only finds comments on claims within the scopes", func(t *testing.T) {
+		assert.Equal(t, []string{"Escalated under ticketX42"}, search(t, "ticketX42", region), "a scope covers the scopes nested under it")
+		assert.Empty(t, search(t, "ticketX42", otherRegion))
+		assert.Empty(t, search(t, "deductible"), "no scopes find nothing")
+	})
+}
@@ -9,22 +9,33 @@ import (
 )
 
 type Querier interface {
+	// Counts the claims ListClaimsWithVector pages through.
+	CountClaimsWithVector(ctx context.Context, arg CountClaimsWithVectorParams) (int64, error)
+	// Counts the claims ListClaimsWithoutVector pages through.
+	CountClaimsWithoutVector(ctx context.Context, arg CountClaimsWithoutVectorParams) (int64, error)
+	// Counts the policyholders ListPolicyholders pages through.
+	CountPolicyholders(ctx context.Context, arg CountPolicyholdersParams) (int64, error)
 	// Fetches a single claim joined with its correspondng policyholder data
 	GetClaimDetails(ctx context.Context, id int64) (GetClaimDetailsRow, error)
 	// Fetches the business status change history for a specific claim item
 	GetClaimStatusHistory(ctx context.Context, itemID int64) ([]GetClaimStatusHistoryRow, error)
 	// Fetches the header chunk's source_custom_properties for a given document ID.
-	GetDocumentHeader(ctx context.Context, documentID string) (interface{}, error)
-	// Fetches and sorts claims by semantic similarity.
+	GetDocumentHeader(ctx context.Context, documentID string) ([]byte, error)
+	// Fetches claims by semantic similarity, sorted by sort_by when given and by similarity otherwise.
 	ListClaimsWithVector(ctx context.Context, arg ListClaimsWithVectorParams) ([]ListClaimsWithVectorRow, error)
 	// Fetches a paginated and filtered list of insurance claims without vector search.
 	ListClaimsWithoutVector(ctx context.Context, arg ListClaimsWithoutVectorParams) ([]ListClaimsWithoutVectorRow, error)
 	// Fetches a paginated and filtered list of policyholders.
 	ListPolicyholders(ctx context.Context, arg ListPolicyholdersParams) ([]VwPolicyholder, error)
-	// Searches comments semantically.
+	// Searches semantically the comments on claims within the caller's scopes.
 	SearchComments(ctx context.Context, arg SearchCommentsParams) ([]SearchCommentsRow, error)
-	// Searches semantically the knowledge base
+	// Searches by keyword the comments on claims within the caller's scopes, matching full-text terms
+	// or the exact substring. LIKE wildcards in the query match literally.
+	SearchCommentsKeyword(ctx context.Context, arg SearchCommentsKeywordParams) ([]SearchCommentsKeywordRow, error)
+	// Searches semantically the active knowledge base, leaving out chunks archived by a re-ingest
 	SearchKnowledgeChunks(ctx context.Context, arg SearchKnowledgeChunksParams) ([]SearchKnowledgeChunksRow, error)
+	// Counts policyholders and sums their claim exposure per state and customer level.
+	SummarizePolicyholderSegments(ctx context.Context) ([]SummarizePolicyholderSegmentsRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,117 @@
+package insurance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClaimWorkflow is the claim business status state machine. Transitions maps each status to
+// the statuses a claim may move to from it; a status with no outgoing moves maps to an empty list.
+// SLADays sets how many days a claim may stay open in a status before it breaches its SLA.
+type ClaimWorkflow struct {
+	Transitions map[string][]string `yaml:"transitions"`
+	SLADays     map[string]int      `yaml:"sla_days,omitempty"`
+}
+
+// InvalidTransitionError reports a status change the workflow does not allow.
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("claim status cannot change from %q to %q", e.From, e.To)
+}
+
+// LoadClaimWorkflow reads and validates the claim workflow YAML at path.
+func LoadClaimWorkflow(path string) (*ClaimWorkflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claim workflow %s: %w", path, err)
+	}
+	var workflow ClaimWorkflow
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse claim workflow %s: %w", path, err)
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed for %s: %w", path, err)
+	}
+	return &workflow, nil
+}
+
+// Validate checks that the workflow declares at least one status and that every transition
+// target is itself a declared status.
+func (w *ClaimWorkflow) Validate() error {
+	if len(w.Transitions) == 0 {
+		return fmt.Errorf("FATAL: claim workflow must declare at least one status under 'transitions'")
+	}
+	for from, targets := range w.Transitions {
+		for _, to := range targets {
+			if _, ok := w.Transitions[to]; !ok {
+				return fmt.Errorf("FATAL: transition from '%s' targets undeclared status '%s'", from, to)
+			}
+		}
+	}
+	for status, days := range w.SLADays {
+		if _, ok := w.Transitions[status]; !ok {
+			return fmt.Errorf("FATAL: sla_days references undeclared status '%s'", status)
+		}
+		if days <= 0 {
+			return fmt.Errorf("FATAL: sla_days for status '%s' must be positive, got %d", status, days)
+		}
+	}
+	return nil
+}
+
+// IsKnownStatus reports whether status is declared in the workflow.
+func (w *ClaimWorkflow) IsKnownStatus(status string) bool {
+	_, ok := w.Transitions[status]
+	return ok
+}
+
+// Statuses returns the declared statuses in sorted order.
+func (w *ClaimWorkflow) Statuses() []string {
+	statuses := make([]string, 0, len(w.Transitions))
+	for status := range w.Transitions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// CheckTransition returns an *InvalidTransitionError unless a claim may move from one status to
+// the other. Keeping the same status is always allowed, and so is any move out of an empty status,
+// since claims loaded before the workflow existed may not carry one.
+func (w *ClaimWorkflow) CheckTransition(from, to string) error {
+	if !w.IsKnownStatus(to) {
+		return &InvalidTransitionError{From: from, To: to}
+	}
+	if from == "" || from == to {
+		return nil
+	}
+	for _, allowed := range w.Transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &InvalidTransitionError{From: from, To: to}
+}
+
+// SLABreached reports whether a claim open for ageDays has outlived the SLA of its status.
+func (w *ClaimWorkflow) SLABreached(status string, ageDays int32) bool {
+	days, ok := w.SLADays[status]
+	return ok && int(ageDays) > days
+}
+
+// SLADaysJSON encodes the SLA thresholds as a JSON object for the claim list queries, which
+// compare each claim's age against it in SQL.
+func (w *ClaimWorkflow) SLADaysJSON() ([]byte, error) {
+	if w.SLADays == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(w.SLADays)
+}
@@ -0,0 +1,63 @@
+package insurance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimWorkflow(t *testing.T) {
+	workflow := &ClaimWorkflow{Transitions: map[string][]string{
+		"Submitted":    {"Under Review"},
+		"Under Review": {"Approved", "Denied"},
+		"Approved":     {},
+		"Denied":       {},
+	}}
+	require.NoError(t, workflow.Validate())
+
+	t.Run("allows declared transitions", func(t *testing.T) {
+		assert.NoError(t, workflow.CheckTransition("Submitted", "Under Review"))
+		assert.NoError(t, workflow.CheckTransition("Under Review", "Denied"))
+	})
+
+	t.Run("allows keeping the same status and leaving an empty one", func(t *testing.T) {
+		assert.NoError(t, workflow.CheckTransition("Approved", "Approved"))
+		assert.NoError(t, workflow.CheckTransition("", "Under Review"))
+	})
+
+	t.Run("rejects undeclared transitions", func(t *testing.T) {
+		err := workflow.CheckTransition("Denied", "Submitted")
+		var transitionErr *InvalidTransitionError
+		require.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, "Denied", transitionErr.From)
+		assert.Equal(t, "Submitted", transitionErr.To)
+	})
+
+	t.Run("rejects unknown target status", func(t *testing.T) {
+		assert.Error(t, workflow.CheckTransition("", "Closed"))
+	})
+
+	t.Run("flags claims older than their status SLA", func(t *testing.T) {
+		workflow.SLADays = map[string]int{"Under Review": 30}
+		assert.True(t, workflow.SLABreached("Under Review", 31))
+		assert.False(t, workflow.SLABreached("Under Review", 30))
+		assert.False(t, workflow.SLABreached("Approved", 400))
+	})
+
+	t.Run("encodes the SLA thresholds for the claim list queries", func(t *testing.T) {
+		encoded, err := workflow.SLADaysJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"Under Review": 30}`, string(encoded))
+
+		encoded, err = (&ClaimWorkflow{}).SLADaysJSON()
+		require.NoError(t, err)
+		assert.Equal(t, "{}", string(encoded), "no thresholds means no claim is breached")
+	})
+
+	t.Run("validate rejects undeclared targets", func(t *testing.T) {
+		bad := &ClaimWorkflow{Transitions: map[string][]string{"Submitted": {"Closed"}}}
+		assert.Error(t, bad.Validate())
+	})
+}
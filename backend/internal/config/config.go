@@ -1,9 +1,18 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
 	"github.com/joho/godotenv" // You'll need to run: go get github.com/joho/godotenv
 )
 
@@ -14,74 +23,235 @@ type Config struct {
 	IDENTITY_PROVIDER_AUDIENCE string
 	AppEnv                     string
 	GCSBucketName              string
-	SentryDSN                  string
-	AIAPIKey                   string
-	LLMURL                     string
-	EMBEDDING_SERVICE_URL      string
+	// StorageBackend is where uploads are kept: "gcs" (the default) or "local" for offline development.
+	StorageBackend string
+	// LocalStorageDir is the directory the local storage backend writes uploads to.
+	LocalStorageDir       string
+	SentryDSN             string
+	AIAPIKey              string
+	LLMURL                string
+	EMBEDDING_SERVICE_URL string
+	// NormalizeEmbeddings L2-normalizes the embedding service's vectors, for models that don't.
+	NormalizeEmbeddings bool
+	// LogLevel overrides the level derived from AppEnv when set (e.g. "debug").
+	LogLevel string
+	// AllowInsecure must be explicitly set to run with authentication disabled.
+	AllowInsecure bool
+	// UseStubLLM replaces the LLM API with canned responses for local development and tests.
+	UseStubLLM bool
+	// RequestTimeout bounds CRUD API requests; LongRequestTimeout bounds upload and RAG requests.
+	RequestTimeout     time.Duration
+	LongRequestTimeout time.Duration
+	// ConfigDir is the directory holding ingestion configs, prompt templates and other app config files.
+	ConfigDir string
+	// DefaultItemStatus is the status given to items created through the API without one.
+	DefaultItemStatus repository.ItemStatus
+	// PageSizes are the default and max page sizes of the list endpoints, from page_sizes.yaml in ConfigDir.
+	PageSizes PageSizes
+}
+
+// AuthDisabled reports whether the API runs with the development auth bypass instead of the identity provider.
+func (c *Config) AuthDisabled() bool {
+	return c.AppEnv == "development"
 }
 
 // LoadConfig reads configuration from environment variables or a .env file.
 // It is the single source of truth for application configuration.
+// Any value of the form sm://projects/<p>/secrets/<s>/versions/<v> is resolved from Secret Manager.
 func LoadConfig() (*Config, error) {
 	// Load .env file if it exists. This is great for local development.
 	// In production, these will be set directly in the environment.
 	_ = godotenv.Load()
 
-	dbURL := os.Getenv("DATABASE_URL")
+	dbURL := getEnv("DATABASE_URL")
 	if dbURL == "" {
 		return nil, fmt.Errorf("FATAL: DATABASE_URL environment variable not set")
 	}
 
-	IDENTITY_PROVIDER_DOMAIN := os.Getenv("IDENTITY_PROVIDER_DOMAIN")
+	IDENTITY_PROVIDER_DOMAIN := getEnv("IDENTITY_PROVIDER_DOMAIN")
 	if IDENTITY_PROVIDER_DOMAIN == "" {
 		return nil, fmt.Errorf("FATAL: IDENTITY_PROVIDER_DOMAIN environment variable not set")
 	}
 
-	IDENTITY_PROVIDER_AUDIENCE := os.Getenv("IDENTITY_PROVIDER_AUDIENCE")
+	IDENTITY_PROVIDER_AUDIENCE := getEnv("IDENTITY_PROVIDER_AUDIENCE")
 	if IDENTITY_PROVIDER_AUDIENCE == "" {
 		return nil, fmt.Errorf("FATAL: IDENTITY_PROVIDER_AUDIENCE environment variable not set")
 	}
 
-	gcsBucketName := os.Getenv("GCS_BUCKET_NAME")
-	if gcsBucketName == "" {
+	// STORAGE_BACKEND=local keeps uploads on disk so the upload and processing flow runs without GCS credentials.
+	storageBackend := getEnv("STORAGE_BACKEND")
+	if storageBackend == "" {
+		storageBackend = filestore.BackendGCS
+	}
+	if storageBackend != filestore.BackendGCS && storageBackend != filestore.BackendLocal {
+		return nil, fmt.Errorf("FATAL: STORAGE_BACKEND must be '%s' or '%s', got '%s'", filestore.BackendGCS, filestore.BackendLocal, storageBackend)
+	}
+	localStorageDir := getEnv("LOCAL_STORAGE_DIR")
+	if localStorageDir == "" {
+		localStorageDir = "./tmp/uploads"
+	}
+
+	gcsBucketName := getEnv("GCS_BUCKET_NAME")
+	if gcsBucketName == "" && storageBackend == filestore.BackendGCS {
 		return nil, fmt.Errorf("FATAL: GCS_BUCKET_NAME environment variable not set")
 	}
 
-	sentryDSN := os.Getenv("SENTRY_DSN")
+	sentryDSN := getEnv("SENTRY_DSN")
 	if sentryDSN == "" {
 		return nil, fmt.Errorf("FATAL: SENTRY_DSN environment variable not set")
 	}
 
-	AIKey := os.Getenv("AI_API_KEY")
-	if AIKey == "" {
-		return nil, fmt.Errorf("FATAL: AI_API_KEY environment variable not set")
+	// AppEnv can have a default value
+	appEnv := getEnv("APP_ENV")
+	if appEnv == "" {
+		appEnv = "development"
 	}
 
-	LLM_URL := os.Getenv("LLM_URL")
-	if LLM_URL == "" {
-		return nil, fmt.Errorf("FATAL: LLM_URL environment variable not set")
+	AIKey := getEnv("AI_API_KEY")
+	LLM_URL := getEnv("LLM_URL")
+
+	// The stub LLM serves canned planner/synthesizer responses so the RAG pipeline can run offline.
+	// It is on when LLM_STUB=true, and by default in development when no AI_API_KEY is provided.
+	useStubLLM := getEnv("LLM_STUB") == "true"
+	if getEnv("LLM_STUB") == "" && appEnv == "development" && AIKey == "" {
+		useStubLLM = true
 	}
 
-	// AppEnv can have a default value
-	appEnv := os.Getenv("APP_ENV")
-	if appEnv == "" {
-		appEnv = "development"
+	if !useStubLLM {
+		if AIKey == "" {
+			return nil, fmt.Errorf("FATAL: AI_API_KEY environment variable not set")
+		}
+		if LLM_URL == "" {
+			return nil, fmt.Errorf("FATAL: LLM_URL environment variable not set")
+		}
 	}
 
-	EMBEDDING_SERVICE_URL := os.Getenv("EMBEDDING_SERVICE_URL")
+	EMBEDDING_SERVICE_URL := getEnv("EMBEDDING_SERVICE_URL")
 	if EMBEDDING_SERVICE_URL == "" {
 		return nil, fmt.Errorf("FATAL: EMBEDDING_SERVICE_URL environment variable not set")
 	}
 
-	return &Config{
+	// LogLevel is optional; when empty the level is derived from APP_ENV.
+	logLevel := getEnv("LOG_LEVEL")
+	if logLevel != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(logLevel)); err != nil {
+			return nil, fmt.Errorf("FATAL: LOG_LEVEL '%s' is not a valid log level", logLevel)
+		}
+	}
+
+	requestTimeout, err := getEnvSeconds("REQUEST_TIMEOUT_SECONDS", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	longRequestTimeout, err := getEnvSeconds("LONG_REQUEST_TIMEOUT_SECONDS", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	// CONFIG_DIR defaults to the repository layout, so the server works from the repo root and in the container.
+	configDir := getEnv("CONFIG_DIR")
+	if configDir == "" {
+		configDir = "./backend/configs"
+	}
+	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("FATAL: CONFIG_DIR '%s' is not a readable directory", configDir)
+	}
+
+	// DEFAULT_ITEM_STATUS defaults to active, so items created without a status show up in listings.
+	defaultItemStatus := repository.ItemStatusActive
+	if raw := getEnv("DEFAULT_ITEM_STATUS"); raw != "" {
+		defaultItemStatus, err = repository.ParseItemStatus(raw)
+		if err != nil {
+			return nil, fmt.Errorf("FATAL: DEFAULT_ITEM_STATUS: %w", err)
+		}
+	}
+
+	pageSizes, err := LoadPageSizes(filepath.Join(configDir, "page_sizes.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("FATAL: %w", err)
+	}
+
+	cfg := &Config{
 		DatabaseURL:                dbURL,
 		IDENTITY_PROVIDER_DOMAIN:   IDENTITY_PROVIDER_DOMAIN,
 		IDENTITY_PROVIDER_AUDIENCE: IDENTITY_PROVIDER_AUDIENCE,
 		AppEnv:                     appEnv,
 		GCSBucketName:              gcsBucketName,
+		StorageBackend:             storageBackend,
+		LocalStorageDir:            localStorageDir,
 		SentryDSN:                  sentryDSN,
 		AIAPIKey:                   AIKey,
 		LLMURL:                     LLM_URL,
 		EMBEDDING_SERVICE_URL:      EMBEDDING_SERVICE_URL,
-	}, nil
+		NormalizeEmbeddings:        getEnv("EMBEDDING_NORMALIZE") == "true",
+		LogLevel:                   logLevel,
+		AllowInsecure:              getEnv("CHIMERA_ALLOW_INSECURE") == "true",
+		UseStubLLM:                 useStubLLM,
+		RequestTimeout:             requestTimeout,
+		LongRequestTimeout:         longRequestTimeout,
+		ConfigDir:                  configDir,
+		DefaultItemStatus:          defaultItemStatus,
+		PageSizes:                  pageSizes,
+	}
+
+	// APP_ENV defaults to "development", which disables authentication. Refuse to start
+	// in that mode unless the operator has explicitly opted in, so a production deploy
+	// with APP_ENV unset can never run wide open by accident.
+	if cfg.AuthDisabled() && !cfg.AllowInsecure {
+		return nil, fmt.Errorf("FATAL: APP_ENV '%s' disables authentication; set CHIMERA_ALLOW_INSECURE=true to run without auth", cfg.AppEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := resolveSecrets(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	// URLs are validated after secret resolution so they may also be stored as secrets.
+	if !cfg.UseStubLLM {
+		if err := validateServiceURL("LLM_URL", cfg.LLMURL); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateServiceURL("EMBEDDING_SERVICE_URL", cfg.EMBEDDING_SERVICE_URL); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// getEnv reads an environment variable and trims surrounding whitespace,
+// which frequently sneaks into values copied into .env files or secrets.
+func getEnv(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// getEnvSeconds reads a positive whole number of seconds, returning def when the variable is unset.
+func getEnvSeconds(key string, def time.Duration) (time.Duration, error) {
+	raw := getEnv(key)
+	if raw == "" {
+		return def, nil
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("FATAL: %s '%s' must be a positive number of seconds", key, raw)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// validateServiceURL ensures a service URL is absolute so misconfiguration
+// fails at startup rather than on the first outbound request.
+func validateServiceURL(name, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("FATAL: %s '%s' is not a valid URL: %w", name, rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("FATAL: %s '%s' must use an http or https scheme", name, rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("FATAL: %s '%s' is missing a host", name, rawURL)
+	}
+	return nil
 }
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resources with a configurable page size, as keyed in page_sizes.yaml.
+const (
+	PageSizeItems         = "items"
+	PageSizeClaims        = "claims"
+	PageSizePolicyholders = "policyholders"
+	PageSizeComments      = "comments"
+	PageSizeCommentSearch = "comment_search"
+	PageSizeIngestionJobs = "ingestion_jobs"
+	PageSizePreview       = "preview"
+)
+
+// PageSize is how many rows a list endpoint returns when the request sets no limit, and the most
+// it returns whatever the request asks for.
+type PageSize struct {
+	Default int `yaml:"default"`
+	Max     int `yaml:"max"`
+}
+
+// Limit returns the page size for a request's raw limit parameter: Default when it is missing or
+// not a positive number, and at most Max.
+func (s PageSize) Limit(raw string) int {
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return s.Default
+	}
+	return min(limit, s.Max)
+}
+
+// PageSizes holds the page size of each resource.
+type PageSizes map[string]PageSize
+
+// DefaultPageSizes returns the page sizes used for resources page_sizes.yaml doesn't set.
+func DefaultPageSizes() PageSizes {
+	return PageSizes{
+		PageSizeItems:         {Default: 50, Max: 200},
+		PageSizeClaims:        {Default: 50, Max: 200},
+		PageSizePolicyholders: {Default: 50, Max: 200},
+		PageSizeComments:      {Default: 50, Max: 200},
+		PageSizeCommentSearch: {Default: 50, Max: 200},
+		PageSizeIngestionJobs: {Default: 20, Max: 100},
+		PageSizePreview:       {Default: 10, Max: 100},
+	}
+}
+
+// For returns the page size of resource, falling back to its default when p doesn't set it, so a
+// zero PageSizes behaves like DefaultPageSizes.
+func (p PageSizes) For(resource string) PageSize {
+	if size, ok := p[resource]; ok {
+		return size
+	}
+	return DefaultPageSizes()[resource]
+}
+
+// LoadPageSizes reads the page sizes YAML at path over the defaults. A missing file means every
+// resource keeps its default; a resource may set only default or max and keep the other.
+func LoadPageSizes(path string) (PageSizes, error) {
+	sizes := DefaultPageSizes()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sizes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page sizes %s: %w", path, err)
+	}
+	var overrides map[string]PageSize
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse page sizes %s: %w", path, err)
+	}
+	for _, resource := range slices.Sorted(maps.Keys(overrides)) {
+		size, known := sizes[resource]
+		if !known {
+			return nil, fmt.Errorf("invalid page sizes %s: unknown resource '%s', expected one of %v", path, resource, slices.Sorted(maps.Keys(sizes)))
+		}
+		override := overrides[resource]
+		if override.Default < 0 || override.Max < 0 {
+			return nil, fmt.Errorf("invalid page sizes %s: '%s' must not be negative", path, resource)
+		}
+		if override.Default > 0 {
+			size.Default = override.Default
+		}
+		if override.Max > 0 {
+			size.Max = override.Max
+		}
+		if size.Default > size.Max {
+			return nil, fmt.Errorf("invalid page sizes %s: default %d for '%s' exceeds its max %d", path, size.Default, resource, size.Max)
+		}
+		sizes[resource] = size
+	}
+	return sizes, nil
+}
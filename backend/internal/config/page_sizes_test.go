@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageSizeLimit(t *testing.T) {
+	size := PageSize{Default: 20, Max: 100}
+	assert.Equal(t, 20, size.Limit(""))
+	assert.Equal(t, 20, size.Limit("lots"))
+	assert.Equal(t, 20, size.Limit("0"))
+	assert.Equal(t, 20, size.Limit("-5"))
+	assert.Equal(t, 35, size.Limit("35"))
+	assert.Equal(t, 100, size.Limit("5000"))
+
+	assert.Equal(t, PageSize{Default: 50, Max: 200}, PageSizes(nil).For(PageSizeClaims), "a zero PageSizes uses the defaults")
+}
+
+func TestLoadPageSizes(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "page_sizes.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("Uses the defaults without a file", func(t *testing.T) {
+		sizes, err := LoadPageSizes(filepath.Join(t.TempDir(), "page_sizes.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, DefaultPageSizes(), sizes)
+	})
+
+	t.Run("Overrides only what the file sets", func(t *testing.T) {
+		sizes, err := LoadPageSizes(write(t, "claims:\n  default: 25\ningestion_jobs:\n  max: 500\n"))
+		require.NoError(t, err)
+		assert.Equal(t, PageSize{Default: 25, Max: 200}, sizes.For(PageSizeClaims))
+		assert.Equal(t, PageSize{Default: 20, Max: 500}, sizes.For(PageSizeIngestionJobs))
+		assert.Equal(t, PageSize{Default: 50, Max: 200}, sizes.For(PageSizeItems))
+	})
+
+	t.Run("Rejects invalid sizes", func(t *testing.T) {
+		_, err := LoadPageSizes(write(t, "claim:\n  default: 25\n"))
+		assert.ErrorContains(t, err, "unknown resource 'claim'")
+		_, err = LoadPageSizes(write(t, "preview:\n  default: 500\n"))
+		assert.ErrorContains(t, err, "default 500 for 'preview' exceeds its max 100")
+		_, err = LoadPageSizes(write(t, "items:\n  max: -1\n"))
+		assert.ErrorContains(t, err, "'items' must not be negative")
+	})
+
+	t.Run("Accepts the shipped config", func(t *testing.T) {
+		sizes, err := LoadPageSizes(filepath.Join("..", "..", "configs", "page_sizes.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, DefaultPageSizes(), sizes)
+	})
+}
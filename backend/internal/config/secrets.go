@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+// secretManagerPrefix marks a config value as a reference to a Google Secret Manager
+// secret version, e.g. sm://projects/my-project/secrets/ai-api-key/versions/latest
+const secretManagerPrefix = "sm://"
+
+// isSecretReference reports whether a config value should be resolved from Secret Manager.
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretManagerPrefix)
+}
+
+// resolveSecrets replaces every Secret Manager reference in the config with the secret's payload.
+// Values that are not references are left untouched, and no client is created unless at least
+// one reference is present, so local development never needs GCP credentials.
+func resolveSecrets(ctx context.Context, cfg *Config) error {
+	fields := map[string]*string{
+		"DATABASE_URL":               &cfg.DatabaseURL,
+		"IDENTITY_PROVIDER_DOMAIN":   &cfg.IDENTITY_PROVIDER_DOMAIN,
+		"IDENTITY_PROVIDER_AUDIENCE": &cfg.IDENTITY_PROVIDER_AUDIENCE,
+		"GCS_BUCKET_NAME":            &cfg.GCSBucketName,
+		"SENTRY_DSN":                 &cfg.SentryDSN,
+		"AI_API_KEY":                 &cfg.AIAPIKey,
+		"LLM_URL":                    &cfg.LLMURL,
+		"EMBEDDING_SERVICE_URL":      &cfg.EMBEDDING_SERVICE_URL,
+	}
+
+	var client *secretmanager.Client
+	for envVar, field := range fields {
+		if !isSecretReference(*field) {
+			continue
+		}
+		if client == nil {
+			var err error
+			client, err = secretmanager.NewClient(ctx)
+			if err != nil {
+				return fmt.Errorf("FATAL: failed to create Secret Manager client: %w", err)
+			}
+			defer client.Close()
+		}
+
+		name := strings.TrimPrefix(*field, secretManagerPrefix)
+		resp, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+		if err != nil {
+			return fmt.Errorf("FATAL: failed to resolve %s from Secret Manager: %w", envVar, err)
+		}
+		*field = strings.TrimSpace(string(resp.GetPayload().GetData()))
+	}
+	return nil
+}
@@ -51,6 +51,6 @@ func (c *Client) Close() {
 }
 
 // Ping verifies the connection to the database is still alive.
-func (c *Client) Ping() error {
-	return c.Pool.Ping(context.Background())
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Pool.Ping(ctx)
 }
@@ -0,0 +1,42 @@
+// Package filestore abstracts where uploaded files are kept. GCS is the default; a local directory
+// can stand in for it in development and tests, so uploads can be processed without credentials.
+package filestore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Backends selectable with STORAGE_BACKEND.
+const (
+	BackendGCS   = "gcs"
+	BackendLocal = "local"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ErrSigningUnsupported is returned by stores that cannot issue signed upload URLs.
+var ErrSigningUnsupported = errors.New("signed upload URLs are not supported by this storage backend")
+
+// SignedURLOptions describes a signed upload URL. Headers must be sent by the client as given.
+type SignedURLOptions struct {
+	Method      string
+	ContentType string
+	Headers     []string
+	Expires     time.Time
+}
+
+// Store reads and writes objects by key.
+type Store interface {
+	// NewWriter returns a writer for key. The object is only complete once the writer is closed.
+	NewWriter(ctx context.Context, key string) (io.WriteCloser, error)
+	// NewReader opens key for reading, returning ErrNotFound if it does not exist.
+	NewReader(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether key exists.
+	Exists(ctx context.Context, key string) (bool, error)
+	// SignedUploadURL issues a URL the client can upload key to directly.
+	SignedUploadURL(key string, opts SignedURLOptions) (string, error)
+}
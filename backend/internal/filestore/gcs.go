@@ -0,0 +1,77 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// gcsMaxAttempts bounds how many times one GCS call, or one chunk of an upload, is attempted.
+const gcsMaxAttempts = 5
+
+// gcsChunkRetryDeadline bounds how long one chunk of an upload is retried.
+const gcsChunkRetryDeadline = 2 * time.Minute
+
+// GCSStore keeps objects in a Google Cloud Storage bucket.
+type GCSStore struct {
+	client *storage.Client
+	bucket string
+}
+
+// NewGCSStore creates a store for bucket.
+func NewGCSStore(client *storage.Client, bucket string) *GCSStore {
+	return &GCSStore{client: client, bucket: bucket}
+}
+
+// object returns the handle for key with a bounded retry on transient errors. storage.ShouldRetry only
+// retries network failures, 408, 429 and 5xx responses, so a missing bucket or a permission error
+// fails on the first attempt. Writes are retried as well: every upload goes to a fresh object key, so
+// repeating one can't overwrite anything else.
+func (s *GCSStore) object(key string) *storage.ObjectHandle {
+	return s.client.Bucket(s.bucket).Object(key).Retryer(
+		storage.WithPolicy(storage.RetryAlways),
+		storage.WithMaxAttempts(gcsMaxAttempts),
+		storage.WithBackoff(gax.Backoff{Initial: 500 * time.Millisecond, Max: 10 * time.Second, Multiplier: 2}),
+		storage.WithErrorFunc(storage.ShouldRetry),
+	)
+}
+
+func (s *GCSStore) NewWriter(ctx context.Context, key string) (io.WriteCloser, error) {
+	writer := s.object(key).NewWriter(ctx)
+	writer.ChunkRetryDeadline = gcsChunkRetryDeadline
+	return writer, nil
+}
+
+func (s *GCSStore) NewReader(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := s.object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (s *GCSStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SignedUploadURL issues a V4 signed URL for key.
+func (s *GCSStore) SignedUploadURL(key string, opts SignedURLOptions) (string, error) {
+	return s.client.Bucket(s.bucket).SignedURL(key, &storage.SignedURLOptions{
+		Scheme:      storage.SigningSchemeV4,
+		Method:      opts.Method,
+		ContentType: opts.ContentType,
+		Headers:     opts.Headers,
+		Expires:     opts.Expires,
+	})
+}
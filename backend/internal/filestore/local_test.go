@@ -0,0 +1,54 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	t.Run("Round-trips an object", func(t *testing.T) {
+		w, err := store.NewWriter(ctx, "raw-reports/claims/job/file.csv")
+		require.NoError(t, err)
+		_, err = io.WriteString(w, "claim_id\nC-1\n")
+		require.NoError(t, err)
+
+		exists, err := store.Exists(ctx, "raw-reports/claims/job/file.csv")
+		require.NoError(t, err)
+		assert.False(t, exists, "object should not exist until the writer is closed")
+
+		require.NoError(t, w.Close())
+		exists, err = store.Exists(ctx, "raw-reports/claims/job/file.csv")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		r, err := store.NewReader(ctx, "raw-reports/claims/job/file.csv")
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "claim_id\nC-1\n", string(data))
+	})
+
+	t.Run("Reports missing objects", func(t *testing.T) {
+		_, err := store.NewReader(ctx, "missing.csv")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Rejects keys outside the directory", func(t *testing.T) {
+		_, err := store.NewWriter(ctx, "../escape.csv")
+		assert.Error(t, err)
+	})
+
+	t.Run("Does not sign upload URLs", func(t *testing.T) {
+		_, err := store.SignedUploadURL("file.csv", SignedURLOptions{Method: "PUT"})
+		assert.ErrorIs(t, err, ErrSigningUnsupported)
+	})
+}
@@ -0,0 +1,66 @@
+package ingestion
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PausedReportType describes a report type whose uploads are currently refused.
+type PausedReportType struct {
+	ReportType string    `json:"report_type"`
+	Reason     string    `json:"reason,omitempty"`
+	PausedBy   int64     `json:"paused_by,omitempty"`
+	PausedAt   time.Time `json:"paused_at"`
+}
+
+// PauseList tracks report types whose ingestion has been paused, e.g. during an incident with a
+// broken feed. It is held in memory, so pauses apply to this server instance and are cleared on
+// restart. It is safe for concurrent use.
+type PauseList struct {
+	mu     sync.RWMutex
+	paused map[string]PausedReportType
+}
+
+// NewPauseList creates an empty pause list.
+func NewPauseList() *PauseList {
+	return &PauseList{paused: make(map[string]PausedReportType)}
+}
+
+// Pause stops uploads for reportType. Pausing an already paused report type updates its reason.
+func (p *PauseList) Pause(reportType, reason string, pausedBy int64) PausedReportType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry := PausedReportType{ReportType: reportType, Reason: reason, PausedBy: pausedBy, PausedAt: time.Now()}
+	p.paused[reportType] = entry
+	return entry
+}
+
+// Resume allows uploads for reportType again. It reports whether the report type was paused.
+func (p *PauseList) Resume(reportType string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, found := p.paused[reportType]
+	delete(p.paused, reportType)
+	return found
+}
+
+// IsPaused returns the pause entry for reportType, if it is paused.
+func (p *PauseList) IsPaused(reportType string) (PausedReportType, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	entry, found := p.paused[reportType]
+	return entry, found
+}
+
+// List returns the paused report types sorted by name.
+func (p *PauseList) List() []PausedReportType {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	entries := make([]PausedReportType, 0, len(p.paused))
+	for _, entry := range p.paused {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ReportType < entries[j].ReportType })
+	return entries
+}
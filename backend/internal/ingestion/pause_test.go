@@ -0,0 +1,53 @@
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseList(t *testing.T) {
+	pauses := NewPauseList()
+	assert.Empty(t, pauses.List())
+
+	t.Run("Pauses a report type", func(t *testing.T) {
+		entry := pauses.Pause("CLAIMS", "feed is broken", 7)
+		assert.Equal(t, "CLAIMS", entry.ReportType)
+		assert.Equal(t, "feed is broken", entry.Reason)
+		assert.EqualValues(t, 7, entry.PausedBy)
+		assert.False(t, entry.PausedAt.IsZero())
+
+		found, paused := pauses.IsPaused("CLAIMS")
+		require.True(t, paused)
+		assert.Equal(t, entry, found)
+		_, paused = pauses.IsPaused("POLICIES")
+		assert.False(t, paused)
+	})
+
+	t.Run("Pausing again updates the reason", func(t *testing.T) {
+		pauses.Pause("CLAIMS", "still broken", 8)
+		entry, _ := pauses.IsPaused("CLAIMS")
+		assert.Equal(t, "still broken", entry.Reason)
+		assert.EqualValues(t, 8, entry.PausedBy)
+		assert.Len(t, pauses.List(), 1)
+	})
+
+	t.Run("Lists paused report types by name", func(t *testing.T) {
+		pauses.Pause("POLICIES", "", 7)
+		pauses.Pause("ADJUSTERS", "", 7)
+		var names []string
+		for _, entry := range pauses.List() {
+			names = append(names, entry.ReportType)
+		}
+		assert.Equal(t, []string{"ADJUSTERS", "CLAIMS", "POLICIES"}, names)
+	})
+
+	t.Run("Resumes a paused report type once", func(t *testing.T) {
+		assert.True(t, pauses.Resume("CLAIMS"))
+		_, paused := pauses.IsPaused("CLAIMS")
+		assert.False(t, paused)
+		assert.False(t, pauses.Resume("CLAIMS"), "the report type is no longer paused")
+		assert.Len(t, pauses.List(), 2)
+	})
+}
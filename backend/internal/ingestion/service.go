@@ -2,46 +2,77 @@ package ingestion
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"path"
+	"time"
 
-	"cloud.google.com/go/storage"
 	//	"github.com/jackc/pgx/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 
 	//	"github.com/jjckrbbt/chimera/backend/internal/logger"
 	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
 )
 
+// signedUploadExpiry is how long a signed upload URL remains valid.
+const signedUploadExpiry = 15 * time.Minute
+
+// uniqueViolation is the Postgres error code for a duplicate key.
+const uniqueViolation = "23505"
+
+// ErrUploadNotFound is returned when a direct upload is registered before the object exists in GCS.
+var ErrUploadNotFound = errors.New("uploaded object not found")
+
+// ErrUploadAlreadyRegistered is returned when a direct upload's job ID already has an ingestion job.
+var ErrUploadAlreadyRegistered = errors.New("upload already registered")
+
+// ErrSignedUploadUnsupported is returned when the storage backend cannot issue signed upload URLs.
+var ErrSignedUploadUnsupported = filestore.ErrSigningUnsupported
+
+// SignedUpload describes a signed URL that a client can use to upload a file directly to GCS.
+type SignedUpload struct {
+	JobID     uuid.UUID `json:"job_id"`
+	ObjectKey string    `json:"object_key"`
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	Headers   []string  `json:"headers,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type Service struct {
-	queries   repository.Querier
-	gcsClient *storage.Client
-	gcsBucket string
-	logger    *slog.Logger
-	cfg       *config.Config
+	queries repository.Querier
+	store   filestore.Store
+	logger  *slog.Logger
+	cfg     *config.Config
 }
 
-func NewService(queries repository.Querier, gcsClient *storage.Client, cfg *config.Config, logger *slog.Logger) (*Service, error) {
+func NewService(queries repository.Querier, store filestore.Store, cfg *config.Config, logger *slog.Logger) (*Service, error) {
 	return &Service{
-		queries:   queries,
-		gcsClient: gcsClient,
-		gcsBucket: cfg.GCSBucketName,
-		logger:    logger.With("component", "ingestion_service"),
-		cfg:       cfg,
+		queries: queries,
+		store:   store,
+		logger:  logger.With("component", "ingestion_service"),
+		cfg:     cfg,
 	}, nil
 }
 
 func (s *Service) StartJob(ctx context.Context, file io.Reader, originalFilename, itemType string, userID int64) (*repository.IngestionJob, error) {
 	jobID := uuid.New()
-	gcsObjectKey := fmt.Sprintf("raw-reports/%s/%s-/%s", itemType, jobID.String(), originalFilename)
+	gcsObjectKey := objectKey(itemType, jobID, originalFilename)
 
 	s.logger.InfoContext(ctx, "Starting ingestion job", "job_id", jobID, "item_type", itemType, "user_id", userID)
 
 	// --- Upload file to GCS ---
-	wc := s.gcsClient.Bucket(s.gcsBucket).Object(gcsObjectKey).NewWriter(ctx)
+	wc, err := s.store.NewWriter(ctx, gcsObjectKey)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to open storage writer", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to open storage writer: %w", err)
+	}
 
 	if _, err := io.Copy(wc, file); err != nil {
 		s.logger.ErrorContext(ctx, "Failed to upload file to GCS", slog.Any("error", err))
@@ -54,7 +85,84 @@ func (s *Service) StartJob(ctx context.Context, file io.Reader, originalFilename
 	}
 	s.logger.InfoContext(ctx, "File successfully uploaded to GCS", "job_id", jobID, "gcs_object_key", gcsObjectKey)
 
-	// --- Create ingestion job record ---
+	return s.createJobRecord(ctx, jobID, gcsObjectKey, originalFilename, itemType, userID)
+}
+
+// CreateSignedUpload issues a V4 signed URL so the client can upload a file straight to GCS.
+// When resumable is true, the URL starts a resumable upload session (POST with x-goog-resumable:start)
+// instead of accepting a single PUT. The returned JobID must be passed to RegisterUpload once the upload completes.
+func (s *Service) CreateSignedUpload(ctx context.Context, originalFilename, itemType, contentType string, resumable bool) (*SignedUpload, error) {
+	jobID := uuid.New()
+	gcsObjectKey := objectKey(itemType, jobID, originalFilename)
+	expiresAt := time.Now().Add(signedUploadExpiry)
+
+	opts := filestore.SignedURLOptions{
+		Method:      "PUT",
+		ContentType: contentType,
+		Expires:     expiresAt,
+	}
+	if resumable {
+		opts.Method = "POST"
+		opts.Headers = []string{"x-goog-resumable:start"}
+	}
+
+	url, err := s.store.SignedUploadURL(gcsObjectKey, opts)
+	if errors.Is(err, filestore.ErrSigningUnsupported) {
+		return nil, ErrSignedUploadUnsupported
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to sign upload URL", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to sign upload URL: %w", err)
+	}
+	s.logger.InfoContext(ctx, "Issued signed upload URL", "job_id", jobID, "gcs_object_key", gcsObjectKey, "resumable", resumable)
+
+	return &SignedUpload{
+		JobID:     jobID,
+		ObjectKey: gcsObjectKey,
+		URL:       url,
+		Method:    opts.Method,
+		Headers:   opts.Headers,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// RegisterUpload creates the ingestion job record for a file that was uploaded directly to GCS
+// via a signed URL. The object key is derived from the job ID and filename, never taken from the client.
+func (s *Service) RegisterUpload(ctx context.Context, jobID uuid.UUID, originalFilename, itemType string, userID int64) (*repository.IngestionJob, error) {
+	gcsObjectKey := objectKey(itemType, jobID, originalFilename)
+
+	exists, err := s.store.Exists(ctx, gcsObjectKey)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to stat uploaded object", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to stat uploaded object: %w", err)
+	}
+	if !exists {
+		return nil, ErrUploadNotFound
+	}
+
+	return s.createJobRecord(ctx, jobID, gcsObjectKey, originalFilename, itemType, userID)
+}
+
+// ReadUploadHead returns up to n leading bytes of a file uploaded via a signed URL, so its format
+// can be checked before it is registered. It returns ErrUploadNotFound if the upload is missing.
+func (s *Service) ReadUploadHead(ctx context.Context, jobID uuid.UUID, originalFilename, itemType string, n int) ([]byte, error) {
+	r, err := s.store.NewReader(ctx, objectKey(itemType, jobID, originalFilename))
+	if errors.Is(err, filestore.ErrNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded object: %w", err)
+	}
+	defer r.Close()
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read uploaded object: %w", err)
+	}
+	return head[:read], nil
+}
+
+func (s *Service) createJobRecord(ctx context.Context, jobID uuid.UUID, gcsObjectKey, originalFilename, itemType string, userID int64) (*repository.IngestionJob, error) {
 	params := repository.CreateIngestionJobParams{
 		ID:            pgtype.UUID{Bytes: jobID, Valid: true},
 		SourceType:    "FILE_UPLOAD",
@@ -65,6 +173,10 @@ func (s *Service) StartJob(ctx context.Context, file io.Reader, originalFilename
 		SourceUri:     pgtype.Text{String: gcsObjectKey, Valid: true},
 	}
 	createdJob, err := s.queries.CreateIngestionJob(ctx, params)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, ErrUploadAlreadyRegistered
+	}
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Failed to create ingestion job record", slog.Any("error", err))
 		return nil, fmt.Errorf("failed to create ingestion job record: %w", err)
@@ -74,6 +186,35 @@ func (s *Service) StartJob(ctx context.Context, file io.Reader, originalFilename
 	return &createdJob, nil
 }
 
+// JobSummary is an ingestion job together with the rows it sent to triage.
+type JobSummary struct {
+	Job        repository.IngestionJob     `json:"job"`
+	TriageRows []repository.IngestionError `json:"triage_rows"`
+}
+
+// GetJobSummary fetches a job and its open triage rows, so an uploader can see the outcome
+// without polling the triage endpoints.
+func (s *Service) GetJobSummary(ctx context.Context, jobID uuid.UUID) (*JobSummary, error) {
+	pgJobID := pgtype.UUID{Bytes: jobID, Valid: true}
+	job, err := s.queries.GetIngestionJob(ctx, pgJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingestion job: %w", err)
+	}
+	triageRows, err := s.queries.GetIngestionErrorsByJobID(ctx, pgJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingestion errors: %w", err)
+	}
+	if triageRows == nil {
+		triageRows = []repository.IngestionError{}
+	}
+	return &JobSummary{Job: job, TriageRows: triageRows}, nil
+}
+
+// objectKey builds the GCS object key for a raw report upload.
+func objectKey(itemType string, jobID uuid.UUID, originalFilename string) string {
+	return fmt.Sprintf("raw-reports/%s/%s-/%s", itemType, jobID.String(), path.Base(originalFilename))
+}
+
 // UpdateJobStatus updates the status of an ingestion job
 func (s *Service) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, errorDetails string, rowsUpserted int64, rowsTriaged int64) error {
 	params := repository.UpdateIngestionJobStatusParams{
@@ -0,0 +1,75 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryQuerier returns a fixed job and triage rows; other Querier methods are not expected to
+// be called.
+type summaryQuerier struct {
+	repository.Querier
+	job        repository.IngestionJob
+	jobErr     error
+	triageRows []repository.IngestionError
+	triageErr  error
+}
+
+func (q *summaryQuerier) GetIngestionJob(ctx context.Context, id pgtype.UUID) (repository.IngestionJob, error) {
+	return q.job, q.jobErr
+}
+
+func (q *summaryQuerier) GetIngestionErrorsByJobID(ctx context.Context, jobID pgtype.UUID) ([]repository.IngestionError, error) {
+	return q.triageRows, q.triageErr
+}
+
+func TestGetJobSummary(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	jobID := uuid.New()
+	job := repository.IngestionJob{ID: pgtype.UUID{Bytes: jobID, Valid: true}, Status: "COMPLETE_WITH_ISSUES"}
+	summary := func(t *testing.T, queries *summaryQuerier) (*JobSummary, error) {
+		store, err := filestore.NewLocalStore(t.TempDir())
+		require.NoError(t, err)
+		s, err := NewService(queries, store, &config.Config{StorageBackend: filestore.BackendLocal}, logger)
+		require.NoError(t, err)
+		return s.GetJobSummary(context.Background(), jobID)
+	}
+
+	t.Run("Returns the job and its triage rows", func(t *testing.T) {
+		triageRows := []repository.IngestionError{{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, ReasonForFailure: "amount is required"}}
+		got, err := summary(t, &summaryQuerier{job: job, triageRows: triageRows})
+		require.NoError(t, err)
+		assert.Equal(t, job, got.Job)
+		assert.Equal(t, triageRows, got.TriageRows)
+	})
+
+	t.Run("Returns an empty list when nothing was triaged", func(t *testing.T) {
+		got, err := summary(t, &summaryQuerier{job: job})
+		require.NoError(t, err)
+		assert.NotNil(t, got.TriageRows, "the rows are listed as [] rather than null")
+		assert.Empty(t, got.TriageRows)
+	})
+
+	t.Run("Fails when the job can't be loaded", func(t *testing.T) {
+		_, err := summary(t, &summaryQuerier{jobErr: pgx.ErrNoRows})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Fails when the triage rows can't be loaded", func(t *testing.T) {
+		dbErr := errors.New("connection reset")
+		_, err := summary(t, &summaryQuerier{job: job, triageErr: dbErr})
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
@@ -2,20 +2,29 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 )
 
 var globalLogger *slog.Logger // The globally accessible logger
 
-func InitLogger(env string) {
+// levelVar backs the handler's level so it can be changed at runtime without rebuilding the handler.
+var levelVar = new(slog.LevelVar)
+
+// InitLogger configures the global logger for the given environment.
+// If levelOverride is non-empty (e.g. "debug", "WARN"), it replaces the level derived from env
+// while keeping the env's handler choice.
+func InitLogger(env string, levelOverride string) {
 	var handler slog.Handler
 	var opts slog.HandlerOptions
 
 	// Customize common handler options
+	opts.Level = levelVar
 	opts.AddSource = true // Always include file:line in logs for easy debugging
 	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
 
@@ -32,14 +41,14 @@ func InitLogger(env string) {
 
 	switch env {
 	case "development":
-		opts.Level = slog.LevelDebug
+		levelVar.Set(slog.LevelDebug)
 		handler = slog.NewTextHandler(os.Stdout, &opts)
 	case "development-json":
-		opts.Level = slog.LevelDebug
+		levelVar.Set(slog.LevelDebug)
 		handler = slog.NewJSONHandler(os.Stdout, &opts)
 	case "production", "staging":
-		opts.Level = slog.LevelInfo // Only info, warn, error, fatal in production
-		opts.AddSource = false      // Optionally remove source in production for performance/log size
+		levelVar.Set(slog.LevelInfo) // Only info, warn, error, fatal in production
+		opts.AddSource = false       // Optionally remove source in production for performance/log size
 		handler = slog.NewJSONHandler(os.Stdout, &opts)
 		// In a production environment, it's common to direct output to stderr
 		// as many container orchestrators/logging agents collect stderr separately.
@@ -47,10 +56,16 @@ func InitLogger(env string) {
 	default:
 		// Fallback for unknown environments, defaulting to production-like logging
 		log.Printf("WARNING: Unknown APP_ENV '%s'. Defaulting to production logging.\n", env)
-		opts.Level = slog.LevelInfo
+		levelVar.Set(slog.LevelInfo)
 		handler = slog.NewJSONHandler(os.Stdout, &opts)
 	}
 
+	if levelOverride != "" {
+		if err := SetLevel(levelOverride); err != nil {
+			log.Printf("WARNING: %v. Keeping level '%s'.\n", err, levelVar.Level())
+		}
+	}
+
 	globalLogger = slog.New(handler)
 	slog.SetDefault(globalLogger) // Set as the default logger for the whole application
 }
@@ -63,7 +78,7 @@ func L() *slog.Logger {
 	if globalLogger == nil {
 		// This block should ideally not be hit if InitLogger is called first in main.
 		// It's a fallback for safety/debugging during early development.
-		InitLogger("development")
+		InitLogger("development", "")
 		log.Println("WARNING: Logger accessed before explicit initialization. Using default development logger.")
 	}
 	return globalLogger
@@ -79,3 +94,27 @@ func SetOutput(w io.Writer) {
 	}
 	slog.SetDefault(slog.New(slog.NewJSONHandler(w, &currentOpts)))
 }
+
+// ParseLevel converts a level name such as "debug" or "WARN" into an slog.Level.
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return l, fmt.Errorf("invalid log level '%s'", level)
+	}
+	return l, nil
+}
+
+// SetLevel changes the minimum level of the global logger at runtime.
+func SetLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levelVar.Set(l)
+	return nil
+}
+
+// Level returns the current minimum level of the global logger.
+func Level() slog.Level {
+	return levelVar.Level()
+}
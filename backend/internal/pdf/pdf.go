@@ -0,0 +1,166 @@
+// Package pdf renders plain text into a minimal, dependency-free PDF document. It is meant for
+// simple exports: one monospaced font, word-wrapped lines and automatic page breaks.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Page geometry, in PDF points (1/72 inch), for US Letter with Courier at fontSize.
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	margin       = 50
+	fontSize     = 9
+	leading      = 11
+	charsPerLine = (pageWidth - 2*margin) * 10 / (fontSize * 6) // Courier glyphs are 0.6em wide.
+	linesPerPage = (pageHeight - 2*margin) / leading
+)
+
+// FromText lays out text as a PDF. Lines longer than the page width are word-wrapped and a new
+// page is started whenever the current one is full. A form feed ("\f") forces a page break.
+func FromText(text string) []byte {
+	pages := paginate(wrapLines(text))
+
+	var buf bytes.Buffer
+	var offsets []int
+	beginObject := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-3 are the catalog, page tree and font; each page then adds a page and a content object.
+	beginObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	beginObject()
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(pages))
+
+	beginObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	for _, lines := range pages {
+		pageID := beginObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			pageWidth, pageHeight, pageID+1)
+
+		content := pageContent(lines)
+		beginObject()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", len(content))
+		buf.Write(content)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+	return buf.Bytes()
+}
+
+// pageContent builds the content stream that draws lines top to bottom.
+func pageContent(lines []string) []byte {
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) '\n", escape(line))
+	}
+	content.WriteString("ET")
+	return content.Bytes()
+}
+
+// wrapLines splits text into lines that fit the page width, breaking on spaces where possible.
+// Page breaks are kept as standalone "\f" entries for paginate.
+func wrapLines(text string) []string {
+	var lines []string
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		raw = strings.ReplaceAll(raw, "\t", "    ")
+		for i, segment := range strings.Split(raw, "\f") {
+			if i > 0 {
+				lines = append(lines, "\f")
+			}
+			if i > 0 && segment == "" {
+				continue
+			}
+			lines = append(lines, wrapLine(segment)...)
+		}
+	}
+	return lines
+}
+
+func wrapLine(line string) []string {
+	runes := []rune(strings.TrimRight(line, " "))
+	if len(runes) <= charsPerLine {
+		return []string{string(runes)}
+	}
+	var wrapped []string
+	for len(runes) > charsPerLine {
+		cut := charsPerLine
+		for i := charsPerLine; i > charsPerLine/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		wrapped = append(wrapped, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	return append(wrapped, string(runes))
+}
+
+func paginate(lines []string) [][]string {
+	pages := [][]string{{}}
+	for _, line := range lines {
+		current := len(pages) - 1
+		if line == "\f" {
+			if len(pages[current]) > 0 {
+				pages = append(pages, []string{})
+			}
+			continue
+		}
+		if len(pages[current]) == linesPerPage {
+			pages = append(pages, []string{})
+			current++
+		}
+		pages[current] = append(pages[current], line)
+	}
+	return pages
+}
+
+// escape encodes a line as a PDF literal string body in WinAnsi (Windows-1252). Characters the
+// encoding cannot represent are replaced with '?'.
+func escape(line string) string {
+	var b strings.Builder
+	for _, r := range line {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromText(t *testing.T) {
+	t.Run("produces a well-formed single page document", func(t *testing.T) {
+		doc := FromText("Claim CLM-1 (open)\nback\\slash")
+
+		assert.True(t, bytes.HasPrefix(doc, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(doc, []byte("%%EOF\n")))
+		assert.Contains(t, string(doc), "/Count 1")
+		assert.Contains(t, string(doc), `(Claim CLM-1 \(open\)) '`)
+		assert.Contains(t, string(doc), `(back\\slash) '`)
+	})
+
+	t.Run("paginates long text and honours form feeds", func(t *testing.T) {
+		text := strings.Repeat("line\n", linesPerPage+1) + "\fafter break"
+		doc := FromText(text)
+
+		assert.Contains(t, string(doc), "/Count 3")
+	})
+
+	t.Run("wraps long lines on spaces", func(t *testing.T) {
+		lines := wrapLine(strings.Repeat("word ", charsPerLine))
+
+		assert.Greater(t, len(lines), 1)
+		for _, line := range lines {
+			assert.LessOrEqual(t, len([]rune(line)), charsPerLine)
+			assert.False(t, strings.HasPrefix(line, " "))
+		}
+	})
+
+	t.Run("encodes non-ASCII in WinAnsi", func(t *testing.T) {
+		assert.Equal(t, `caf\351 ?`, escape("café ☃"))
+	})
+}
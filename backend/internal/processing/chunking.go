@@ -0,0 +1,131 @@
+package processing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk size units. Tokens are approximated by whitespace-separated words, which is close enough to
+// tune retrieval granularity without depending on the embedding model's tokenizer.
+const (
+	ChunkUnitChars  = "chars"
+	ChunkUnitTokens = "tokens"
+)
+
+// ChunkTextField is the json_field holding a chunk's text; knowledge search reads it.
+const ChunkTextField = "chunk_text"
+
+// ChunkNumberField is the json_field holding a chunk's 0-based position in its source document.
+const ChunkNumberField = "metadata.chunk_number"
+
+// Chunking splits the text of each row into overlapping chunks before embedding, turning one
+// document row into one item per chunk. Every chunk keeps the row's other fields, including its
+// document ID, and records its position in ChunkNumberField so results can be stitched back
+// together in order.
+type Chunking struct {
+	SourceField string `yaml:"source_field"`
+	Unit        string `yaml:"unit,omitempty"`
+	Size        int    `yaml:"size"`
+	Overlap     int    `yaml:"overlap,omitempty"`
+}
+
+func (c *Chunking) validate(definedFields map[string]bool, businessKey []string) error {
+	if c.SourceField == "" {
+		return fmt.Errorf("config validation failed: chunking.source_field is required")
+	}
+	if !definedFields[c.SourceField] {
+		return fmt.Errorf("config validation failed: chunking.source_field '%s' does not match any json_field", c.SourceField)
+	}
+	if c.Unit != "" && c.Unit != ChunkUnitChars && c.Unit != ChunkUnitTokens {
+		return fmt.Errorf("config validation failed: chunking.unit must be '%s' or '%s', got '%s'", ChunkUnitChars, ChunkUnitTokens, c.Unit)
+	}
+	if c.Size <= 0 {
+		return fmt.Errorf("config validation failed: chunking.size must be positive")
+	}
+	if c.Overlap < 0 || c.Overlap >= c.Size {
+		return fmt.Errorf("config validation failed: chunking.overlap must be at least 0 and less than chunking.size")
+	}
+	if !definedFields[DocumentIDField] {
+		return fmt.Errorf("config validation failed: chunking requires a column mapped to json_field '%s'", DocumentIDField)
+	}
+	if !slices.Contains(businessKey, ChunkNumberField) {
+		return fmt.Errorf("config validation failed: chunking requires '%s' in business_key so each chunk is stored separately", ChunkNumberField)
+	}
+	return nil
+}
+
+// chunkRow splits a row's processed data into one copy per chunk of the chunking source field. The
+// source text is replaced by the chunk's text and number. Without chunking, the row is returned as is.
+func (p *GenericProcessor) chunkRow(processedData map[string]interface{}) ([]map[string]interface{}, error) {
+	chunking := p.config.Chunking
+	if chunking == nil {
+		return []map[string]interface{}{processedData}, nil
+	}
+
+	text, _ := processedData[chunking.SourceField].(string)
+	chunks := splitText(text, chunking.Unit, chunking.Size, chunking.Overlap)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("chunking source field '%s' is empty", chunking.SourceField)
+	}
+
+	chunkData := make([]map[string]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		data := make(map[string]interface{}, len(processedData)+1)
+		for key, val := range processedData {
+			data[key] = val
+		}
+		delete(data, chunking.SourceField)
+		data[ChunkTextField] = chunk
+		data[ChunkNumberField] = i
+		chunkData[i] = data
+	}
+	return chunkData, nil
+}
+
+// splitText cuts text into chunks of at most size units, breaking only between words, with each
+// chunk repeating up to overlap units from the end of the one before. A single word longer than size
+// becomes a chunk of its own.
+func splitText(text, unit string, size, overlap int) []string {
+	words := strings.Fields(text)
+	measure := func(word string, separated bool) int {
+		if unit == ChunkUnitTokens {
+			return 1
+		}
+		n := utf8.RuneCountInString(word)
+		if separated {
+			n++
+		}
+		return n
+	}
+
+	var chunks []string
+	for start := 0; start < len(words); {
+		end, used := start, 0
+		for end < len(words) {
+			next := used + measure(words[end], end > start)
+			if end > start && next > size {
+				break
+			}
+			used = next
+			end++
+		}
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+
+		// Start the next chunk far enough back to repeat the overlap, but always move forward.
+		next, repeated := end, 0
+		for next > start+1 {
+			repeated += measure(words[next-1], true)
+			if repeated > overlap {
+				break
+			}
+			next--
+		}
+		start = next
+	}
+	return chunks
+}
@@ -0,0 +1,96 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitText(t *testing.T) {
+	t.Run("Splits by words with token overlap", func(t *testing.T) {
+		chunks := splitText("a b c d e f g", ChunkUnitTokens, 3, 1)
+		assert.Equal(t, []string{"a b c", "c d e", "e f g"}, chunks)
+	})
+
+	t.Run("Splits by characters without breaking words", func(t *testing.T) {
+		chunks := splitText("alpha beta gamma delta", ChunkUnitChars, 11, 0)
+		assert.Equal(t, []string{"alpha beta", "gamma delta"}, chunks)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, len(chunk), 11)
+		}
+	})
+
+	t.Run("Repeats up to overlap characters", func(t *testing.T) {
+		chunks := splitText("alpha beta gamma delta", ChunkUnitChars, 16, 6)
+		assert.Equal(t, []string{"alpha beta gamma", "gamma delta"}, chunks)
+	})
+
+	t.Run("Keeps an oversized word as its own chunk", func(t *testing.T) {
+		chunks := splitText("tiny enormousword tiny", ChunkUnitChars, 5, 0)
+		assert.Equal(t, []string{"tiny", "enormousword", "tiny"}, chunks)
+	})
+
+	t.Run("Returns nothing for blank text", func(t *testing.T) {
+		assert.Empty(t, splitText("  \n ", ChunkUnitChars, 10, 0))
+	})
+}
+
+func newChunkingTestConfig() IngestionConfig {
+	return IngestionConfig{
+		ReportType:  "TEST_DOCUMENTS",
+		ItemType:    "KNOWLEDGE_CHUNK",
+		ScopeField:  ScopeFields{"document name"},
+		BusinessKey: []string{DocumentIDField, ChunkNumberField},
+		Chunking:    &Chunking{SourceField: "document_text", Unit: ChunkUnitTokens, Size: 4, Overlap: 1},
+		ColumnMappings: []ColumnMapping{
+			{CSVHeader: "document name", JSONField: "scope", Validation: ValidationRule{Required: true}},
+			{CSVHeader: "document id", JSONField: DocumentIDField, Validation: ValidationRule{Required: true}},
+			{CSVHeader: "text", JSONField: "document_text"},
+		},
+	}
+}
+
+func TestChunkingProcess(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Creates one item per chunk with document ID and chunk number", func(t *testing.T) {
+		csvData := "document name,document id,text\nPolicy,DOC-1,one two three four five six seven\n"
+
+		result, err := NewGenericProcessor(newChunkingTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 2)
+
+		for i, want := range []string{"one two three four", "four five six seven"} {
+			item := result.SuccessfulItems[i]
+			var props map[string]interface{}
+			require.NoError(t, json.Unmarshal(item.CustomProperties, &props))
+			assert.Equal(t, want, props[ChunkTextField])
+			assert.EqualValues(t, i, props[ChunkNumberField])
+			assert.Equal(t, "DOC-1", props[DocumentIDField])
+			assert.NotContains(t, props, "document_text")
+		}
+		assert.NotEqual(t, result.SuccessfulItems[0].BusinessKey, result.SuccessfulItems[1].BusinessKey)
+	})
+
+	t.Run("Triages rows with no text to chunk", func(t *testing.T) {
+		csvData := "document name,document id,text\nPolicy,DOC-2,\n"
+
+		result, err := NewGenericProcessor(newChunkingTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "chunking source field 'document_text' is empty")
+	})
+
+	t.Run("Requires the chunk number in the business key", func(t *testing.T) {
+		config := newChunkingTestConfig()
+		config.BusinessKey = []string{DocumentIDField}
+		err := config.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires 'metadata.chunk_number' in business_key")
+	})
+}
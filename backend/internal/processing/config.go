@@ -1,6 +1,14 @@
 package processing
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
 
 // ValidationRule defines the validation rules for a single column
 // yaml tags tell our parser how to map the YAML fields to our struct
@@ -17,28 +25,208 @@ type ProcessingAttempt struct {
 	Transforms []string `yaml:"transforms,omitempty"`
 }
 
-// ColumnMapping defines how to map and transform a single CSV column
+// ColumnMapping defines how to map and transform a single CSV column.
+// For fixed-width files, Start and Length locate the field on each line instead of a header, and
+// CSVHeader only names the column in triage records and scope_field.
 type ColumnMapping struct {
 	CSVHeader         string              `yaml:"csv_header"`
+	JSONPath          string              `yaml:"json_path,omitempty"`
+	Start             int                 `yaml:"start,omitempty"`
+	Length            int                 `yaml:"length,omitempty"`
 	JSONField         string              `yaml:"json_field"`
 	MergeExcessFields bool                `yaml:"merge_excess_fields,omitempty"`
 	Attempts          []ProcessingAttempt `yaml:"attempts"`
 	Validation        ValidationRule      `yaml:"validation"`
 }
 
-// EmbedContent defines the configuration for generating embeddings during ingestion
+// EmbedContent defines the configuration for generating embeddings during ingestion.
+// SourceColumns feed the item's combined embedding; Fields add named embeddings, each stored in its
+// own vector column so searches can target, say, a document's title rather than its body.
+// Template, when set, builds the combined embedding's text instead of joining SourceColumns, so the
+// embedding carries labeled context; SourceColumns should still list the fields it reads, since
+// search uses them to pick the text shown for a hit.
 type EmbedContent struct {
+	SourceColumns []string         `yaml:"source_columns"`
+	Fields        []EmbeddingField `yaml:"fields,omitempty"`
+	Template      string           `yaml:"template,omitempty"`
+
+	// template is Template parsed by Validate.
+	template *template.Template
+}
+
+// EmbeddingField is one named embedding and the json_fields its text is built from.
+type EmbeddingField struct {
+	Name          string   `yaml:"name"`
 	SourceColumns []string `yaml:"source_columns"`
 }
 
+// Named embeddings. Each has its own vector column on items.
+const (
+	EmbeddingFieldTitle = "title"
+	EmbeddingFieldBody  = "body"
+)
+
+// Distance metrics for vector search, matching the pgvector operators <=>, <-> and <#>. Searching
+// with l2 or inner_product is only fast when an HNSW index with vector_l2_ops or vector_ip_ops exists
+// on the column; items.embedding and comments.embedding have all three. A config's distance_metric
+// only applies to the platform search-everything endpoint: the insurance app's claim, comment and
+// knowledge chunk vector searches always rank by cosine.
+const (
+	DistanceMetricCosine       = "cosine"
+	DistanceMetricL2           = "l2"
+	DistanceMetricInnerProduct = "inner_product"
+)
+
+// IsDistanceMetric reports whether name is a supported distance metric.
+func IsDistanceMetric(name string) bool {
+	return name == DistanceMetricCosine || name == DistanceMetricL2 || name == DistanceMetricInnerProduct
+}
+
+// IsEmbeddingField reports whether name is a known named embedding.
+func IsEmbeddingField(name string) bool {
+	return name == EmbeddingFieldTitle || name == EmbeddingFieldBody
+}
+
+// AllSourceColumns returns every json_field that feeds one of the embeddings, without duplicates.
+func (e *EmbedContent) AllSourceColumns() []string {
+	columns := slices.Clone(e.SourceColumns)
+	for _, field := range e.Fields {
+		for _, column := range field.SourceColumns {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// DocumentIDField is the json_field that identifies the source document of a knowledge chunk.
+// Configs with replace_on_reingest use it to archive a document's previous items when it is re-ingested.
+const DocumentIDField = "metadata.document_id"
+
+// ChunkMetadataField is the custom_properties key that holds structured chunk metadata.
+const ChunkMetadataField = "chunk_metadata"
+
+// ChunkMetadata defines structured metadata attached to each item at ingestion time, so knowledge
+// search returns it with each chunk instead of looking up the document header per chunk.
+// Fields maps a metadata key to the json_field whose processed value it should hold.
+type ChunkMetadata struct {
+	Fields map[string]string `yaml:"fields"`
+}
+
+// ScopeSeparator joins the values of a composite scope, outermost level first, so an item scoped
+// by region and branch gets "WEST/BR01". A scope grant covers the scopes nested under it, so no
+// scope field value may contain the separator, or a flat value like "WEST/X" would be read as
+// nested under WEST.
+const ScopeSeparator = "/"
+
+// ValidateScope checks a scope supplied through the API. Composite scopes are allowed, but every
+// level must be non-empty, so "WEST/", "/BR01" and "WEST//BR01" are rejected.
+func ValidateScope(scope string) error {
+	for _, part := range strings.Split(scope, ScopeSeparator) {
+		if strings.TrimSpace(part) == "" {
+			return fmt.Errorf("scope '%s' has an empty level; levels are separated by '%s'", scope, ScopeSeparator)
+		}
+	}
+	return nil
+}
+
+// ScopeFields lists the CSV headers whose values make up an item's scope. In YAML, scope_field
+// takes either a single header or a list of headers ordered from the broadest level down.
+type ScopeFields []string
+
+// UnmarshalYAML accepts scope_field as a scalar or a sequence.
+func (f *ScopeFields) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*f = ScopeFields{value.Value}
+		return nil
+	}
+	var fields []string
+	if err := value.Decode(&fields); err != nil {
+		return fmt.Errorf("scope_field must be a string or a list of strings: %w", err)
+	}
+	*f = fields
+	return nil
+}
+
+// Supported values for IngestionConfig.HeaderMatching.
+const (
+	HeaderMatchingStrict     = "strict"
+	HeaderMatchingNormalized = "normalized"
+)
+
+// Supported values for IngestionConfig.DuplicateHeaders.
+const (
+	// DuplicateHeadersReject fails the file when two headers match the key of a column mapping.
+	// Repeated headers that no mapping reads are ignored.
+	DuplicateHeadersReject = "reject"
+	// DuplicateHeadersNumber keeps the first of a repeated header as is and renames the later ones
+	// with a _2, _3... suffix, so column mappings can address each of them.
+	DuplicateHeadersNumber = "number"
+)
+
+// Supported values for IngestionConfig.EmptyValues, which controls how a field whose transform
+// produced no value (such as to_integer on an empty cell) is written to custom_properties.
+// The default is EmptyValuesNull.
+const (
+	// EmptyValuesNull writes the key with a JSON null.
+	EmptyValuesNull = "null"
+	// EmptyValuesOmit leaves the key out of custom_properties.
+	EmptyValuesOmit = "omit"
+	// EmptyValuesEmpty writes the key with an empty string.
+	EmptyValuesEmpty = "empty"
+)
+
+// GeoPoint defines how to combine a latitude and longitude field into a GeoJSON point
+type GeoPoint struct {
+	LatitudeField  string `yaml:"latitude_field"`
+	LongitudeField string `yaml:"longitude_field"`
+	JSONField      string `yaml:"json_field"`
+}
+
 // IngestionConfig is the top-level struct that represents a full ingestion configuration fields
 type IngestionConfig struct {
-	ReportType     string          `yaml:"report_type"`
-	ItemType       string          `yaml:"item_type"`
-	ScopeField     string          `yaml:"scope_field"`
-	BusinessKey    []string        `yaml:"business_key"`
-	EmbedContent   *EmbedContent   `yaml:"embed_content,omitempty"`
-	ColumnMappings []ColumnMapping `yaml:"column_mappings"`
+	ReportType         string          `yaml:"report_type"`
+	Format             string          `yaml:"format,omitempty"`
+	Encoding           string          `yaml:"encoding,omitempty"`
+	TrimAll            bool            `yaml:"trim_all,omitempty"`
+	CollectRowErrors   bool            `yaml:"collect_row_errors,omitempty"`
+	HeaderMatching     string          `yaml:"header_matching,omitempty"`
+	HeaderRowOffset    int             `yaml:"header_row_offset,omitempty"`
+	DuplicateHeaders   string          `yaml:"duplicate_headers,omitempty"`
+	EmptyValues        string          `yaml:"empty_values,omitempty"`
+	ItemType           string          `yaml:"item_type"`
+	ScopeField         ScopeFields     `yaml:"scope_field"`
+	BusinessKey        []string        `yaml:"business_key"`
+	EmbedContent       *EmbedContent   `yaml:"embed_content,omitempty"`
+	DistanceMetric     string          `yaml:"distance_metric,omitempty"`
+	GeoPoint           *GeoPoint       `yaml:"geo_point,omitempty"`
+	ChunkMetadata      *ChunkMetadata  `yaml:"chunk_metadata,omitempty"`
+	Chunking           *Chunking       `yaml:"chunking,omitempty"`
+	ReplaceOnReingest  bool            `yaml:"replace_on_reingest,omitempty"`
+	Delta              bool            `yaml:"delta,omitempty"`
+	ArchiveMissing     bool            `yaml:"archive_missing,omitempty"`
+	TriageAlertPercent float64         `yaml:"triage_alert_percent,omitempty"`
+	DegradedWebhookURL string          `yaml:"degraded_webhook_url,omitempty"`
+	SaveBatchSize      int             `yaml:"save_batch_size,omitempty"`
+	AcceptedFormats    []string        `yaml:"accepted_formats,omitempty"`
+	ColumnMappings     []ColumnMapping `yaml:"column_mappings"`
+}
+
+// DefaultSaveBatchSize is how many processed items are saved per batch when save_batch_size is unset.
+const DefaultSaveBatchSize = 500
+
+// EffectiveSaveBatchSize returns how many items to save per batch while a file is processed, or 0 when
+// the items must be saved in one transaction at the end. Archiving missing items and replacing
+// re-ingested documents both compare against the file's full set of items, so they disable batching.
+func (c *IngestionConfig) EffectiveSaveBatchSize() int {
+	if c.ArchiveMissing || c.ReplaceOnReingest {
+		return 0
+	}
+	if c.SaveBatchSize > 0 {
+		return c.SaveBatchSize
+	}
+	return DefaultSaveBatchSize
 }
 
 // Validate checks if the IngestionConfig is valid
@@ -49,7 +237,7 @@ func (c *IngestionConfig) Validate() error {
 	if c.ItemType == "" {
 		return fmt.Errorf("config validation failed: item_type is required")
 	}
-	if c.ScopeField == "" {
+	if len(c.ScopeField) == 0 {
 		return fmt.Errorf("config validation failed: scope_field is required")
 	}
 	if len(c.BusinessKey) == 0 {
@@ -65,9 +253,186 @@ func (c *IngestionConfig) Validate() error {
 		definedHeaders[mapping.CSVHeader] = true
 	}
 
-	// Check if the scopeFields value exists in the defined headers
-	if _, exists := definedHeaders[c.ScopeField]; !exists {
-		return fmt.Errorf("config validation failed: scope_field '%s' does not match any defined CSV headers", c.ScopeField)
+	// Check that every scope field exists in the defined headers
+	seenScopeFields := make(map[string]bool)
+	for _, field := range c.ScopeField {
+		if field == "" {
+			return fmt.Errorf("config validation failed: scope_field contains an empty entry")
+		}
+		if seenScopeFields[field] {
+			return fmt.Errorf("config validation failed: scope_field '%s' is listed more than once", field)
+		}
+		seenScopeFields[field] = true
+		if _, exists := definedHeaders[field]; !exists {
+			return fmt.Errorf("config validation failed: scope_field '%s' does not match any defined CSV headers", field)
+		}
+	}
+
+	if c.Format != "" && c.Format != FormatCSV && c.Format != FormatJSONL && c.Format != FormatFixedWidth {
+		return fmt.Errorf("config validation failed: format must be '%s', '%s' or '%s', got '%s'", FormatCSV, FormatJSONL, FormatFixedWidth, c.Format)
+	}
+
+	if c.HeaderRowOffset < 0 {
+		return fmt.Errorf("config validation failed: header_row_offset must not be negative, got %d", c.HeaderRowOffset)
+	}
+	if c.HeaderRowOffset > 0 && c.Format == FormatJSONL {
+		return fmt.Errorf("config validation failed: header_row_offset is not supported for format '%s'", FormatJSONL)
+	}
+
+	if c.HeaderMatching != "" && c.HeaderMatching != HeaderMatchingStrict && c.HeaderMatching != HeaderMatchingNormalized {
+		return fmt.Errorf("config validation failed: header_matching must be '%s' or '%s', got '%s'", HeaderMatchingStrict, HeaderMatchingNormalized, c.HeaderMatching)
+	}
+
+	if c.DuplicateHeaders != "" && c.DuplicateHeaders != DuplicateHeadersReject && c.DuplicateHeaders != DuplicateHeadersNumber {
+		return fmt.Errorf("config validation failed: duplicate_headers must be '%s' or '%s', got '%s'", DuplicateHeadersReject, DuplicateHeadersNumber, c.DuplicateHeaders)
+	}
+
+	if c.EmptyValues != "" && c.EmptyValues != EmptyValuesNull && c.EmptyValues != EmptyValuesOmit && c.EmptyValues != EmptyValuesEmpty {
+		return fmt.Errorf("config validation failed: empty_values must be '%s', '%s' or '%s', got '%s'", EmptyValuesNull, EmptyValuesOmit, EmptyValuesEmpty, c.EmptyValues)
+	}
+
+	if c.Encoding != "" && c.Encoding != EncodingAuto {
+		if _, ok := knownEncodings[strings.ToLower(c.Encoding)]; !ok {
+			return fmt.Errorf("config validation failed: unsupported encoding '%s'", c.Encoding)
+		}
+	}
+
+	for _, mapping := range c.ColumnMappings {
+		if mapping.JSONPath != "" && c.Format != FormatJSONL {
+			return fmt.Errorf("config validation failed: json_path on column '%s' requires format '%s'", mapping.CSVHeader, FormatJSONL)
+		}
+		if c.Format == FormatFixedWidth && (mapping.Start < 1 || mapping.Length < 1) {
+			return fmt.Errorf("config validation failed: column '%s' needs a start of at least 1 and a positive length for format '%s'", mapping.CSVHeader, FormatFixedWidth)
+		}
+		if c.Format != FormatFixedWidth && (mapping.Start != 0 || mapping.Length != 0) {
+			return fmt.Errorf("config validation failed: start and length on column '%s' require format '%s'", mapping.CSVHeader, FormatFixedWidth)
+		}
+	}
+
+	for _, format := range c.AcceptedFormats {
+		if !knownFormats[format] {
+			return fmt.Errorf("config validation failed: accepted_formats contains unknown format '%s'", format)
+		}
+		if format != c.uploadFormat() {
+			return fmt.Errorf("config validation failed: accepted_formats contains '%s', which format '%s' cannot process", format, c.effectiveFormat())
+		}
+	}
+
+	definedFields := make(map[string]bool)
+	for _, mapping := range c.ColumnMappings {
+		definedFields[mapping.JSONField] = true
+	}
+
+	if c.ReplaceOnReingest && !definedFields[DocumentIDField] {
+		return fmt.Errorf("config validation failed: replace_on_reingest requires a column mapped to json_field '%s'", DocumentIDField)
+	}
+
+	if c.Chunking != nil {
+		if err := c.Chunking.validate(definedFields, c.BusinessKey); err != nil {
+			return err
+		}
+		// Chunking adds these fields to every item, so later checks may reference them.
+		definedFields[ChunkTextField] = true
+		definedFields[ChunkNumberField] = true
+	}
+
+	if c.ArchiveMissing && !c.Delta {
+		return fmt.Errorf("config validation failed: archive_missing requires delta: true")
+	}
+
+	if c.TriageAlertPercent < 0 || c.TriageAlertPercent > 100 {
+		return fmt.Errorf("config validation failed: triage_alert_percent must be between 0 and 100")
+	}
+
+	if c.DegradedWebhookURL != "" {
+		if c.TriageAlertPercent == 0 {
+			return fmt.Errorf("config validation failed: degraded_webhook_url requires triage_alert_percent")
+		}
+		webhookURL, err := url.Parse(c.DegradedWebhookURL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return fmt.Errorf("config validation failed: degraded_webhook_url must be an absolute http or https URL, got '%s'", c.DegradedWebhookURL)
+		}
+	}
+
+	if c.SaveBatchSize < 0 {
+		return fmt.Errorf("config validation failed: save_batch_size must not be negative")
+	}
+
+	if c.EmbedContent != nil {
+		if err := c.EmbedContent.parseTemplate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+		seenEmbeddingFields := make(map[string]bool)
+		for _, field := range c.EmbedContent.Fields {
+			if !IsEmbeddingField(field.Name) {
+				return fmt.Errorf("config validation failed: embed_content field name must be '%s' or '%s', got '%s'", EmbeddingFieldTitle, EmbeddingFieldBody, field.Name)
+			}
+			if seenEmbeddingFields[field.Name] {
+				return fmt.Errorf("config validation failed: embed_content field '%s' is listed more than once", field.Name)
+			}
+			seenEmbeddingFields[field.Name] = true
+			if len(field.SourceColumns) == 0 {
+				return fmt.Errorf("config validation failed: embed_content field '%s' needs at least one source column", field.Name)
+			}
+		}
+	}
+
+	if c.DistanceMetric != "" && !IsDistanceMetric(c.DistanceMetric) {
+		return fmt.Errorf("config validation failed: distance_metric must be '%s', '%s' or '%s', got '%s'", DistanceMetricCosine, DistanceMetricL2, DistanceMetricInnerProduct, c.DistanceMetric)
+	}
+
+	if c.ChunkMetadata != nil {
+		for key, field := range c.ChunkMetadata.Fields {
+			if !definedFields[field] {
+				return fmt.Errorf("config validation failed: chunk_metadata key '%s' references unknown json_field '%s'", key, field)
+			}
+		}
+	}
+
+	if c.GeoPoint != nil {
+		if c.GeoPoint.JSONField == "" {
+			return fmt.Errorf("config validation failed: geo_point.json_field is required")
+		}
+		for _, field := range []string{c.GeoPoint.LatitudeField, c.GeoPoint.LongitudeField} {
+			if !definedFields[field] {
+				return fmt.Errorf("config validation failed: geo_point field '%s' does not match any defined json_field", field)
+			}
+		}
 	}
 	return nil
 }
+
+// AcceptsFormat reports whether an uploaded file of the given format is allowed for this config.
+func (c *IngestionConfig) AcceptsFormat(format string) bool {
+	for _, accepted := range c.ExpectedFormats() {
+		if accepted == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectedFormats returns the formats allowed on upload, defaulting to the config's own format.
+func (c *IngestionConfig) ExpectedFormats() []string {
+	if len(c.AcceptedFormats) > 0 {
+		return c.AcceptedFormats
+	}
+	return []string{c.uploadFormat()}
+}
+
+// uploadFormat returns the format DetectFormat reports for files this config can process.
+// Fixed-width files are plain text, which is detected as csv.
+func (c *IngestionConfig) uploadFormat() string {
+	if c.Format == FormatJSONL {
+		return FormatJSONL
+	}
+	return FormatCSV
+}
+
+// effectiveFormat returns the config's processing format, where an empty format means csv.
+func (c *IngestionConfig) effectiveFormat() string {
+	if c.Format == "" {
+		return FormatCSV
+	}
+	return c.Format
+}
@@ -6,17 +6,25 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ingestionConfigDir is the directory name ingestion configs must live in to be loaded.
+const ingestionConfigDir = "ingestion"
+
 // ConfigLoader holds the loaded ingestion configurations
 type ConfigLoader struct {
-	configs map[string]IngestionConfig
+	configs  map[string]IngestionConfig
+	loadedAt time.Time
 }
 
-// NewConfigLoader recursively scans a directory for YAML files, loads them, validates them
-// and returns a ConfigLoader instance.
+// NewConfigLoader recursively scans a directory for YAML files in "ingestion" directories, loads
+// them, validates them and returns a ConfigLoader instance. YAML elsewhere under configPath (app
+// workflow config, for example) is left to its own loader.
 func NewConfigLoader(configPath string) (*ConfigLoader, error) {
 	configs := make(map[string]IngestionConfig)
 
@@ -28,21 +36,15 @@ func NewConfigLoader(configPath string) (*ConfigLoader, error) {
 		if d.IsDir() || (filepath.Ext(d.Name()) != ".yaml" && filepath.Ext(d.Name()) != ".yml") {
 			return nil
 		}
+		if filepath.Base(filepath.Dir(path)) != ingestionConfigDir {
+			return nil
+		}
 
 		slog.Info("Loading ingestion config", "file", path)
 
-		data, err := os.ReadFile(path)
+		config, err := LoadConfigFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to read config file %s: %w", path, err)
-		}
-
-		var config IngestionConfig
-		if err := yaml.Unmarshal(data, &config); err != nil {
-			return fmt.Errorf("failed to parse YAML for %s: %w", path, err)
-		}
-
-		if err := config.Validate(); err != nil {
-			return fmt.Errorf("validation failed for %s: %w", path, err)
+			return err
 		}
 
 		if _, exists := configs[config.ReportType]; exists {
@@ -61,7 +63,29 @@ func NewConfigLoader(configPath string) (*ConfigLoader, error) {
 		slog.Warn("No ingestion configs were loaded.", "path", configPath)
 	}
 
-	return &ConfigLoader{configs: configs}, nil
+	loader := &ConfigLoader{configs: configs, loadedAt: time.Now()}
+	if _, err := loader.distanceMetrics(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// LoadConfigFile reads, parses and validates a single ingestion config.
+func LoadConfigFile(path string) (IngestionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return IngestionConfig{}, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var config IngestionConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return IngestionConfig{}, fmt.Errorf("failed to parse YAML for %s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return IngestionConfig{}, fmt.Errorf("validation failed for %s: %w", path, err)
+	}
+	return config, nil
 }
 
 // GetConfig retrieves a validated configuration by its report type.
@@ -69,3 +93,74 @@ func (l *ConfigLoader) GetConfig(reportType string) (IngestionConfig, bool) {
 	config, ok := l.configs[reportType]
 	return config, ok
 }
+
+// ReportTypes returns the report types of all loaded configurations, sorted.
+func (l *ConfigLoader) ReportTypes() []string {
+	reportTypes := make([]string, 0, len(l.configs))
+	for reportType := range l.configs {
+		reportTypes = append(reportTypes, reportType)
+	}
+	sort.Strings(reportTypes)
+	return reportTypes
+}
+
+// LoadedAt returns when the configurations were last loaded.
+func (l *ConfigLoader) LoadedAt() time.Time {
+	return l.loadedAt
+}
+
+// DistanceMetricByItemType returns the distance metric configured for each item type that sets one.
+func (l *ConfigLoader) DistanceMetricByItemType() map[string]string {
+	metrics, _ := l.distanceMetrics()
+	return metrics
+}
+
+// distanceMetrics collects the configured distance metric of each item type, failing if configs
+// that share an item type disagree.
+func (l *ConfigLoader) distanceMetrics() (map[string]string, error) {
+	metrics := make(map[string]string)
+	for _, reportType := range l.ReportTypes() {
+		config := l.configs[reportType]
+		if config.DistanceMetric == "" {
+			continue
+		}
+		if existing, ok := metrics[config.ItemType]; ok && existing != config.DistanceMetric {
+			return nil, fmt.Errorf("conflicting distance_metric for item_type '%s': '%s' and '%s' (report type %s)", config.ItemType, existing, config.DistanceMetric, reportType)
+		}
+		metrics[config.ItemType] = config.DistanceMetric
+	}
+	return metrics, nil
+}
+
+// EmbedFieldsByItemType returns, for each item type with embedded content, the custom_properties
+// fields its embedding text is built from. Configs that share an item type are merged.
+func (l *ConfigLoader) EmbedFieldsByItemType() map[string][]string {
+	fields := make(map[string][]string)
+	for _, reportType := range l.ReportTypes() {
+		config := l.configs[reportType]
+		if config.EmbedContent == nil {
+			continue
+		}
+		for _, field := range config.EmbedContent.AllSourceColumns() {
+			if !slices.Contains(fields[config.ItemType], field) {
+				fields[config.ItemType] = append(fields[config.ItemType], field)
+			}
+		}
+	}
+	return fields
+}
+
+// FieldsByItemType returns, for each item type, the custom_properties fields its configs map
+// columns to. Configs that share an item type are merged.
+func (l *ConfigLoader) FieldsByItemType() map[string][]string {
+	fields := make(map[string][]string)
+	for _, reportType := range l.ReportTypes() {
+		config := l.configs[reportType]
+		for _, mapping := range config.ColumnMappings {
+			if !slices.Contains(fields[config.ItemType], mapping.JSONField) {
+				fields[config.ItemType] = append(fields[config.ItemType], mapping.JSONField)
+			}
+		}
+	}
+	return fields
+}
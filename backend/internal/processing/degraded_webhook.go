@@ -0,0 +1,62 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// degradedWebhookTimeout bounds one degraded-job notification, so a slow receiver can't hold up the job.
+const degradedWebhookTimeout = 10 * time.Second
+
+// DegradedJobNotification is the JSON body posted to a config's degraded_webhook_url when a job's
+// triage rate exceeds its triage_alert_percent.
+type DegradedJobNotification struct {
+	JobID            string  `json:"job_id"`
+	ReportType       string  `json:"report_type"`
+	Status           string  `json:"status"`
+	Degraded         bool    `json:"degraded"`
+	TriagePercent    float64 `json:"triage_percent"`
+	ThresholdPercent float64 `json:"threshold_percent"`
+	RowsTotal        int64   `json:"rows_total"`
+	RowsTriaged      int64   `json:"rows_triaged"`
+	Message          string  `json:"message"`
+}
+
+// notifyDegraded posts notification to webhookURL. The notification is best-effort: failures are
+// logged and never change the job's outcome.
+func notifyDegraded(ctx context.Context, webhookURL string, notification DegradedJobNotification, logger *slog.Logger) {
+	if err := postDegradedNotification(ctx, webhookURL, notification); err != nil {
+		logger.WarnContext(ctx, "Failed to send degraded job notification", "error", err)
+		return
+	}
+	logger.InfoContext(ctx, "Sent degraded job notification")
+}
+
+func postDegradedNotification(ctx context.Context, webhookURL string, notification DegradedJobNotification) error {
+	ctx, cancel := context.WithTimeout(ctx, degradedWebhookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package processing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDegradedWebhookURLValidation(t *testing.T) {
+	config := newProcessTestConfig()
+	config.DegradedWebhookURL = "https://alerts.example.com/hooks/ingestion"
+	assert.ErrorContains(t, config.Validate(), "degraded_webhook_url requires triage_alert_percent")
+
+	config.TriageAlertPercent = 20
+	require.NoError(t, config.Validate())
+
+	config.DegradedWebhookURL = "alerts.example.com/hooks"
+	assert.ErrorContains(t, config.Validate(), "degraded_webhook_url must be an absolute http or https URL")
+}
@@ -0,0 +1,21 @@
+package processing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaValidation(t *testing.T) {
+	config := newProcessTestConfig()
+	config.ArchiveMissing = true
+	assert.ErrorContains(t, config.Validate(), "archive_missing requires delta: true")
+
+	config.Delta = true
+	require.NoError(t, config.Validate())
+	assert.Equal(t, 0, config.EffectiveSaveBatchSize(), "archiving compares against the whole file")
+
+	config.ArchiveMissing = false
+	assert.Equal(t, DefaultSaveBatchSize, config.EffectiveSaveBatchSize(), "delta alone still saves in batches")
+}
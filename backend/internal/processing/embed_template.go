@@ -0,0 +1,101 @@
+package processing
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// parseTemplate parses Template, if set, so malformed templates fail config validation rather than
+// every row of an ingestion.
+func (e *EmbedContent) parseTemplate() error {
+	tmpl, err := newEmbedTemplate(e.Template)
+	if err != nil {
+		return err
+	}
+	e.template = tmpl
+	return nil
+}
+
+// newEmbedTemplate parses an embed_content template, returning nil for an empty one.
+func newEmbedTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("embed_content").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("embed_content template is invalid: %w", err)
+	}
+	return tmpl, nil
+}
+
+// missingField is what text/template prints for a key the data doesn't have.
+const missingField = "<no value>"
+
+// combinedText builds the text for the item's combined embedding. With a template, it is executed
+// against the processed fields, e.g. "Claim for {{.policyholder}}, status {{.status}}". Fields are
+// formatted as they would be when concatenated; names containing dots are read with index, as in
+// {{index . "metadata.document_id"}}. Missing, null and empty fields are skipped along with their
+// label: see dropMissingFields. Without a template the source column values are joined by spaces.
+func (e *EmbedContent) combinedText(processedData map[string]interface{}) (string, error) {
+	if e.Template == "" {
+		return columnsText(processedData, e.SourceColumns), nil
+	}
+	tmpl := e.template
+	if tmpl == nil {
+		// Configs built in code rather than loaded by the ConfigLoader haven't been validated.
+		var err error
+		if tmpl, err = newEmbedTemplate(e.Template); err != nil {
+			return "", err
+		}
+	}
+	// Empty fields are left out so they render as missing and {{with}} and {{if}} treat them as unset.
+	fields := make(map[string]interface{}, len(processedData))
+	for key, val := range processedData {
+		if val == nil {
+			continue
+		}
+		if formatted := fmt.Sprintf("%v", val); strings.TrimSpace(formatted) != "" {
+			fields[key] = formatted
+		}
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, fields); err != nil {
+		return "", fmt.Errorf("failed to render embed_content template: %w", err)
+	}
+	return dropMissingFields(text.String()), nil
+}
+
+// dropMissingFields removes the parts of rendered template text that show a missing field, so
+// "Claim for John, status <no value>, amount 42" becomes "Claim for John, amount 42" rather than
+// embedding a label with nothing after it. Each line is split into clauses at commas and
+// semicolons, and a clause naming a missing field is dropped; a line left with no clauses is
+// dropped entirely.
+func dropMissingFields(text string) string {
+	if !strings.Contains(text, missingField) {
+		return text
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.Contains(line, missingField) {
+			lines = append(lines, line)
+			continue
+		}
+		var clauses []string
+		for _, sentence := range strings.Split(line, "; ") {
+			var kept []string
+			for _, clause := range strings.Split(sentence, ", ") {
+				if !strings.Contains(clause, missingField) {
+					kept = append(kept, clause)
+				}
+			}
+			if len(kept) > 0 {
+				clauses = append(clauses, strings.Join(kept, ", "))
+			}
+		}
+		if len(clauses) > 0 {
+			lines = append(lines, strings.Join(clauses, "; "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,93 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// DefaultBackfillBatchSize is how many items an embedding backfill reads per query.
+const DefaultBackfillBatchSize = 100
+
+// BackfillOptions selects the items an embedding backfill processes.
+type BackfillOptions struct {
+	ItemType string
+	// AfterID resumes an earlier run: only items with a greater id are processed.
+	AfterID   int64
+	BatchSize int32
+}
+
+// BackfillResult summarizes an embedding backfill.
+type BackfillResult struct {
+	Embedded int
+	// Skipped counts items whose source columns were all empty, leaving no text to embed. They keep
+	// a NULL embedding, as they would have at ingestion.
+	Skipped int
+	// LastID is the id of the last item processed. Pass it as AfterID to resume after a failure.
+	LastID int64
+}
+
+// BackfillEmbeddings embeds items of a type that were ingested before embed_content was configured
+// for it, or before it configured the title or body embedding. The embedding text is rebuilt from custom_properties the same way ingestion builds it, and
+// items are read in id order in batches, so a run that fails can resume from its LastID. It stops at
+// the first embedding or database error rather than skipping items, so a resumed run leaves no gaps.
+func BackfillEmbeddings(ctx context.Context, q repository.Querier, embedContent *EmbedContent, embedder interfaces.EmbedderFunc, opts BackfillOptions, logger *slog.Logger) (BackfillResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBackfillBatchSize
+	}
+	logger = logger.With("item_type", opts.ItemType)
+	result := BackfillResult{LastID: opts.AfterID}
+	var withTitle, withBody bool
+	for _, field := range embedContent.Fields {
+		withTitle = withTitle || field.Name == EmbeddingFieldTitle
+		withBody = withBody || field.Name == EmbeddingFieldBody
+	}
+
+	for {
+		rows, err := q.ListItemsMissingEmbedding(ctx, repository.ListItemsMissingEmbeddingParams{
+			ItemType:  repository.ItemType(opts.ItemType),
+			AfterID:   result.LastID,
+			WithTitle: withTitle,
+			WithBody:  withBody,
+			BatchSize: opts.BatchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to list items without embeddings: %w", err)
+		}
+		if len(rows) == 0 {
+			return result, nil
+		}
+
+		for _, row := range rows {
+			var processedData map[string]interface{}
+			if err := json.Unmarshal(row.CustomProperties, &processedData); err != nil {
+				return result, fmt.Errorf("item %d: failed to parse custom_properties: %w", row.ID, err)
+			}
+			embeddings, err := embedItem(ctx, processedData, embedContent, embedder)
+			if err != nil {
+				return result, fmt.Errorf("item %d: failed to generate embedding: %w", row.ID, err)
+			}
+			if len(embeddings.combined.Slice()) == 0 {
+				result.Skipped++
+				result.LastID = row.ID
+				continue
+			}
+			err = q.SetItemEmbeddings(ctx, repository.SetItemEmbeddingsParams{
+				ID:             row.ID,
+				Embedding:      embeddings.combined,
+				TitleEmbedding: embeddings.title.String(),
+				BodyEmbedding:  embeddings.body.String(),
+			})
+			if err != nil {
+				return result, fmt.Errorf("item %d: failed to store embedding: %w", row.ID, err)
+			}
+			result.Embedded++
+			result.LastID = row.ID
+		}
+		logger.InfoContext(ctx, "Backfilled embedding batch", "embedded", result.Embedded, "skipped", result.Skipped, "last_id", result.LastID)
+	}
+}
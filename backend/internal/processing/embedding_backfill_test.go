@@ -0,0 +1,102 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backfillQuerier pages through items in id order like ListItemsMissingEmbedding and records the
+// embeddings stored for them.
+type backfillQuerier struct {
+	repository.Querier
+	items  []repository.ListItemsMissingEmbeddingRow
+	pages  []repository.ListItemsMissingEmbeddingParams
+	stored []repository.SetItemEmbeddingsParams
+}
+
+func (q *backfillQuerier) ListItemsMissingEmbedding(ctx context.Context, arg repository.ListItemsMissingEmbeddingParams) ([]repository.ListItemsMissingEmbeddingRow, error) {
+	q.pages = append(q.pages, arg)
+	var page []repository.ListItemsMissingEmbeddingRow
+	for _, item := range q.items {
+		if item.ID > arg.AfterID && len(page) < int(arg.BatchSize) {
+			page = append(page, item)
+		}
+	}
+	return page, nil
+}
+
+func (q *backfillQuerier) SetItemEmbeddings(ctx context.Context, arg repository.SetItemEmbeddingsParams) error {
+	q.stored = append(q.stored, arg)
+	return nil
+}
+
+func TestBackfillEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	embedContent := &EmbedContent{
+		SourceColumns: []string{"description"},
+		Fields:        []EmbeddingField{{Name: EmbeddingFieldTitle, SourceColumns: []string{"title"}}},
+	}
+	newQuerier := func() *backfillQuerier {
+		return &backfillQuerier{items: []repository.ListItemsMissingEmbeddingRow{
+			{ID: 3, CustomProperties: []byte(`{"description": "Water damage", "title": "Kitchen leak"}`)},
+			{ID: 5, CustomProperties: []byte(`{"claim_id": "C-5"}`)},
+			{ID: 8, CustomProperties: []byte(`{"description": "Hail"}`)},
+		}}
+	}
+
+	t.Run("Embeds every item in batches", func(t *testing.T) {
+		q := newQuerier()
+		embedder := &mockEmbedder{}
+		result, err := BackfillEmbeddings(ctx, q, embedContent, embedder.embed, BackfillOptions{ItemType: "INSURANCE_CLAIM", BatchSize: 2}, logger)
+		require.NoError(t, err)
+
+		assert.Equal(t, BackfillResult{Embedded: 2, Skipped: 1, LastID: 8}, result)
+		assert.Equal(t, []string{"Water damage", "Kitchen leak", "Hail"}, embedder.texts)
+		require.Len(t, q.stored, 2)
+		assert.Equal(t, int64(3), q.stored[0].ID)
+		assert.Equal(t, "[0.1,0.2,0.3]", q.stored[0].TitleEmbedding)
+		assert.Equal(t, int64(8), q.stored[1].ID)
+		assert.Equal(t, "[]", q.stored[1].TitleEmbedding, "an item without a title gets a NULL title embedding")
+		assert.Len(t, q.pages, 3)
+	})
+
+	t.Run("Resumes after the given id", func(t *testing.T) {
+		q := newQuerier()
+		embedder := &mockEmbedder{}
+		result, err := BackfillEmbeddings(ctx, q, embedContent, embedder.embed, BackfillOptions{ItemType: "INSURANCE_CLAIM", AfterID: 5}, logger)
+		require.NoError(t, err)
+		assert.Equal(t, BackfillResult{Embedded: 1, LastID: 8}, result)
+		assert.Equal(t, int32(DefaultBackfillBatchSize), q.pages[0].BatchSize)
+	})
+
+	t.Run("Lists items missing a configured named embedding", func(t *testing.T) {
+		q := newQuerier()
+		_, err := BackfillEmbeddings(ctx, q, embedContent, (&mockEmbedder{}).embed, BackfillOptions{ItemType: "INSURANCE_CLAIM"}, logger)
+		require.NoError(t, err)
+		assert.True(t, q.pages[0].WithTitle)
+		assert.False(t, q.pages[0].WithBody, "body is not configured")
+
+		q = newQuerier()
+		_, err = BackfillEmbeddings(ctx, q, &EmbedContent{SourceColumns: []string{"description"}}, (&mockEmbedder{}).embed, BackfillOptions{ItemType: "INSURANCE_CLAIM"}, logger)
+		require.NoError(t, err)
+		assert.False(t, q.pages[0].WithTitle)
+	})
+
+	t.Run("Stops at the first embedding failure with the id to resume from", func(t *testing.T) {
+		q := newQuerier()
+		embedder := &mockEmbedder{err: errors.New("embedding service unavailable")}
+		q.items[0].CustomProperties = []byte(`{"claim_id": "C-3"}`)
+		result, err := BackfillEmbeddings(ctx, q, embedContent, embedder.embed, BackfillOptions{ItemType: "INSURANCE_CLAIM"}, logger)
+		assert.ErrorContains(t, err, "item 8: failed to generate embedding")
+		assert.Equal(t, int64(5), result.LastID)
+		assert.Empty(t, q.stored)
+	})
+}
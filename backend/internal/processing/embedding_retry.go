@@ -0,0 +1,96 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
+)
+
+// embeddingRetryBackoff is the wait before each retry round for rows whose embedding failed.
+// The number of entries is the number of retry rounds.
+var embeddingRetryBackoff = []time.Duration{2 * time.Second, 5 * time.Second, 15 * time.Second}
+
+// embeddingError marks a row that failed only because the embedder returned an error,
+// which is usually a transient infrastructure problem rather than bad data.
+type embeddingError struct {
+	rowNum int
+	err    error
+}
+
+func (e *embeddingError) Error() string {
+	return fmt.Sprintf("Row %d: failed to generate embedding: %s", e.rowNum, e.err.Error())
+}
+
+func (e *embeddingError) Unwrap() error {
+	return e.err
+}
+
+// pendingEmbeddingRow is a processed row waiting for an embedding retry.
+type pendingEmbeddingRow struct {
+	processedData  map[string]interface{}
+	originalRecord map[string]string
+	rowNum         int
+	lastErr        error
+}
+
+// retryPendingEmbeddings retries rows whose embedding failed in batches with backoff, adding the
+// rows that succeed to the result and triaging only those that still fail after the last round.
+func (p *GenericProcessor) retryPendingEmbeddings(
+	ctx context.Context,
+	pending []pendingEmbeddingRow,
+	scopeJSONFields []string,
+	embedder interfaces.EmbedderFunc,
+	result *ProcessingResult,
+) {
+	for round, delay := range embeddingRetryBackoff {
+		if len(pending) == 0 {
+			return
+		}
+		slog.InfoContext(ctx, "Retrying rows with failed embeddings", "rows", len(pending), "round", round+1, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			for i := range pending {
+				pending[i].lastErr = ctx.Err()
+			}
+			p.triagePendingEmbeddings(pending, result)
+			return
+		case <-time.After(delay):
+		}
+
+		var stillPending []pendingEmbeddingRow
+		for _, row := range pending {
+			item, err := p.buildItem(ctx, row.processedData, scopeJSONFields, row.rowNum, embedder)
+			if err != nil {
+				var embedErr *embeddingError
+				if errors.As(err, &embedErr) {
+					row.lastErr = err
+					stillPending = append(stillPending, row)
+					continue
+				}
+				result.TriageRows = append(result.TriageRows, TriageRow{
+					OriginalRecord: row.originalRecord,
+					FailureReason:  err.Error(),
+				})
+				continue
+			}
+			result.SuccessfulItems = append(result.SuccessfulItems, item)
+		}
+		pending = stillPending
+	}
+
+	p.triagePendingEmbeddings(pending, result)
+}
+
+func (p *GenericProcessor) triagePendingEmbeddings(pending []pendingEmbeddingRow, result *ProcessingResult) {
+	for _, row := range pending {
+		result.TriageRows = append(result.TriageRows, TriageRow{
+			OriginalRecord: row.originalRecord,
+			FailureReason:  row.lastErr.Error(),
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package processing
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// Supported values for IngestionConfig.Encoding.
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingLatin1      = "latin1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// encodingSniffLen is how many bytes auto-detection inspects before choosing a charset. Files
+// longer than this that look like UTF-8 are still checked as they are read; see utf8Checker.
+const encodingSniffLen = 64 * 1024
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var knownEncodings = map[string]encoding.Encoding{
+	EncodingUTF8:        nil,
+	EncodingLatin1:      charmap.ISO8859_1,
+	EncodingWindows1252: charmap.Windows1252,
+}
+
+// NewUTF8Reader wraps file so that readers downstream always see UTF-8 without a BOM.
+// charset is one of the Encoding* constants; an empty value is treated as auto, which keeps
+// valid UTF-8 as-is and otherwise decodes the file as Windows-1252 (a superset of Latin-1).
+// Auto-detection only inspects the first 64KB, so a file that turns out not to be UTF-8 past
+// that point fails when the invalid bytes are read, naming the encoding to configure instead.
+func NewUTF8Reader(file io.Reader, charset string) (io.Reader, error) {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" {
+		charset = EncodingAuto
+	}
+
+	buffered := bufio.NewReaderSize(file, encodingSniffLen)
+	head, err := buffered.Peek(encodingSniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read file for encoding detection: %w", err)
+	}
+
+	if bytes.HasPrefix(head, utf8BOM) {
+		// A UTF-8 BOM is authoritative; strip it so it doesn't end up in the first header.
+		if _, err := buffered.Discard(len(utf8BOM)); err != nil {
+			return nil, fmt.Errorf("failed to strip byte order mark: %w", err)
+		}
+		return buffered, nil
+	}
+
+	if charset == EncodingAuto {
+		charset = detectCharset(head)
+		if charset == EncodingUTF8 && len(head) == encodingSniffLen {
+			return &utf8Checker{r: buffered}, nil
+		}
+	}
+
+	enc, ok := knownEncodings[charset]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding '%s'", charset)
+	}
+	if enc == nil {
+		return buffered, nil
+	}
+	return transform.NewReader(buffered, enc.NewDecoder()), nil
+}
+
+// detectCharset guesses the charset of the leading bytes of a file.
+func detectCharset(head []byte) string {
+	if len(head) == encodingSniffLen {
+		// The sniff window may have cut a multi-byte rune in half; drop the last rune before checking.
+		cut := len(head) - 1
+		for cut > 0 && len(head)-cut < utf8.UTFMax && !utf8.RuneStart(head[cut]) {
+			cut--
+		}
+		head = head[:cut]
+	}
+	if utf8.Valid(head) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// utf8Checker passes through a file auto-detected as UTF-8 from its first encodingSniffLen bytes,
+// failing on the first invalid byte rather than letting mis-encoded text into the ingested items.
+type utf8Checker struct {
+	r io.Reader
+	// offset is how many bytes have been checked and returned.
+	offset int64
+	// partial holds the start of a rune split across reads, already returned but not yet checked.
+	partial []byte
+}
+
+func (c *utf8Checker) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	data := append(c.partial, p[:n]...)
+	end := len(data)
+	if err == nil {
+		end = completeRunesLen(data)
+	}
+	for i := 0; i < end; {
+		r, size := utf8.DecodeRune(data[i:end])
+		if r == utf8.RuneError && size <= 1 {
+			return 0, fmt.Errorf("file is not valid UTF-8 at byte %d: its encoding was detected from the first %dKB only; set encoding to '%s' or '%s' in the ingestion config",
+				c.offset+int64(i), encodingSniffLen/1024, EncodingWindows1252, EncodingLatin1)
+		}
+		i += size
+	}
+	c.offset += int64(end)
+	c.partial = bytes.Clone(data[end:])
+	return n, err
+}
+
+// completeRunesLen returns the length of data without a multi-byte rune cut off at its end.
+func completeRunesLen(data []byte) int {
+	for start := len(data) - 1; start >= 0 && len(data)-start < utf8.UTFMax; start-- {
+		if utf8.RuneStart(data[start]) {
+			if !utf8.FullRune(data[start:]) {
+				return start
+			}
+			break
+		}
+	}
+	return len(data)
+}
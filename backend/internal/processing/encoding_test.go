@@ -0,0 +1,61 @@
+package processing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUTF8Reader(t *testing.T) {
+	read := func(t *testing.T, file io.Reader, charset string) (string, error) {
+		t.Helper()
+		reader, err := NewUTF8Reader(file, charset)
+		require.NoError(t, err)
+		text, err := io.ReadAll(reader)
+		return string(text), err
+	}
+	// utf8Head fills the sniff window with UTF-8 whose last rune straddles the end of the window.
+	utf8Head := strings.Repeat("a", encodingSniffLen-1) + "é"
+
+	t.Run("Decodes Windows-1252 detected in the sniff window", func(t *testing.T) {
+		text, err := read(t, strings.NewReader("claim_id,note\nC-1,caf\xe9\n"), EncodingAuto)
+		require.NoError(t, err)
+		assert.Equal(t, "claim_id,note\nC-1,café\n", text)
+	})
+
+	t.Run("Strips a UTF-8 byte order mark", func(t *testing.T) {
+		text, err := read(t, strings.NewReader("\xEF\xBB\xBFclaim_id\n"), "")
+		require.NoError(t, err)
+		assert.Equal(t, "claim_id\n", text)
+	})
+
+	t.Run("Reads UTF-8 past the sniff window", func(t *testing.T) {
+		text, err := read(t, iotest.OneByteReader(strings.NewReader(utf8Head+"ü€\n")), EncodingAuto)
+		require.NoError(t, err)
+		assert.Equal(t, utf8Head+"ü€\n", text)
+	})
+
+	t.Run("Fails on invalid UTF-8 past the sniff window", func(t *testing.T) {
+		file := utf8Head + "caf\xe9\n"
+		_, err := read(t, iotest.OneByteReader(strings.NewReader(file)), EncodingAuto)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file is not valid UTF-8 at byte 65540")
+		assert.Contains(t, err.Error(), "set encoding to 'windows-1252'")
+	})
+
+	t.Run("Fails on a rune cut off at the end of the file", func(t *testing.T) {
+		_, err := read(t, bytes.NewReader([]byte(utf8Head+"\xe2\x82")), EncodingAuto)
+		assert.ErrorContains(t, err, "file is not valid UTF-8 at byte 65537")
+	})
+
+	t.Run("Leaves a configured encoding unchecked", func(t *testing.T) {
+		text, err := read(t, strings.NewReader(utf8Head+"caf\xe9"), EncodingWindows1252)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(text, "café"))
+	})
+}
@@ -0,0 +1,107 @@
+package processing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// processFixedWidth handles fixed-width files, where each line is one record and every mapping reads
+// the Length characters starting at its 1-based Start. Positions count characters of the decoded
+// UTF-8 text rather than bytes, so a multi-byte character occupies one position, as it does in the
+// single-byte encodings these feeds usually come in. Field values are trimmed of their padding, and
+// a field past the end of a short line is empty.
+func (p *GenericProcessor) processFixedWidth(
+	ctx context.Context,
+	file io.Reader,
+	queries repository.Querier,
+	embedder interfaces.EmbedderFunc,
+) (*ProcessingResult, error) {
+	result := &ProcessingResult{}
+
+	scopeJSONFields, err := p.scopeJSONFields()
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(file)
+	if p.config.HeaderRowOffset > 0 {
+		if err := skipLines(buffered, p.config.HeaderRowOffset); err != nil {
+			return nil, err
+		}
+	}
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineBytes)
+
+	var pendingEmbeddings []pendingEmbeddingRow
+	lineNum := p.config.HeaderRowOffset
+	dataLines := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("processing stopped after %d lines: %w", lineNum, err)
+		}
+		lineNum++
+		line := []rune(strings.TrimRight(scanner.Text(), "\r"))
+		if strings.TrimSpace(string(line)) == "" {
+			result.BlankRowsDiscarded++
+			continue
+		}
+		dataLines++
+
+		originalRecord := make(map[string]string, len(p.config.ColumnMappings))
+		for _, mapping := range p.config.ColumnMappings {
+			originalRecord[mapping.CSVHeader] = fixedWidthField(line, mapping)
+		}
+
+		processedData, err := p.processValues(ctx, func(mapping ColumnMapping) string {
+			return originalRecord[mapping.CSVHeader]
+		}, queries)
+		if err != nil {
+			result.TriageRows = append(result.TriageRows, TriageRow{
+				OriginalRecord: originalRecord,
+				FailureReason:  err.Error(),
+			})
+			continue
+		}
+
+		pending, err := p.addRowItems(ctx, processedData, originalRecord, lineNum, scopeJSONFields, embedder, result)
+		pendingEmbeddings = append(pendingEmbeddings, pending...)
+		if err != nil {
+			return result, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read fixed-width file at line %d: %w", lineNum+1, err)
+	}
+	if dataLines == 0 {
+		return result, ErrNoDataRows
+	}
+
+	p.retryPendingEmbeddings(ctx, pendingEmbeddings, scopeJSONFields, embedder, result)
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("processing stopped while retrying failed embeddings: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Processing complete",
+		"successful_items", len(result.SuccessfulItems),
+		"triage_rows", len(result.TriageRows),
+		"blank_rows_discarded", result.BlankRowsDiscarded,
+	)
+	return result, nil
+}
+
+// fixedWidthField returns the trimmed value of mapping's positions on line.
+func fixedWidthField(line []rune, mapping ColumnMapping) string {
+	start := mapping.Start - 1
+	if start >= len(line) {
+		return ""
+	}
+	end := min(start+mapping.Length, len(line))
+	return strings.TrimSpace(string(line[start:end]))
+}
@@ -0,0 +1,97 @@
+package processing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFixedWidthTestConfig lays out claim_id in columns 1-6, description in 7-18, region in 19-24
+// and amount in 25-30.
+func newFixedWidthTestConfig() IngestionConfig {
+	config := newProcessTestConfig()
+	config.Format = FormatFixedWidth
+	config.ColumnMappings[0].Start, config.ColumnMappings[0].Length = 1, 6
+	config.ColumnMappings[1].Start, config.ColumnMappings[1].Length = 7, 12
+	config.ColumnMappings[1].MergeExcessFields = false
+	config.ColumnMappings[2].Start, config.ColumnMappings[2].Length = 19, 6
+	config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{
+		CSVHeader: "amount",
+		JSONField: "amount",
+		Start:     25,
+		Length:    6,
+		Attempts: []ProcessingAttempt{
+			{Transforms: []string{"to_integer"}},
+		},
+	})
+	return config
+}
+
+func TestProcessFixedWidth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Reads fields from their positions", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		data := "C-1   Water damagewest    1500\r\n" +
+			"      \n" +
+			"C-2   Hail        east\n"
+
+		config := newFixedWidthTestConfig()
+		require.NoError(t, config.Validate())
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(data), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 2)
+		assert.Empty(t, result.TriageRows)
+		assert.Equal(t, 1, result.BlankRowsDiscarded)
+
+		assert.Equal(t, "C-1-WEST", result.SuccessfulItems[0].BusinessKey.String)
+		assert.JSONEq(t, `{"claim_id": "C-1", "description": "Water damage", "region": "WEST", "amount": 1500}`, string(result.SuccessfulItems[0].CustomProperties))
+		assert.JSONEq(t, `{"claim_id": "C-2", "description": "Hail", "region": "EAST", "amount": null}`, string(result.SuccessfulItems[1].CustomProperties),
+			"a field past the end of a short line is empty")
+		assert.Equal(t, []string{"Water damage", "Hail"}, embedder.texts)
+	})
+
+	t.Run("Reports a file without data lines", func(t *testing.T) {
+		config := newFixedWidthTestConfig()
+		config.HeaderRowOffset = 1
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader("CLAIM DESCRIPTION REGION AMOUNT\n      \n"), &mockQuerier{}, nil)
+		require.ErrorIs(t, err, ErrNoDataRows)
+		assert.Empty(t, result.SuccessfulItems)
+		assert.Equal(t, 1, result.BlankRowsDiscarded)
+	})
+
+	t.Run("Counts positions in characters", func(t *testing.T) {
+		data := "C-3   Dégât d'eau south  200\n"
+
+		result, err := NewGenericProcessor(newFixedWidthTestConfig()).Process(ctx, strings.NewReader(data), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.JSONEq(t, `{"claim_id": "C-3", "description": "Dégât d'eau", "region": "SOUTH", "amount": 200}`, string(result.SuccessfulItems[0].CustomProperties))
+	})
+
+	t.Run("Triages lines that fail validation with their fields", func(t *testing.T) {
+		data := "title line\n      Fire        north    12\n"
+		config := newFixedWidthTestConfig()
+		config.HeaderRowOffset = 1
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(data), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Equal(t, map[string]string{"claim_id": "", "description": "Fire", "region": "north", "amount": "12"}, result.TriageRows[0].OriginalRecord)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "validation failed for column 'claim_id'")
+	})
+
+	t.Run("Requires positions on every column", func(t *testing.T) {
+		config := newFixedWidthTestConfig()
+		config.ColumnMappings[3].Length = 0
+		assert.ErrorContains(t, config.Validate(), "column 'amount' needs a start of at least 1 and a positive length")
+
+		config = newProcessTestConfig()
+		config.ColumnMappings[0].Start = 1
+		assert.ErrorContains(t, config.Validate(), "start and length on column 'claim_id' require format 'fixed_width'")
+	})
+}
@@ -0,0 +1,51 @@
+package processing
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// File formats DetectFormat can identify, and the formats a file is processed as. csv, jsonl and
+// fixed_width files can be processed, but only the detectable csv and jsonl can be declared in an
+// IngestionConfig's accepted_formats; xlsx and gz are detected so an upload of either is rejected
+// with a clear format name.
+const (
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+	FormatGzip  = "gz"
+	FormatJSONL = "jsonl"
+	// FormatFixedWidth is a processing format only: fixed-width files are plain text, which
+	// DetectFormat reports as csv, so they are accepted on upload as csv.
+	FormatFixedWidth = "fixed_width"
+)
+
+var knownFormats = map[string]bool{
+	FormatCSV:   true,
+	FormatJSONL: true,
+}
+
+// SniffLen is the number of leading bytes DetectFormat needs to identify a file.
+const SniffLen = 512
+
+// DetectFormat inspects the leading bytes of a file and returns the matching format name
+// (csv, xlsx, gz, jsonl), or the sniffed MIME type when the content is not a supported format.
+func DetectFormat(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return FormatGzip
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// xlsx workbooks are zip archives.
+		return FormatXLSX
+	}
+
+	if bytes.HasPrefix(bytes.TrimLeft(head, " \t\r\n\ufeff"), []byte("{")) {
+		return FormatJSONL
+	}
+
+	contentType := http.DetectContentType(head)
+	if strings.HasPrefix(contentType, "text/plain") || strings.HasPrefix(contentType, "text/csv") {
+		return FormatCSV
+	}
+	return contentType
+}
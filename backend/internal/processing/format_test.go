@@ -0,0 +1,45 @@
+package processing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptedFormats(t *testing.T) {
+	t.Run("Defaults to the format the config processes", func(t *testing.T) {
+		config := newProcessTestConfig()
+		assert.Equal(t, []string{FormatCSV}, config.ExpectedFormats())
+
+		config.Format = FormatFixedWidth
+		assert.Equal(t, []string{FormatCSV}, config.ExpectedFormats(), "fixed-width files are detected as csv")
+
+		config.Format = FormatJSONL
+		assert.Equal(t, []string{FormatJSONL}, config.ExpectedFormats())
+	})
+
+	t.Run("Rejects gzip and xlsx uploads, which cannot be processed", func(t *testing.T) {
+		config := newProcessTestConfig()
+		assert.False(t, config.AcceptsFormat(DetectFormat([]byte{0x1f, 0x8b, 0x08, 0x00})))
+		assert.False(t, config.AcceptsFormat(DetectFormat([]byte("PK\x03\x04"))))
+
+		config.AcceptedFormats = []string{FormatGzip}
+		assert.ErrorContains(t, config.Validate(), "accepted_formats contains unknown format 'gz'")
+		config.AcceptedFormats = []string{FormatXLSX}
+		assert.ErrorContains(t, config.Validate(), "accepted_formats contains unknown format 'xlsx'")
+	})
+
+	t.Run("Rejects formats the config's format cannot process", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.AcceptedFormats = []string{FormatCSV}
+		require.NoError(t, config.Validate())
+
+		config.AcceptedFormats = []string{FormatCSV, FormatJSONL}
+		assert.ErrorContains(t, config.Validate(), "accepted_formats contains 'jsonl', which format 'csv' cannot process")
+
+		config = newJSONLTestConfig()
+		config.AcceptedFormats = []string{FormatCSV}
+		assert.ErrorContains(t, config.Validate(), "accepted_formats contains 'csv', which format 'jsonl' cannot process")
+	})
+}
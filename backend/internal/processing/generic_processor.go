@@ -1,12 +1,15 @@
 package processing
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -16,19 +19,26 @@ import (
 )
 
 // Processor defines the standard interface for any processor.
+// The file is any io.Reader and the embedder is injected, so callers (and tests) can drive
+// the full pipeline with in-memory CSV data and a fake embedder.
 type Processor interface {
 	Process(
 		ctx context.Context,
 		file io.Reader,
 		queries repository.Querier,
+		embedder interfaces.EmbedderFunc,
 	) (*ProcessingResult, error)
 }
 
+var _ Processor = (*GenericProcessor)(nil)
+
 // ProcessingResult holds the outcome of a file processing operation
 type ProcessingResult struct {
 	SuccessfulItems    []repository.Item
 	TriageRows         []TriageRow
 	BlankRowsDiscarded int
+	// SavedItems is how many of SuccessfulItems, from the start, were already saved in batches.
+	SavedItems int
 }
 
 // TriageRow represents a row that failed processing and needs human review
@@ -37,16 +47,88 @@ type TriageRow struct {
 	FailureReason  string            `json:"failure_reason"`
 }
 
+// ErrNoDataRows is returned when a file has no records to ingest: a CSV file with a header row but
+// no records after it, or a JSONL or fixed-width file with only blank lines. Such an upload is
+// reported instead of completing with zero items.
+var ErrNoDataRows = errors.New("file contained no data rows")
+
+// HeaderMismatchError is returned when a CSV file lacks headers required by the config.
+// Its message lists the expected and actual headers so analysts can spot typos or a wrong file.
+type HeaderMismatchError struct {
+	Missing  []string `json:"missing"`
+	Expected []string `json:"expected"`
+	Actual   []string `json:"actual"`
+}
+
+func (e *HeaderMismatchError) Error() string {
+	return fmt.Sprintf("configuration error: CSV file is missing required header(s) %s; expected headers: %s; actual headers: %s",
+		quoteList(e.Missing), quoteList(e.Expected), quoteList(e.Actual))
+}
+
+// ColumnErrors holds every column that failed its transforms or validation in one row, reported
+// together when collect_row_errors is set so an analyst can fix the whole row at once.
+type ColumnErrors []error
+
+func (e ColumnErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d columns failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e ColumnErrors) Unwrap() []error {
+	return e
+}
+
+// DuplicateHeaderError is returned when a CSV file repeats a mapped header and the config does not
+// number duplicates. Keeping either column would silently drop the other's data, so the file is
+// rejected. Repeated headers that no column mapping reads are ignored.
+type DuplicateHeaderError struct {
+	Duplicates []string `json:"duplicates"`
+}
+
+func (e *DuplicateHeaderError) Error() string {
+	return fmt.Sprintf("configuration error: CSV file has duplicate header(s) %s; rename the columns or set duplicate_headers: %s in the ingestion config",
+		quoteList(e.Duplicates), DuplicateHeadersNumber)
+}
+
 // GenericProcessor uses an IngestionConfig to process a CSV file
 type GenericProcessor struct {
-	config IngestionConfig
+	config    IngestionConfig
+	batchSize int
+	saveBatch BatchSaver
 }
 
+// BatchSaver persists a batch of processed items while the rest of the file is still being processed.
+type BatchSaver func(ctx context.Context, items []repository.Item) error
+
 // NewGenericProcessor creates a new processor with a specific configuration
 func NewGenericProcessor(config IngestionConfig) *GenericProcessor {
 	return &GenericProcessor{config: config}
 }
 
+// SaveInBatches makes Process hand every batchSize successful items to save as soon as they are built,
+// so a late failure keeps the work already done. Items left over when processing ends are not saved;
+// the caller saves result.SuccessfulItems[result.SavedItems:] itself.
+func (p *GenericProcessor) SaveInBatches(batchSize int, save BatchSaver) {
+	p.batchSize = batchSize
+	p.saveBatch = save
+}
+
+// flushBatch saves the unsaved items once a full batch of them has built up.
+func (p *GenericProcessor) flushBatch(ctx context.Context, result *ProcessingResult) error {
+	if p.saveBatch == nil || len(result.SuccessfulItems)-result.SavedItems < p.batchSize {
+		return nil
+	}
+	batch := result.SuccessfulItems[result.SavedItems:]
+	if err := p.saveBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save batch of %d items: %w", len(batch), err)
+	}
+	result.SavedItems += len(batch)
+	return nil
+}
+
 // Process is the main entry point that executes the entire ingestion logic
 func (p *GenericProcessor) Process(
 	ctx context.Context,
@@ -54,6 +136,26 @@ func (p *GenericProcessor) Process(
 	queries repository.Querier,
 	embedder interfaces.EmbedderFunc,
 ) (*ProcessingResult, error) {
+	file, err := NewUTF8Reader(file, p.config.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.config.Format {
+	case FormatJSONL:
+		return p.processJSONL(ctx, file, queries, embedder)
+	case FormatFixedWidth:
+		return p.processFixedWidth(ctx, file, queries, embedder)
+	}
+
+	if p.config.HeaderRowOffset > 0 {
+		buffered := bufio.NewReader(file)
+		if err := skipLines(buffered, p.config.HeaderRowOffset); err != nil {
+			return nil, err
+		}
+		file = buffered
+	}
+
 	result := &ProcessingResult{}
 	csvReader := csv.NewReader(file)
 	csvReader.TrimLeadingSpace = true
@@ -64,24 +166,34 @@ func (p *GenericProcessor) Process(
 		return nil, fmt.Errorf("error reading header row: %w", err)
 	}
 
+	headers, err = p.dedupeHeaders(headers)
+	if err != nil {
+		return nil, err
+	}
+
 	headerMap := make(map[string]int)
 	for i, h := range headers {
-		headerMap[strings.TrimSpace(h)] = i
+		headerMap[p.headerKey(h)] = i
 	}
 
-	// ADD THIS BLOCK: Fail-fast on configuration errors.
+	// Fail-fast on configuration errors, reporting every missing header at once.
+	var missingHeaders, expectedHeaders []string
 	for _, mapping := range p.config.ColumnMappings {
-		if _, ok := headerMap[mapping.CSVHeader]; !ok {
-			return nil, fmt.Errorf("configuration error: CSV file is missing required header '%s'", mapping.CSVHeader)
+		expectedHeaders = append(expectedHeaders, mapping.CSVHeader)
+		if _, ok := headerMap[p.headerKey(mapping.CSVHeader)]; !ok {
+			missingHeaders = append(missingHeaders, mapping.CSVHeader)
 		}
 	}
+	if len(missingHeaders) > 0 {
+		return nil, &HeaderMismatchError{Missing: missingHeaders, Expected: expectedHeaders, Actual: headers}
+	}
 
 	numHeaders := len(headers)
 
 	mergeColumnIndex := -1
 	for _, mapping := range p.config.ColumnMappings {
 		if mapping.MergeExcessFields {
-			if idx, ok := headerMap[mapping.CSVHeader]; ok {
+			if idx, ok := headerMap[p.headerKey(mapping.CSVHeader)]; ok {
 				mergeColumnIndex = idx
 				break // assume only one column can be merge target
 			}
@@ -92,20 +204,20 @@ func (p *GenericProcessor) Process(
 	if err != nil {
 		return nil, fmt.Errorf("failed to read all CSV records: %w", err)
 	}
-
-	var scopeJSONField string
-	for _, mapping := range p.config.ColumnMappings {
-		if mapping.CSVHeader == p.config.ScopeField {
-			scopeJSONField = mapping.JSONField
-			break
-		}
+	if len(allRecords) == 0 {
+		return result, ErrNoDataRows
 	}
-	if scopeJSONField == "" {
-		return nil, fmt.Errorf("config validation error: could not find a column mapping for the specified scope_field '%s'", p.config.ScopeField)
+
+	scopeJSONFields, err := p.scopeJSONFields()
+	if err != nil {
+		return nil, err
 	}
 
-RecordLoop:
+	var pendingEmbeddings []pendingEmbeddingRow
 	for i, record := range allRecords {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("processing stopped after %d of %d rows: %w", i, len(allRecords), err)
+		}
 		if len(record) > numHeaders && mergeColumnIndex != -1 {
 			numExtraFields := len(record) - numHeaders
 
@@ -124,9 +236,9 @@ RecordLoop:
 		if len(record) != numHeaders {
 			result.TriageRows = append(result.TriageRows, TriageRow{
 				OriginalRecord: createOriginalRecordMap(record, headers),
-				FailureReason:  fmt.Sprintf("Row as %d fields, but header has %d. Triage required.", len(record), numHeaders),
+				FailureReason:  fmt.Sprintf("Row has %d fields, but header has %d. Triage required.", len(record), numHeaders),
 			})
-			continue RecordLoop // skip to next record
+			continue // skip to next record
 		}
 
 		if isRowBlank(record) {
@@ -143,105 +255,368 @@ RecordLoop:
 			continue
 		}
 
-		var embedding pgvector.Vector
-		if p.config.EmbedContent != nil && embedder != nil {
+		pending, err := p.addRowItems(ctx, processedData, createOriginalRecordMap(record, headers), p.config.HeaderRowOffset+i+2, scopeJSONFields, embedder, result)
+		pendingEmbeddings = append(pendingEmbeddings, pending...)
+		if err != nil {
+			return result, err
+		}
+	}
 
-			var textToEmbedBuilder strings.Builder
-			for _, colName := range p.config.EmbedContent.SourceColumns {
-				if val, ok := processedData[colName]; ok {
-					textToEmbedBuilder.WriteString(fmt.Sprintf("%v ", val))
-				}
+	p.retryPendingEmbeddings(ctx, pendingEmbeddings, scopeJSONFields, embedder, result)
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("processing stopped while retrying failed embeddings: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Processing complete",
+		"successful_items", len(result.SuccessfulItems),
+		"triage_rows", len(result.TriageRows),
+		"blank_rows_discarded", result.BlankRowsDiscarded,
+	)
+	return result, nil
+}
+
+// addRowItems builds the items for one row's processed data and adds them to result, one item per
+// chunk when chunking is configured. Items whose embedding failed are returned to be retried at the
+// end; any other failure sends the whole row to triage. The error is only set when saving a batch fails.
+func (p *GenericProcessor) addRowItems(
+	ctx context.Context,
+	processedData map[string]interface{},
+	originalRecord map[string]string,
+	rowNum int,
+	scopeJSONFields []string,
+	embedder interfaces.EmbedderFunc,
+	result *ProcessingResult,
+) ([]pendingEmbeddingRow, error) {
+	chunks, err := p.chunkRow(processedData)
+	if err != nil {
+		result.TriageRows = append(result.TriageRows, TriageRow{
+			OriginalRecord: originalRecord,
+			FailureReason:  fmt.Sprintf("Row %d: %s", rowNum, err.Error()),
+		})
+		return nil, nil
+	}
+
+	var items []repository.Item
+	var pending []pendingEmbeddingRow
+	for _, data := range chunks {
+		item, err := p.buildItem(ctx, data, scopeJSONFields, rowNum, embedder)
+		if err != nil {
+			var embedErr *embeddingError
+			if errors.As(err, &embedErr) {
+				// Embedding failures are usually transient; retry them in a batch at the end.
+				pending = append(pending, pendingEmbeddingRow{
+					processedData:  data,
+					originalRecord: originalRecord,
+					rowNum:         rowNum,
+					lastErr:        err,
+				})
+				continue
 			}
-			textToEmbed := strings.TrimSpace(textToEmbedBuilder.String())
-
-			if textToEmbed != "" {
-				slog.Debug("Generating embedding for text", "text", textToEmbed)
-				embeddingVector, err := embedder(ctx, textToEmbed)
-				if err != nil {
-					triageRow := TriageRow{
-						OriginalRecord: createOriginalRecordMap(record, headers),
-						FailureReason:  fmt.Sprintf("Row %d: failed to generate embedding: %s", i+2, err.Error()),
-					}
-					result.TriageRows = append(result.TriageRows, triageRow)
-					continue
-				}
-				embedding = pgvector.NewVector(embeddingVector)
+			result.TriageRows = append(result.TriageRows, TriageRow{
+				OriginalRecord: originalRecord,
+				FailureReason:  err.Error(),
+			})
+			return nil, nil
+		}
+		items = append(items, item)
+	}
+
+	for _, item := range items {
+		result.SuccessfulItems = append(result.SuccessfulItems, item)
+		if err := p.flushBatch(ctx, result); err != nil {
+			return pending, err
+		}
+	}
+	return pending, nil
+}
+
+// buildItem turns a row's processed data into an item: it attaches the geo point, chunk metadata and embedding,
+// then assembles scope and business key. rowNum is the 1-based line number used in failure messages.
+func (p *GenericProcessor) buildItem(ctx context.Context, processedData map[string]interface{}, scopeJSONFields []string, rowNum int, embedder interfaces.EmbedderFunc) (repository.Item, error) {
+	if p.config.GeoPoint != nil {
+		if point := buildGeoPoint(processedData, p.config.GeoPoint); point != nil {
+			processedData[p.config.GeoPoint.JSONField] = point
+		}
+	}
 
+	if p.config.ChunkMetadata != nil {
+		chunkMetadata := make(map[string]interface{}, len(p.config.ChunkMetadata.Fields))
+		for key, field := range p.config.ChunkMetadata.Fields {
+			if val, ok := processedData[field]; ok && val != nil {
+				chunkMetadata[key] = val
 			}
 		}
+		processedData[ChunkMetadataField] = chunkMetadata
+	}
 
-		customPropsJSON, err := json.Marshal(processedData)
+	var embeddings itemEmbeddings
+	if p.config.EmbedContent != nil && embedder != nil {
+		var err error
+		embeddings, err = embedItem(ctx, processedData, p.config.EmbedContent, embedder)
 		if err != nil {
-			result.TriageRows = append(result.TriageRows, TriageRow{
-				OriginalRecord: createOriginalRecordMap(record, headers),
-				FailureReason:  fmt.Sprintf("Row %d: failed to marshal processed data to JSON: %s", i+2, err.Error()),
-			})
-			continue
+			return repository.Item{}, &embeddingError{rowNum: rowNum, err: err}
 		}
+	}
 
-		scopeVal, ok := processedData[scopeJSONField]
-		if !ok || scopeVal == nil {
-			result.TriageRows = append(result.TriageRows, TriageRow{
-				OriginalRecord: createOriginalRecordMap(record, headers),
-				FailureReason:  fmt.Sprintf("scope field '%s' is missing or nil", scopeJSONField),
-			})
-			continue
+	customPropsJSON, err := json.Marshal(p.customProperties(processedData))
+	if err != nil {
+		return repository.Item{}, fmt.Errorf("Row %d: failed to marshal processed data to JSON: %s", rowNum, err.Error())
+	}
+
+	scopeString, err := buildScope(processedData, scopeJSONFields)
+	if err != nil {
+		return repository.Item{}, err
+	}
+
+	// Build the business key; if any part is missing, the whole row is triaged once.
+	var businessKeyParts []string
+	for _, field := range p.config.BusinessKey {
+		val, ok := processedData[field]
+		if !ok || val == nil {
+			return repository.Item{}, fmt.Errorf("business key field '%s' is missing or nil", field)
 		}
+		businessKeyParts = append(businessKeyParts, fmt.Sprintf("%v", val))
+	}
 
-		scopeString, ok := scopeVal.(string)
-		if !ok {
-			result.TriageRows = append(result.TriageRows, TriageRow{
-				OriginalRecord: createOriginalRecordMap(record, headers),
-				FailureReason:  fmt.Sprintf("scope field '%s' is not a string", scopeJSONField),
-			})
-			continue
+	item := repository.Item{
+		ItemType:         repository.ItemType(p.config.ItemType),
+		Scope:            pgtype.Text{String: scopeString, Valid: true},
+		BusinessKey:      pgtype.Text{String: strings.Join(businessKeyParts, "-"), Valid: true},
+		Status:           "active",
+		CustomProperties: customPropsJSON,
+		Embedding:        embeddings.combined,
+		TitleEmbedding:   embeddings.title,
+		BodyEmbedding:    embeddings.body,
+	}
+	return item, nil
+}
+
+// itemEmbeddings holds the vectors built from an item's embed_content columns. A vector is empty
+// when none of its columns have a value.
+type itemEmbeddings struct {
+	combined, title, body pgvector.Vector
+}
+
+// embedItem builds the combined embedding and each named embedding configured in embedContent.
+func embedItem(ctx context.Context, processedData map[string]interface{}, embedContent *EmbedContent, embedder interfaces.EmbedderFunc) (itemEmbeddings, error) {
+	var embeddings itemEmbeddings
+	var err error
+	combinedText, err := embedContent.combinedText(processedData)
+	if err != nil {
+		return itemEmbeddings{}, err
+	}
+	embeddings.combined, err = embedText(ctx, combinedText, embedder)
+	if err != nil {
+		return itemEmbeddings{}, err
+	}
+	for _, field := range embedContent.Fields {
+		vector, err := embedColumns(ctx, processedData, field.SourceColumns, embedder)
+		if err != nil {
+			return itemEmbeddings{}, fmt.Errorf("%s embedding: %w", field.Name, err)
+		}
+		switch field.Name {
+		case EmbeddingFieldTitle:
+			embeddings.title = vector
+		case EmbeddingFieldBody:
+			embeddings.body = vector
 		}
+	}
+	return embeddings, nil
+}
 
-		// Build the business key, and if any part is missing, triage the row ONCE and move to the next record.
-		var businessKeyParts []string
-		for _, field := range p.config.BusinessKey {
-			val, ok := processedData[field]
-			if !ok || val == nil {
-				result.TriageRows = append(result.TriageRows, TriageRow{
-					OriginalRecord: createOriginalRecordMap(record, headers),
-					FailureReason:  fmt.Sprintf("business key field '%s' is missing or nil", field),
-				})
-				continue RecordLoop // This is the key change to prevent multiple errors for one row
+// embedColumns embeds the values of columns joined by spaces. It returns an empty vector when none
+// of the columns have a value.
+func embedColumns(ctx context.Context, processedData map[string]interface{}, columns []string, embedder interfaces.EmbedderFunc) (pgvector.Vector, error) {
+	return embedText(ctx, columnsText(processedData, columns), embedder)
+}
+
+// columnsText joins the values of columns by spaces, skipping columns that are absent.
+func columnsText(processedData map[string]interface{}, columns []string) string {
+	var textToEmbedBuilder strings.Builder
+	for _, colName := range columns {
+		if val, ok := processedData[colName]; ok {
+			textToEmbedBuilder.WriteString(fmt.Sprintf("%v ", val))
+		}
+	}
+	return strings.TrimSpace(textToEmbedBuilder.String())
+}
+
+// embedText embeds text, returning an empty vector when it is blank.
+func embedText(ctx context.Context, textToEmbed string, embedder interfaces.EmbedderFunc) (pgvector.Vector, error) {
+	textToEmbed = strings.TrimSpace(textToEmbed)
+	if textToEmbed == "" {
+		return pgvector.Vector{}, nil
+	}
+
+	slog.Debug("Generating embedding for text", "text", textToEmbed)
+	embeddingVector, err := embedder(ctx, textToEmbed)
+	if err != nil {
+		return pgvector.Vector{}, err
+	}
+	return pgvector.NewVector(embeddingVector), nil
+}
+
+// customProperties returns the data to store as an item's custom_properties, with nil values written
+// as configured by empty_values. processedData itself keeps its nils, so a missing business key or
+// scope field is still reported as missing.
+func (p *GenericProcessor) customProperties(processedData map[string]interface{}) map[string]interface{} {
+	if p.config.EmptyValues == "" || p.config.EmptyValues == EmptyValuesNull {
+		return processedData
+	}
+	props := make(map[string]interface{}, len(processedData))
+	for key, val := range processedData {
+		switch {
+		case val != nil:
+			props[key] = val
+		case p.config.EmptyValues == EmptyValuesEmpty:
+			props[key] = ""
+		}
+	}
+	return props
+}
+
+// headerKey returns the key used to match a header against the header map. In strict mode this is
+// the trimmed header; in normalized mode case, underscores and repeated spaces are ignored.
+func (p *GenericProcessor) headerKey(header string) string {
+	if p.config.HeaderMatching == HeaderMatchingNormalized {
+		return normalizeHeader(header)
+	}
+	return strings.TrimSpace(header)
+}
+
+// skipLines discards the n lines before the header row, such as a vendor's title or metadata block.
+// The lines are skipped as raw text, so they need not be valid CSV.
+func skipLines(r *bufio.Reader, n int) error {
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if line != "" {
+					i++
+				}
+				return fmt.Errorf("configuration error: header_row_offset skips %d lines, but the file has only %d", n, i)
 			}
-			businessKeyParts = append(businessKeyParts, fmt.Sprintf("%v", val))
+			return fmt.Errorf("error skipping line %d before the header row: %w", i+1, err)
 		}
+	}
+	if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+		return fmt.Errorf("configuration error: header_row_offset skips %d lines, leaving no header row", n)
+	}
+	return nil
+}
 
-		item := repository.Item{
-			ItemType:         repository.ItemType(p.config.ItemType),
-			Scope:            pgtype.Text{String: scopeString, Valid: true},
-			BusinessKey:      pgtype.Text{String: strings.Join(businessKeyParts, "-"), Valid: true},
-			Status:           "active",
-			CustomProperties: customPropsJSON,
-			Embedding:        embedding,
+// dedupeHeaders checks that no two headers match the same key. When duplicate_headers is "number",
+// later occurrences of a repeated header get the first free _2, _3... suffix. Otherwise repeating a
+// header a column mapping reads is an error, and other repeated headers are left as they are.
+func (p *GenericProcessor) dedupeHeaders(headers []string) ([]string, error) {
+	seen := make(map[string]bool, len(headers))
+	var duplicates []string
+	for _, h := range headers {
+		key := p.headerKey(h)
+		if seen[key] && !slices.Contains(duplicates, h) {
+			duplicates = append(duplicates, h)
 		}
-		result.SuccessfulItems = append(result.SuccessfulItems, item)
+		seen[key] = true
+	}
+	if len(duplicates) == 0 {
+		return headers, nil
+	}
+	if p.config.DuplicateHeaders != DuplicateHeadersNumber {
+		mapped := make(map[string]bool, len(p.config.ColumnMappings))
+		for _, mapping := range p.config.ColumnMappings {
+			mapped[p.headerKey(mapping.CSVHeader)] = true
+		}
+		duplicates = slices.DeleteFunc(duplicates, func(h string) bool { return !mapped[p.headerKey(h)] })
+		if len(duplicates) > 0 {
+			return nil, &DuplicateHeaderError{Duplicates: duplicates}
+		}
+		return headers, nil
 	}
 
-	slog.InfoContext(ctx, "Processing complete",
-		"successful_items", len(result.SuccessfulItems),
-		"triage_rows", len(result.TriageRows),
-		"blank_rows_discarded", result.BlankRowsDiscarded,
-	)
-	return result, nil
+	// Every header's own name is taken first, so a generated name never shadows a real column.
+	renamed := make([]string, len(headers))
+	kept := make(map[string]bool, len(headers))
+	for i, h := range headers {
+		key := p.headerKey(h)
+		if !kept[key] {
+			kept[key] = true
+			renamed[i] = h
+			continue
+		}
+		name := h
+		for n := 2; seen[p.headerKey(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", strings.TrimSpace(h), n)
+		}
+		seen[p.headerKey(name)] = true
+		renamed[i] = name
+	}
+	return renamed, nil
+}
+
+// scopeJSONFields returns the json_fields that the configured scope_field headers map to, in order.
+func (p *GenericProcessor) scopeJSONFields() ([]string, error) {
+	fields := make([]string, 0, len(p.config.ScopeField))
+	for _, header := range p.config.ScopeField {
+		found := false
+		for _, mapping := range p.config.ColumnMappings {
+			if mapping.CSVHeader == header {
+				fields = append(fields, mapping.JSONField)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("config validation error: could not find a column mapping for the specified scope_field '%s'", header)
+		}
+	}
+	return fields, nil
+}
+
+// buildScope resolves the scope fields of a row and joins them with ScopeSeparator. A value that
+// contains the separator would read as a nested scope, widening who can see the item, so it is
+// rejected whether the scope has one field or several.
+func buildScope(processedData map[string]interface{}, scopeJSONFields []string) (string, error) {
+	parts := make([]string, 0, len(scopeJSONFields))
+	for _, field := range scopeJSONFields {
+		val, ok := processedData[field]
+		if !ok || val == nil {
+			return "", fmt.Errorf("scope field '%s' is missing or nil", field)
+		}
+		part, ok := val.(string)
+		if !ok {
+			return "", fmt.Errorf("scope field '%s' is not a string", field)
+		}
+		if strings.Contains(part, ScopeSeparator) {
+			return "", fmt.Errorf("scope field '%s' contains the scope separator '%s'", field, ScopeSeparator)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ScopeSeparator), nil
 }
 
 // processRow handles the 'attempts' logic for a single, non-blank row.
 func (p *GenericProcessor) processRow(ctx context.Context, record []string, headerMap map[string]int, queries repository.Querier) (map[string]interface{}, error) {
-	processedData := make(map[string]interface{})
-
-	for _, mapping := range p.config.ColumnMappings {
+	return p.processValues(ctx, func(mapping ColumnMapping) string {
 		// The check for header existence is now done in the main Process loop.
 		// We can safely assume the key exists here.
-		colIdx := headerMap[mapping.CSVHeader]
-
-		var rawValue string
+		colIdx := headerMap[p.headerKey(mapping.CSVHeader)]
 		if colIdx < len(record) {
-			rawValue = record[colIdx]
+			return record[colIdx]
+		}
+		return ""
+	}, queries)
+}
+
+// processValues runs the transforms and validation for every column mapping, using rawValueFor
+// to look up each mapping's raw source value. It is shared by the CSV and JSONL paths.
+func (p *GenericProcessor) processValues(ctx context.Context, rawValueFor func(mapping ColumnMapping) string, queries repository.Querier) (map[string]interface{}, error) {
+	processedData := make(map[string]interface{})
+	var columnErrs ColumnErrors
+
+	for _, mapping := range p.config.ColumnMappings {
+		rawValue := rawValueFor(mapping)
+		if p.config.TrimAll {
+			rawValue = strings.TrimSpace(rawValue)
 		}
 
 		var transformedValue interface{} = rawValue
@@ -260,14 +635,24 @@ func (p *GenericProcessor) processRow(ctx context.Context, record []string, head
 			}
 
 			if !transformSuccessful {
-				return nil, fmt.Errorf("all transform attempts failed for column '%s' with value '%s': %w", mapping.CSVHeader, rawValue, transformError)
+				err := fmt.Errorf("all transform attempts failed for column '%s' with value '%s': %w", mapping.CSVHeader, rawValue, transformError)
+				if !p.config.CollectRowErrors {
+					return nil, err
+				}
+				columnErrs = append(columnErrs, err)
+				continue
 			}
 		} else {
 			transformSuccessful = true
 		}
 
 		if err := applyValidation(ctx, queries, transformedValue, mapping.Validation); err != nil {
-			return nil, fmt.Errorf("validation failed for column '%s' with value '%v': %w", mapping.CSVHeader, transformedValue, err)
+			err = fmt.Errorf("validation failed for column '%s' with value '%v': %w", mapping.CSVHeader, transformedValue, err)
+			if !p.config.CollectRowErrors {
+				return nil, err
+			}
+			columnErrs = append(columnErrs, err)
+			continue
 		}
 
 		// Add detailed logging to trace the final value and type for each field.
@@ -280,11 +665,33 @@ func (p *GenericProcessor) processRow(ctx context.Context, record []string, head
 		processedData[mapping.JSONField] = transformedValue
 	}
 
-	return processedData, nil
+	switch len(columnErrs) {
+	case 0:
+		return processedData, nil
+	case 1:
+		return nil, columnErrs[0]
+	default:
+		return nil, columnErrs
+	}
 }
 
 // --- Helper functions ---
 
+// normalizeHeader lowercases a header and collapses underscores and whitespace runs into single spaces,
+// so "Policy_Number", "policy number" and " POLICY  NUMBER" all match.
+func normalizeHeader(header string) string {
+	header = strings.ReplaceAll(strings.ToLower(header), "_", " ")
+	return strings.Join(strings.Fields(header), " ")
+}
+
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("'%s'", v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
 func isRowBlank(record []string) bool {
 	for _, field := range record {
 		if strings.TrimSpace(field) != "" {
@@ -306,6 +713,21 @@ func createOriginalRecordMap(record []string, headers []string) map[string]strin
 	return rowMap
 }
 
+// buildGeoPoint combines the configured latitude and longitude fields into a GeoJSON point.
+// It returns nil when either coordinate is absent so rows without a location still ingest.
+func buildGeoPoint(processedData map[string]interface{}, config *GeoPoint) map[string]interface{} {
+	lat, latOK := processedData[config.LatitudeField].(float64)
+	lng, lngOK := processedData[config.LongitudeField].(float64)
+	if !latOK || !lngOK {
+		return nil
+	}
+	// GeoJSON orders coordinates as [longitude, latitude].
+	return map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []float64{lng, lat},
+	}
+}
+
 func applyTransforms(value string, transforms []string) (interface{}, error) {
 	var currentValue interface{} = value
 	for _, transformCall := range transforms {
@@ -2,10 +2,16 @@ package processing
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 // Mock Querier for testing 'exists_in_items'
@@ -26,7 +32,7 @@ func TestProcessRowValidation(t *testing.T) {
 	testConfig := IngestionConfig{
 		ReportType:  "TEST_VALIDATION",
 		ItemType:    "TEST_ITEM",
-		ScopeField:  "department",
+		ScopeField:  ScopeFields{"department"},
 		BusinessKey: []string{"employee_id"},
 		ColumnMappings: []ColumnMapping{
 			{
@@ -127,4 +133,544 @@ func TestProcessRowValidation(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Reports every failing column when collect_row_errors is set", func(t *testing.T) {
+		config := testConfig
+		config.CollectRowErrors = true
+		record := []string{"", "PENDING", "not-an-email", "manager1"}
+		headerMap := map[string]int{"employee_id": 0, "status": 1, "email": 2, "manager_id": 3}
+
+		_, err := NewGenericProcessor(config).processRow(ctx, record, headerMap, &mockQuerier{itemExists: true})
+		var columnErrs ColumnErrors
+		require.ErrorAs(t, err, &columnErrs)
+		assert.Len(t, columnErrs, 3)
+		assert.True(t, strings.HasPrefix(err.Error(), "3 columns failed: validation failed for column 'employee_id'"), err.Error())
+		assert.Contains(t, err.Error(), "; validation failed for column 'status' with value 'PENDING'")
+		assert.Contains(t, err.Error(), "; validation failed for column 'email' with value 'not-an-email'")
+
+		_, err = NewGenericProcessor(config).processRow(ctx, []string{"123", "PENDING", "test@example.com", "manager1"}, headerMap, &mockQuerier{itemExists: true})
+		assert.ErrorContains(t, err, "is not in the allowed list")
+		assert.NotContains(t, err.Error(), "columns failed", "a single failure keeps its own message")
+	})
+}
+
+// mockEmbedder returns a fixed vector and records every text it was asked to embed.
+// It fails the first failCount calls (or every call when err is set).
+type mockEmbedder struct {
+	texts     []string
+	err       error
+	failCount int
+}
+
+func (m *mockEmbedder) embed(ctx context.Context, text string) ([]float32, error) {
+	m.texts = append(m.texts, text)
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.failCount > 0 {
+		m.failCount--
+		return nil, errors.New("transient embedding failure")
+	}
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func newProcessTestConfig() IngestionConfig {
+	return IngestionConfig{
+		ReportType:  "TEST_PROCESS",
+		ItemType:    "TEST_ITEM",
+		ScopeField:  ScopeFields{"region"},
+		BusinessKey: []string{"claim_id", "region"},
+		EmbedContent: &EmbedContent{
+			SourceColumns: []string{"description"},
+		},
+		ColumnMappings: []ColumnMapping{
+			{
+				CSVHeader:  "claim_id",
+				JSONField:  "claim_id",
+				Validation: ValidationRule{Required: true},
+			},
+			{
+				CSVHeader:         "description",
+				JSONField:         "description",
+				MergeExcessFields: true,
+			},
+			{
+				CSVHeader: "region",
+				JSONField: "region",
+				Attempts: []ProcessingAttempt{
+					{Transforms: []string{"trim_space", "to_uppercase"}},
+				},
+			},
+		},
+	}
+}
+
+func TestProcess(t *testing.T) {
+	ctx := context.Background()
+
+	originalBackoff := embeddingRetryBackoff
+	embeddingRetryBackoff = []time.Duration{0, 0}
+	t.Cleanup(func() { embeddingRetryBackoff = originalBackoff })
+
+	t.Run("Builds items with business key and embedding", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		csvData := "claim_id,description,region\nC-1,Water damage,west\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Empty(t, result.TriageRows)
+
+		item := result.SuccessfulItems[0]
+		assert.Equal(t, repository.ItemType("TEST_ITEM"), item.ItemType)
+		assert.Equal(t, "WEST", item.Scope.String)
+		assert.Equal(t, "C-1-WEST", item.BusinessKey.String)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, item.Embedding.Slice())
+		assert.Equal(t, []string{"Water damage"}, embedder.texts)
+	})
+
+	t.Run("Builds named embeddings from their own source columns", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		config := newProcessTestConfig()
+		config.EmbedContent.Fields = []EmbeddingField{
+			{Name: EmbeddingFieldTitle, SourceColumns: []string{"claim_id"}},
+			{Name: EmbeddingFieldBody, SourceColumns: []string{"description"}},
+		}
+		csvData := "claim_id,description,region\nC-5,Water damage,west\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+
+		item := result.SuccessfulItems[0]
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, item.Embedding.Slice())
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, item.TitleEmbedding.Slice())
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, item.BodyEmbedding.Slice())
+		assert.Equal(t, []string{"Water damage", "C-5", "Water damage"}, embedder.texts)
+	})
+
+	t.Run("Builds the combined embedding text from a template", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		config := newProcessTestConfig()
+		config.EmbedContent.Template = "Claim {{.claim_id}} in {{.region}}: {{.description}}{{with .adjuster}}, adjuster {{.}}{{end}}"
+		require.NoError(t, config.Validate())
+		csvData := "claim_id,description,region\nC-5,Water damage,west\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, []string{"Claim C-5 in WEST: Water damage"}, embedder.texts)
+	})
+
+	t.Run("Skips empty fields in the embedding template", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		config := newProcessTestConfig()
+		config.EmbedContent.Template = "Claim {{.claim_id}}, region {{.region}}, adjuster {{.adjuster}}\nNotes: {{.notes}}\nDescription: {{.description}}"
+		require.NoError(t, config.Validate())
+		csvData := "claim_id,description,region\nC-5,Water damage,\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, []string{"Claim C-5\nDescription: Water damage"}, embedder.texts)
+	})
+
+	t.Run("Rejects a malformed embedding template", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent.Template = "Claim {{.claim_id"
+		assert.ErrorContains(t, config.Validate(), "embed_content template is invalid")
+	})
+
+	t.Run("Merges excess fields into the merge column", func(t *testing.T) {
+		csvData := "claim_id,description,region\nC-2,Roof leak, kitchen, hallway,east\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+
+		var props map[string]interface{}
+		require.NoError(t, json.Unmarshal(result.SuccessfulItems[0].CustomProperties, &props))
+		assert.Equal(t, "Roof leak,kitchen,hallway", props["description"])
+		assert.Equal(t, "EAST", props["region"])
+	})
+
+	t.Run("Triages rows with too few fields", func(t *testing.T) {
+		csvData := "claim_id,description,region\nC-3,Hail\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "Row has 2 fields, but header has 3")
+		assert.Equal(t, "C-3", result.TriageRows[0].OriginalRecord["claim_id"])
+	})
+
+	t.Run("Triages rows with too many fields when no merge column is configured", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.ColumnMappings[1].MergeExcessFields = false
+		csvData := "claim_id,description,region\nC-4,Fire,smoke,north\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "Row has 4 fields, but header has 3")
+	})
+
+	t.Run("Discards blank rows", func(t *testing.T) {
+		csvData := "claim_id,description,region\n , , \nC-5,Theft,south\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.SuccessfulItems, 1)
+		assert.Empty(t, result.TriageRows)
+		assert.Equal(t, 1, result.BlankRowsDiscarded)
+	})
+
+	t.Run("Reports a file with headers but no data rows", func(t *testing.T) {
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader("claim_id,description,region\n"), &mockQuerier{}, nil)
+		require.ErrorIs(t, err, ErrNoDataRows)
+		assert.Empty(t, result.SuccessfulItems)
+	})
+
+	t.Run("Triages rows whose scope field is missing", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.ColumnMappings[2].Attempts = []ProcessingAttempt{{Transforms: []string{"to_integer"}}}
+		csvData := "claim_id,description,region\nC-6,Flood,\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "scope field 'region' is missing or nil")
+	})
+
+	t.Run("Retries transient embedding failures at the end of the job", func(t *testing.T) {
+		embedder := &mockEmbedder{failCount: 2}
+		csvData := "claim_id,description,region\nC-14,Storm,west\nC-15,Quake,east\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		assert.Len(t, result.SuccessfulItems, 2)
+		assert.Empty(t, result.TriageRows)
+	})
+
+	t.Run("Triages rows when the embedder keeps failing", func(t *testing.T) {
+		embedder := &mockEmbedder{err: errors.New("embedding service unavailable")}
+		csvData := "claim_id,description,region\nC-7,Vandalism,west\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "Row 2: failed to generate embedding")
+		assert.Len(t, embedder.texts, 1+len(embeddingRetryBackoff))
+	})
+
+	t.Run("Trims every raw value when trim_all is set", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.TrimAll = true
+		config.ColumnMappings[2].Attempts = nil
+		csvData := "claim_id,description,region\nC-11 ,Burst pipe  ,west \n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "C-11-west", result.SuccessfulItems[0].BusinessKey.String)
+
+		var props map[string]interface{}
+		require.NoError(t, json.Unmarshal(result.SuccessfulItems[0].CustomProperties, &props))
+		assert.Equal(t, "Burst pipe", props["description"])
+	})
+
+	t.Run("Strips a UTF-8 byte order mark from the first header", func(t *testing.T) {
+		csvData := "\xEF\xBB\xBFclaim_id,description,region\nC-9,Mold,west\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "C-9-WEST", result.SuccessfulItems[0].BusinessKey.String)
+	})
+
+	t.Run("Transcodes Latin-1 input to UTF-8", func(t *testing.T) {
+		// "Daño" encoded as Latin-1 (0xF1 for ñ).
+		csvData := "claim_id,description,region\nC-10,Da\xF1o,west\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+
+		var props map[string]interface{}
+		require.NoError(t, json.Unmarshal(result.SuccessfulItems[0].CustomProperties, &props))
+		assert.Equal(t, "Daño", props["description"])
+	})
+
+	t.Run("Matches differently formatted headers in normalized mode", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.HeaderMatching = HeaderMatchingNormalized
+		csvData := "Claim_ID,DESCRIPTION,  Region\nC-12,Smoke,west\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "C-12-WEST", result.SuccessfulItems[0].BusinessKey.String)
+	})
+
+	t.Run("Keeps strict header matching by default", func(t *testing.T) {
+		csvData := "Claim_ID,description,region\nC-13,Smoke,west\n"
+
+		_, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing required header(s) ['claim_id']")
+	})
+
+	t.Run("Fails fast when a mapped header is missing", func(t *testing.T) {
+		csvData := "claim_id,description\nC-8,Wind\n"
+
+		_, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing required header(s) ['region']")
+		assert.Contains(t, err.Error(), "actual headers: ['claim_id', 'description']")
+
+		var mismatch *HeaderMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, []string{"claim_id", "description", "region"}, mismatch.Expected)
+	})
+
+	t.Run("Rejects a file with duplicate headers", func(t *testing.T) {
+		csvData := "claim_id,description,region,Description,region\nC-9,Hail,west,Roof,east\n"
+		config := newProcessTestConfig()
+		config.HeaderMatching = HeaderMatchingNormalized
+
+		_, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		var duplicate *DuplicateHeaderError
+		require.ErrorAs(t, err, &duplicate)
+		assert.Equal(t, []string{"Description", "region"}, duplicate.Duplicates)
+		assert.Contains(t, err.Error(), "duplicate header(s) ['Description', 'region']")
+	})
+
+	t.Run("Ignores duplicate headers that no column mapping reads", func(t *testing.T) {
+		csvData := "claim_id,description,region,notes,Notes\nC-9,Hail,west,first,second\n"
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.HeaderMatching = HeaderMatchingNormalized
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.JSONEq(t, `{"claim_id": "C-9", "description": "Hail", "region": "WEST"}`, string(result.SuccessfulItems[0].CustomProperties))
+	})
+
+	t.Run("Numbers duplicate headers when configured", func(t *testing.T) {
+		csvData := "claim_id,description,region,region_2,region\nC-9,Hail,west,north,east\n"
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.DuplicateHeaders = DuplicateHeadersNumber
+		config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{CSVHeader: "region_3", JSONField: "second_region"})
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.JSONEq(t, `{"claim_id": "C-9", "description": "Hail", "region": "WEST", "second_region": "east"}`, string(result.SuccessfulItems[0].CustomProperties))
+	})
+
+	t.Run("Skips lines before the header row", func(t *testing.T) {
+		csvData := "Vendor Claims Export\nGenerated: 2025-06-01, \"all regions\"\nclaim_id,description,region\nC-9,Hail,west\n,Wind,east\n"
+		config := newProcessTestConfig()
+		config.HeaderRowOffset = 2
+		require.NoError(t, config.Validate())
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "C-9-WEST", result.SuccessfulItems[0].BusinessKey.String)
+		assert.Len(t, result.TriageRows, 1)
+	})
+
+	t.Run("Fails when header_row_offset skips the whole file", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.HeaderRowOffset = 3
+
+		_, err := NewGenericProcessor(config).Process(ctx, strings.NewReader("Vendor Claims Export\nclaim_id,description,region"), &mockQuerier{}, nil)
+		assert.EqualError(t, err, "configuration error: header_row_offset skips 3 lines, but the file has only 2")
+
+		config.HeaderRowOffset = 2
+		_, err = NewGenericProcessor(config).Process(ctx, strings.NewReader("Vendor Claims Export\nclaim_id,description,region\n"), &mockQuerier{}, nil)
+		assert.EqualError(t, err, "configuration error: header_row_offset skips 2 lines, leaving no header row")
+	})
+
+	t.Run("Rejects a negative header_row_offset", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.HeaderRowOffset = -1
+		assert.ErrorContains(t, config.Validate(), "header_row_offset must not be negative")
+	})
+
+	t.Run("Writes empty values as configured", func(t *testing.T) {
+		csvData := "claim_id,description,region,amount\nC-9,Hail,west,\n"
+		for emptyValues, expected := range map[string]string{
+			"":               `{"claim_id": "C-9", "description": "Hail", "region": "WEST", "amount": null}`,
+			EmptyValuesNull:  `{"claim_id": "C-9", "description": "Hail", "region": "WEST", "amount": null}`,
+			EmptyValuesOmit:  `{"claim_id": "C-9", "description": "Hail", "region": "WEST"}`,
+			EmptyValuesEmpty: `{"claim_id": "C-9", "description": "Hail", "region": "WEST", "amount": ""}`,
+		} {
+			config := newProcessTestConfig()
+			config.EmbedContent = nil
+			config.EmptyValues = emptyValues
+			config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{
+				CSVHeader: "amount",
+				JSONField: "amount",
+				Attempts:  []ProcessingAttempt{{Transforms: []string{"to_integer"}}},
+			})
+			require.NoError(t, config.Validate())
+
+			result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+			require.NoError(t, err)
+			require.Len(t, result.SuccessfulItems, 1)
+			assert.JSONEq(t, expected, string(result.SuccessfulItems[0].CustomProperties), "empty_values %q", emptyValues)
+		}
+	})
+
+	t.Run("Keeps a nil business key field missing when empty values are written as empty strings", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmptyValues = EmptyValuesEmpty
+		config.BusinessKey = []string{"claim_id", "amount"}
+		config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{
+			CSVHeader: "amount",
+			JSONField: "amount",
+			Attempts:  []ProcessingAttempt{{Transforms: []string{"to_integer"}}},
+		})
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader("claim_id,description,region,amount\nC-9,Hail,west,\n"), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "business key field 'amount' is missing")
+	})
+
+	t.Run("Joins composite scope fields in order", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{CSVHeader: "branch", JSONField: "branch"})
+		config.ScopeField = ScopeFields{"region", "branch"}
+		csvData := "claim_id,description,region,branch\nC-14,Hail,west,BR01\nC-15,Hail,west,BR/02\n"
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "WEST/BR01", result.SuccessfulItems[0].Scope.String)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "contains the scope separator")
+	})
+
+	t.Run("Rejects the scope separator in a single scope field", func(t *testing.T) {
+		// Stored as is, "west/BR01" would be visible to every holder of the WEST scope.
+		csvData := "claim_id,description,region\nC-18,Hail,west/BR01\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "scope field 'region' contains the scope separator")
+	})
+
+	t.Run("Stops with partial progress when the context expires", func(t *testing.T) {
+		expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+		defer cancel()
+		csvData := "claim_id,description,region\nC-16,Flood,west\nC-17,Flood,east\n"
+
+		result, err := NewGenericProcessor(newProcessTestConfig()).Process(expired, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "stopped after 0 of 2 rows")
+		require.NotNil(t, result)
+		assert.Empty(t, result.SuccessfulItems)
+	})
+
+	t.Run("Saves full batches while processing", func(t *testing.T) {
+		var saved [][]string
+		processor := NewGenericProcessor(newProcessTestConfig())
+		processor.SaveInBatches(2, func(ctx context.Context, items []repository.Item) error {
+			var keys []string
+			for _, item := range items {
+				keys = append(keys, item.BusinessKey.String)
+			}
+			saved = append(saved, keys)
+			return nil
+		})
+		csvData := "claim_id,description,region\nC-18,Fire,west\nC-19,Fire,west\nC-20,Fire,west\n"
+
+		result, err := processor.Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"C-18-WEST", "C-19-WEST"}}, saved)
+		assert.Equal(t, 2, result.SavedItems)
+		assert.Len(t, result.SuccessfulItems, 3)
+	})
+
+	t.Run("Keeps saved batches when a later batch fails", func(t *testing.T) {
+		calls := 0
+		processor := NewGenericProcessor(newProcessTestConfig())
+		processor.SaveInBatches(1, func(ctx context.Context, items []repository.Item) error {
+			calls++
+			if calls == 2 {
+				return errors.New("connection reset")
+			}
+			return nil
+		})
+		csvData := "claim_id,description,region\nC-21,Fire,west\nC-22,Fire,west\nC-23,Fire,west\n"
+
+		result, err := processor.Process(ctx, strings.NewReader(csvData), &mockQuerier{}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to save batch of 1 items: connection reset")
+		assert.Equal(t, 1, result.SavedItems)
+	})
+}
+
+func TestScopeFieldsYAML(t *testing.T) {
+	var single struct {
+		ScopeField ScopeFields `yaml:"scope_field"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(`scope_field: "region"`), &single))
+	assert.Equal(t, ScopeFields{"region"}, single.ScopeField)
+
+	var composite struct {
+		ScopeField ScopeFields `yaml:"scope_field"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte("scope_field: [region, branch]"), &composite))
+	assert.Equal(t, ScopeFields{"region", "branch"}, composite.ScopeField)
+}
+
+func TestValidateScope(t *testing.T) {
+	for _, scope := range []string{"WEST", "WEST/BR01", "WEST/BR01/TEAM A"} {
+		assert.NoError(t, ValidateScope(scope), scope)
+	}
+	for _, scope := range []string{"", "/", "WEST/", "/BR01", "WEST//BR01", "WEST/ /BR01"} {
+		assert.Error(t, ValidateScope(scope), scope)
+	}
+}
+
+func TestCoordinateTransforms(t *testing.T) {
+	testCases := []struct {
+		name        string
+		transform   string
+		input       string
+		expected    interface{}
+		expectError bool
+	}{
+		{name: "Valid latitude", transform: "to_latitude", input: " 29.7604 ", expected: 29.7604},
+		{name: "Latitude out of range", transform: "to_latitude", input: "-95.3698", expectError: true},
+		{name: "Valid longitude", transform: "to_longitude", input: "-95.3698", expected: -95.3698},
+		{name: "Longitude out of range", transform: "to_longitude", input: "181", expectError: true},
+		{name: "Unparseable coordinate", transform: "to_longitude", input: "north", expectError: true},
+		{name: "Empty coordinate becomes nil", transform: "to_latitude", input: "", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := applyTransforms(tc.input, []string{tc.transform})
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, val)
+		})
+	}
 }
@@ -0,0 +1,104 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// updateGolden rewrites each golden case's expected.json from the current output:
+//
+//	go test ./internal/processing -run TestGoldenIngestion -update
+var updateGolden = flag.Bool("update", false, "rewrite golden ingestion files in testdata/golden")
+
+// goldenCase is a case.yaml under testdata/golden/<name>/. Paths are relative to the case directory,
+// so a case can point at a shipped config under configs/ or keep its own next to the input.
+type goldenCase struct {
+	Config string `yaml:"config"`
+	Input  string `yaml:"input"`
+}
+
+// goldenItem is the part of an ingested item a golden file pins down. Embedding vectors come from
+// mockEmbedder, so the texts sent to it are recorded instead.
+type goldenItem struct {
+	ItemType         string          `json:"item_type"`
+	Scope            string          `json:"scope"`
+	BusinessKey      string          `json:"business_key"`
+	Status           string          `json:"status"`
+	CustomProperties json.RawMessage `json:"custom_properties"`
+}
+
+type goldenResult struct {
+	Items              []goldenItem `json:"items"`
+	TriageRows         []TriageRow  `json:"triage_rows"`
+	BlankRowsDiscarded int          `json:"blank_rows_discarded"`
+	EmbeddedTexts      []string     `json:"embedded_texts"`
+}
+
+// TestGoldenIngestion runs every input in testdata/golden through GenericProcessor with its config
+// and compares the items, triage rows and embedding texts with expected.json, so a config or
+// processor change that alters ingestion output fails until the golden file is updated.
+func TestGoldenIngestion(t *testing.T) {
+	caseDirs, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, caseDirs, "no golden cases found")
+
+	for _, dir := range caseDirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, "case.yaml"))
+			require.NoError(t, err)
+			var gc goldenCase
+			require.NoError(t, yaml.Unmarshal(data, &gc))
+
+			config, err := LoadConfigFile(filepath.Join(dir, gc.Config))
+			require.NoError(t, err)
+			input, err := os.Open(filepath.Join(dir, gc.Input))
+			require.NoError(t, err)
+			defer input.Close()
+
+			embedder := &mockEmbedder{}
+			result, err := NewGenericProcessor(config).Process(context.Background(), input, &mockQuerier{itemExists: true}, embedder.embed)
+			require.NoError(t, err)
+
+			got := goldenResult{
+				Items:              []goldenItem{},
+				TriageRows:         result.TriageRows,
+				BlankRowsDiscarded: result.BlankRowsDiscarded,
+				EmbeddedTexts:      embedder.texts,
+			}
+			for _, item := range result.SuccessfulItems {
+				got.Items = append(got.Items, goldenItem{
+					ItemType:         string(item.ItemType),
+					Scope:            item.Scope.String,
+					BusinessKey:      item.BusinessKey.String,
+					Status:           string(item.Status),
+					CustomProperties: item.CustomProperties,
+				})
+			}
+			if got.TriageRows == nil {
+				got.TriageRows = []TriageRow{}
+			}
+			if got.EmbeddedTexts == nil {
+				got.EmbeddedTexts = []string{}
+			}
+			actual, err := json.MarshalIndent(got, "", "  ")
+			require.NoError(t, err)
+
+			expectedPath := filepath.Join(dir, "expected.json")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(expectedPath, append(actual, '\n'), 0o644))
+				return
+			}
+			expected, err := os.ReadFile(expectedPath)
+			require.NoError(t, err, "run with -update to create the golden file")
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
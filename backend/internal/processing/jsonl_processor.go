@@ -0,0 +1,199 @@
+package processing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// maxJSONLLineBytes caps the size of a single JSON line.
+const maxJSONLLineBytes = 10 * 1024 * 1024
+
+// processJSONL handles newline-delimited JSON sources. Each line is one object whose keys are
+// matched against each mapping's csv_header, or its dotted json_path when set; malformed lines
+// are triaged individually.
+func (p *GenericProcessor) processJSONL(
+	ctx context.Context,
+	file io.Reader,
+	queries repository.Querier,
+	embedder interfaces.EmbedderFunc,
+) (*ProcessingResult, error) {
+	result := &ProcessingResult{}
+
+	scopeJSONFields, err := p.scopeJSONFields()
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineBytes)
+
+	var pendingEmbeddings []pendingEmbeddingRow
+	lineNum := 0
+	dataLines := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("processing stopped after %d lines: %w", lineNum, err)
+		}
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			result.BlankRowsDiscarded++
+			continue
+		}
+		dataLines++
+
+		object, err := decodeJSONLine(line)
+		if err != nil {
+			result.TriageRows = append(result.TriageRows, TriageRow{
+				OriginalRecord: map[string]string{"line": string(line)},
+				FailureReason:  fmt.Sprintf("Line %d: malformed JSON: %s", lineNum, err.Error()),
+			})
+			continue
+		}
+
+		originalRecord := createOriginalObjectMap(object)
+
+		processedData, err := p.processValues(ctx, func(mapping ColumnMapping) string {
+			if mapping.JSONPath != "" {
+				return stringifyJSONValue(lookupJSONPath(object, mapping.JSONPath))
+			}
+			return stringifyJSONValue(object[mapping.CSVHeader])
+		}, queries)
+		if err != nil {
+			result.TriageRows = append(result.TriageRows, TriageRow{
+				OriginalRecord: originalRecord,
+				FailureReason:  err.Error(),
+			})
+			continue
+		}
+
+		pending, err := p.addRowItems(ctx, processedData, originalRecord, lineNum, scopeJSONFields, embedder, result)
+		pendingEmbeddings = append(pendingEmbeddings, pending...)
+		if err != nil {
+			return result, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read JSONL file at line %d: %w", lineNum+1, err)
+	}
+	if dataLines == 0 {
+		return result, ErrNoDataRows
+	}
+
+	p.retryPendingEmbeddings(ctx, pendingEmbeddings, scopeJSONFields, embedder, result)
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("processing stopped while retrying failed embeddings: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Processing complete",
+		"successful_items", len(result.SuccessfulItems),
+		"triage_rows", len(result.TriageRows),
+		"blank_rows_discarded", result.BlankRowsDiscarded,
+	)
+	return result, nil
+}
+
+// decodeJSONLine decodes one JSONL line, which must hold a single JSON object and nothing after it.
+func decodeJSONLine(line []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after the JSON value at offset %d", end)
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object, got %s", jsonTypeName(value))
+	}
+	return object, nil
+}
+
+// jsonTypeName names the JSON type of a value decoded with UseNumber.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// lookupJSONPath walks a dotted path such as "policy.holder.state" through nested objects.
+// Numeric segments index into arrays. A missing key or index anywhere along the path yields nil.
+func lookupJSONPath(object map[string]interface{}, path string) interface{} {
+	var current interface{} = object
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			current = node[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// stringifyJSONValue converts a decoded JSON value into the raw string form the transforms expect.
+// Missing and null values become empty strings; objects and arrays are re-encoded as JSON.
+func stringifyJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
+
+func createOriginalObjectMap(object map[string]interface{}) map[string]string {
+	rowMap := make(map[string]string, len(object))
+	for key, value := range object {
+		rowMap[key] = stringifyJSONValue(value)
+	}
+	return rowMap
+}
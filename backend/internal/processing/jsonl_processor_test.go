@@ -0,0 +1,197 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJSONLTestConfig() IngestionConfig {
+	config := newProcessTestConfig()
+	config.Format = FormatJSONL
+	config.ColumnMappings = append(config.ColumnMappings, ColumnMapping{
+		CSVHeader: "amount",
+		JSONField: "amount",
+		Attempts: []ProcessingAttempt{
+			{Transforms: []string{"to_integer"}},
+		},
+	})
+	return config
+}
+
+func TestProcessJSONL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Maps JSON keys through transforms into items", func(t *testing.T) {
+		embedder := &mockEmbedder{}
+		jsonlData := `{"claim_id": "C-1", "description": "Water damage", "region": " west ", "amount": 1500}` + "\n"
+
+		result, err := NewGenericProcessor(newJSONLTestConfig()).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, embedder.embed)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Empty(t, result.TriageRows)
+
+		item := result.SuccessfulItems[0]
+		assert.Equal(t, "WEST", item.Scope.String)
+		assert.Equal(t, "C-1-WEST", item.BusinessKey.String)
+		assert.Equal(t, []string{"Water damage"}, embedder.texts)
+
+		var props map[string]interface{}
+		require.NoError(t, json.Unmarshal(item.CustomProperties, &props))
+		assert.Equal(t, float64(1500), props["amount"])
+	})
+
+	t.Run("Triages malformed lines individually and discards blank lines", func(t *testing.T) {
+		jsonlData := strings.Join([]string{
+			`{"claim_id": "C-2", "description": "Hail", "region": "east"}`,
+			`{"claim_id": "C-3", "description": `,
+			``,
+			`{"claim_id": "C-4", "description": "Wind", "region": "north"}`,
+		}, "\n")
+
+		result, err := NewGenericProcessor(newJSONLTestConfig()).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.SuccessfulItems, 2)
+		assert.Equal(t, 1, result.BlankRowsDiscarded)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "Line 2: malformed JSON")
+	})
+
+	t.Run("Triages lines that aren't a single object", func(t *testing.T) {
+		jsonlData := strings.Join([]string{
+			`{"claim_id": "C-5", "description": "Hail", "region": "east"} trailing`,
+			`{"claim_id": "C-6", "description": "Hail", "region": "east"}{"claim_id": "C-7"}`,
+			`null`,
+			`["C-8", "Hail", "east"]`,
+			`{"claim_id": "C-9", "description": "Wind", "region": "north"}`,
+		}, "\n")
+
+		result, err := NewGenericProcessor(newJSONLTestConfig()).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "C-9-NORTH", result.SuccessfulItems[0].BusinessKey.String)
+		require.Len(t, result.TriageRows, 4)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "Line 1: malformed JSON")
+		assert.Contains(t, result.TriageRows[1].FailureReason, "Line 2: malformed JSON: unexpected data after the JSON value")
+		assert.Contains(t, result.TriageRows[2].FailureReason, "Line 3: malformed JSON: expected a JSON object, got null")
+		assert.Contains(t, result.TriageRows[3].FailureReason, "Line 4: malformed JSON: expected a JSON object, got an array")
+	})
+
+	t.Run("Reports a file without data lines", func(t *testing.T) {
+		for _, jsonlData := range []string{"", "\n  \n\n"} {
+			result, err := NewGenericProcessor(newJSONLTestConfig()).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+			require.ErrorIs(t, err, ErrNoDataRows, "%q", jsonlData)
+			assert.Empty(t, result.SuccessfulItems)
+		}
+	})
+
+	t.Run("Applies validation to missing keys", func(t *testing.T) {
+		jsonlData := `{"description": "Theft", "region": "south"}`
+
+		result, err := NewGenericProcessor(newJSONLTestConfig()).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "validation failed for column 'claim_id'")
+		assert.Equal(t, "south", result.TriageRows[0].OriginalRecord["region"])
+	})
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	object := map[string]interface{}{
+		"claim_id": "C-1",
+		"policy": map[string]interface{}{
+			"holder": map[string]interface{}{
+				"address": map[string]interface{}{
+					"state": "TX",
+				},
+			},
+			"drivers": []interface{}{
+				map[string]interface{}{"name": "Ana"},
+			},
+			"number": nil,
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected interface{}
+	}{
+		{name: "Top-level key", path: "claim_id", expected: "C-1"},
+		{name: "Deeply nested key", path: "policy.holder.address.state", expected: "TX"},
+		{name: "Array index", path: "policy.drivers.0.name", expected: "Ana"},
+		{name: "Missing leaf key", path: "policy.holder.address.zip", expected: nil},
+		{name: "Missing intermediate key", path: "policy.vehicle.vin", expected: nil},
+		{name: "Path through a scalar", path: "claim_id.value", expected: nil},
+		{name: "Path through a null", path: "policy.number.prefix", expected: nil},
+		{name: "Array index out of range", path: "policy.drivers.3.name", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, lookupJSONPath(object, tc.path))
+		})
+	}
+}
+
+func TestProcessJSONLNestedPaths(t *testing.T) {
+	ctx := context.Background()
+	config := newJSONLTestConfig()
+	config.ColumnMappings[0].JSONPath = "claim.id"
+	config.ColumnMappings[2].JSONPath = "policy.holder.state"
+
+	t.Run("Extracts nested values into flat json fields", func(t *testing.T) {
+		jsonlData := `{"claim": {"id": "C-9"}, "description": "Flood", "policy": {"holder": {"state": "tx"}}}`
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.SuccessfulItems, 1)
+		assert.Equal(t, "TX", result.SuccessfulItems[0].Scope.String)
+		assert.Equal(t, "C-9-TX", result.SuccessfulItems[0].BusinessKey.String)
+	})
+
+	t.Run("Treats a missing intermediate key as empty for required validation", func(t *testing.T) {
+		jsonlData := `{"description": "Flood", "policy": {"holder": {"state": "tx"}}}`
+
+		result, err := NewGenericProcessor(config).Process(ctx, strings.NewReader(jsonlData), &mockQuerier{}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.SuccessfulItems)
+		require.Len(t, result.TriageRows, 1)
+		assert.Contains(t, result.TriageRows[0].FailureReason, "validation failed for column 'claim_id'")
+	})
+}
+
+func TestDecodeJSONLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{"an object", `{"claim_id": "C-1", "amount": 1500}`, map[string]interface{}{"claim_id": "C-1", "amount": json.Number("1500")}, ""},
+		{"an object followed by spaces", `{"claim_id": "C-1"}   `, map[string]interface{}{"claim_id": "C-1"}, ""},
+		{"trailing text", `{"claim_id": "C-1"} oops`, nil, "unexpected data after the JSON value at offset 19"},
+		{"a second object", `{"claim_id": "C-1"}{"claim_id": "C-2"}`, nil, "unexpected data after the JSON value at offset 19"},
+		{"a stray closing brace", `{"claim_id": "C-1"}}`, nil, "unexpected data after the JSON value at offset 19"},
+		{"null", `null`, nil, "expected a JSON object, got null"},
+		{"a string", `"C-1"`, nil, "expected a JSON object, got a string"},
+		{"a number", `42`, nil, "expected a JSON object, got a number"},
+		{"truncated", `{"claim_id": `, nil, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeJSONLine([]byte(tt.line))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package processing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultPreviewRows is the number of data rows returned by PreviewFile when no limit is given.
+const DefaultPreviewRows = 10
+
+// FilePreview holds the header and first data rows of a file, without any transforms applied.
+type FilePreview struct {
+	Format  string     `json:"format"`
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// PreviewFile reads only the header and the first maxRows data rows of a CSV or JSONL file.
+// For JSONL, the headers are the union of top-level keys across the previewed lines.
+func PreviewFile(file io.Reader, maxRows int) (*FilePreview, error) {
+	if maxRows <= 0 {
+		maxRows = DefaultPreviewRows
+	}
+
+	utf8File, err := NewUTF8Reader(file, EncodingAuto)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(utf8File)
+	head, err := buffered.Peek(SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	format := DetectFormat(head)
+	switch format {
+	case FormatCSV:
+		return previewCSV(buffered, maxRows)
+	case FormatJSONL:
+		return previewJSONL(buffered, maxRows)
+	default:
+		return nil, fmt.Errorf("preview is not supported for format '%s'", format)
+	}
+}
+
+func previewCSV(file io.Reader, maxRows int) (*FilePreview, error) {
+	csvReader := csv.NewReader(file)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+
+	headers, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header row: %w", err)
+	}
+
+	preview := &FilePreview{Format: FormatCSV, Headers: headers, Rows: [][]string{}}
+	for len(preview.Rows) < maxRows {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading row %d: %w", len(preview.Rows)+2, err)
+		}
+		preview.Rows = append(preview.Rows, record)
+	}
+	return preview, nil
+}
+
+func previewJSONL(file io.Reader, maxRows int) (*FilePreview, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineBytes)
+
+	var objects []map[string]interface{}
+	keys := make(map[string]bool)
+	lineNum := 0
+	for len(objects) < maxRows && scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		object, err := decodeJSONLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: malformed JSON: %w", lineNum, err)
+		}
+		for key := range object {
+			keys[key] = true
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSONL file: %w", err)
+	}
+
+	headers := make([]string, 0, len(keys))
+	for key := range keys {
+		headers = append(headers, key)
+	}
+	sort.Strings(headers)
+
+	preview := &FilePreview{Format: FormatJSONL, Headers: headers, Rows: make([][]string, 0, len(objects))}
+	for _, object := range objects {
+		row := make([]string, len(headers))
+		for i, header := range headers {
+			row[i] = stringifyJSONValue(object[header])
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+	return preview, nil
+}
@@ -0,0 +1,63 @@
+package processing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewFile(t *testing.T) {
+	t.Run("Returns the CSV header and first rows", func(t *testing.T) {
+		preview, err := PreviewFile(strings.NewReader("claim_id,region\nC-1, west\nC-2,east\nC-3,north\n"), 2)
+		require.NoError(t, err)
+		assert.Equal(t, FormatCSV, preview.Format)
+		assert.Equal(t, []string{"claim_id", "region"}, preview.Headers)
+		assert.Equal(t, [][]string{{"C-1", "west"}, {"C-2", "east"}}, preview.Rows)
+	})
+
+	t.Run("Defaults to ten rows", func(t *testing.T) {
+		var csvData strings.Builder
+		csvData.WriteString("claim_id\n")
+		for i := 0; i < 15; i++ {
+			fmt.Fprintf(&csvData, "C-%d\n", i)
+		}
+		preview, err := PreviewFile(strings.NewReader(csvData.String()), 0)
+		require.NoError(t, err)
+		assert.Len(t, preview.Rows, DefaultPreviewRows)
+	})
+
+	t.Run("Returns an empty row list for a header-only file", func(t *testing.T) {
+		preview, err := PreviewFile(strings.NewReader("claim_id,region\n"), 5)
+		require.NoError(t, err)
+		assert.NotNil(t, preview.Rows)
+		assert.Empty(t, preview.Rows)
+	})
+
+	t.Run("Decodes Windows-1252 text", func(t *testing.T) {
+		preview, err := PreviewFile(strings.NewReader("claim_id,note\nC-1,caf\xe9\n"), 5)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"C-1", "café"}}, preview.Rows)
+	})
+
+	t.Run("Reports a malformed CSV row by line", func(t *testing.T) {
+		_, err := PreviewFile(strings.NewReader("claim_id,note\nC-1,\"unterminated\n"), 5)
+		assert.ErrorContains(t, err, "error reading row 2")
+	})
+
+	t.Run("Unions the keys of JSONL lines into the headers", func(t *testing.T) {
+		jsonl := `{"claim_id": "C-1", "amount": 12.5}` + "\n\n" + `{"claim_id": "C-2", "tags": ["hail"], "open": true}` + "\n" + `{"claim_id": "C-3"}` + "\n"
+		preview, err := PreviewFile(strings.NewReader(jsonl), 2)
+		require.NoError(t, err)
+		assert.Equal(t, FormatJSONL, preview.Format)
+		assert.Equal(t, []string{"amount", "claim_id", "open", "tags"}, preview.Headers)
+		assert.Equal(t, [][]string{{"12.5", "C-1", "", ""}, {"", "C-2", "true", `["hail"]`}}, preview.Rows)
+	})
+
+	t.Run("Reports a malformed JSONL line", func(t *testing.T) {
+		_, err := PreviewFile(strings.NewReader(`{"claim_id": "C-1"}`+"\n"+`{"claim_id": }`+"\n"), 5)
+		assert.ErrorContains(t, err, "line 2: malformed JSON")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -32,6 +33,8 @@ func init() {
 	transformRegistry["to_integer"] = transformToInteger
 	transformRegistry["to_decimal"] = transformToDecimal
 	transformRegistry["to_date"] = transformToDate
+	transformRegistry["to_latitude"] = transformToLatitude
+	transformRegistry["to_longitude"] = transformToLongitude
 
 	// Register Validations
 	validationRegistry["required"] = validationRequired
@@ -107,6 +110,37 @@ func transformToDate(input interface{}, arg string) (interface{}, error) {
 	return t, nil
 }
 
+func transformToLatitude(input interface{}, arg string) (interface{}, error) {
+	return parseCoordinate(input, "to_latitude", 90)
+}
+
+func transformToLongitude(input interface{}, arg string) (interface{}, error) {
+	return parseCoordinate(input, "to_longitude", 180)
+}
+
+// parseCoordinate parses a decimal-degree coordinate and rejects values outside [-limit, limit].
+// Empty values become nil so optional coordinate columns don't fail the row.
+func parseCoordinate(input interface{}, transformName string, limit float64) (interface{}, error) {
+	str, ok := input.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s requires a string input", transformName)
+	}
+
+	cleanStr := strings.TrimSpace(str)
+	if cleanStr == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(cleanStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse '%s' as coordinate: %w", str, err)
+	}
+	if math.IsNaN(f) || f < -limit || f > limit {
+		return nil, fmt.Errorf("coordinate %v is outside the allowed range [-%v, %v]", f, limit, limit)
+	}
+	return f, nil
+}
+
 // --- Validation Implementaton ---
 
 func validationRequired(ctx context.Context, queries repository.Querier, input interface{}, rule ValidationRule) error {
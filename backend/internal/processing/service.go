@@ -3,6 +3,7 @@ package processing
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	//	"io"
@@ -10,36 +11,55 @@ import (
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool" // Import the pgxpool package
 	"github.com/jjckrbbt/chimera/backend/internal/config"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
 	"github.com/jjckrbbt/chimera/backend/internal/ingestion"
 	"github.com/jjckrbbt/chimera/backend/internal/interfaces"
 	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/pgvector/pgvector-go"
 )
 
+const (
+	// jobTimeout bounds a whole processing job, from reading the upload to saving its items.
+	jobTimeout = 15 * time.Minute
+	// jobOutcomeTimeout bounds recording the outcome of a job that ran out of time.
+	jobOutcomeTimeout = 30 * time.Second
+)
+
+// JobStatusUpdater records the status and row counts of an ingestion job. *ingestion.Service implements it.
+type JobStatusUpdater interface {
+	UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, errorDetails string, rowsUpserted int64, rowsTriaged int64) error
+}
+
+var _ JobStatusUpdater = (*ingestion.Service)(nil)
+
+// itemSaver writes a set of processed items to the items table in one transaction.
+type itemSaver interface {
+	saveItems(ctx context.Context, items []repository.Item, ingestionConfig IngestionConfig, archiveMissing bool) (ingestionCounts, error)
+}
+
 // Service orchestrates the processing of an ingestion job.
 type Service struct {
-	ingestionService *ingestion.Service
+	ingestionService JobStatusUpdater
 	configLoader     *ConfigLoader
-	queries          *repository.Queries
-	gcsClient        *storage.Client
+	queries          repository.Querier
+	store            filestore.Store
+	items            itemSaver
 	gcsBucket        string
 	logger           *slog.Logger
 	cfg              *config.Config
-	// CORRECTED: Use a connection pool
-	dbpool *pgxpool.Pool
 }
 
 // NewService creates and initializes a new processing service.
 func NewService(
-	ingestionService *ingestion.Service,
+	ingestionService JobStatusUpdater,
 	configLoader *ConfigLoader,
 	queries *repository.Queries,
-	gcsClient *storage.Client,
+	store filestore.Store,
 	logger *slog.Logger,
 	cfg *config.Config,
 	dbpool *pgxpool.Pool, // CORRECTED: Expect a pool
@@ -48,22 +68,23 @@ func NewService(
 		ingestionService: ingestionService,
 		configLoader:     configLoader,
 		queries:          queries,
-		gcsClient:        gcsClient,
+		store:            store,
+		items:            &pgItemSaver{queries: queries, dbpool: dbpool, logger: logger},
 		gcsBucket:        cfg.GCSBucketName,
 		logger:           logger,
 		cfg:              cfg,
-		dbpool:           dbpool,
 	}
 }
 
 // RunJob is the main entry point for processing a file. It's designed to be run in a goroutine.
 func (s *Service) RunJob(ctx context.Context, jobID uuid.UUID, reportType, gcsURI string, embedder interfaces.EmbedderFunc) {
 	// ... (The beginning of this function is unchanged)
-	jobCtx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	jobCtx, cancel := context.WithTimeout(context.Background(), jobTimeout)
 	defer cancel()
 
 	procLogger := s.logger.With("job_id", jobID.String(), "report_type", reportType)
 	procLogger.InfoContext(jobCtx, "Starting asynchronous processing job")
+	startedAt := time.Now()
 
 	err := s.ingestionService.UpdateJobStatus(jobCtx, jobID, "PROCESSING", "", 0, 0)
 	if err != nil {
@@ -72,10 +93,11 @@ func (s *Service) RunJob(ctx context.Context, jobID uuid.UUID, reportType, gcsUR
 	}
 
 	storageKey := strings.TrimPrefix(gcsURI, fmt.Sprintf("gs://%s/", s.gcsBucket))
-	reader, err := s.gcsClient.Bucket(s.gcsBucket).Object(storageKey).NewReader(jobCtx)
+	reader, err := s.store.NewReader(jobCtx, storageKey)
 	if err != nil {
-		procLogger.ErrorContext(jobCtx, "Failed to create GCS reader for file", "storage_key", storageKey, "error", err)
+		procLogger.ErrorContext(jobCtx, "Failed to open file in storage", "storage_key", storageKey, "error", err)
 		_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", fmt.Sprintf("Failed to read file from storage: %v", err), 0, 0)
+		s.recordJobStats(jobCtx, jobID, reportType, "FAILED", 0, 0, 0, time.Since(startedAt))
 		return
 	}
 	defer reader.Close()
@@ -85,53 +107,214 @@ func (s *Service) RunJob(ctx context.Context, jobID uuid.UUID, reportType, gcsUR
 		errorMsg := fmt.Sprintf("No processor configuration found for report type: %s", reportType)
 		procLogger.ErrorContext(jobCtx, errorMsg)
 		_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", errorMsg, 0, 0)
+		s.recordJobStats(jobCtx, jobID, reportType, "FAILED", 0, 0, 0, time.Since(startedAt))
 		return
 	}
 
 	processor := NewGenericProcessor(ingestionConfig)
+	// Saving in batches as rows are processed means a late failure doesn't discard the items already built.
+	var counts ingestionCounts
+	if batchSize := ingestionConfig.EffectiveSaveBatchSize(); batchSize > 0 {
+		processor.SaveInBatches(batchSize, func(ctx context.Context, items []repository.Item) error {
+			batchCounts, err := s.items.saveItems(ctx, items, ingestionConfig, false)
+			if err != nil {
+				return err
+			}
+			counts.add(batchCounts)
+			procLogger.InfoContext(ctx, "Saved batch of processed items", "items", len(items), "items_saved", counts.saved())
+			return nil
+		})
+	}
 	result, err := processor.Process(jobCtx, reader, s.queries, embedder)
 
+	if err != nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+		s.markTimedOut(jobID, reportType, result, "processing rows", err, counts.saved(), startedAt, procLogger)
+		return
+	}
+
+	if errors.Is(err, ErrNoDataRows) {
+		procLogger.WarnContext(jobCtx, "File has no data rows")
+		_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "NO_DATA", "The file contained no data rows, so nothing was ingested.", 0, 0)
+		s.recordJobStats(jobCtx, jobID, reportType, "NO_DATA", 0, 0, 0, time.Since(startedAt))
+		return
+	}
+
 	if result != nil && len(result.TriageRows) > 0 {
 		s.logTriageItems(jobCtx, jobID, result.TriageRows)
 	}
 
 	if err != nil {
 		errorMsg := err.Error()
-		rowsTriaged := int64(0)
+		rowsSaved := counts.saved()
+		if rowsSaved > 0 {
+			errorMsg += fmt.Sprintf(". %d items were saved before the failure.", rowsSaved)
+		}
+		// Failures before any row is read, such as a header mismatch, have no result and are recorded with zero counts.
+		var rowsTriaged, rowsBlank int64
 		if result != nil {
 			rowsTriaged = int64(len(result.TriageRows))
+			rowsBlank = int64(result.BlankRowsDiscarded)
+		}
+		var mismatch *HeaderMismatchError
+		if errors.As(err, &mismatch) {
+			// The error message carries expected vs actual headers, so error_details shows the full diff.
+			procLogger.ErrorContext(jobCtx, "File headers do not match ingestion config", "missing_headers", mismatch.Missing, "actual_headers", mismatch.Actual)
+		}
+		var duplicate *DuplicateHeaderError
+		if errors.As(err, &duplicate) {
+			procLogger.ErrorContext(jobCtx, "File has duplicate headers", "duplicate_headers", duplicate.Duplicates)
 		}
 		procLogger.ErrorContext(jobCtx, "Processing job finished with critical error", "error", err)
-		_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", errorMsg, 0, rowsTriaged)
+		_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", errorMsg, rowsSaved, rowsTriaged)
+		s.recordJobStats(jobCtx, jobID, reportType, "FAILED", rowsSaved, rowsTriaged, rowsBlank, time.Since(startedAt))
 		return
 	}
 
-	var rowsUpserted int64 = 0
-	if result != nil && len(result.SuccessfulItems) > 0 {
-		upsertedCount, err := s.saveSuccessfulItems(jobCtx, result.SuccessfulItems)
+	if remaining := result.SuccessfulItems[result.SavedItems:]; len(remaining) > 0 {
+		// Rows sent to triage are missing from the staged set, so archiving would wrongly retire their items.
+		archiveMissing := ingestionConfig.ArchiveMissing && len(result.TriageRows) == 0
+		if ingestionConfig.ArchiveMissing && !archiveMissing {
+			procLogger.WarnContext(jobCtx, "Skipping archival of missing items because rows were sent for triage", "rows_for_triage", len(result.TriageRows))
+		}
+		remainingCounts, err := s.items.saveItems(jobCtx, remaining, ingestionConfig, archiveMissing)
+		if err != nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+			s.markTimedOut(jobID, reportType, result, "saving items", err, counts.saved(), startedAt, procLogger)
+			return
+		}
 		if err != nil {
-			procLogger.ErrorContext(jobCtx, "Failed to save successful items to database", "error", err)
-			_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", "Error saving processed data to database", 0, int64(len(result.TriageRows)))
+			procLogger.ErrorContext(jobCtx, "Failed to save successful items to database", "error", err, "items_saved", counts.saved())
+			errorMsg := fmt.Sprintf("Error saving processed data to database. %d items were saved before the failure.", counts.saved())
+			_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, "FAILED", errorMsg, counts.saved(), int64(len(result.TriageRows)))
+			s.recordJobStats(jobCtx, jobID, reportType, "FAILED", counts.saved(), int64(len(result.TriageRows)), int64(result.BlankRowsDiscarded), time.Since(startedAt))
 			return
 		}
-		rowsUpserted = upsertedCount
+		counts.add(remainingCounts)
 	}
 
+	rowsProcessed := counts.saved()
 	rowsTriaged := int64(len(result.TriageRows))
 	finalStatus := "COMPLETE"
-	finalMessage := fmt.Sprintf("Processed %d items successfully. %d rows sent for triage. %d blank rows discarded.", rowsUpserted, rowsTriaged, result.BlankRowsDiscarded)
+	finalMessage := fmt.Sprintf("Processed %d items successfully (%d inserted, %d updated). %d rows sent for triage. %d blank rows discarded.", rowsProcessed, counts.Inserted, counts.Updated, rowsTriaged, result.BlankRowsDiscarded)
+	if ingestionConfig.Delta {
+		finalMessage = fmt.Sprintf("Processed %d items successfully (%d new, %d changed, %d unchanged, %d archived). %d rows sent for triage. %d blank rows discarded.", rowsProcessed, counts.Inserted, counts.Updated, counts.Unchanged, counts.Archived, rowsTriaged, result.BlankRowsDiscarded)
+	}
 	if rowsTriaged > 0 {
 		finalStatus = "COMPLETE_WITH_ISSUES"
 	}
-	procLogger.InfoContext(jobCtx, "Processing job completed", "status", finalStatus, "rows_upserted", rowsUpserted, "rows_for_triage", rowsTriaged)
-	_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, finalStatus, finalMessage, rowsUpserted, rowsTriaged)
+	rowsTotal := rowsProcessed + rowsTriaged + int64(result.BlankRowsDiscarded)
+	degraded := triageRateExceeded(ingestionConfig.TriageAlertPercent, rowsTriaged, rowsTotal)
+	var triagePercent float64
+	if degraded {
+		triagePercent = float64(rowsTriaged) / float64(rowsTotal) * 100
+		procLogger.ErrorContext(jobCtx, "Triage rate exceeded alert threshold, the feed or its config may be broken",
+			"triage_percent", triagePercent, "threshold_percent", ingestionConfig.TriageAlertPercent, "rows_for_triage", rowsTriaged, "rows_total", rowsTotal)
+		finalMessage += fmt.Sprintf(" Degraded: %.1f%% of rows were sent for triage (alert threshold %.1f%%).", triagePercent, ingestionConfig.TriageAlertPercent)
+		if err := s.queries.MarkIngestionJobDegraded(jobCtx, pgtype.UUID{Bytes: jobID, Valid: true}); err != nil {
+			procLogger.ErrorContext(jobCtx, "Failed to flag job as degraded", "error", err)
+		}
+	}
+	procLogger.InfoContext(jobCtx, "Processing job completed", "status", finalStatus, "rows_processed", rowsProcessed, "rows_inserted", counts.Inserted, "rows_updated", counts.Updated, "rows_unchanged", counts.Unchanged, "rows_archived", counts.Archived, "rows_for_triage", rowsTriaged)
+	_ = s.ingestionService.UpdateJobStatus(jobCtx, jobID, finalStatus, finalMessage, rowsProcessed, rowsTriaged)
+	s.recordJobStats(jobCtx, jobID, reportType, finalStatus, rowsProcessed, rowsTriaged, int64(result.BlankRowsDiscarded), time.Since(startedAt))
+	if degraded && ingestionConfig.DegradedWebhookURL != "" {
+		notifyDegraded(jobCtx, ingestionConfig.DegradedWebhookURL, DegradedJobNotification{
+			JobID:            jobID.String(),
+			ReportType:       reportType,
+			Status:           finalStatus,
+			Degraded:         true,
+			TriagePercent:    triagePercent,
+			ThresholdPercent: ingestionConfig.TriageAlertPercent,
+			RowsTotal:        rowsTotal,
+			RowsTriaged:      rowsTriaged,
+			Message:          finalMessage,
+		}, procLogger)
+	}
+}
+
+// markTimedOut records a job whose deadline fired as TIMED_OUT rather than FAILED, with a message saying
+// how far it got. jobCtx has expired by then, so the outcome is written with a fresh context. rowsSaved
+// counts the items saved in batches before the deadline; they stay in the items table.
+func (s *Service) markTimedOut(jobID uuid.UUID, reportType string, result *ProcessingResult, stage string, cause error, rowsSaved int64, startedAt time.Time, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobOutcomeTimeout)
+	defer cancel()
+
+	var rowsReady, rowsTriaged, rowsBlank int64
+	if result != nil {
+		rowsReady = int64(len(result.SuccessfulItems) - result.SavedItems)
+		rowsTriaged = int64(len(result.TriageRows))
+		rowsBlank = int64(result.BlankRowsDiscarded)
+		if rowsTriaged > 0 {
+			s.logTriageItems(ctx, jobID, result.TriageRows)
+		}
+	}
+
+	message := fmt.Sprintf("Job timed out after %s while %s (%v). Progress before the deadline: %d items saved, %d rows processed but not saved, %d rows sent for triage, %d blank rows discarded.",
+		jobTimeout, stage, cause, rowsSaved, rowsReady, rowsTriaged, rowsBlank)
+	logger.ErrorContext(ctx, "Processing job timed out", "stage", stage, "items_saved", rowsSaved, "rows_unsaved", rowsReady, "rows_for_triage", rowsTriaged, "error", cause)
+	_ = s.ingestionService.UpdateJobStatus(ctx, jobID, "TIMED_OUT", message, rowsSaved, rowsTriaged)
+	s.recordJobStats(ctx, jobID, reportType, "TIMED_OUT", rowsSaved, rowsTriaged, rowsBlank, time.Since(startedAt))
+}
+
+// triageRateExceeded reports whether more than thresholdPercent of a job's rows went to triage.
+// A threshold of zero disables the check.
+func triageRateExceeded(thresholdPercent float64, rowsTriaged, rowsTotal int64) bool {
+	if thresholdPercent <= 0 || rowsTotal == 0 {
+		return false
+	}
+	return float64(rowsTriaged)/float64(rowsTotal)*100 > thresholdPercent
+}
+
+// recordJobStats persists a job's aggregate counters for the ingestion quality time series.
+// Failing to record them is logged but doesn't fail the job.
+func (s *Service) recordJobStats(ctx context.Context, jobID uuid.UUID, reportType, status string, rowsUpserted, rowsTriaged, rowsBlank int64, duration time.Duration) {
+	err := s.queries.CreateIngestionJobStats(ctx, repository.CreateIngestionJobStatsParams{
+		JobID:        pgtype.UUID{Bytes: jobID, Valid: true},
+		ReportType:   reportType,
+		Status:       status,
+		RowsTotal:    int32(rowsUpserted + rowsTriaged + rowsBlank),
+		RowsUpserted: int32(rowsUpserted),
+		RowsTriaged:  int32(rowsTriaged),
+		RowsBlank:    int32(rowsBlank),
+		DurationMs:   duration.Milliseconds(),
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record ingestion job stats", "job_id", jobID, "report_type", reportType, "error", err)
+	}
+}
+
+// ingestionCounts tallies what saving a job did to the items table. Unchanged and Archived are
+// only populated by delta ingestion.
+type ingestionCounts struct {
+	Inserted  int64
+	Updated   int64
+	Unchanged int64
+	Archived  int64
+}
+
+func (c *ingestionCounts) add(other ingestionCounts) {
+	c.Inserted += other.Inserted
+	c.Updated += other.Updated
+	c.Unchanged += other.Unchanged
+	c.Archived += other.Archived
+}
+
+// saved is how many of the job's items are now stored, whether or not the save changed them.
+func (c ingestionCounts) saved() int64 {
+	return c.Inserted + c.Updated + c.Unchanged
+}
+
+// pgItemSaver saves items by copying them into a staging table and upserting from it.
+type pgItemSaver struct {
+	queries *repository.Queries
+	dbpool  *pgxpool.Pool
+	logger  *slog.Logger
 }
 
-func (s *Service) saveSuccessfulItems(ctx context.Context, items []repository.Item) (int64, error) {
+func (s *pgItemSaver) saveItems(ctx context.Context, items []repository.Item, ingestionConfig IngestionConfig, archiveMissing bool) (ingestionCounts, error) {
 	// Start a new database transaction. This is crucial for data integrity.
 	tx, err := s.dbpool.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return ingestionCounts{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	// Defer a rollback. If we commit successfully, this does nothing. If we error out, it cleans up the mess.
 	defer tx.Rollback(ctx)
@@ -140,8 +323,10 @@ func (s *Service) saveSuccessfulItems(ctx context.Context, items []repository.It
 	qtx := s.queries.WithTx(tx)
 
 	// --- Step 1: Create the temp table using our new sqlc function ---
+	// temp_items_staging is private to this connection and dropped when the transaction ends, so the
+	// batches of one job, saved one after another, and jobs running on other connections never collide.
 	if err := qtx.CreateTempItemsStagingTable(ctx); err != nil {
-		return 0, fmt.Errorf("failed to create temp staging table: %w", err)
+		return ingestionCounts{}, fmt.Errorf("failed to create temp staging table: %w", err)
 	}
 
 	// --- Step 2: Use pgx.CopyFrom to bulk-insert data into the temp table ---
@@ -149,59 +334,82 @@ func (s *Service) saveSuccessfulItems(ctx context.Context, items []repository.It
 	_, err = tx.CopyFrom(
 		ctx,
 		pgx.Identifier{"temp_items_staging"},
-		[]string{"item_type", "scope", "business_key", "status", "custom_properties", "embedding"},
+		[]string{"item_type", "scope", "business_key", "status", "custom_properties", "embedding", "title_embedding", "body_embedding"},
 		pgx.CopyFromSlice(len(items), func(i int) ([]interface{}, error) {
-			var embeddingValue interface{}
-
-			// Assuming items[i].Embedding is a type with a Slice() method returning []float32
-			embeddingSlice := items[i].Embedding.Slice()
-
-			// Check if the slice is not nil AND actually contains data
-			if len(embeddingSlice) > 0 {
-
-				// DEFENSIVE CHECK: Add a hard limit to prevent the DB error.
-				// The root cause is likely upstream data corruption, but this protects the database.
-				const maxEmbeddingDims = 384
-				if len(embeddingSlice) > maxEmbeddingDims {
-					s.logger.WarnContext(ctx, "Embedding exceeds maximum allowed dimensions, nullifying", "business_key", items[i].BusinessKey, "dims", len(embeddingSlice))
-					// This should not happen. If it does, it's a sign of the upstream bug.
-					embeddingValue = nil
-				} else {
-					// The embedding looks valid, use it.
-					embeddingValue = items[i].Embedding
-				}
-			} else {
-				// The embedding is nil or empty, so we'll insert NULL.
-				embeddingValue = nil
-			}
-
 			return []interface{}{
 				items[i].ItemType,
 				items[i].Scope,
 				items[i].BusinessKey,
 				items[i].Status,
 				items[i].CustomProperties,
-				embeddingValue,
+				s.embeddingCopyValue(ctx, items[i].Embedding, items[i].BusinessKey),
+				s.embeddingCopyValue(ctx, items[i].TitleEmbedding, items[i].BusinessKey),
+				s.embeddingCopyValue(ctx, items[i].BodyEmbedding, items[i].BusinessKey),
 			}, nil
 		}),
 	)
 
 	if err != nil {
-		return 0, fmt.Errorf("failed to copy data to staging table: %w", err)
+		return ingestionCounts{}, fmt.Errorf("failed to copy data to staging table: %w", err)
+	}
+
+	// --- Step 3: For re-ingested documents, archive chunks that are not part of the new version ---
+	if ingestionConfig.ReplaceOnReingest {
+		archived, err := qtx.ArchiveStaleDocumentItems(ctx, repository.ItemType(ingestionConfig.ItemType))
+		if err != nil {
+			return ingestionCounts{}, fmt.Errorf("failed to archive previous document items: %w", err)
+		}
+		s.logger.InfoContext(ctx, "Replaced previously ingested document items", "archived", archived)
+	}
+
+	// --- Step 4: For delta ingestion, archive items missing from the file and skip unchanged rows ---
+	var counts ingestionCounts
+	if ingestionConfig.Delta {
+		if archiveMissing {
+			counts.Archived, err = qtx.ArchiveMissingItems(ctx, repository.ItemType(ingestionConfig.ItemType))
+			if err != nil {
+				return ingestionCounts{}, fmt.Errorf("failed to archive missing items: %w", err)
+			}
+		}
+		counts.Unchanged, err = qtx.DiscardUnchangedStagedItems(ctx)
+		if err != nil {
+			return ingestionCounts{}, fmt.Errorf("failed to discard unchanged items: %w", err)
+		}
 	}
 
-	// --- Step 3: Upsert from the staging table using our existing sqlc function ---
-	rowsAffected, err := qtx.UpsertItems(ctx)
+	// --- Step 5: Upsert from the staging table using our existing sqlc function ---
+	upserted, err := qtx.UpsertItems(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to upsert items from staging table: %w", err)
+		return ingestionCounts{}, fmt.Errorf("failed to upsert items from staging table: %w", err)
 	}
 
-	// --- Step 4: If all steps succeeded, commit the transaction ---
+	// --- Step 6: If all steps succeeded, commit the transaction ---
 	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return ingestionCounts{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	counts.Inserted = upserted.InsertedCount
+	counts.Updated = upserted.UpdatedCount
+	return counts, nil
+}
+
+// embeddingCopyValue returns the value to copy into a vector column: NULL for an empty embedding
+// or one with more dimensions than the column allows.
+func (s *pgItemSaver) embeddingCopyValue(ctx context.Context, embedding pgvector.Vector, businessKey pgtype.Text) interface{} {
+	embeddingSlice := embedding.Slice()
+	// The embedding is nil or empty, so we'll insert NULL.
+	if len(embeddingSlice) == 0 {
+		return nil
 	}
 
-	return rowsAffected, nil
+	// DEFENSIVE CHECK: Add a hard limit to prevent the DB error.
+	// The root cause is likely upstream data corruption, but this protects the database.
+	const maxEmbeddingDims = 384
+	if len(embeddingSlice) > maxEmbeddingDims {
+		s.logger.WarnContext(ctx, "Embedding exceeds maximum allowed dimensions, nullifying", "business_key", businessKey, "dims", len(embeddingSlice))
+		return nil
+	}
+	return embedding
 }
 
 func (s *Service) logTriageItems(ctx context.Context, jobID uuid.UUID, triageRows []TriageRow) {
@@ -0,0 +1,305 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/filestore"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusUpdate struct {
+	Status       string
+	ErrorDetails string
+	RowsUpserted int64
+	RowsTriaged  int64
+}
+
+type fakeJobStatusUpdater struct {
+	updates []statusUpdate
+}
+
+func (f *fakeJobStatusUpdater) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, errorDetails string, rowsUpserted int64, rowsTriaged int64) error {
+	f.updates = append(f.updates, statusUpdate{status, errorDetails, rowsUpserted, rowsTriaged})
+	return nil
+}
+
+func (f *fakeJobStatusUpdater) statuses() []string {
+	var statuses []string
+	for _, update := range f.updates {
+		statuses = append(statuses, update.Status)
+	}
+	return statuses
+}
+
+type fakeJobQuerier struct {
+	repository.Querier
+	triage []repository.CreateIngestionErrorParams
+	stats  []repository.CreateIngestionJobStatsParams
+}
+
+func (f *fakeJobQuerier) CreateIngestionError(ctx context.Context, arg repository.CreateIngestionErrorParams) (repository.IngestionError, error) {
+	f.triage = append(f.triage, arg)
+	return repository.IngestionError{}, nil
+}
+
+func (f *fakeJobQuerier) CreateIngestionJobStats(ctx context.Context, arg repository.CreateIngestionJobStatsParams) error {
+	f.stats = append(f.stats, arg)
+	return nil
+}
+
+func (f *fakeJobQuerier) MarkIngestionJobDegraded(ctx context.Context, id pgtype.UUID) error {
+	return nil
+}
+
+// fakeItemSaver records every saved batch and fails the call numbered failOn, counting from 1. A
+// delta save reports the items in unchanged as unchanged and archived items as archived when asked to.
+type fakeItemSaver struct {
+	batches        [][]string
+	archiveMissing []bool
+	failOn         int
+	unchanged      map[string]bool
+	archived       int64
+}
+
+func (f *fakeItemSaver) saveItems(ctx context.Context, items []repository.Item, ingestionConfig IngestionConfig, archiveMissing bool) (ingestionCounts, error) {
+	if len(f.batches)+1 == f.failOn {
+		return ingestionCounts{}, errors.New("connection reset")
+	}
+	var keys []string
+	var counts ingestionCounts
+	for _, item := range items {
+		keys = append(keys, item.BusinessKey.String)
+		if f.unchanged[item.BusinessKey.String] {
+			counts.Unchanged++
+		} else {
+			counts.Inserted++
+		}
+	}
+	f.batches = append(f.batches, keys)
+	f.archiveMissing = append(f.archiveMissing, archiveMissing)
+	if archiveMissing {
+		counts.Archived = f.archived
+	}
+	return counts, nil
+}
+
+type runJobFixture struct {
+	service *Service
+	jobs    *fakeJobStatusUpdater
+	queries *fakeJobQuerier
+	items   *fakeItemSaver
+}
+
+func newRunJobFixture(t *testing.T, config IngestionConfig, fileKey, contents string) *runJobFixture {
+	t.Helper()
+	store, err := filestore.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	if contents != "" {
+		w, err := store.NewWriter(context.Background(), fileKey)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, contents)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	f := &runJobFixture{jobs: &fakeJobStatusUpdater{}, queries: &fakeJobQuerier{}, items: &fakeItemSaver{}}
+	f.service = &Service{
+		ingestionService: f.jobs,
+		configLoader:     &ConfigLoader{configs: map[string]IngestionConfig{config.ReportType: config}},
+		queries:          f.queries,
+		store:            store,
+		items:            f.items,
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	return f
+}
+
+func TestRunJob(t *testing.T) {
+	ctx := context.Background()
+	const fileKey = "raw-reports/TEST_ITEM/claims.csv"
+	csvData := "claim_id,description,region\nC-1,Fire,west\nC-2,Hail,east\n,Wind,west\nC-3,Flood,west\n"
+
+	t.Run("Saves items and triages bad rows", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		f := newRunJobFixture(t, config, fileKey, csvData)
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "COMPLETE_WITH_ISSUES"}, f.jobs.statuses())
+		final := f.jobs.updates[1]
+		assert.EqualValues(t, 3, final.RowsUpserted)
+		assert.EqualValues(t, 1, final.RowsTriaged)
+		assert.Equal(t, [][]string{{"C-1-WEST", "C-2-EAST", "C-3-WEST"}}, f.items.batches)
+		require.Len(t, f.queries.triage, 1)
+		assert.Contains(t, f.queries.triage[0].ReasonForFailure, "claim_id")
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "COMPLETE_WITH_ISSUES", f.queries.stats[0].Status)
+	})
+
+	t.Run("Posts a degraded job to the webhook", func(t *testing.T) {
+		notifications := make(chan DegradedJobNotification, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var notification DegradedJobNotification
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+			notifications <- notification
+		}))
+		defer server.Close()
+
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.TriageAlertPercent = 10
+		config.DegradedWebhookURL = server.URL
+		f := newRunJobFixture(t, config, fileKey, csvData)
+		jobID := uuid.New()
+
+		f.service.RunJob(ctx, jobID, config.ReportType, fileKey, nil)
+
+		require.Len(t, notifications, 1)
+		notification := <-notifications
+		assert.Equal(t, jobID.String(), notification.JobID)
+		assert.Equal(t, "COMPLETE_WITH_ISSUES", notification.Status)
+		assert.True(t, notification.Degraded)
+		assert.EqualValues(t, 4, notification.RowsTotal)
+		assert.EqualValues(t, 1, notification.RowsTriaged)
+		assert.InDelta(t, 25, notification.TriagePercent, 0.001)
+	})
+
+	t.Run("Completes the job when the webhook fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.TriageAlertPercent = 10
+		config.DegradedWebhookURL = server.URL
+		f := newRunJobFixture(t, config, fileKey, csvData)
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "COMPLETE_WITH_ISSUES"}, f.jobs.statuses())
+		require.Len(t, f.queries.stats, 1)
+	})
+
+	t.Run("Records items saved before a failed batch", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.SaveBatchSize = 1
+		f := newRunJobFixture(t, config, fileKey, csvData)
+		f.items.failOn = 2
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "FAILED"}, f.jobs.statuses())
+		final := f.jobs.updates[1]
+		assert.EqualValues(t, 1, final.RowsUpserted)
+		assert.Contains(t, final.ErrorDetails, "connection reset")
+		assert.Contains(t, final.ErrorDetails, "1 items were saved before the failure")
+		assert.Equal(t, [][]string{{"C-1-WEST"}}, f.items.batches)
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "FAILED", f.queries.stats[0].Status)
+		assert.EqualValues(t, 1, f.queries.stats[0].RowsUpserted)
+	})
+
+	t.Run("Records stats when the final save fails", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		f := newRunJobFixture(t, config, fileKey, csvData)
+		f.items.failOn = 1
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "FAILED"}, f.jobs.statuses())
+		assert.Contains(t, f.jobs.updates[1].ErrorDetails, "Error saving processed data to database")
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "FAILED", f.queries.stats[0].Status)
+		assert.EqualValues(t, 0, f.queries.stats[0].RowsUpserted)
+		assert.EqualValues(t, 1, f.queries.stats[0].RowsTriaged)
+	})
+
+	t.Run("Records stats with zero counts when the headers do not match", func(t *testing.T) {
+		config := newProcessTestConfig()
+		f := newRunJobFixture(t, config, fileKey, "policy_id,description\nP-1,Fire\n")
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "FAILED"}, f.jobs.statuses())
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "FAILED", f.queries.stats[0].Status)
+		assert.EqualValues(t, 0, f.queries.stats[0].RowsTotal)
+	})
+
+	t.Run("Marks a header-only file as having no data", func(t *testing.T) {
+		config := newProcessTestConfig()
+		f := newRunJobFixture(t, config, fileKey, "claim_id,description,region\n")
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "NO_DATA"}, f.jobs.statuses())
+		assert.Contains(t, f.jobs.updates[1].ErrorDetails, "no data rows")
+		assert.Empty(t, f.items.batches)
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "NO_DATA", f.queries.stats[0].Status)
+	})
+
+	t.Run("Reports delta counts and archives items missing from the file", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.Delta = true
+		config.ArchiveMissing = true
+		config.SaveBatchSize = 1
+		f := newRunJobFixture(t, config, fileKey, "claim_id,description,region\nC-1,Fire,west\nC-2,Hail,east\n")
+		f.items.unchanged = map[string]bool{"C-1-WEST": true}
+		f.items.archived = 4
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "COMPLETE"}, f.jobs.statuses())
+		final := f.jobs.updates[1]
+		assert.Equal(t, "Processed 2 items successfully (1 new, 0 changed, 1 unchanged, 4 archived). 0 rows sent for triage. 0 blank rows discarded.", final.ErrorDetails)
+		assert.EqualValues(t, 2, final.RowsUpserted)
+		assert.Equal(t, [][]string{{"C-1-WEST", "C-2-EAST"}}, f.items.batches, "the file is saved in one batch so archival sees every row")
+		assert.Equal(t, []bool{true}, f.items.archiveMissing)
+	})
+
+	t.Run("Skips archival when rows are sent for triage", func(t *testing.T) {
+		config := newProcessTestConfig()
+		config.EmbedContent = nil
+		config.Delta = true
+		config.ArchiveMissing = true
+		f := newRunJobFixture(t, config, fileKey, csvData)
+		f.items.archived = 4
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "COMPLETE_WITH_ISSUES"}, f.jobs.statuses())
+		assert.Equal(t, []bool{false}, f.items.archiveMissing, "a triaged row's item would be archived as missing")
+		assert.Contains(t, f.jobs.updates[1].ErrorDetails, "0 archived")
+	})
+
+	t.Run("Fails when the file is missing from storage", func(t *testing.T) {
+		config := newProcessTestConfig()
+		f := newRunJobFixture(t, config, fileKey, "")
+
+		f.service.RunJob(ctx, uuid.New(), config.ReportType, fileKey, nil)
+
+		assert.Equal(t, []string{"PROCESSING", "FAILED"}, f.jobs.statuses())
+		assert.Contains(t, f.jobs.updates[1].ErrorDetails, "Failed to read file from storage")
+		assert.Empty(t, f.items.batches)
+		require.Len(t, f.queries.stats, 1)
+		assert.Equal(t, "FAILED", f.queries.stats[0].Status)
+	})
+}
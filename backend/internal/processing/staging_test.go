@@ -0,0 +1,83 @@
+package processing
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStagingTableScope checks that the fixed temp_items_staging name is safe for batched saves: the
+// table lives only as long as its transaction and only on its connection. It needs a Postgres with the
+// platform migrations applied and is skipped unless TEST_DATABASE_URL points at it.
+func TestStagingTableScope(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	t.Run("Sequential batches on one connection", func(t *testing.T) {
+		conn, err := pool.Acquire(ctx)
+		require.NoError(t, err)
+		defer conn.Release()
+
+		for batch := 0; batch < 2; batch++ {
+			tx, err := conn.Begin(ctx)
+			require.NoError(t, err)
+			require.NoError(t, repository.New(tx).CreateTempItemsStagingTable(ctx), "batch %d", batch)
+			require.NoError(t, tx.Commit(ctx))
+		}
+
+		// A rolled back batch must not leave the table behind either.
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, repository.New(tx).CreateTempItemsStagingTable(ctx))
+		require.NoError(t, tx.Rollback(ctx))
+
+		var exists bool
+		require.NoError(t, conn.QueryRow(ctx, `SELECT to_regclass('pg_temp.temp_items_staging') IS NOT NULL`).Scan(&exists))
+		require.False(t, exists)
+	})
+
+	t.Run("Concurrent jobs on separate connections", func(t *testing.T) {
+		const jobs = 4
+		ready := make(chan struct{})
+		errs := make(chan error, jobs)
+		var wg sync.WaitGroup
+		for i := 0; i < jobs; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tx, err := pool.Begin(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer tx.Rollback(ctx)
+				<-ready
+				// Every job holds its staging table open at the same time.
+				if err := repository.New(tx).CreateTempItemsStagingTable(ctx); err != nil {
+					errs <- err
+					return
+				}
+				_, err = tx.Exec(ctx, `SELECT pg_sleep(0.1)`)
+				errs <- err
+			}()
+		}
+		close(ready)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+	})
+}
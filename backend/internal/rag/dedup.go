@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultDuplicateThreshold is the text similarity at or above which two retrieved chunks are
+// treated as duplicates of each other.
+const DefaultDuplicateThreshold = 0.9
+
+// TextSimilarity compares two texts by word-level edit distance, normalized by the longer text:
+// 1 means the same words in the same order, 0 means nothing in common. Case and punctuation are
+// ignored, so chunks cut from overlapping windows of a document compare as near-identical.
+func TextSimilarity(a, b string) float64 {
+	wa, wb := similarityWords(a), similarityWords(b)
+	longest := max(len(wa), len(wb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(wordEditDistance(wa, wb))/float64(longest)
+}
+
+// DedupeNearDuplicates drops items whose text is at least threshold similar to an item that is
+// already kept. Items are considered best first according to better (or in their given order when
+// better is nil), so the best item of each duplicate group survives. Kept items stay in their
+// original order.
+func DedupeNearDuplicates[T any](items []T, text func(T) string, better func(a, b T) bool, threshold float64) []T {
+	if len(items) < 2 {
+		return items
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	if better != nil {
+		sort.SliceStable(order, func(i, j int) bool { return better(items[order[i]], items[order[j]]) })
+	}
+
+	words := make([][]string, len(items))
+	for i, item := range items {
+		words[i] = similarityWords(text(item))
+	}
+	keep := make([]bool, len(items))
+	var kept []int
+	for _, i := range order {
+		duplicate := false
+		for _, k := range kept {
+			if nearDuplicate(words[i], words[k], threshold) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			keep[i] = true
+			kept = append(kept, i)
+		}
+	}
+
+	deduped := make([]T, 0, len(kept))
+	for i, item := range items {
+		if keep[i] {
+			deduped = append(deduped, item)
+		}
+	}
+	return deduped
+}
+
+// nearDuplicate reports whether two word sequences are at least threshold similar. The edit
+// distance is at least the difference in length, so texts of very different length are ruled out
+// without computing it.
+func nearDuplicate(a, b []string, threshold float64) bool {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return true
+	}
+	if float64(min(len(a), len(b)))/float64(longest) < threshold {
+		return false
+	}
+	return 1-float64(wordEditDistance(a, b))/float64(longest) >= threshold
+}
+
+func similarityWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordEditDistance is the Levenshtein distance between two word sequences.
+func wordEditDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,43 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, TextSimilarity("Water damage, kitchen.", "water damage kitchen"))
+	assert.InDelta(t, 0.75, TextSimilarity("the roof was damaged", "the roof was replaced"), 1e-9)
+	assert.Equal(t, 0.0, TextSimilarity("hail", "flood"))
+}
+
+func TestDedupeNearDuplicates(t *testing.T) {
+	type chunk struct {
+		text     string
+		distance float64
+	}
+	text := func(c chunk) string { return c.text }
+	closer := func(a, b chunk) bool { return a.distance < b.distance }
+
+	t.Run("Keeps the best chunk of each duplicate group in original order", func(t *testing.T) {
+		chunks := []chunk{
+			{"Collision coverage pays for damage to your car after an accident with another vehicle.", 0.30},
+			{"Comprehensive coverage pays for theft, fire and hail damage.", 0.25},
+			{"collision coverage pays for damage to your car after an accident with another vehicle", 0.10},
+		}
+		deduped := DedupeNearDuplicates(chunks, text, closer, DefaultDuplicateThreshold)
+		assert.Equal(t, []chunk{chunks[1], chunks[2]}, deduped)
+	})
+
+	t.Run("Uses the given order when better is nil", func(t *testing.T) {
+		chunks := []chunk{{text: "Claim 12 was approved."}, {text: "Claim 12 was approved"}, {text: "Claim 13 was denied."}}
+		deduped := DedupeNearDuplicates(chunks, text, nil, DefaultDuplicateThreshold)
+		assert.Equal(t, []chunk{chunks[0], chunks[2]}, deduped)
+	})
+
+	t.Run("Keeps distinct chunks", func(t *testing.T) {
+		chunks := []chunk{{text: "Deductibles apply per claim."}, {text: "Rental reimbursement is capped at 30 days."}}
+		assert.Equal(t, chunks, DedupeNearDuplicates(chunks, text, closer, DefaultDuplicateThreshold))
+	})
+}
@@ -0,0 +1,40 @@
+package rag
+
+import (
+	"fmt"
+	"math"
+)
+
+// EmbeddingDimensions is the size of the vector columns embeddings are stored in.
+const EmbeddingDimensions = 384
+
+// ValidateEmbedding checks that the embedding service returned a vector that fits the vector
+// columns. An empty or wrongly sized vector would otherwise be stored as NULL and the row would
+// silently drop out of semantic search.
+func ValidateEmbedding(v []float32) error {
+	if len(v) == 0 {
+		return fmt.Errorf("embedding service returned an empty embedding")
+	}
+	if len(v) != EmbeddingDimensions {
+		return fmt.Errorf("embedding service returned %d dimensions, expected %d", len(v), EmbeddingDimensions)
+	}
+	return nil
+}
+
+// NormalizeL2 scales v to unit length, in place, and returns it. Cosine distance assumes unit
+// vectors, and inner-product search only ranks correctly when they are. A zero vector is
+// returned unchanged.
+func NormalizeL2(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := math.Sqrt(sum)
+	for i, x := range v {
+		v[i] = float32(float64(x) / norm)
+	}
+	return v
+}
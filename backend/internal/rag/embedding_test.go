@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func vectorLength(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+func TestNormalizeL2(t *testing.T) {
+	t.Run("Scales vectors to unit length", func(t *testing.T) {
+		for _, v := range [][]float32{{3, 4}, {0.1, 0.2, 0.3}, {-12.5, 0, 7, 1e-3}} {
+			normalized := NormalizeL2(v)
+			assert.InDelta(t, 1.0, vectorLength(normalized), 1e-6)
+		}
+	})
+
+	t.Run("Keeps direction", func(t *testing.T) {
+		assert.InDeltaSlice(t, []float32{0.6, 0.8}, NormalizeL2([]float32{3, 4}), 1e-6)
+	})
+
+	t.Run("Leaves zero vectors unchanged", func(t *testing.T) {
+		assert.Equal(t, []float32{0, 0, 0}, NormalizeL2([]float32{0, 0, 0}))
+	})
+}
+
+// fullSizeEmbedding returns an embedding of EmbeddingDimensions that starts with values.
+func fullSizeEmbedding(values ...float32) []float32 {
+	return append(values, make([]float32, EmbeddingDimensions-len(values))...)
+}
+
+func TestGetEmbeddingNormalization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(EmbeddingResponse{Embedding: fullSizeEmbedding(3, 4)})
+	}))
+	defer server.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	raw, err := NewRAGService(server.URL, false, "", "", true, nil, logger).GetEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, fullSizeEmbedding(3, 4), raw)
+
+	normalized, err := NewRAGService(server.URL, true, "", "", true, nil, logger).GetEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, vectorLength(normalized), 1e-6)
+}
+
+func TestGetEmbeddingValidation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, tc := range map[string]struct {
+		body    string
+		wantErr string
+	}{
+		"Empty object":    {body: `{}`, wantErr: "empty embedding"},
+		"Empty embedding": {body: `{"embedding": []}`, wantErr: "empty embedding"},
+		"Wrong dimension": {body: `{"embedding": [0.1, 0.2, 0.3]}`, wantErr: "returned 3 dimensions, expected 384"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tc.body)
+			}))
+			defer server.Close()
+
+			_, err := NewRAGService(server.URL, false, "", "", true, nil, logger).GetEmbedding(context.Background(), "text")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
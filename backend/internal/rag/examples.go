@@ -0,0 +1,56 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PlannerExample is a few-shot example for a planner prompt: a question, optionally the chat
+// history leading up to it, and the tool calls the planner should answer with.
+type PlannerExample struct {
+	Question  string        `yaml:"question" json:"question"`
+	History   []ChatMessage `yaml:"history,omitempty" json:"history,omitempty"`
+	ToolCalls []ToolCall    `yaml:"tool_calls" json:"tool_calls"`
+}
+
+// PlanJSON renders the example's tool calls in the planner's response format, for use in templates.
+func (e PlannerExample) PlanJSON() (string, error) {
+	toolCalls := e.ToolCalls
+	if toolCalls == nil {
+		toolCalls = []ToolCall{}
+	}
+	plan, err := json.Marshal(PlannerResponse{ToolCalls: toolCalls})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal example plan for %q: %w", e.Question, err)
+	}
+	return string(plan), nil
+}
+
+// LoadPlannerExamples reads the few-shot examples YAML at path. The file holds an "examples" list
+// of question / tool_calls pairs.
+func LoadPlannerExamples(path string) ([]PlannerExample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read planner examples %s: %w", path, err)
+	}
+	var file struct {
+		Examples []PlannerExample `yaml:"examples"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse planner examples %s: %w", path, err)
+	}
+	for i, example := range file.Examples {
+		if example.Question == "" {
+			return nil, fmt.Errorf("FATAL: planner example %d in %s is missing a question", i+1, path)
+		}
+		for _, call := range example.ToolCalls {
+			if call.ToolName == "" {
+				return nil, fmt.Errorf("FATAL: planner example %q in %s has a tool call without a tool", example.Question, path)
+			}
+		}
+	}
+	return file.Examples, nil
+}
@@ -0,0 +1,58 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPlannerExamples(t *testing.T) {
+	writeExamples := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "examples.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("Loads examples and renders their plans", func(t *testing.T) {
+		path := writeExamples(t, `
+examples:
+  - question: "Show me flagged claims"
+    tool_calls:
+      - tool: get_claims_data
+        arguments:
+          status: Flagged
+  - question: "Thanks!"
+    history:
+      - sender: ai
+        content: "Here are the claims."
+    tool_calls: []
+`)
+		examples, err := LoadPlannerExamples(path)
+		require.NoError(t, err)
+		require.Len(t, examples, 2)
+
+		plan, err := examples[0].PlanJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"tool_calls": [{"tool": "get_claims_data", "arguments": {"status": "Flagged"}}]}`, plan)
+
+		assert.Equal(t, []ChatMessage{{Sender: "ai", Content: "Here are the claims."}}, examples[1].History)
+		plan, err = examples[1].PlanJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"tool_calls": []}`, plan)
+	})
+
+	t.Run("Rejects a tool call without a tool", func(t *testing.T) {
+		path := writeExamples(t, `
+examples:
+  - question: "Show me flagged claims"
+    tool_calls:
+      - arguments:
+          status: Flagged
+`)
+		_, err := LoadPlannerExamples(path)
+		assert.ErrorContains(t, err, "without a tool")
+	})
+}
@@ -0,0 +1,43 @@
+package rag
+
+import "regexp"
+
+// injectionPatterns are phrasings commonly used to override a prompt's instructions. The guard is
+// deliberately narrow: it catches the obvious attempts, while the fencing of user input in the
+// prompt templates is what keeps the rest from being read as instructions.
+var injectionPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+)?(of\s+)?(the\s+|your\s+)?(previous|prior|above|earlier|system|original)\s+(instructions|prompts?|rules|directions)`)},
+	{"reveal_prompt", regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output)\s+(me\s+)?(your|the)\s+(system\s+|original\s+)?(prompt|instructions)`)},
+	{"role_override", regexp.MustCompile(`(?i)\byou\s+are\s+now\b|\bnew\s+instructions\s*:`)},
+	{"role_marker", regexp.MustCompile(`(?im)^\s*(system|assistant)\s*:`)},
+	{"fence_escape", regexp.MustCompile(`(?i)</?\s*(user_question|chat_history)\s*>`)},
+}
+
+// DetectPromptInjection scans user input for known prompt-injection patterns. It returns the
+// name of the first pattern that matched.
+func DetectPromptInjection(input string) (string, bool) {
+	for _, p := range injectionPatterns {
+		if p.pattern.MatchString(input) {
+			return p.name, true
+		}
+	}
+	return "", false
+}
+
+// DetectHistoryInjection scans the messages the user wrote in a chat history, which the prompts
+// replay alongside the question. It returns the name of the first pattern that matched and the
+// index of the message it matched in.
+func DetectHistoryInjection(history []ChatMessage) (string, int, bool) {
+	for i, message := range history {
+		if message.Sender != UserSender {
+			continue
+		}
+		if pattern, found := DetectPromptInjection(message.Content); found {
+			return pattern, i, true
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,34 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLanguage is the language answers are written in when a request doesn't ask for one.
+const DefaultLanguage = "English"
+
+// supportedLanguages maps ISO 639-1 codes to the language names given to the synthesizer.
+var supportedLanguages = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+}
+
+// ResolveLanguage turns a requested language, given as an ISO 639-1 code or a language name, into
+// the name used in the synthesizer prompt. An empty language resolves to DefaultLanguage. Only
+// supported languages are accepted so that free text from the request never reaches the prompt.
+func ResolveLanguage(language string) (string, error) {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return DefaultLanguage, nil
+	}
+	if name, ok := supportedLanguages[strings.ToLower(language)]; ok {
+		return name, nil
+	}
+	for _, name := range supportedLanguages {
+		if strings.EqualFold(name, language) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", language)
+}
@@ -5,9 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -31,17 +34,26 @@ func NewRAGHandler(reg *RAGRegistry, svc *RAGService, logger *slog.Logger, queri
 	}
 }
 
+// DebugPermission gates plan_only queries, which expose the raw planner output.
+const DebugPermission = "rag:debug"
+
 // --- Structs for the RAG Pipeline ---
 
 type RAGRequest struct {
 	Context  string        `json:"context"`
 	Question string        `json:"question"`
 	History  []ChatMessage `json:"history"`
+	// Language is the language the answer should be written in, as an ISO 639-1 code or name.
+	// Tools still query in the data's own language. Defaults to DefaultLanguage.
+	Language string `json:"language,omitempty"`
 }
 
+// UserSender is the ChatMessage sender of the messages the user wrote; the assistant's are "ai".
+const UserSender = "user"
+
 type ChatMessage struct {
-	Sender  string `json:"sender"`
-	Content string `json:"content"`
+	Sender  string `json:"sender" yaml:"sender"`
+	Content string `json:"content" yaml:"content"`
 }
 
 type PlannerResponse struct {
@@ -49,8 +61,8 @@ type PlannerResponse struct {
 }
 
 type ToolCall struct {
-	ToolName  string                 `json:"tool"`
-	Arguments map[string]interface{} `json:"arguments"`
+	ToolName  string                 `json:"tool" yaml:"tool"`
+	Arguments map[string]interface{} `json:"arguments" yaml:"arguments"`
 }
 
 // --- Main Handler ---
@@ -63,6 +75,21 @@ func (h *RAGHandler) HandleRAGQuery(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body: "+err.Error())
 	}
 
+	if pattern, found := DetectPromptInjection(req.Question); found {
+		h.logger.WarnContext(ctx, "Rejected RAG question matching a prompt-injection pattern", "request_id", c.Get("requestID"), "pattern", pattern)
+		return echo.NewHTTPError(http.StatusBadRequest, "The question looks like an attempt to change the assistant's instructions. Please rephrase it as a question about your data.")
+	}
+	if pattern, index, found := DetectHistoryInjection(req.History); found {
+		h.logger.WarnContext(ctx, "Rejected RAG history matching a prompt-injection pattern", "request_id", c.Get("requestID"), "pattern", pattern, "message_index", index)
+		return echo.NewHTTPError(http.StatusBadRequest, "A message in the conversation history looks like an attempt to change the assistant's instructions. Please start a new conversation.")
+	}
+
+	language, err := ResolveLanguage(req.Language)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "field 'language': "+err.Error())
+	}
+	req.Language = language
+
 	// 1. Look up the context from the registry
 	ragContext, found := h.registry.Get(req.Context)
 	if !found {
@@ -70,10 +97,45 @@ func (h *RAGHandler) HandleRAGQuery(c echo.Context) error {
 	}
 
 	reqLogger := h.logger.With("request_id", c.Get("requestID"), "context", req.Context)
-	reqLogger.InfoContext(ctx, "Executing RAG query", "question", req.Question)
+
+	// The LLM only ever sees the question and history with PII masked. The placeholders are put back
+	// into tool arguments before the tools run, and into the answer when the context restores it.
+	redactions := NewRedactionMap()
+	prompt := req
+	prompt.Question = ragContext.Redactor.Redact(req.Question, redactions)
+	prompt.History = ragContext.Redactor.RedactHistory(req.History, redactions)
+
+	usage := h.service.NewUsageTracker()
+	ctx = WithUsageTracker(ctx, usage)
+	defer func() {
+		summary := usage.Summary()
+		reqLogger.InfoContext(ctx, "RAG query LLM usage", "llm_calls", summary.Calls, "prompt_tokens", summary.PromptTokens,
+			"completion_tokens", summary.CompletionTokens, "total_tokens", summary.TotalTokens, "estimated_cost_usd", summary.EstimatedCostUSD)
+	}()
+
+	// plan_only returns the planner's tool calls for the first cycle without running any tools,
+	// so prompt engineers can iterate on the planner template.
+	if c.QueryParam("plan_only") == "true" {
+		if _, ok := userPermissionSet(ctx)[DebugPermission]; !ok {
+			reqLogger.WarnContext(ctx, "User requested a plan preview without the debug permission")
+			return echo.NewHTTPError(http.StatusForbidden, "Plan preview requires the "+DebugPermission+" permission")
+		}
+		reqLogger.InfoContext(ctx, "Previewing RAG query plan", "question", prompt.Question)
+		plan, err := h.getExecutionPlan(ctx, ragContext, prompt, map[string]interface{}{}, redactions)
+		if err != nil {
+			reqLogger.ErrorContext(ctx, "Failed to get execution plan", "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Error during planning phase")
+		}
+		return c.JSON(http.StatusOK, PlannerResponse{ToolCalls: plan})
+	}
+
+	// Logs carry the redacted question, like the prompts.
+	reqLogger.InfoContext(ctx, "Executing RAG query", "question", prompt.Question)
 
 	// --- The ReAct Loop ---
-	scratchpad := make(map[string]interface{})
+	scratchpad := NewScratchpad(ragContext.MaxScratchpadBytes, ragContext.MaxScratchpadEntries)
+	// unavailable holds the critical tools that failed even when retried, until a later cycle runs them successfully.
+	unavailable := make(map[string]struct{})
 	var finalAnswer json.RawMessage
 
 	// use the configured limit, with safe default of 1
@@ -86,35 +148,64 @@ func (h *RAGHandler) HandleRAGQuery(c echo.Context) error {
 		reqLogger.InfoContext(ctx, "Starting ReAct Cycle", "cycle", i+1, "max_cycles", maxCycles)
 
 		// STEP 1: PLAN - Decide which tools to use
-		plan, err := h.getExecutionPlan(ctx, ragContext, req, scratchpad)
+		plan, err := h.getExecutionPlan(ctx, ragContext, prompt, scratchpad.Entries(), redactions)
 		if err != nil {
 			reqLogger.ErrorContext(ctx, "Failed to get execution plan", "error", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Error during planning phase")
 		}
 
-		if len(plan) == 1 && plan[0].ToolName == "final_answer" {
+		// An empty plan means no tools are needed, as for a greeting or a clarification question, or
+		// that the data gathered so far is enough, so the answer is synthesized straight away.
+		if len(plan) == 0 {
+			reqLogger.InfoContext(ctx, "Planner chose no tools, synthesizing answer", "cycle", i+1, "scratchpad_entries", len(scratchpad.Entries()))
+			break
+		}
+
+		if len(plan) == 1 && plan[0].ToolName == finalAnswerTool {
 			if answer, ok := plan[0].Arguments["answer"].(string); ok {
+				if ragContext.Redactor.RestoresAnswer() {
+					answer = redactions.RestoreJSON(answer)
+				}
 				finalAnswer = json.RawMessage(answer)
+				if !json.Valid(finalAnswer) {
+					reqLogger.WarnContext(ctx, "Planner returned a malformed final answer, falling back to a text response", "raw_content", ragContext.Redactor.Redact(answer, redactions))
+					if finalAnswer, err = textResponse(answer); err != nil {
+						reqLogger.ErrorContext(ctx, "Failed to build fallback answer", "error", err)
+						return echo.NewHTTPError(http.StatusInternalServerError, "Error during synthesis phase")
+					}
+				}
 			}
 			break
 		}
 
 		// STEP 2: EXECUTE - Run the tools to fetch data
-		retrievedData, err := h.executePlan(ctx, ragContext, plan)
+		retrievedData, failed, err := h.executePlan(ctx, ragContext, plan)
 		if err != nil {
 			reqLogger.ErrorContext(ctx, "Failed to execute plan", "error", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Error during execution phase")
 		}
+		for key := range retrievedData {
+			delete(unavailable, key)
+		}
+		for _, toolName := range failed {
+			unavailable[toolName] = struct{}{}
+		}
 
-		for key, value := range retrievedData {
-			scratchpad[key] = value
+		// Results are added in a stable order so eviction doesn't depend on map iteration.
+		for _, key := range slices.Sorted(maps.Keys(retrievedData)) {
+			for _, eviction := range scratchpad.Set(key, retrievedData[key]) {
+				reqLogger.WarnContext(ctx, "Scratchpad limit reached, evicted entry", "tool_name", eviction.Key, "action", eviction.Action,
+					"entry_bytes", eviction.Bytes, "scratchpad_bytes", scratchpad.Size())
+			}
 		}
 	}
 	// STEP 3: SYNTHESIZE - Generate a final response from the data
-	var err error
 	if finalAnswer == nil {
-		reqLogger.InfoContext(ctx, "Max cycles reached. Synthesizing final answer from scratchpad.")
-		finalAnswer, err = h.synthesizeAnswer(ctx, ragContext, req, scratchpad)
+		reqLogger.InfoContext(ctx, "Synthesizing final answer from scratchpad.")
+		if len(unavailable) > 0 {
+			reqLogger.WarnContext(ctx, "Synthesizing without the data of failed critical tools", "unavailable_tools", slices.Sorted(maps.Keys(unavailable)))
+		}
+		finalAnswer, err = h.synthesizeAnswer(ctx, ragContext, prompt, scratchpad.Entries(), slices.Sorted(maps.Keys(unavailable)), redactions)
 		if err != nil {
 			reqLogger.ErrorContext(ctx, "Failed to synthesize answer", "error", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Error during synthesis phase")
@@ -125,45 +216,161 @@ func (h *RAGHandler) HandleRAGQuery(c echo.Context) error {
 
 // --- Pipeline Helper Functions ---
 
-func (h *RAGHandler) getExecutionPlan(ctx context.Context, ragCtx RAGContext, req RAGRequest, scratchpad map[string]interface{}) ([]ToolCall, error) {
+// getExecutionPlan asks the planner for the next tool calls. req must already be redacted; the
+// scratchpad is redacted here with redactions, and placeholders in the planned tool arguments are
+// restored so the tools query the original values.
+func (h *RAGHandler) getExecutionPlan(ctx context.Context, ragCtx RAGContext, req RAGRequest, scratchpad map[string]interface{}, redactions *RedactionMap) ([]ToolCall, error) {
 	var promptBuffer bytes.Buffer
 
+	scratchpad, err := ragCtx.Redactor.RedactEntries(scratchpad, redactions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tools and ToolsPrompt are generated from the registered tools the user may run, so the prompt
+	// can't drift from what executePlan will actually run. A plan naming another tool is corrected
+	// like one naming a tool that doesn't exist.
+	ragCtx = ragCtx.forPermissions(userPermissionSet(ctx))
+	tools := ragCtx.plannerTools()
 	templateData := map[string]interface{}{
 		"UserQuestion": req.Question,
 		"History":      req.History,
 		"Scratchpad":   scratchpad,
+		"Tools":        tools,
+		"ToolsPrompt":  FormatPlannerTools(tools),
+		// NativeToolCalling lets templates leave out the JSON response format instructions.
+		"NativeToolCalling": ragCtx.NativeToolCalling,
 	}
 	if err := ragCtx.PlannerTemplate.Execute(&promptBuffer, templateData); err != nil {
 		return nil, fmt.Errorf("failed to execute planner template: %w", err)
 	}
 
-	llmResponseContent, err := h.service.CallLLM(ctx, promptBuffer.String(), true)
+	plan, err := h.requestPlan(ctx, ragCtx, promptBuffer.String())
+	if err != nil {
+		return nil, err
+	}
+	unknown := ragCtx.unknownTools(plan)
+	if len(unknown) == 0 {
+		return restorePlan(plan, redactions), nil
+	}
+
+	// A hallucinated tool would be skipped and the answer degraded, so give the model one chance to
+	// fix its plan against the real tool list.
+	h.logger.WarnContext(ctx, "Planner requested unknown tools, asking it to correct the plan", "attempt", 1, "unknown_tools", unknown, "plan", toolNames(plan))
+	corrected, err := h.requestPlan(ctx, ragCtx, promptBuffer.String()+plannerCorrection(unknown, ragCtx.validToolNames()))
+	if err != nil {
+		h.logger.WarnContext(ctx, "Planner correction failed, keeping the first plan", "attempt", 2, "error", err)
+		return restorePlan(plan, redactions), nil
+	}
+	if stillUnknown := ragCtx.unknownTools(corrected); len(stillUnknown) > 0 {
+		h.logger.WarnContext(ctx, "Corrected plan still requests unknown tools, they will be skipped", "attempt", 2, "unknown_tools", stillUnknown, "plan", toolNames(corrected))
+	} else {
+		h.logger.InfoContext(ctx, "Planner corrected its plan", "attempt", 2, "plan", toolNames(corrected))
+	}
+	return restorePlan(corrected, redactions), nil
+}
+
+// restorePlan puts the redacted values back into the tool arguments of plan. The final answer is
+// left alone; whether it is restored depends on the context's redaction config.
+func restorePlan(plan []ToolCall, redactions *RedactionMap) []ToolCall {
+	for i, call := range plan {
+		if call.ToolName != finalAnswerTool {
+			plan[i].Arguments = redactions.RestoreArguments(call.Arguments)
+		}
+	}
+	return plan
+}
+
+// requestPlan asks the model for a tool plan, using native tool calling when the context enables it.
+func (h *RAGHandler) requestPlan(ctx context.Context, ragCtx RAGContext, prompt string) ([]ToolCall, error) {
+	if ragCtx.NativeToolCalling {
+		toolCalls, content, err := h.service.CallLLMWithTools(ctx, prompt, ragCtx.toolDefinitions())
+		switch {
+		case err != nil:
+			h.logger.WarnContext(ctx, "Native tool calling failed, falling back to a JSON plan", "error", err)
+		case len(toolCalls) > 0 || strings.TrimSpace(content) == "":
+			return toolCalls, nil
+		default:
+			// The model answered in text despite the tools; it may still have written a JSON plan.
+			return parsePlannerResponse(content)
+		}
+	}
+
+	llmResponseContent, err := h.service.CallLLM(ctx, prompt, true)
 	if err != nil {
 		return nil, fmt.Errorf("LLM call for planning failed: %w", err)
 	}
+	return parsePlannerResponse(llmResponseContent)
+}
+
+// finalAnswerTool is the pseudo-tool the planner calls to answer directly instead of running tools.
+const finalAnswerTool = "final_answer"
+
+// NoDataRetrievedContext is given to the synthesizer as its context when no tool retrieved anything,
+// so small talk and clarification questions get an answer instead of a summary of empty data.
+const NoDataRetrievedContext = "No data was retrieved for this question. Answer from the conversation alone, do not invent records or figures, and ask a clarifying question if the request is unclear."
+
+// unavailableDataNotice tells the synthesizer which critical tools failed, so it caveats the answer
+// instead of filling the gap.
+func unavailableDataNotice(toolNames []string) string {
+	return fmt.Sprintf("The data from these tools could not be retrieved, even on retry: %s. Tell the user which information is unavailable "+
+		"and that the answer may be incomplete, and do not guess the missing data.", strings.Join(toolNames, ", "))
+}
+
+// validToolNames lists the tool names a plan may use, sorted.
+func (c RAGContext) validToolNames() []string {
+	return append(slices.Sorted(maps.Keys(c.Tools)), finalAnswerTool)
+}
+
+// unknownTools returns the tool names in plan that the context doesn't provide, in plan order.
+func (c RAGContext) unknownTools(plan []ToolCall) []string {
+	var unknown []string
+	for _, toolCall := range plan {
+		if _, found := c.Tools[toolCall.ToolName]; found || toolCall.ToolName == finalAnswerTool {
+			continue
+		}
+		if !slices.Contains(unknown, toolCall.ToolName) {
+			unknown = append(unknown, toolCall.ToolName)
+		}
+	}
+	return unknown
+}
+
+// plannerCorrection is appended to the planner prompt when the first plan used tools that don't exist.
+func plannerCorrection(unknown, valid []string) string {
+	return fmt.Sprintf("\n\nYour previous plan requested tools that do not exist: %s. The only available tools are: %s. "+
+		"Respond again with a complete plan that uses only the available tools.", strings.Join(unknown, ", "), strings.Join(valid, ", "))
+}
 
+func toolNames(plan []ToolCall) []string {
+	names := make([]string, len(plan))
+	for i, toolCall := range plan {
+		names[i] = toolCall.ToolName
+	}
+	return names
+}
+
+// parsePlannerResponse reads a tool plan out of the planner's text response.
+func parsePlannerResponse(llmResponseContent string) ([]ToolCall, error) {
 	cleanedJSON := strings.Trim(strings.TrimSpace(llmResponseContent), "```json \n")
 	var plannerResponse PlannerResponse
 	if err := json.Unmarshal([]byte(cleanedJSON), &plannerResponse); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tool call plan from LLM: %w. Raw content: %s", err, llmResponseContent)
 	}
-
 	return plannerResponse.ToolCalls, nil
 }
 
-func (h *RAGHandler) executePlan(ctx context.Context, context RAGContext, plan []ToolCall) (map[string]interface{}, error) {
+// executePlan runs the plan's tools and returns their results by tool name. A tool that fails
+// is recorded as an error marker in the results, except a critical tool: it is retried once, and
+// if it fails again it is left out of the results and returned among the failed tools instead.
+func (h *RAGHandler) executePlan(ctx context.Context, context RAGContext, plan []ToolCall) (map[string]interface{}, []string, error) {
 	retrievedData := make(map[string]interface{})
+	var failed []string
 
 	// Get the user's permissions and scopes that were injected by the middleware.
-	userPermissions, _ := ctx.Value("user_permissions").([]string)
+	permissionSet := userPermissionSet(ctx)
 	userScopes, _ := ctx.Value("user_scopes").([]string)
 
-	// Create a map for quick permission lookups.
-	permissionSet := make(map[string]struct{})
-	for _, p := range userPermissions {
-		permissionSet[p] = struct{}{}
-	}
-
 	for _, toolCall := range plan {
 		tool, found := context.Tools[toolCall.ToolName]
 		if !found {
@@ -181,19 +388,62 @@ func (h *RAGHandler) executePlan(ctx context.Context, context RAGContext, plan [
 
 		// === EXECUTE TOOL WITH SCOPES (Data-Based) ===
 		// The user's authorized scopes are passed directly to the tool function.
-		result, err := tool.Function(ctx, h.queriers, userScopes, toolCall.Arguments)
+		result, err := h.runTool(ctx, tool, toolCall, userScopes)
+		if err != nil && tool.Critical {
+			h.logger.WarnContext(ctx, "Critical tool failed, retrying it once", "tool_name", toolCall.ToolName, "attempt", 1)
+			result, err = h.runTool(ctx, tool, toolCall, userScopes)
+			if err != nil {
+				h.logger.ErrorContext(ctx, "Critical tool failed again, its data will be reported as unavailable", "tool_name", toolCall.ToolName, "attempt", 2)
+				// A tool the plan already ran successfully keeps its earlier result.
+				if _, ok := retrievedData[toolCall.ToolName]; !ok && !slices.Contains(failed, toolCall.ToolName) {
+					failed = append(failed, toolCall.ToolName)
+				}
+				continue
+			}
+		}
 		if err != nil {
-			h.logger.ErrorContext(ctx, "Tool execution failed", "tool_name", toolCall.ToolName, "error", err)
 			retrievedData[toolCall.ToolName] = map[string]string{"error": err.Error()}
 			continue
 		}
 		retrievedData[toolCall.ToolName] = result
+		failed = slices.DeleteFunc(failed, func(name string) bool { return name == toolCall.ToolName })
 	}
 
-	return retrievedData, nil
+	return retrievedData, failed, nil
 }
 
-func (h *RAGHandler) synthesizeAnswer(ctx context.Context, ragCtx RAGContext, req RAGRequest, data map[string]interface{}) (json.RawMessage, error) {
+// errMalformedToolResult is the error marker for a result that failed CheckResult.
+var errMalformedToolResult = errors.New("The tool returned a malformed result, so it was discarded.")
+
+// runTool runs one tool call and checks its result. The returned error is safe to show the
+// synthesizer; the underlying failure is logged.
+func (h *RAGHandler) runTool(ctx context.Context, tool Tool, toolCall ToolCall, userScopes []string) (interface{}, error) {
+	result, err := tool.Function(ctx, h.queriers, userScopes, toolCall.Arguments)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Tool execution failed", "tool_name", toolCall.ToolName, "error", err)
+		return nil, err
+	}
+	if err := tool.CheckResult(result); err != nil {
+		h.logger.ErrorContext(ctx, "Tool returned a malformed result", "tool_name", toolCall.ToolName, "error", err)
+		return nil, errMalformedToolResult
+	}
+	return result, nil
+}
+
+// userPermissionSet returns the permissions injected by the middleware as a set for quick lookups.
+func userPermissionSet(ctx context.Context) map[string]struct{} {
+	userPermissions, _ := ctx.Value("user_permissions").([]string)
+	permissionSet := make(map[string]struct{}, len(userPermissions))
+	for _, p := range userPermissions {
+		permissionSet[p] = struct{}{}
+	}
+	return permissionSet
+}
+
+// synthesizeAnswer writes the final answer from the scratchpad data. unavailable names the critical
+// tools that failed; the synthesizer is told their data is missing so it can caveat the answer.
+// req must already be redacted with redactions, which then masks the data as well.
+func (h *RAGHandler) synthesizeAnswer(ctx context.Context, ragCtx RAGContext, req RAGRequest, data map[string]interface{}, unavailable []string, redactions *RedactionMap) (json.RawMessage, error) {
 	var promptBuffer bytes.Buffer
 
 	// Marshal the retrieved data so it can be injected into the prompt
@@ -202,10 +452,26 @@ func (h *RAGHandler) synthesizeAnswer(ctx context.Context, ragCtx RAGContext, re
 		return nil, fmt.Errorf("failed to marshal context data for synthesizer: %w", err)
 	}
 
+	contextData := ragCtx.Redactor.Redact(string(contextDataJSON), redactions)
+	switch {
+	case len(unavailable) > 0 && len(data) == 0:
+		contextData = unavailableDataNotice(unavailable)
+	case len(unavailable) > 0:
+		contextData += "\n\n" + unavailableDataNotice(unavailable)
+	case len(data) == 0:
+		contextData = NoDataRetrievedContext
+	}
+	if redactions.Len() > 0 {
+		h.logger.InfoContext(ctx, "Redacted PII from synthesizer context", "redacted_values", redactions.Len())
+	}
+
 	templateData := map[string]interface{}{
 		"UserQuestion": req.Question,
 		"History":      req.History,
-		"ContextData":  string(contextDataJSON),
+		"ContextData":  contextData,
+		"Language":     req.Language,
+		// UnavailableData lets templates place the failed tools themselves; ContextData already notes them.
+		"UnavailableData": unavailable,
 	}
 
 	if err := ragCtx.SynthesizerTemplate.Execute(&promptBuffer, templateData); err != nil {
@@ -217,7 +483,30 @@ func (h *RAGHandler) synthesizeAnswer(ctx context.Context, ragCtx RAGContext, re
 		return nil, fmt.Errorf("LLM call for synthesis failed: %w", err)
 	}
 
+	if ragCtx.Redactor.RestoresAnswer() {
+		finalResponse = redactions.RestoreJSON(finalResponse)
+	}
+
 	// We return the raw JSON from the LLM, as it's expected to be the final, structured
 	// response for the frontend (e.g., with text_response, render_table actions).
+	if !json.Valid([]byte(finalResponse)) {
+		h.logger.WarnContext(ctx, "Synthesizer returned malformed JSON, falling back to a text response", "raw_content", ragCtx.Redactor.Redact(finalResponse, redactions))
+		return textResponse(finalResponse)
+	}
 	return json.RawMessage(finalResponse), nil
 }
+
+// textResponse wraps plain text in a response with a single text_response action, the shape the
+// frontend expects from the synthesizer.
+func textResponse(text string) (json.RawMessage, error) {
+	response := map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{"type": "text_response", "payload": text},
+		},
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build fallback text response: %w", err)
+	}
+	return b, nil
+}
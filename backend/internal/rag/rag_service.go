@@ -16,18 +16,27 @@ import (
 type RAGService struct {
 	httpClient          *http.Client
 	embeddingServiceURL string
+	normalizeEmbeddings bool
 	AIAPIKey            string
 	LLM_URL             string
+	useStubLLM          bool
+	prices              PriceTable
 	logger              *slog.Logger
 }
 
 // NewRAGService creates a new instance of the RAGService.
-func NewRAGService(embeddingURL string, AIKey string, LLM_URL string, logger *slog.Logger) *RAGService {
+// When useStubLLM is true, CallLLM returns canned responses instead of calling the AI API.
+// prices is used to estimate the cost of the LLM calls made for each request.
+// When normalizeEmbeddings is true, GetEmbedding L2-normalizes the vectors the embedding service returns.
+func NewRAGService(embeddingURL string, normalizeEmbeddings bool, AIKey string, LLM_URL string, useStubLLM bool, prices PriceTable, logger *slog.Logger) *RAGService {
 	return &RAGService{
 		httpClient:          &http.Client{Timeout: 90 * time.Second},
 		embeddingServiceURL: embeddingURL,
+		normalizeEmbeddings: normalizeEmbeddings,
 		AIAPIKey:            AIKey,
 		LLM_URL:             LLM_URL,
+		useStubLLM:          useStubLLM,
+		prices:              prices,
 		logger:              logger.With("component", "rag_service"),
 	}
 }
@@ -43,9 +52,11 @@ type EmbeddingResponse struct {
 }
 
 type LLMRequestBody struct {
-	Model          string          `json:"model"`
-	Messages       []ChatMessage   `json:"messages"`
-	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Model          string           `json:"model"`
+	Messages       []ChatMessage    `json:"messages"`
+	ResponseFormat *ResponseFormat  `json:"response_format,omitempty"`
+	Tools          []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice     string           `json:"tool_choice,omitempty"`
 }
 
 type ResponseFormat struct {
@@ -55,9 +66,34 @@ type ResponseFormat struct {
 type LLMResponse struct {
 	Choices []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string        `json:"content"`
+			ToolCalls []LLMToolCall `json:"tool_calls"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage TokenUsage `json:"usage"`
+}
+
+// ToolDefinition describes a tool to the model in the API's native "tools" field.
+type ToolDefinition struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition is the function part of a ToolDefinition. Parameters is a JSON schema.
+type FunctionDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// LLMToolCall is a structured tool call returned by the model. Arguments is a JSON-encoded object.
+type LLMToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 // GetEmbedding is the single, platform-wide method for generating embeddings.
@@ -84,11 +120,28 @@ func (s *RAGService) GetEmbedding(ctx context.Context, textToEmbed string) ([]fl
 	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
 		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
 	}
+	if err := ValidateEmbedding(embeddingResp.Embedding); err != nil {
+		return nil, err
+	}
+	if s.normalizeEmbeddings {
+		return NormalizeL2(embeddingResp.Embedding), nil
+	}
 	return embeddingResp.Embedding, nil
 }
 
+// NewUsageTracker creates a tracker for the LLM calls of one request, priced with the service's price table.
+func (s *RAGService) NewUsageTracker() *UsageTracker {
+	return NewUsageTracker(s.prices)
+}
+
 // CallLLM is the centralized method for making requests to the AI Chat Completions API.
+// Token usage is recorded into the UsageTracker carried by ctx, if any.
 func (s *RAGService) CallLLM(ctx context.Context, prompt string, useJSONMode bool) (string, error) {
+	if s.useStubLLM {
+		s.logger.DebugContext(ctx, "Returning stub LLM response", "prompt_length", len(prompt))
+		return StubLLMResponse(prompt), nil
+	}
+
 	if s.AIAPIKey == "" {
 		return "", fmt.Errorf("AI API key is not configured")
 	}
@@ -104,44 +157,98 @@ func (s *RAGService) CallLLM(ctx context.Context, prompt string, useJSONMode boo
 		requestBody.ResponseFormat = &ResponseFormat{Type: "json_object"}
 	}
 
+	// 2. Send it to the API.
+	llmResponse, err := s.postChatCompletion(ctx, requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	// 3. Return the content of the first message.
+	return llmResponse.Choices[0].Message.Content, nil
+}
+
+// CallLLMWithTools asks the model for a plan using native tool calling: tools are sent in the
+// API's "tools" field and the structured tool calls in the response are returned. If the model
+// answers with text instead, that text is returned as content for the caller to interpret.
+func (s *RAGService) CallLLMWithTools(ctx context.Context, prompt string, tools []ToolDefinition) ([]ToolCall, string, error) {
+	if s.useStubLLM {
+		s.logger.DebugContext(ctx, "Returning stub LLM tool calls", "prompt_length", len(prompt))
+		return nil, "", nil
+	}
+
+	if s.AIAPIKey == "" {
+		return nil, "", fmt.Errorf("AI API key is not configured")
+	}
+
+	requestBody := LLMRequestBody{
+		Model: "gpt-4o",
+		Messages: []ChatMessage{
+			{Sender: "user", Content: prompt},
+		},
+		Tools:      tools,
+		ToolChoice: "auto",
+	}
+	llmResponse, err := s.postChatCompletion(ctx, requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	message := llmResponse.Choices[0].Message
+	toolCalls := make([]ToolCall, 0, len(message.ToolCalls))
+	for _, call := range message.ToolCalls {
+		arguments := map[string]interface{}{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+				return nil, "", fmt.Errorf("failed to parse arguments of tool call '%s': %w", call.Function.Name, err)
+			}
+		}
+		toolCalls = append(toolCalls, ToolCall{ToolName: call.Function.Name, Arguments: arguments})
+	}
+	return toolCalls, message.Content, nil
+}
+
+// postChatCompletion sends requestBody to the Chat Completions API and decodes the response.
+// Token usage is recorded into the UsageTracker carried by ctx, if any.
+func (s *RAGService) postChatCompletion(ctx context.Context, requestBody LLMRequestBody) (*LLMResponse, error) {
 	payloadBytes, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal OpenAI request: %w", err)
+		return nil, fmt.Errorf("failed to marshal OpenAI request: %w", err)
 	}
 
-	// 2. Create the HTTP request.
+	// 1. Create the HTTP request.
 	req, err := http.NewRequestWithContext(ctx, "POST", s.LLM_URL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return "", fmt.Errorf("failed to create AI request: %w", err)
+		return nil, fmt.Errorf("failed to create AI request: %w", err)
 	}
 
-	// 3. Set the required headers.
+	// 2. Set the required headers.
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.AIAPIKey)
 
-	// 4. Execute the request.
+	// 3. Execute the request.
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call AI API: %w", err)
+		return nil, fmt.Errorf("failed to call AI API: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// 5. Handle non-successful status codes.
+	// 4. Handle non-successful status codes.
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("AI API returned non-OK status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("AI API returned non-OK status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	// 6. Decode the successful response.
+	// 5. Decode the successful response.
 	var llmResponse LLMResponse
 	if err := json.NewDecoder(resp.Body).Decode(&llmResponse); err != nil {
-		return "", fmt.Errorf("failed to decode AI response: %w", err)
+		return nil, fmt.Errorf("failed to decode AI response: %w", err)
 	}
 
+	RecordUsage(ctx, requestBody.Model, llmResponse.Usage)
+
 	if len(llmResponse.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from AI")
+		return nil, fmt.Errorf("no choices returned from AI")
 	}
 
-	// 7. Return the content of the first message.
-	return llmResponse.Choices[0].Message.Content, nil
+	return &llmResponse, nil
 }
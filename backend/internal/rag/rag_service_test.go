@@ -0,0 +1,50 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallLLMWithTools(t *testing.T) {
+	var received LLMRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"choices": [{"message": {"content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_claims_data", "arguments": "{\"status\": \"Open\", \"limit\": 5}"}}
+			]}}],
+			"usage": {"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120}
+		}`)
+	}))
+	defer server.Close()
+
+	svc := NewRAGService("", false, "test-key", server.URL, false, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ragCtx := RAGContext{Tools: map[string]Tool{
+		"search_comments": {Description: "Searches claim comments."},
+		"get_claims_data": {Description: "Lists claims.", Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"status": map[string]interface{}{"type": "string"}},
+		}},
+	}}
+
+	toolCalls, content, err := svc.CallLLMWithTools(context.Background(), "plan this", ragCtx.toolDefinitions())
+	require.NoError(t, err)
+	assert.Empty(t, content)
+	assert.Equal(t, []ToolCall{{ToolName: "get_claims_data", Arguments: map[string]interface{}{"status": "Open", "limit": float64(5)}}}, toolCalls)
+
+	require.Len(t, received.Tools, 2)
+	assert.Equal(t, "auto", received.ToolChoice)
+	assert.Equal(t, "get_claims_data", received.Tools[0].Function.Name)
+	assert.Equal(t, "function", received.Tools[0].Type)
+	assert.Equal(t, "search_comments", received.Tools[1].Function.Name)
+	assert.Equal(t, "object", received.Tools[1].Function.Parameters["type"])
+}
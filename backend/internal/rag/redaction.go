@@ -0,0 +1,255 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactionRule masks one kind of PII. valid, when set, filters out false positives of pattern.
+type redactionRule struct {
+	label   string
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+}
+
+// redactionRules are the built-in rules, keyed by the name used in redaction config. They are
+// applied in redactionOrder so that, for example, card numbers aren't partly matched as phone numbers.
+var redactionRules = map[string]redactionRule{
+	"ssn":         {label: "SSN", pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	"credit_card": {label: "CARD", pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhnValid},
+	"email":       {label: "EMAIL", pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	"phone":       {label: "PHONE", pattern: regexp.MustCompile(`(?:\(\d{3}\)\s?|\b\d{3}[-. ])\d{3}[-. ]\d{4}\b`)},
+}
+
+var redactionOrder = []string{"ssn", "credit_card", "email", "phone"}
+
+// RedactionConfig is the YAML form of a Redactor.
+type RedactionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Patterns names the built-in rules to apply: ssn, credit_card, email and phone.
+	Patterns []string `yaml:"patterns"`
+	// RestoreAnswer puts the original values back into the LLM's answer before it is returned.
+	RestoreAnswer bool `yaml:"restore_answer"`
+}
+
+// Redactor masks PII in retrieved context before it is sent to the LLM. The values it masks are
+// kept in a RedactionMap that never leaves the server.
+type Redactor struct {
+	rules         []redactionRule
+	restoreAnswer bool
+}
+
+// NewRedactor builds a Redactor from cfg. It returns nil when redaction is disabled.
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if len(cfg.Patterns) == 0 {
+		return nil, fmt.Errorf("FATAL: redaction is enabled but no patterns are configured")
+	}
+	wanted := make(map[string]bool, len(cfg.Patterns))
+	for _, name := range cfg.Patterns {
+		if _, ok := redactionRules[name]; !ok {
+			return nil, fmt.Errorf("FATAL: unknown redaction pattern '%s'", name)
+		}
+		wanted[name] = true
+	}
+	r := &Redactor{restoreAnswer: cfg.RestoreAnswer}
+	for _, name := range redactionOrder {
+		if wanted[name] {
+			r.rules = append(r.rules, redactionRules[name])
+		}
+	}
+	return r, nil
+}
+
+// LoadRedactor reads a RedactionConfig YAML at path and builds a Redactor from it. It returns nil
+// when redaction is disabled.
+func LoadRedactor(path string) (*Redactor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction config %s: %w", path, err)
+	}
+	var cfg RedactionConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction config %s: %w", path, err)
+	}
+	r, err := NewRedactor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction config %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// RestoresAnswer reports whether answers should be un-redacted before they are returned.
+func (r *Redactor) RestoresAnswer() bool {
+	return r != nil && r.restoreAnswer
+}
+
+// Redact replaces each match in text with a placeholder such as [REDACTED_SSN_1], recording the
+// original value in m. The same value always gets the same placeholder within m. A nil Redactor
+// returns text unchanged.
+func (r *Redactor) Redact(text string, m *RedactionMap) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			return m.placeholder(rule.label, match)
+		})
+	}
+	return text
+}
+
+// RedactHistory returns a copy of history with PII masked in each message. A nil Redactor returns
+// history unchanged.
+func (r *Redactor) RedactHistory(history []ChatMessage, m *RedactionMap) []ChatMessage {
+	if r == nil {
+		return history
+	}
+	redacted := make([]ChatMessage, len(history))
+	for i, message := range history {
+		message.Content = r.Redact(message.Content, m)
+		redacted[i] = message
+	}
+	return redacted
+}
+
+// RedactEntries returns a copy of scratchpad entries with PII masked in every value. An entry with
+// something to mask is round-tripped through JSON, so it comes back as plain maps and slices
+// rather than its original type. A nil Redactor returns entries unchanged.
+func (r *Redactor) RedactEntries(entries map[string]interface{}, m *RedactionMap) (map[string]interface{}, error) {
+	if r == nil {
+		return entries, nil
+	}
+	redacted := make(map[string]interface{}, len(entries))
+	for key, value := range entries {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal scratchpad entry %s for redaction: %w", key, err)
+		}
+		masked := r.Redact(string(raw), m)
+		if masked == string(raw) {
+			redacted[key] = value
+			continue
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(masked), &decoded); err != nil {
+			return nil, fmt.Errorf("failed to decode redacted scratchpad entry %s: %w", key, err)
+		}
+		redacted[key] = decoded
+	}
+	return redacted, nil
+}
+
+// RedactionMap holds the placeholders handed out while redacting one request.
+type RedactionMap struct {
+	byValue  map[string]string
+	byHolder map[string]string
+	counts   map[string]int
+}
+
+// NewRedactionMap creates an empty map for one request.
+func NewRedactionMap() *RedactionMap {
+	return &RedactionMap{byValue: map[string]string{}, byHolder: map[string]string{}, counts: map[string]int{}}
+}
+
+func (m *RedactionMap) placeholder(label, value string) string {
+	if holder, ok := m.byValue[value]; ok {
+		return holder
+	}
+	m.counts[label]++
+	holder := fmt.Sprintf("[REDACTED_%s_%d]", label, m.counts[label])
+	m.byValue[value] = holder
+	m.byHolder[holder] = value
+	return holder
+}
+
+// Len returns the number of distinct values redacted.
+func (m *RedactionMap) Len() int {
+	return len(m.byHolder)
+}
+
+// Restore replaces the placeholders in text with the original values.
+func (m *RedactionMap) Restore(text string) string {
+	return m.restore(text, func(value string) string { return value })
+}
+
+// RestoreJSON replaces the placeholders in a JSON document, escaping the original values so the
+// document stays valid.
+func (m *RedactionMap) RestoreJSON(text string) string {
+	return m.restore(text, func(value string) string {
+		quoted, _ := json.Marshal(value)
+		return string(quoted[1 : len(quoted)-1])
+	})
+}
+
+// RestoreArguments replaces the placeholders in the string values of tool arguments, so a tool the
+// planner calls with a redacted value, such as an email from the question, looks up the original.
+func (m *RedactionMap) RestoreArguments(arguments map[string]interface{}) map[string]interface{} {
+	if len(m.byHolder) == 0 || arguments == nil {
+		return arguments
+	}
+	restored := make(map[string]interface{}, len(arguments))
+	for key, value := range arguments {
+		restored[key] = m.restoreValue(value)
+	}
+	return restored
+}
+
+func (m *RedactionMap) restoreValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return m.Restore(v)
+	case []interface{}:
+		restored := make([]interface{}, len(v))
+		for i, item := range v {
+			restored[i] = m.restoreValue(item)
+		}
+		return restored
+	case map[string]interface{}:
+		return m.RestoreArguments(v)
+	default:
+		return value
+	}
+}
+
+func (m *RedactionMap) restore(text string, encode func(string) string) string {
+	if len(m.byHolder) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(m.byHolder))
+	for holder, value := range m.byHolder {
+		pairs = append(pairs, holder, encode(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, digits := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor, err := NewRedactor(RedactionConfig{Enabled: true, Patterns: []string{"ssn", "credit_card", "email", "phone"}, RestoreAnswer: true})
+	require.NoError(t, err)
+
+	t.Run("Masks PII and restores it from the map", func(t *testing.T) {
+		redactions := NewRedactionMap()
+		text := "Claimant SSN 123-45-6789, card 4111 1111 1111 1111, email jane.doe@example.com, phone (555) 123-4567. SSN again: 123-45-6789. Claim 20240115."
+		redacted := redactor.Redact(text, redactions)
+
+		assert.Equal(t, "Claimant SSN [REDACTED_SSN_1], card [REDACTED_CARD_1], email [REDACTED_EMAIL_1], phone [REDACTED_PHONE_1]. SSN again: [REDACTED_SSN_1]. Claim 20240115.", redacted)
+		assert.Equal(t, 4, redactions.Len())
+		assert.Equal(t, text, redactions.Restore(redacted))
+	})
+
+	t.Run("Leaves numbers that fail the card checksum alone", func(t *testing.T) {
+		assert.Equal(t, "Policy 1234 5678 9012 3456", redactor.Redact("Policy 1234 5678 9012 3456", NewRedactionMap()))
+	})
+
+	t.Run("Restores into a JSON answer", func(t *testing.T) {
+		redactions := NewRedactionMap()
+		redactor.Redact("contact jane.doe@example.com", redactions)
+		restored := redactions.RestoreJSON(`{"payload": "Email [REDACTED_EMAIL_1]"}`)
+		assert.True(t, json.Valid([]byte(restored)), restored)
+		assert.JSONEq(t, `{"payload": "Email jane.doe@example.com"}`, restored)
+	})
+
+	t.Run("Redacts history and scratchpad entries", func(t *testing.T) {
+		redactions := NewRedactionMap()
+		history := []ChatMessage{{Sender: "user", Content: "I'm jane.doe@example.com"}}
+		assert.Equal(t, []ChatMessage{{Sender: "user", Content: "I'm [REDACTED_EMAIL_1]"}}, redactor.RedactHistory(history, redactions))
+		assert.Equal(t, "I'm jane.doe@example.com", history[0].Content, "the caller's history is not modified")
+
+		type claim struct {
+			SSN string `json:"ssn"`
+		}
+		entries, err := redactor.RedactEntries(map[string]interface{}{"claims": []claim{{SSN: "123-45-6789"}}, "count": 3}, redactions)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"claims": []interface{}{map[string]interface{}{"ssn": "[REDACTED_SSN_1]"}}, "count": 3}, entries)
+	})
+
+	t.Run("Restores tool arguments", func(t *testing.T) {
+		redactions := NewRedactionMap()
+		redactor.Redact("jane.doe@example.com 123-45-6789", redactions)
+		restored := redactions.RestoreArguments(map[string]interface{}{
+			"email":   "[REDACTED_EMAIL_1]",
+			"filters": []interface{}{map[string]interface{}{"value": "[REDACTED_SSN_1]"}},
+			"limit":   float64(5),
+		})
+		assert.Equal(t, map[string]interface{}{
+			"email":   "jane.doe@example.com",
+			"filters": []interface{}{map[string]interface{}{"value": "123-45-6789"}},
+			"limit":   float64(5),
+		}, restored)
+	})
+
+	t.Run("Disabled config yields no redactor", func(t *testing.T) {
+		disabled, err := NewRedactor(RedactionConfig{Patterns: []string{"ssn"}})
+		require.NoError(t, err)
+		assert.Nil(t, disabled)
+		assert.Equal(t, "123-45-6789", disabled.Redact("123-45-6789", NewRedactionMap()))
+		assert.False(t, disabled.RestoresAnswer())
+	})
+
+	t.Run("Rejects unknown patterns", func(t *testing.T) {
+		_, err := NewRedactor(RedactionConfig{Enabled: true, Patterns: []string{"passport"}})
+		assert.ErrorContains(t, err, "passport")
+	})
+}
@@ -3,18 +3,69 @@ package rag
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"text/template"
 )
 
 // ToolFunc defines the signature for any function that can be used as a tool by the RAG agent.
-// It accepts a map of queriers and a map of arguments from the LLM planner.
-type ToolFunc func(ctx context.Context, queriers map[string]interface{}, userScopes []string, args map[string]interface{}) (interface{}, error)
+// It accepts a map of queriers and the arguments from the LLM planner, read through ToolArgs'
+// typed accessors.
+type ToolFunc func(ctx context.Context, queriers map[string]interface{}, userScopes []string, args ToolArgs) (interface{}, error)
+
+// ResultValidator checks a tool's result before it is added to the scratchpad.
+type ResultValidator func(result interface{}) error
 
 // Tool bundles the function with the required permission
 type Tool struct {
 	Function           ToolFunc
 	RequiredPermission string
+	// ValidateResult, when set, is run on every result of Function. Results that fail are
+	// discarded rather than passed to the LLM.
+	ValidateResult ResultValidator
+	// Description and Parameters (a JSON schema for the arguments) describe the tool to the
+	// model: they generate the tool list in the planner prompt and, when the context uses native
+	// tool calling, the API's tool definitions.
+	Description string
+	Parameters  map[string]interface{}
+	// Critical marks a tool whose data the answer depends on. A critical tool that fails is retried
+	// once; if it fails again, its data is left out and the synthesizer is told it is unavailable,
+	// rather than being handed an error marker among the results.
+	Critical bool
+}
+
+// toolDefinitions describes the context's tools for native tool calling, sorted by name.
+func (c RAGContext) toolDefinitions() []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(c.Tools))
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		tool := c.Tools[name]
+		parameters := tool.Parameters
+		if parameters == nil {
+			parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		definitions = append(definitions, ToolDefinition{
+			Type:     "function",
+			Function: FunctionDefinition{Name: name, Description: tool.Description, Parameters: parameters},
+		})
+	}
+	return definitions
+}
+
+// CheckResult reports whether result can be passed to the LLM: it must marshal to JSON, since
+// the scratchpad is sent to the synthesizer as JSON, and pass the tool's ValidateResult if any.
+func (t Tool) CheckResult(result interface{}) error {
+	if _, err := json.Marshal(result); err != nil {
+		return fmt.Errorf("result is not JSON-serializable: %w", err)
+	}
+	if t.ValidateResult != nil {
+		if err := t.ValidateResult(result); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // RAGContext holds the specific configuration for a single RAG application personality.
@@ -24,10 +75,21 @@ type RAGContext struct {
 	SynthesizerTemplate *template.Template
 	Tools               map[string]Tool
 	MaxReActCycles      int
+	// MaxScratchpadBytes and MaxScratchpadEntries cap the tool results carried between cycles and
+	// into the synthesizer. Zero means DefaultMaxScratchpadBytes / DefaultMaxScratchpadEntries.
+	MaxScratchpadBytes   int
+	MaxScratchpadEntries int
+	// NativeToolCalling plans with the API's native tool calling instead of parsing a JSON plan
+	// out of the model's text. The text path is still used if the native call fails.
+	NativeToolCalling bool
+	// Redactor, when set, masks PII in retrieved context before it is sent to the synthesizer.
+	Redactor *Redactor
 }
 
 // RAGRegistry holds all the registered RAG contexts for the platform.
+// It is safe for concurrent use, so contexts may be registered or reloaded while queries are being served.
 type RAGRegistry struct {
+	mu       sync.RWMutex
 	contexts map[string]RAGContext
 }
 
@@ -39,15 +101,28 @@ func NewRAGRegistry() *RAGRegistry {
 }
 
 // Register adds a new RAG context to the registry.
+// It may be called after startup; registering a name twice panics, use Update to replace a context.
 func (r *RAGRegistry) Register(context RAGContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, exists := r.contexts[context.Name]; exists {
 		panic(fmt.Sprintf("RAG context '%s' is already registered", context.Name))
 	}
 	r.contexts[context.Name] = context
 }
 
+// Update registers context under its name, replacing any context already registered with it,
+// e.g. after its templates were reloaded. Queries already running keep the context they started with.
+func (r *RAGRegistry) Update(context RAGContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contexts[context.Name] = context
+}
+
 // Get retrieves a RAG context from the registry by name.
 func (r *RAGRegistry) Get(name string) (RAGContext, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	context, found := r.contexts[name]
 	return context, found
 }
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRAGRegistry(t *testing.T) {
+	t.Run("Update replaces a registered context", func(t *testing.T) {
+		reg := NewRAGRegistry()
+		reg.Register(RAGContext{Name: "claims", MaxReActCycles: 1})
+		reg.Update(RAGContext{Name: "claims", MaxReActCycles: 3})
+
+		ctx, found := reg.Get("claims")
+		assert.True(t, found)
+		assert.Equal(t, 3, ctx.MaxReActCycles)
+	})
+
+	t.Run("Register panics on a duplicate name", func(t *testing.T) {
+		reg := NewRAGRegistry()
+		reg.Register(RAGContext{Name: "claims"})
+		assert.Panics(t, func() { reg.Register(RAGContext{Name: "claims"}) })
+	})
+
+	t.Run("Allows registration while contexts are being read", func(t *testing.T) {
+		reg := NewRAGRegistry()
+		reg.Register(RAGContext{Name: "claims"})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				reg.Register(RAGContext{Name: fmt.Sprintf("context-%d", i)})
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, found := reg.Get("claims")
+				assert.True(t, found)
+			}()
+		}
+		wg.Wait()
+
+		_, found := reg.Get("context-19")
+		assert.True(t, found)
+	})
+}
+
+func TestToolCheckResult(t *testing.T) {
+	tool := Tool{ValidateResult: func(result interface{}) error {
+		if _, ok := result.([]string); !ok {
+			return errors.New("expected a list of strings")
+		}
+		return nil
+	}}
+
+	assert.NoError(t, tool.CheckResult([]string{"claim-1"}))
+	assert.ErrorContains(t, tool.CheckResult(map[string]int{"count": 1}), "expected a list of strings")
+	assert.ErrorContains(t, Tool{}.CheckResult(make(chan int)), "not JSON-serializable")
+}
@@ -0,0 +1,144 @@
+package rag
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Default scratchpad limits, used when a RAGContext doesn't set its own. 64 KiB of JSON is
+// roughly 16k tokens.
+const (
+	DefaultMaxScratchpadBytes   = 64 * 1024
+	DefaultMaxScratchpadEntries = 20
+)
+
+// Scratchpad holds the tool results gathered across the cycles of the ReAct loop. It keeps its
+// marshaled size under a byte and entry cap so a broad query can't blow up the prompts.
+type Scratchpad struct {
+	maxBytes   int
+	maxEntries int
+	entries    map[string]interface{}
+	sizes      map[string]int
+	summarized map[string]bool
+	order      []string
+}
+
+// ScratchpadEviction describes an entry that was summarized or dropped to respect the limits.
+type ScratchpadEviction struct {
+	Key    string
+	Bytes  int
+	Action string // "summarized" or "dropped"
+}
+
+// NewScratchpad creates a scratchpad with the given limits. Limits of zero or less fall back to
+// the defaults.
+func NewScratchpad(maxBytes, maxEntries int) *Scratchpad {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxScratchpadBytes
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxScratchpadEntries
+	}
+	return &Scratchpad{
+		maxBytes:   maxBytes,
+		maxEntries: maxEntries,
+		entries:    make(map[string]interface{}),
+		sizes:      make(map[string]int),
+		summarized: make(map[string]bool),
+	}
+}
+
+// Set stores value under key, replacing and refreshing any earlier value, then enforces the
+// limits. It returns the entries that were evicted to make room.
+func (s *Scratchpad) Set(key string, value interface{}) []ScratchpadEviction {
+	s.remove(key)
+	s.entries[key] = value
+	s.sizes[key] = marshaledSize(value)
+	s.order = append(s.order, key)
+	return s.enforce()
+}
+
+// Entries returns the scratchpad contents, for templates and the synthesizer.
+func (s *Scratchpad) Entries() map[string]interface{} {
+	return s.entries
+}
+
+// Size returns the marshaled size of the entries in bytes.
+func (s *Scratchpad) Size() int {
+	total := 0
+	for _, size := range s.sizes {
+		total += size
+	}
+	return total
+}
+
+// enforce drops the oldest entries beyond the entry cap, then replaces the largest entries with
+// short summaries until the byte cap is met. If the summaries alone are still too big, the oldest
+// entries are dropped.
+func (s *Scratchpad) enforce() []ScratchpadEviction {
+	var evictions []ScratchpadEviction
+	for len(s.order) > s.maxEntries {
+		evictions = append(evictions, s.drop(s.order[0]))
+	}
+	for s.Size() > s.maxBytes {
+		largest := ""
+		for _, key := range s.order {
+			if !s.summarized[key] && (largest == "" || s.sizes[key] > s.sizes[largest]) {
+				largest = key
+			}
+		}
+		if largest == "" {
+			evictions = append(evictions, s.drop(s.order[0]))
+			continue
+		}
+		evictions = append(evictions, ScratchpadEviction{Key: largest, Bytes: s.sizes[largest], Action: "summarized"})
+		summary := summarizeResult(s.entries[largest], s.sizes[largest])
+		s.entries[largest] = summary
+		s.sizes[largest] = marshaledSize(summary)
+		s.summarized[largest] = true
+	}
+	return evictions
+}
+
+func (s *Scratchpad) drop(key string) ScratchpadEviction {
+	eviction := ScratchpadEviction{Key: key, Bytes: s.sizes[key], Action: "dropped"}
+	s.remove(key)
+	return eviction
+}
+
+func (s *Scratchpad) remove(key string) {
+	if _, ok := s.entries[key]; !ok {
+		return
+	}
+	delete(s.entries, key)
+	delete(s.sizes, key)
+	delete(s.summarized, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// summarizeResult stands in for a result that is too large for the prompt, telling the model
+// what was left out so it can narrow its next tool call.
+func summarizeResult(result interface{}, size int) map[string]interface{} {
+	summary := map[string]interface{}{
+		"omitted":        true,
+		"reason":         "The result was too large to include. Use a narrower query or filters to see it.",
+		"original_bytes": size,
+	}
+	if v := reflect.ValueOf(result); v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map {
+		summary["item_count"] = v.Len()
+	}
+	return summary
+}
+
+func marshaledSize(value interface{}) int {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
@@ -0,0 +1,52 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScratchpad(t *testing.T) {
+	t.Run("Summarizes the largest entry when over the byte cap", func(t *testing.T) {
+		pad := NewScratchpad(1000, 10)
+		assert.Empty(t, pad.Set("small", "ok"))
+
+		rows := make([]string, 50)
+		for i := range rows {
+			rows[i] = strings.Repeat("x", 30)
+		}
+		evictions := pad.Set("claims", rows)
+
+		require.Len(t, evictions, 1)
+		assert.Equal(t, ScratchpadEviction{Key: "claims", Bytes: 1651, Action: "summarized"}, evictions[0])
+		assert.LessOrEqual(t, pad.Size(), 1000)
+		assert.Equal(t, "ok", pad.Entries()["small"])
+		summary := pad.Entries()["claims"].(map[string]interface{})
+		assert.Equal(t, true, summary["omitted"])
+		assert.Equal(t, 50, summary["item_count"])
+	})
+
+	t.Run("Drops the oldest entries over the entry cap", func(t *testing.T) {
+		pad := NewScratchpad(0, 2)
+		pad.Set("first", 1)
+		pad.Set("second", 2)
+		evictions := pad.Set("third", 3)
+
+		require.Len(t, evictions, 1)
+		assert.Equal(t, "first", evictions[0].Key)
+		assert.Equal(t, "dropped", evictions[0].Action)
+		assert.Equal(t, map[string]interface{}{"second": 2, "third": 3}, pad.Entries())
+	})
+
+	t.Run("Replacing an entry refreshes its age", func(t *testing.T) {
+		pad := NewScratchpad(0, 2)
+		pad.Set("first", 1)
+		pad.Set("second", 2)
+		pad.Set("first", 10)
+		pad.Set("third", 3)
+
+		assert.Equal(t, map[string]interface{}{"first": 10, "third": 3}, pad.Entries())
+	})
+}
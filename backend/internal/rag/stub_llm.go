@@ -0,0 +1,27 @@
+// backend/internal/rag/stub_llm.go
+package rag
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// StubLLMResponse returns a canned, valid response for the given prompt so the ReAct loop can
+// be exercised without a live LLM endpoint. Planner prompts receive an empty tool plan, and
+// synthesizer prompts receive a single text_response action.
+func StubLLMResponse(prompt string) string {
+	switch {
+	case strings.Contains(prompt, "tool_calls"):
+		return `{"tool_calls": []}`
+	case strings.Contains(prompt, "actions"):
+		answer := map[string]interface{}{
+			"actions": []map[string]interface{}{
+				{"type": "text_response", "payload": "This is a stubbed answer. The LLM is running in stub mode, so no model was called."},
+			},
+		}
+		b, _ := json.Marshal(answer)
+		return string(b)
+	default:
+		return `{"answer": "This is a stubbed answer."}`
+	}
+}
@@ -0,0 +1,41 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStubLLMResponses(t *testing.T) {
+	svc := NewRAGService("http://localhost:5001/embed", false, "", "", true, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	t.Run("Planner prompt returns a valid empty plan", func(t *testing.T) {
+		content, err := svc.CallLLM(ctx, `respond with a JSON object with a key named "tool_calls"`, true)
+		require.NoError(t, err)
+
+		var plan PlannerResponse
+		require.NoError(t, json.Unmarshal([]byte(content), &plan))
+		assert.Empty(t, plan.ToolCalls)
+	})
+
+	t.Run("Synthesizer prompt returns a text_response action", func(t *testing.T) {
+		content, err := svc.CallLLM(ctx, `respond with a JSON object with a key named "actions"`, true)
+		require.NoError(t, err)
+
+		var resp struct {
+			Actions []struct {
+				Type    string      `json:"type"`
+				Payload interface{} `json:"payload"`
+			} `json:"actions"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(content), &resp))
+		require.Len(t, resp.Actions, 1)
+		assert.Equal(t, "text_response", resp.Actions[0].Type)
+	})
+}
@@ -0,0 +1,143 @@
+package rag
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jjckrbbt/chimera/backend/internal/repository"
+)
+
+// ToolArgs holds the arguments the planner passed to a tool, as decoded from JSON. Its accessors
+// convert values to the type a tool needs and accept the forms models commonly produce instead,
+// such as a number written as a string. A missing, null or empty-string argument yields the
+// default; a value that cannot be converted is an error naming the argument.
+type ToolArgs map[string]interface{}
+
+// lookup returns the argument for key, or false if it is missing, null or an empty string.
+func (a ToolArgs) lookup(key string) (interface{}, bool) {
+	val, ok := a[key]
+	if !ok || val == nil {
+		return nil, false
+	}
+	if s, isString := val.(string); isString && strings.TrimSpace(s) == "" {
+		return nil, false
+	}
+	return val, true
+}
+
+func argError(key, want string, val interface{}) error {
+	return fmt.Errorf("argument '%s' must be %s, got %T %v", key, want, val, val)
+}
+
+// String returns the argument as a string. Numbers are formatted without a trailing fraction, so
+// an ID the model sent as 1234 reads as "1234".
+func (a ToolArgs) String(key, def string) (string, error) {
+	val, ok := a.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	}
+	return "", argError(key, "a string", val)
+}
+
+// Text returns the argument as a pgtype.Text that is NULL when the argument is absent, for
+// optional query filters.
+func (a ToolArgs) Text(key string) (pgtype.Text, error) {
+	s, err := a.String(key, "")
+	if err != nil || s == "" {
+		return pgtype.Text{}, err
+	}
+	return pgtype.Text{String: s, Valid: true}, nil
+}
+
+// Float returns the argument as a float64.
+func (a ToolArgs) Float(key string, def float64) (float64, error) {
+	val, ok := a.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, argError(key, "a number", val)
+}
+
+// Int returns the argument as an int. Fractional values are rejected rather than truncated.
+func (a ToolArgs) Int(key string, def int) (int, error) {
+	val, ok := a.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int(v), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return i, nil
+		}
+	}
+	return 0, argError(key, "an integer", val)
+}
+
+// Numeric returns the argument as a pgtype.Numeric that is NULL when the argument is absent.
+// Strings are parsed as exact decimals, so "1250.10" keeps its precision.
+func (a ToolArgs) Numeric(key string) (pgtype.Numeric, error) {
+	val, ok := a.lookup(key)
+	if !ok {
+		return pgtype.Numeric{}, nil
+	}
+	var s string
+	switch v := val.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		s = strconv.Itoa(v)
+	case string:
+		s = v
+	default:
+		return pgtype.Numeric{}, argError(key, "a number", val)
+	}
+	num, err := repository.ParseNumeric(s)
+	if err != nil {
+		return pgtype.Numeric{}, argError(key, "a number", val)
+	}
+	return num, nil
+}
+
+// Bool returns the argument as a bool. The strings "true" and "false" are accepted.
+func (a ToolArgs) Bool(key string, def bool) (bool, error) {
+	val, ok := a.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, nil
+		}
+	}
+	return false, argError(key, "true or false", val)
+}
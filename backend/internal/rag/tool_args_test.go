@@ -0,0 +1,121 @@
+package rag
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolArgs(t *testing.T) {
+	var args ToolArgs
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"claim_id": 1234,
+		"status": "Open",
+		"blank": "  ",
+		"missing_value": null,
+		"limit": "25",
+		"page": 2,
+		"ratio": 0.5,
+		"min_amount": 1250.1,
+		"max_amount": "99999.99",
+		"include_closed": "true",
+		"flag": true,
+		"tags": ["a"]
+	}`), &args))
+
+	t.Run("String formats numbers and falls back to the default", func(t *testing.T) {
+		s, err := args.String("claim_id", "")
+		require.NoError(t, err)
+		assert.Equal(t, "1234", s)
+
+		for _, key := range []string{"blank", "missing_value", "absent"} {
+			s, err = args.String(key, "fallback")
+			require.NoError(t, err)
+			assert.Equal(t, "fallback", s, key)
+		}
+
+		_, err = args.String("tags", "")
+		assert.ErrorContains(t, err, "argument 'tags' must be a string")
+	})
+
+	t.Run("Text is NULL when absent", func(t *testing.T) {
+		text, err := args.Text("status")
+		require.NoError(t, err)
+		assert.True(t, text.Valid)
+		assert.Equal(t, "Open", text.String)
+
+		text, err = args.Text("absent")
+		require.NoError(t, err)
+		assert.False(t, text.Valid)
+	})
+
+	t.Run("Int accepts whole numbers and numeric strings", func(t *testing.T) {
+		n, err := args.Int("limit", 10)
+		require.NoError(t, err)
+		assert.Equal(t, 25, n)
+
+		n, err = args.Int("page", 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		n, err = args.Int("absent", 10)
+		require.NoError(t, err)
+		assert.Equal(t, 10, n)
+
+		_, err = args.Int("ratio", 0)
+		assert.ErrorContains(t, err, "argument 'ratio' must be an integer")
+	})
+
+	t.Run("Float accepts numbers and numeric strings", func(t *testing.T) {
+		f, err := args.Float("ratio", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0.5, f)
+
+		f, err = args.Float("limit", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 25.0, f)
+
+		_, err = args.Float("status", 0)
+		assert.ErrorContains(t, err, "argument 'status' must be a number")
+	})
+
+	t.Run("Numeric keeps the value's precision", func(t *testing.T) {
+		num, err := args.Numeric("min_amount")
+		require.NoError(t, err)
+		value, err := num.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "1250.1", value)
+
+		num, err = args.Numeric("max_amount")
+		require.NoError(t, err)
+		value, err = num.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "99999.99", value)
+
+		num, err = args.Numeric("absent")
+		require.NoError(t, err)
+		assert.False(t, num.Valid)
+
+		_, err = args.Numeric("status")
+		assert.ErrorContains(t, err, "argument 'status' must be a number")
+	})
+
+	t.Run("Bool accepts booleans and boolean strings", func(t *testing.T) {
+		b, err := args.Bool("include_closed", false)
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		b, err = args.Bool("flag", false)
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		b, err = args.Bool("absent", true)
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		_, err = args.Bool("status", false)
+		assert.ErrorContains(t, err, "argument 'status' must be true or false")
+	})
+}
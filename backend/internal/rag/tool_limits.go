@@ -0,0 +1,61 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultToolResultLimit caps how many results a tool passes to the LLM when its config sets no
+// limit. It is kept well below the list endpoints' page size: every result is copied into the
+// prompt, and past a few dozen rows more results cost tokens without improving answers.
+const DefaultToolResultLimit = 20
+
+// ToolLimits sets the maximum number of results each tool adds to the LLM's context.
+type ToolLimits struct {
+	// Default applies to tools not listed in Tools.
+	Default int            `yaml:"default"`
+	Tools   map[string]int `yaml:"tools"`
+}
+
+// LoadToolLimits reads a ToolLimits YAML at path.
+func LoadToolLimits(path string) (ToolLimits, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ToolLimits{}, fmt.Errorf("failed to read tool limits %s: %w", path, err)
+	}
+	var limits ToolLimits
+	if err := yaml.Unmarshal(data, &limits); err != nil {
+		return ToolLimits{}, fmt.Errorf("failed to parse tool limits %s: %w", path, err)
+	}
+	if limits.Default < 0 {
+		return ToolLimits{}, fmt.Errorf("invalid tool limits %s: default must not be negative", path)
+	}
+	for tool, limit := range limits.Tools {
+		if limit <= 0 {
+			return ToolLimits{}, fmt.Errorf("invalid tool limits %s: limit for '%s' must be positive", path, tool)
+		}
+	}
+	return limits, nil
+}
+
+// Limit returns the result cap for tool.
+func (l ToolLimits) Limit(tool string) int {
+	if limit, ok := l.Tools[tool]; ok {
+		return limit
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return DefaultToolResultLimit
+}
+
+// CapResults truncates results to limit and reports whether any were dropped. Tools should fetch
+// one more row than the limit, so a result set of exactly limit rows is not reported as truncated.
+func CapResults[T any](results []T, limit int) ([]T, bool) {
+	if len(results) <= limit {
+		return results, false
+	}
+	return results[:limit], true
+}
@@ -0,0 +1,41 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolLimits(t *testing.T) {
+	writeLimits := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "tool_limits.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("Uses per-tool limits and falls back to the default", func(t *testing.T) {
+		limits, err := LoadToolLimits(writeLimits(t, "default: 15\ntools:\n  search_comments: 5\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 5, limits.Limit("search_comments"))
+		assert.Equal(t, 15, limits.Limit("get_claims_data"))
+		assert.Equal(t, DefaultToolResultLimit, ToolLimits{}.Limit("get_claims_data"))
+	})
+
+	t.Run("Rejects non-positive limits", func(t *testing.T) {
+		_, err := LoadToolLimits(writeLimits(t, "tools:\n  search_comments: 0\n"))
+		assert.ErrorContains(t, err, "limit for 'search_comments' must be positive")
+	})
+
+	t.Run("Caps results and reports truncation", func(t *testing.T) {
+		capped, truncated := CapResults([]int{1, 2, 3}, 2)
+		assert.Equal(t, []int{1, 2}, capped)
+		assert.True(t, truncated)
+
+		capped, truncated = CapResults([]int{1, 2}, 2)
+		assert.Equal(t, []int{1, 2}, capped)
+		assert.False(t, truncated)
+	})
+}
@@ -0,0 +1,142 @@
+package rag
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PlannerTool is a registered tool as described to the planner prompt, derived from the Tool's
+// Description and Parameters schema.
+type PlannerTool struct {
+	Name        string
+	Description string
+	Arguments   []ToolArgument
+}
+
+// ToolArgument is one property of a tool's Parameters schema.
+type ToolArgument struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// plannerTools describes the context's tools for the planner prompt, sorted by name so the
+// prompt is stable between requests.
+func (c RAGContext) plannerTools() []PlannerTool {
+	return PlannerTools(c.Tools)
+}
+
+// PlannerTools describes tools for a planner prompt, sorted by name. Handlers that run their own
+// tools, rather than a RAGContext's, use it to build the same tool list.
+func PlannerTools(tools map[string]Tool) []PlannerTool {
+	described := make([]PlannerTool, 0, len(tools))
+	for _, name := range slices.Sorted(maps.Keys(tools)) {
+		tool := tools[name]
+		described = append(described, PlannerTool{Name: name, Description: tool.Description, Arguments: schemaArguments(tool.Parameters)})
+	}
+	return described
+}
+
+// forPermissions returns a copy of the context with only the tools the permissions allow, so the
+// planner is never offered a tool executePlan would refuse.
+func (c RAGContext) forPermissions(permissions map[string]struct{}) RAGContext {
+	permitted := make(map[string]Tool, len(c.Tools))
+	for name, tool := range c.Tools {
+		if _, ok := permissions[tool.RequiredPermission]; ok {
+			permitted[name] = tool
+		}
+	}
+	c.Tools = permitted
+	return c
+}
+
+// schemaArguments reads the top-level properties of a JSON schema object, required ones first.
+func schemaArguments(schema map[string]interface{}) []ToolArgument {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	switch names := schema["required"].(type) {
+	case []string:
+		for _, name := range names {
+			required[name] = true
+		}
+	case []interface{}:
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	arguments := make([]ToolArgument, 0, len(properties))
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		property, _ := properties[name].(map[string]interface{})
+		argument := ToolArgument{Name: name, Required: required[name]}
+		argument.Type, _ = property["type"].(string)
+		argument.Description, _ = property["description"].(string)
+		if enum, ok := property["enum"].([]interface{}); ok {
+			for _, value := range enum {
+				argument.Enum = append(argument.Enum, fmt.Sprint(value))
+			}
+		} else if enum, ok := property["enum"].([]string); ok {
+			argument.Enum = enum
+		}
+		arguments = append(arguments, argument)
+	}
+	slices.SortStableFunc(arguments, func(a, b ToolArgument) int {
+		switch {
+		case a.Required == b.Required:
+			return 0
+		case a.Required:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return arguments
+}
+
+// FormatPlannerTools renders tools as the markdown tool list used in planner prompts.
+func FormatPlannerTools(tools []PlannerTool) string {
+	var b strings.Builder
+	for i, tool := range tools {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "**%d. Tool: `%s`**\n", i+1, tool.Name)
+		if tool.Description != "" {
+			fmt.Fprintf(&b, "- **Description**: %s\n", tool.Description)
+		}
+		if len(tool.Arguments) == 0 {
+			b.WriteString("- **Arguments**: none\n")
+			continue
+		}
+		b.WriteString("- **Arguments**:\n")
+		for _, arg := range tool.Arguments {
+			typ := arg.Type
+			if typ == "" {
+				typ = "any"
+			}
+			requirement := "optional"
+			if arg.Required {
+				requirement = "required"
+			}
+			fmt.Fprintf(&b, "    - `%s` (%s, %s)", arg.Name, typ, requirement)
+			if arg.Description != "" {
+				fmt.Fprintf(&b, ": %s", arg.Description)
+			}
+			if len(arg.Enum) > 0 {
+				quoted := make([]string, len(arg.Enum))
+				for i, value := range arg.Enum {
+					quoted[i] = fmt.Sprintf("%q", value)
+				}
+				fmt.Fprintf(&b, " MUST be one of: %s.", strings.Join(quoted, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlannerTools(t *testing.T) {
+	ragCtx := RAGContext{Tools: map[string]Tool{
+		"search_comments": {Description: "Searches adjuster comments."},
+		"get_claims_data": {
+			Description: "Gets structured claim data.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"status":       map[string]interface{}{"type": "string", "description": "Claim status.", "enum": []interface{}{"Open", "Paid"}},
+					"claim_id":     map[string]interface{}{"type": "string", "description": "A single claim."},
+					"search_query": map[string]interface{}{"type": "string"},
+				},
+				"required": []interface{}{"search_query"},
+			},
+		},
+	}}
+
+	tools := ragCtx.plannerTools()
+	assert.Equal(t, []PlannerTool{
+		{Name: "get_claims_data", Description: "Gets structured claim data.", Arguments: []ToolArgument{
+			{Name: "search_query", Type: "string", Required: true},
+			{Name: "claim_id", Type: "string", Description: "A single claim."},
+			{Name: "status", Type: "string", Description: "Claim status.", Enum: []string{"Open", "Paid"}},
+		}},
+		{Name: "search_comments", Description: "Searches adjuster comments.", Arguments: []ToolArgument{}},
+	}, tools)
+
+	assert.Equal(t, "**1. Tool: `get_claims_data`**\n"+
+		"- **Description**: Gets structured claim data.\n"+
+		"- **Arguments**:\n"+
+		"    - `search_query` (string, required)\n"+
+		"    - `claim_id` (string, optional): A single claim.\n"+
+		"    - `status` (string, optional): Claim status. MUST be one of: \"Open\", \"Paid\".\n"+
+		"\n"+
+		"**2. Tool: `search_comments`**\n"+
+		"- **Description**: Searches adjuster comments.\n"+
+		"- **Arguments**: none\n", FormatPlannerTools(tools))
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TokenUsage is the usage block returned by the Chat Completions API.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	PromptPerMillion     float64 `yaml:"prompt_per_million"`
+	CompletionPerMillion float64 `yaml:"completion_per_million"`
+}
+
+// PriceTable maps a model name to its price.
+type PriceTable map[string]ModelPrice
+
+// LoadPriceTable reads and validates the LLM price table YAML at path.
+func LoadPriceTable(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LLM price table %s: %w", path, err)
+	}
+	var file struct {
+		Models PriceTable `yaml:"models"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM price table %s: %w", path, err)
+	}
+	for model, price := range file.Models {
+		if price.PromptPerMillion < 0 || price.CompletionPerMillion < 0 {
+			return nil, fmt.Errorf("FATAL: price for model '%s' in %s must not be negative", model, path)
+		}
+	}
+	return file.Models, nil
+}
+
+// Cost estimates the USD cost of usage on model. Unknown models cost nothing.
+func (p PriceTable) Cost(model string, usage TokenUsage) float64 {
+	price, ok := p[model]
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.PromptPerMillion + float64(usage.CompletionTokens)*price.CompletionPerMillion) / 1_000_000
+}
+
+// UsageSummary is the accumulated LLM usage of one request.
+type UsageSummary struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// UsageTracker accumulates token usage across the LLM calls made while serving one request.
+// It is safe for concurrent use.
+type UsageTracker struct {
+	mu      sync.Mutex
+	prices  PriceTable
+	summary UsageSummary
+}
+
+// NewUsageTracker creates a tracker that prices calls with prices.
+func NewUsageTracker(prices PriceTable) *UsageTracker {
+	return &UsageTracker{prices: prices}
+}
+
+// Add records one LLM call on model.
+func (t *UsageTracker) Add(model string, usage TokenUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.summary.Calls++
+	t.summary.PromptTokens += usage.PromptTokens
+	t.summary.CompletionTokens += usage.CompletionTokens
+	t.summary.TotalTokens += usage.TotalTokens
+	t.summary.EstimatedCostUSD += t.prices.Cost(model, usage)
+}
+
+// Summary returns the totals recorded so far.
+func (t *UsageTracker) Summary() UsageSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.summary
+}
+
+type usageTrackerKey struct{}
+
+// WithUsageTracker returns a context that LLM calls record their usage into.
+func WithUsageTracker(ctx context.Context, tracker *UsageTracker) context.Context {
+	return context.WithValue(ctx, usageTrackerKey{}, tracker)
+}
+
+// RecordUsage adds usage to the tracker carried by ctx, if any.
+func RecordUsage(ctx context.Context, model string, usage TokenUsage) {
+	if tracker, ok := ctx.Value(usageTrackerKey{}).(*UsageTracker); ok {
+		tracker.Add(model, usage)
+	}
+}
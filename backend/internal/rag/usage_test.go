@@ -0,0 +1,49 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTracker(t *testing.T) {
+	prices := PriceTable{"gpt-4o": {PromptPerMillion: 2.5, CompletionPerMillion: 10}}
+
+	t.Run("Accumulates usage and cost across calls in a request", func(t *testing.T) {
+		tracker := NewUsageTracker(prices)
+		ctx := WithUsageTracker(context.Background(), tracker)
+
+		RecordUsage(ctx, "gpt-4o", TokenUsage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200})
+		RecordUsage(ctx, "gpt-4o", TokenUsage{PromptTokens: 3000, CompletionTokens: 800, TotalTokens: 3800})
+		RecordUsage(ctx, "unpriced-model", TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+
+		summary := tracker.Summary()
+		assert.Equal(t, 3, summary.Calls)
+		assert.Equal(t, 4010, summary.PromptTokens)
+		assert.Equal(t, 1005, summary.CompletionTokens)
+		assert.Equal(t, 5015, summary.TotalTokens)
+		assert.InDelta(t, 0.02, summary.EstimatedCostUSD, 1e-9)
+	})
+
+	t.Run("Ignores usage when no tracker is attached", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			RecordUsage(context.Background(), "gpt-4o", TokenUsage{PromptTokens: 1})
+		})
+	})
+
+	t.Run("Loads and validates the price table", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pricing.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("models:\n  gpt-4o:\n    prompt_per_million: 2.5\n    completion_per_million: 10\n"), 0o600))
+		loaded, err := LoadPriceTable(path)
+		require.NoError(t, err)
+		assert.Equal(t, prices, loaded)
+
+		require.NoError(t, os.WriteFile(path, []byte("models:\n  gpt-4o:\n    prompt_per_million: -1\n"), 0o600))
+		_, err = LoadPriceTable(path)
+		assert.Error(t, err)
+	})
+}
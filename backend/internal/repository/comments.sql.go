@@ -31,6 +31,22 @@ func (q *Queries) AddMentionToComment(ctx context.Context, arg AddMentionToComme
 	return err
 }
 
+const countCommentsForItem = `-- name: CountCommentsForItem :one
+SELECT COUNT(*)
+FROM comments
+WHERE
+	item_id = $1
+	AND deleted_at IS NULL
+`
+
+// Counts the live comments on an item, for paginating ListCommentsForItem
+func (q *Queries) CountCommentsForItem(ctx context.Context, itemID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countCommentsForItem, itemID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createComment = `-- name: CreateComment :one
 INSERT INTO comments (
 	item_id,
@@ -71,6 +87,26 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 	return i, err
 }
 
+const getCommentAuthor = `-- name: GetCommentAuthor :one
+SELECT user_id
+FROM comments
+WHERE
+	id = $1 AND item_id = $2 AND deleted_at IS NULL
+`
+
+type GetCommentAuthorParams struct {
+	ID     int64 `json:"id"`
+	ItemID int64 `json:"item_id"`
+}
+
+// Returns the author of a live comment, so edits and deletes can be limited to them
+func (q *Queries) GetCommentAuthor(ctx context.Context, arg GetCommentAuthorParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getCommentAuthor, arg.ID, arg.ItemID)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
 const listCommentsForItem = `-- name: ListCommentsForItem :many
 SELECT
 	c.id,
@@ -90,10 +126,22 @@ JOIN
 	users u ON c.user_id = u.id
 WHERE
 	c.item_id = $1
+	AND c.deleted_at IS NULL
+	AND (
+		$2::BIGINT IS NULL
+		OR (c.created_at, c.id) < (SELECT b.created_at, b.id FROM comments b WHERE b.id = $2)
+	)
 ORDER BY
-	c.created_at ASC
+	c.created_at DESC, c.id DESC
+LIMIT $3
 `
 
+type ListCommentsForItemParams struct {
+	ItemID    int64       `json:"item_id"`
+	BeforeID  pgtype.Int8 `json:"before_id"`
+	PageLimit int32       `json:"page_limit"`
+}
+
 type ListCommentsForItemRow struct {
 	ID             int64              `json:"id"`
 	Comment        string             `json:"comment"`
@@ -102,8 +150,10 @@ type ListCommentsForItemRow struct {
 	MentionedUsers interface{}        `json:"mentioned_users"`
 }
 
-func (q *Queries) ListCommentsForItem(ctx context.Context, itemID int64) ([]ListCommentsForItemRow, error) {
-	rows, err := q.db.Query(ctx, listCommentsForItem, itemID)
+// Lists the live comments on an item, newest first, one page at a time. A page after the first
+// starts below before_id, the oldest comment of the page before it
+func (q *Queries) ListCommentsForItem(ctx context.Context, arg ListCommentsForItemParams) ([]ListCommentsForItemRow, error) {
+	rows, err := q.db.Query(ctx, listCommentsForItem, arg.ItemID, arg.BeforeID, arg.PageLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -146,3 +196,79 @@ func (q *Queries) SetCommentEmbedding(ctx context.Context, arg SetCommentEmbeddi
 	_, err := q.db.Exec(ctx, setCommentEmbedding, arg.ID, arg.Embedding)
 	return err
 }
+
+const softDeleteComment = `-- name: SoftDeleteComment :execrows
+UPDATE comments
+SET
+	deleted_at = NOW(),
+	updated_at = NOW()
+WHERE
+	id = $1 AND item_id = $2 AND user_id = $3 AND deleted_at IS NULL
+`
+
+type SoftDeleteCommentParams struct {
+	ID     int64 `json:"id"`
+	ItemID int64 `json:"item_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// Hides a comment by its author from listings and search while keeping the row for the audit trail
+func (q *Queries) SoftDeleteComment(ctx context.Context, arg SoftDeleteCommentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteComment, arg.ID, arg.ItemID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateComment = `-- name: UpdateComment :one
+WITH previous AS (
+	SELECT id, comment
+	FROM comments
+	WHERE id = $1 AND item_id = $2 AND user_id = $4 AND deleted_at IS NULL
+	FOR UPDATE
+)
+UPDATE comments c
+SET
+	comment = $3,
+	embedding = NULL,
+	updated_at = NOW()
+FROM previous p
+WHERE
+	c.id = p.id
+RETURNING c.id, c.item_id, c.comment, c.user_id, c.created_at, c.updated_at, p.comment AS previous_comment
+`
+
+type UpdateCommentParams struct {
+	ID      int64  `json:"id"`
+	ItemID  int64  `json:"item_id"`
+	Comment string `json:"comment"`
+	UserID  int64  `json:"user_id"`
+}
+
+type UpdateCommentRow struct {
+	ID              int64              `json:"id"`
+	ItemID          int64              `json:"item_id"`
+	Comment         string             `json:"comment"`
+	UserID          int64              `json:"user_id"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	PreviousComment string             `json:"previous_comment"`
+}
+
+// Replaces the text of a live comment by its author and returns the previous text for the audit trail.
+// The embedding of the old text is cleared so search never matches it against the new text
+func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (UpdateCommentRow, error) {
+	row := q.db.QueryRow(ctx, updateComment, arg.ID, arg.ItemID, arg.Comment, arg.UserID)
+	var i UpdateCommentRow
+	err := row.Scan(
+		&i.ID,
+		&i.ItemID,
+		&i.Comment,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PreviousComment,
+	)
+	return i, err
+}
@@ -12,6 +12,169 @@ import (
 	"github.com/pgvector/pgvector-go"
 )
 
+const addItemPropertyArrayValue = `-- name: AddItemPropertyArrayValue :one
+UPDATE items
+SET
+	custom_properties = jsonb_set(
+		custom_properties,
+		ARRAY[$1::TEXT],
+		CASE
+			WHEN EXISTS (
+				SELECT 1 FROM jsonb_array_elements(COALESCE(custom_properties->$1::TEXT, '[]'::JSONB)) AS e(value)
+				WHERE e.value = $2::JSONB
+			) THEN custom_properties->$1::TEXT
+			ELSE COALESCE(custom_properties->$1::TEXT, '[]'::JSONB) || jsonb_build_array($2::JSONB)
+		END
+	),
+	updated_at = NOW()
+WHERE
+	id = $3
+	AND jsonb_typeof(COALESCE(custom_properties->$1::TEXT, '[]'::JSONB)) = 'array'
+RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding
+`
+
+type AddItemPropertyArrayValueParams struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	ID    int64  `json:"id"`
+}
+
+// Appends value to the custom_properties array under key in a single statement, creating the array
+// when the key is absent. A value already in the array isn't added again, so retried requests don't
+// duplicate it. Matches no row when the key holds something other than an array
+func (q *Queries) AddItemPropertyArrayValue(ctx context.Context, arg AddItemPropertyArrayValueParams) (Item, error) {
+	row := q.db.QueryRow(ctx, addItemPropertyArrayValue, arg.Key, arg.Value, arg.ID)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.ItemType,
+		&i.Scope,
+		&i.BusinessKey,
+		&i.Status,
+		&i.CustomProperties,
+		&i.Embedding,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
+	)
+	return i, err
+}
+
+const archiveMissingItems = `-- name: ArchiveMissingItems :execrows
+UPDATE items SET status = 'archived', updated_at = NOW()
+WHERE items.item_type = $1
+AND items.status = 'active'
+AND NOT EXISTS (
+	SELECT 1 FROM temp_items_staging s
+	WHERE s.item_type = items.item_type AND s.business_key = items.business_key
+)
+`
+
+// Archives active items of the type that are absent from the staging table, for delta
+// ingestion of files that carry the full current set of records
+func (q *Queries) ArchiveMissingItems(ctx context.Context, itemType ItemType) (int64, error) {
+	result, err := q.db.Exec(ctx, archiveMissingItems, itemType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const archiveStaleDocumentItems = `-- name: ArchiveStaleDocumentItems :execrows
+UPDATE items SET status = 'archived', updated_at = NOW()
+WHERE items.item_type = $1
+AND items.status = 'active'
+AND items.custom_properties->>'metadata.document_id' IN (
+	SELECT custom_properties->>'metadata.document_id' FROM temp_items_staging
+)
+AND NOT EXISTS (
+	SELECT 1 FROM temp_items_staging s
+	WHERE s.item_type = items.item_type AND s.business_key = items.business_key
+)
+`
+
+// Archives items for documents present in the staging table that were not re-staged,
+// so re-ingesting a document replaces its previous chunks. They are archived rather than
+// deleted because their items_events rows must keep pointing at them
+func (q *Queries) ArchiveStaleDocumentItems(ctx context.Context, itemType ItemType) (int64, error) {
+	result, err := q.db.Exec(ctx, archiveStaleDocumentItems, itemType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearItemEmbeddings = `-- name: ClearItemEmbeddings :one
+UPDATE items
+SET
+	embedding = NULL,
+	title_embedding = NULL,
+	body_embedding = NULL
+WHERE
+	id = $1
+RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding
+`
+
+// Clears the embeddings of an item whose content changed, so they are no longer matched against
+// the new content and the embedding backfill picks the item up again
+func (q *Queries) ClearItemEmbeddings(ctx context.Context, id int64) (Item, error) {
+	row := q.db.QueryRow(ctx, clearItemEmbeddings, id)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.ItemType,
+		&i.Scope,
+		&i.BusinessKey,
+		&i.Status,
+		&i.CustomProperties,
+		&i.Embedding,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
+	)
+	return i, err
+}
+
+const countItemsInScope = `-- name: CountItemsInScope :one
+SELECT COUNT(*)
+FROM items
+WHERE
+	item_type::TEXT = $1::TEXT
+	AND ($2::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest($3::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	))
+	AND NOT EXISTS (
+		SELECT 1
+		FROM jsonb_to_recordset($4::JSONB) AS f(field TEXT, op TEXT, value TEXT, "values" TEXT[], number NUMERIC)
+		WHERE NOT item_filter_matches(items.custom_properties, f.field, f.op, f.value, f."values", f.number)
+	)
+`
+
+type CountItemsInScopeParams struct {
+	ItemType string   `json:"item_type"`
+	ViewAll  bool     `json:"view_all"`
+	Scopes   []string `json:"scopes"`
+	Filters  []byte   `json:"filters"`
+}
+
+// Counts the items ListItemsInScope pages through, with the same filters
+func (q *Queries) CountItemsInScope(ctx context.Context, arg CountItemsInScopeParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countItemsInScope,
+		arg.ItemType,
+		arg.ViewAll,
+		arg.Scopes,
+		arg.Filters,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createItem = `-- name: CreateItem :one
 INSERT INTO items (
 	item_type, 
@@ -23,7 +186,7 @@ INSERT INTO items (
 ) VALUES (
 	$1, $2, $3, $4, $5, $6
 )
-RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at
+RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding
 `
 
 type CreateItemParams struct {
@@ -57,6 +220,9 @@ func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) (Item, e
 		&i.Embedding,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
 	)
 	return i, err
 }
@@ -115,6 +281,27 @@ func (q *Queries) DeactivateItemsBySource(ctx context.Context, arg DeactivateIte
 	return err
 }
 
+const discardUnchangedStagedItems = `-- name: DiscardUnchangedStagedItems :execrows
+DELETE FROM temp_items_staging s
+USING items i
+WHERE i.item_type = s.item_type
+AND i.business_key = s.business_key
+AND i.status = 'active'
+AND i.scope IS NOT DISTINCT FROM s.scope
+AND i.content_hash = md5((i.custom_properties || s.custom_properties)::text)
+`
+
+// Drops staged rows whose content already matches the stored active item, so a delta
+// ingestion only upserts new and changed rows. Upserts merge properties, so a row is
+// unchanged when merging it would leave the stored content hash as it is
+func (q *Queries) DiscardUnchangedStagedItems(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, discardUnchangedStagedItems)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getEventsForItem = `-- name: GetEventsForItem :many
 SELECT id, item_id, event_type, event_data, created_by, created_at FROM "items_events"
 WHERE item_id = $1
@@ -150,11 +337,12 @@ func (q *Queries) GetEventsForItem(ctx context.Context, itemID int64) ([]ItemsEv
 }
 
 const getItemForUpdate = `-- name: GetItemForUpdate :one
-SELECT id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at FROM "items"
+SELECT id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding FROM "items"
 WHERE id = $1 LIMIT 1
+FOR UPDATE
 `
 
-// Fetch a single item for update
+// Fetch a single item for update, locking its row until the transaction ends
 func (q *Queries) GetItemForUpdate(ctx context.Context, id int64) (Item, error) {
 	row := q.db.QueryRow(ctx, getItemForUpdate, id)
 	var i Item
@@ -168,10 +356,277 @@ func (q *Queries) GetItemForUpdate(ctx context.Context, id int64) (Item, error)
 		&i.Embedding,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
+	)
+	return i, err
+}
+
+const getItemInScope = `-- name: GetItemInScope :one
+SELECT id, item_type, scope, business_key, status, custom_properties, created_at, updated_at, content_hash
+FROM items
+WHERE
+	id = $1
+	AND ($2::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest($3::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	))
+`
+
+type GetItemInScopeParams struct {
+	ID      int64    `json:"id"`
+	ViewAll bool     `json:"view_all"`
+	Scopes  []string `json:"scopes"`
+}
+
+type GetItemInScopeRow struct {
+	ID               int64              `json:"id"`
+	ItemType         ItemType           `json:"item_type"`
+	Scope            pgtype.Text        `json:"scope"`
+	BusinessKey      pgtype.Text        `json:"business_key"`
+	Status           ItemStatus         `json:"status"`
+	CustomProperties []byte             `json:"custom_properties"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ContentHash      pgtype.Text        `json:"content_hash"`
+}
+
+// Fetch a single item if it falls within the caller's scopes; view_all skips the scope check.
+// A scope also covers the composite scopes nested under it, e.g. WEST covers WEST/BR01.
+func (q *Queries) GetItemInScope(ctx context.Context, arg GetItemInScopeParams) (GetItemInScopeRow, error) {
+	row := q.db.QueryRow(ctx, getItemInScope, arg.ID, arg.ViewAll, arg.Scopes)
+	var i GetItemInScopeRow
+	err := row.Scan(
+		&i.ID,
+		&i.ItemType,
+		&i.Scope,
+		&i.BusinessKey,
+		&i.Status,
+		&i.CustomProperties,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContentHash,
+	)
+	return i, err
+}
+
+const listItemsInScope = `-- name: ListItemsInScope :many
+SELECT id, item_type, scope, business_key, status, custom_properties, created_at, updated_at, content_hash
+FROM items
+WHERE
+	item_type::TEXT = $1::TEXT
+	AND ($2::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest($3::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	))
+	AND NOT EXISTS (
+		SELECT 1
+		FROM jsonb_to_recordset($4::JSONB) AS f(field TEXT, op TEXT, value TEXT, "values" TEXT[], number NUMERIC)
+		WHERE NOT item_filter_matches(items.custom_properties, f.field, f.op, f.value, f."values", f.number)
+	)
+ORDER BY updated_at DESC, id DESC
+LIMIT $6 OFFSET $5
+`
+
+type ListItemsInScopeParams struct {
+	ItemType     string   `json:"item_type"`
+	ViewAll      bool     `json:"view_all"`
+	Scopes       []string `json:"scopes"`
+	Filters      []byte   `json:"filters"`
+	ResultOffset int32    `json:"result_offset"`
+	ResultLimit  int32    `json:"result_limit"`
+}
+
+type ListItemsInScopeRow struct {
+	ID               int64              `json:"id"`
+	ItemType         ItemType           `json:"item_type"`
+	Scope            pgtype.Text        `json:"scope"`
+	BusinessKey      pgtype.Text        `json:"business_key"`
+	Status           ItemStatus         `json:"status"`
+	CustomProperties []byte             `json:"custom_properties"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ContentHash      pgtype.Text        `json:"content_hash"`
+}
+
+// Lists items of one type within the caller's scopes, most recently updated first.
+// filters is a JSON array of {field, op, value, values, number} conditions on custom_properties,
+// built by the API's filter parser; an item must match all of them. number is set for numeric
+// filter values so numeric properties, and string properties holding a number, compare as numbers.
+// Each condition is evaluated by the item_filter_matches SQL function
+func (q *Queries) ListItemsInScope(ctx context.Context, arg ListItemsInScopeParams) ([]ListItemsInScopeRow, error) {
+	rows, err := q.db.Query(ctx, listItemsInScope,
+		arg.ItemType,
+		arg.ViewAll,
+		arg.Scopes,
+		arg.Filters,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListItemsInScopeRow
+	for rows.Next() {
+		var i ListItemsInScopeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ItemType,
+			&i.Scope,
+			&i.BusinessKey,
+			&i.Status,
+			&i.CustomProperties,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ContentHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsMissingEmbedding = `-- name: ListItemsMissingEmbedding :many
+SELECT id, custom_properties
+FROM items
+WHERE item_type = $1 AND id > $2
+	AND (
+		embedding IS NULL
+		OR ($3::BOOLEAN AND title_embedding IS NULL)
+		OR ($4::BOOLEAN AND body_embedding IS NULL)
+	)
+ORDER BY id
+LIMIT $5
+`
+
+type ListItemsMissingEmbeddingParams struct {
+	ItemType  ItemType `json:"item_type"`
+	AfterID   int64    `json:"after_id"`
+	WithTitle bool     `json:"with_title"`
+	WithBody  bool     `json:"with_body"`
+	BatchSize int32    `json:"batch_size"`
+}
+
+type ListItemsMissingEmbeddingRow struct {
+	ID               int64  `json:"id"`
+	CustomProperties []byte `json:"custom_properties"`
+}
+
+// Lists items of one type that have no embedding, in id order after after_id, so an embedding
+// backfill can resume from the last item a previous run processed. with_title and with_body also
+// list items missing that named embedding: delta ingestion skips unchanged rows, so items ingested
+// before the named embedding was configured never get one otherwise
+func (q *Queries) ListItemsMissingEmbedding(ctx context.Context, arg ListItemsMissingEmbeddingParams) ([]ListItemsMissingEmbeddingRow, error) {
+	rows, err := q.db.Query(ctx, listItemsMissingEmbedding,
+		arg.ItemType,
+		arg.AfterID,
+		arg.WithTitle,
+		arg.WithBody,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListItemsMissingEmbeddingRow
+	for rows.Next() {
+		var i ListItemsMissingEmbeddingRow
+		if err := rows.Scan(&i.ID, &i.CustomProperties); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeItemPropertyArrayValue = `-- name: RemoveItemPropertyArrayValue :one
+UPDATE items
+SET
+	custom_properties = CASE
+		WHEN custom_properties->$1::TEXT IS NULL THEN custom_properties
+		ELSE jsonb_set(
+			custom_properties,
+			ARRAY[$1::TEXT],
+			COALESCE((
+				SELECT jsonb_agg(e.value ORDER BY e.position)
+				FROM jsonb_array_elements(custom_properties->$1::TEXT) WITH ORDINALITY AS e(value, position)
+				WHERE e.value <> $2::JSONB
+			), '[]'::JSONB)
+		)
+	END,
+	updated_at = NOW()
+WHERE
+	id = $3
+	AND jsonb_typeof(COALESCE(custom_properties->$1::TEXT, '[]'::JSONB)) = 'array'
+RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding
+`
+
+type RemoveItemPropertyArrayValueParams struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	ID    int64  `json:"id"`
+}
+
+// Removes every element equal to value from the custom_properties array under key in a single
+// statement, leaving an absent key absent. Matches no row when the key holds something other than an array
+func (q *Queries) RemoveItemPropertyArrayValue(ctx context.Context, arg RemoveItemPropertyArrayValueParams) (Item, error) {
+	row := q.db.QueryRow(ctx, removeItemPropertyArrayValue, arg.Key, arg.Value, arg.ID)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.ItemType,
+		&i.Scope,
+		&i.BusinessKey,
+		&i.Status,
+		&i.CustomProperties,
+		&i.Embedding,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
 	)
 	return i, err
 }
 
+const setItemEmbeddings = `-- name: SetItemEmbeddings :exec
+UPDATE items
+SET
+	embedding = $1,
+	title_embedding = NULLIF($2::TEXT, '[]')::vector,
+	body_embedding = NULLIF($3::TEXT, '[]')::vector
+WHERE
+	id = $4
+`
+
+type SetItemEmbeddingsParams struct {
+	Embedding      pgvector.Vector `json:"embedding"`
+	TitleEmbedding string          `json:"title_embedding"`
+	BodyEmbedding  string          `json:"body_embedding"`
+	ID             int64           `json:"id"`
+}
+
+// Stores embeddings generated after ingestion. The named embeddings are passed as vector text so
+// an empty vector, from a field with no text to embed, is stored as NULL
+func (q *Queries) SetItemEmbeddings(ctx context.Context, arg SetItemEmbeddingsParams) error {
+	_, err := q.db.Exec(ctx, setItemEmbeddings,
+		arg.Embedding,
+		arg.TitleEmbedding,
+		arg.BodyEmbedding,
+		arg.ID,
+	)
+	return err
+}
+
 const updateItem = `-- name: UpdateItem :one
 UPDATE items
 SET
@@ -181,7 +636,7 @@ SET
 	updated_at = NOW()
 WHERE
 	id = $1
-RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at
+RETURNING id, item_type, scope, business_key, status, custom_properties, embedding, created_at, updated_at, content_hash, title_embedding, body_embedding
 `
 
 type UpdateItemParams struct {
@@ -210,35 +665,54 @@ func (q *Queries) UpdateItem(ctx context.Context, arg UpdateItemParams) (Item, e
 		&i.Embedding,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
+		&i.TitleEmbedding,
+		&i.BodyEmbedding,
 	)
 	return i, err
 }
 
-const upsertItems = `-- name: UpsertItems :execrows
-INSERT INTO items (
-	item_type, scope, business_key, status, custom_properties, embedding
+const upsertItems = `-- name: UpsertItems :one
+WITH upserted AS (
+	INSERT INTO items (
+		item_type, scope, business_key, status, custom_properties, embedding, title_embedding, body_embedding
+	)
+	SELECT
+		item_type,
+		scope,
+		business_key,
+		'active',
+		custom_properties,
+		embedding,
+		title_embedding,
+		body_embedding
+	FROM temp_items_staging
+	ON CONFLICT (item_type, business_key) DO UPDATE SET
+		status = EXCLUDED.status,
+		scope = EXCLUDED.scope,
+		custom_properties = items.custom_properties || EXCLUDED.custom_properties,
+		embedding = EXCLUDED.embedding,
+		title_embedding = EXCLUDED.title_embedding,
+		body_embedding = EXCLUDED.body_embedding,
+		updated_at = NOW()
+	RETURNING (xmax = 0) AS inserted
 )
 SELECT
-	item_type,
-	scope, 
-	business_key,
-	'active',
-	custom_properties,
-	embedding
-FROM temp_items_staging
-ON CONFLICT (item_type, business_key) DO UPDATE SET 
-	status = EXCLUDED.status,
-	scope = EXCLUDED.scope,
-	custom_properties = items.custom_properties || EXCLUDED.custom_properties,
-	embedding = EXCLUDED.embedding,
-	updated_at = NOW()
+	COUNT(*) FILTER (WHERE inserted)::bigint AS inserted_count,
+	COUNT(*) FILTER (WHERE NOT inserted)::bigint AS updated_count
+FROM upserted
 `
 
-// Insert new records from staging, or update existing ones based on business key
-func (q *Queries) UpsertItems(ctx context.Context) (int64, error) {
-	result, err := q.db.Exec(ctx, upsertItems)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
+type UpsertItemsRow struct {
+	InsertedCount int64 `json:"inserted_count"`
+	UpdatedCount  int64 `json:"updated_count"`
+}
+
+// Insert new records from staging, or update existing ones based on business key.
+// Returns how many rows were inserted vs updated (xmax is 0 only for freshly inserted rows)
+func (q *Queries) UpsertItems(ctx context.Context) (UpsertItemsRow, error) {
+	row := q.db.QueryRow(ctx, upsertItems)
+	var i UpsertItemsRow
+	err := row.Scan(&i.InsertedCount, &i.UpdatedCount)
+	return i, err
 }
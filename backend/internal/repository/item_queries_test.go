@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListItemsInScopeFilters runs item filters against Postgres, covering the numeric comparisons
+// the item_filter_matches function makes. It needs a Postgres with the platform migrations applied
+// and is skipped unless TEST_DATABASE_URL points at it.
+func TestListItemsInScopeFilters(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	// Items live in a scope of their own so other rows of the type can't match.
+	scope := "filter-test-" + uuid.NewString()
+	for key, properties := range map[string]string{
+		"number":     `{"amount": 15000, "region": "West", "tags": ["urgent"]}`,
+		"string":     `{"amount": "12000.50", "region": "East", "tags": ["routine"]}`,
+		"small":      `{"amount": "900", "region": "West", "tags": []}`,
+		"not-number": `{"amount": "unknown", "region": "North", "tags": []}`,
+	} {
+		_, err := tx.Exec(ctx, `INSERT INTO items (item_type, scope, business_key, status, custom_properties) VALUES ('INSURANCE_CLAIM', $1, $2, 'active', $3)`,
+			scope, scope+"/"+key, properties)
+		require.NoError(t, err)
+	}
+	q := New(tx)
+
+	list := func(t *testing.T, filters string) []string {
+		t.Helper()
+		rows, err := q.ListItemsInScope(ctx, ListItemsInScopeParams{
+			ItemType:    string(ItemTypeINSURANCECLAIM),
+			Scopes:      []string{scope},
+			Filters:     []byte(filters),
+			ResultLimit: 10,
+		})
+		require.NoError(t, err)
+		count, err := q.CountItemsInScope(ctx, CountItemsInScopeParams{
+			ItemType: string(ItemTypeINSURANCECLAIM),
+			Scopes:   []string{scope},
+			Filters:  []byte(filters),
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, len(rows), count, "count and list must apply the same filters")
+
+		var keys []string
+		for _, row := range rows {
+			keys = append(keys, row.BusinessKey.String[len(scope)+1:])
+		}
+		return keys
+	}
+
+	t.Run("Compares numbers stored as strings numerically", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"number", "string"}, list(t, `[{"field": "amount", "op": ">", "value": "10000", "number": 10000}]`))
+		assert.ElementsMatch(t, []string{"small"}, list(t, `[{"field": "amount", "op": "<", "value": "1000", "number": 1000}]`))
+		assert.ElementsMatch(t, []string{"string"}, list(t, `[{"field": "amount", "op": "=", "value": "12000.5", "number": 12000.5}]`))
+	})
+
+	t.Run("Treats non-numeric strings as text", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"not-number"}, list(t, `[{"field": "amount", "op": "=", "value": "unknown"}]`))
+		assert.ElementsMatch(t, []string{"number", "string", "small"}, list(t, `[{"field": "amount", "op": "!=", "value": "unknown"}]`))
+	})
+
+	t.Run("Combines conditions", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"number"}, list(t, `[
+			{"field": "amount", "op": ">", "value": "10000", "number": 10000},
+			{"field": "region", "op": "in", "values": ["West", "North"]},
+			{"field": "tags", "op": "contains", "value": "urgent"}
+		]`))
+	})
+
+	t.Run("Matches everything without filters", func(t *testing.T) {
+		assert.Len(t, list(t, `[]`), 4)
+	})
+}
+
+// TestArchiveStaleDocumentItems re-ingests a document whose old chunks have events, which a
+// delete would trip over. It needs a Postgres with the platform migrations applied and is skipped
+// unless TEST_DATABASE_URL points at it.
+func TestArchiveStaleDocumentItems(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	q := New(tx)
+
+	run := uuid.NewString()
+	document, otherDocument := "doc-"+run, "other-"+run
+	var userID int64
+	require.NoError(t, tx.QueryRow(ctx, `INSERT INTO users (auth_provider_subject, email) VALUES ($1, $1 || '@example.com') RETURNING id`, run).Scan(&userID))
+	ids := map[string]int64{}
+	for key, documentID := range map[string]string{"kept": document, "dropped": document, "other": otherDocument} {
+		var id int64
+		require.NoError(t, tx.QueryRow(ctx, `INSERT INTO items (item_type, business_key, status, custom_properties) VALUES ('KNOWLEDGE_CHUNK', $1, 'active', jsonb_build_object('metadata.document_id', $2::text)) RETURNING id`,
+			run+"/"+key, documentID).Scan(&id))
+		_, err := tx.Exec(ctx, `INSERT INTO items_events (item_id, event_type, event_data, created_by) VALUES ($1, 'ITEM_UPDATED', '{}', $2)`, id, userID)
+		require.NoError(t, err)
+		ids[key] = id
+	}
+
+	// The new version of the document keeps one of its two chunks.
+	require.NoError(t, q.CreateTempItemsStagingTable(ctx))
+	_, err = tx.Exec(ctx, `INSERT INTO temp_items_staging (item_type, business_key, status, custom_properties) VALUES ('KNOWLEDGE_CHUNK', $1, 'active', jsonb_build_object('metadata.document_id', $2::text))`,
+		run+"/kept", document)
+	require.NoError(t, err)
+
+	archived, err := q.ArchiveStaleDocumentItems(ctx, ItemTypeKNOWLEDGECHUNK)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, archived)
+
+	status := func(key string) ItemStatus {
+		var status ItemStatus
+		require.NoError(t, tx.QueryRow(ctx, `SELECT status FROM items WHERE id = $1`, ids[key]).Scan(&status))
+		return status
+	}
+	assert.Equal(t, ItemStatusArchived, status("dropped"))
+	assert.Equal(t, ItemStatusActive, status("kept"))
+	assert.Equal(t, ItemStatusActive, status("other"), "documents that weren't re-ingested are left alone")
+
+	events, err := q.GetEventsForItem(ctx, ids["dropped"])
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "the archived chunk keeps its history")
+}
+
+// TestListItemsInScopeMatchesWholeLevels checks that a scope grant covers the scopes nested under
+// it but not scopes that merely start with the same text. It needs a Postgres with the platform
+// migrations applied and is skipped unless TEST_DATABASE_URL points at it.
+func TestListItemsInScopeMatchesWholeLevels(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	region := "scope-test-" + uuid.NewString()
+	for _, scope := range []string{region, region + "/BR01", region + "ERN", region + "ERN/BR01", region + "-BR01"} {
+		_, err := tx.Exec(ctx, `INSERT INTO items (item_type, scope, business_key, status, custom_properties) VALUES ('INSURANCE_CLAIM', $1, $1, 'active', '{}')`, scope)
+		require.NoError(t, err)
+	}
+	q := New(tx)
+
+	list := func(t *testing.T, scopes ...string) []string {
+		t.Helper()
+		rows, err := q.ListItemsInScope(ctx, ListItemsInScopeParams{
+			ItemType:    string(ItemTypeINSURANCECLAIM),
+			Scopes:      scopes,
+			Filters:     []byte(`[]`),
+			ResultLimit: 10,
+		})
+		require.NoError(t, err)
+		var found []string
+		for _, row := range rows {
+			found = append(found, row.Scope.String)
+		}
+		return found
+	}
+
+	assert.ElementsMatch(t, []string{region, region + "/BR01"}, list(t, region), "a grant covers its own level and the levels nested under it")
+	assert.ElementsMatch(t, []string{region + "/BR01"}, list(t, region+"/BR01"), "a nested grant does not cover its parent")
+}
+
+// TestDeltaIngestionQueries stages a file against stored items, checking that unchanged rows are
+// dropped from the staging table and that items missing from the file are archived. It needs a
+// Postgres with the platform migrations applied and is skipped unless TEST_DATABASE_URL points at it.
+func TestDeltaIngestionQueries(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	q := New(tx)
+
+	// The item type is shared with other rows, so archival is checked on this run's items only.
+	run := uuid.NewString()
+	key := func(name string) string { return run + "/" + name }
+	for name, stored := range map[string]struct{ scope, properties string }{
+		"unchanged": {"WEST", `{"amount": 100, "note": "kept"}`},
+		"changed":   {"WEST", `{"amount": 100}`},
+		"moved":     {"WEST", `{"amount": 100}`},
+		"missing":   {"WEST", `{"amount": 100}`},
+	} {
+		_, err := tx.Exec(ctx, `INSERT INTO items (item_type, scope, business_key, status, custom_properties) VALUES ('INSURANCE_CLAIM', $1, $2, 'active', $3)`,
+			stored.scope, key(name), stored.properties)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, q.CreateTempItemsStagingTable(ctx))
+	for name, staged := range map[string]struct{ scope, properties string }{
+		"unchanged": {"WEST", `{"amount": 100}`},
+		"changed":   {"WEST", `{"amount": 200}`},
+		"moved":     {"EAST", `{"amount": 100}`},
+		"new":       {"WEST", `{"amount": 100}`},
+	} {
+		_, err := tx.Exec(ctx, `INSERT INTO temp_items_staging (item_type, scope, business_key, status, custom_properties) VALUES ('INSURANCE_CLAIM', $1, $2, 'active', $3)`,
+			staged.scope, key(name), staged.properties)
+		require.NoError(t, err)
+	}
+
+	archived, err := q.ArchiveMissingItems(ctx, ItemTypeINSURANCECLAIM)
+	require.NoError(t, err)
+	unchanged, err := q.DiscardUnchangedStagedItems(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, unchanged, "a row whose properties are all stored is unchanged")
+
+	var staged []string
+	rows, err := tx.Query(ctx, `SELECT business_key FROM temp_items_staging ORDER BY business_key`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var businessKey string
+		require.NoError(t, rows.Scan(&businessKey))
+		staged = append(staged, businessKey)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{key("changed"), key("moved"), key("new")}, staged, "changed properties and scopes are upserted")
+
+	status := func(name string) ItemStatus {
+		var status ItemStatus
+		require.NoError(t, tx.QueryRow(ctx, `SELECT status FROM items WHERE business_key = $1`, key(name)).Scan(&status))
+		return status
+	}
+	assert.Equal(t, ItemStatusArchived, status("missing"))
+	for _, name := range []string{"unchanged", "changed", "moved"} {
+		assert.Equal(t, ItemStatusActive, status(name), name)
+	}
+	assert.GreaterOrEqual(t, archived, int64(1), "other items of the type may be archived too, within this transaction")
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ItemStatuses lists the values of the item_status enum.
+var ItemStatuses = []ItemStatus{ItemStatusActive, ItemStatusInactive, ItemStatusArchived}
+
+// ParseItemStatus returns s as an ItemStatus, or an error naming the allowed values when s is not
+// one of them. Postgres would reject the value anyway, but only after the request reached the
+// database, and with an error that doesn't say which values are allowed.
+func ParseItemStatus(s string) (ItemStatus, error) {
+	for _, status := range ItemStatuses {
+		if s == string(status) {
+			return status, nil
+		}
+	}
+	allowed := make([]string, len(ItemStatuses))
+	for i, status := range ItemStatuses {
+		allowed[i] = string(status)
+	}
+	return "", fmt.Errorf("status must be one of %s, got '%s'", strings.Join(allowed, ", "), s)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseItemStatus(t *testing.T) {
+	status, err := ParseItemStatus("archived")
+	require.NoError(t, err)
+	assert.Equal(t, ItemStatusArchived, status)
+
+	_, err = ParseItemStatus("")
+	assert.ErrorContains(t, err, "status must be one of active, inactive, archived, got ''")
+
+	_, err = ParseItemStatus("Active")
+	assert.Error(t, err)
+}
@@ -128,6 +128,7 @@ type Comment struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 	Embedding pgvector.Vector    `json:"embedding"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 }
 
 type CommentMention struct {
@@ -135,6 +136,24 @@ type CommentMention struct {
 	UserID    int64 `json:"user_id"`
 }
 
+type ConversationTurn struct {
+	ID               int64              `json:"id"`
+	ConversationID   pgtype.UUID        `json:"conversation_id"`
+	RagContext       string             `json:"rag_context"`
+	UserID           pgtype.Int8        `json:"user_id"`
+	Question         string             `json:"question"`
+	History          []byte             `json:"history"`
+	RetrievedContext []byte             `json:"retrieved_context"`
+	Answer           []byte             `json:"answer"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	Language         string             `json:"language"`
+	LlmCalls         int32              `json:"llm_calls"`
+	PromptTokens     int32              `json:"prompt_tokens"`
+	CompletionTokens int32              `json:"completion_tokens"`
+	TotalTokens      int32              `json:"total_tokens"`
+	EstimatedCostUsd float64            `json:"estimated_cost_usd"`
+}
+
 type Contact struct {
 	ID          int64              `json:"id"`
 	DisplayName string             `json:"display_name"`
@@ -181,6 +200,22 @@ type IngestionJob struct {
 	// A counter for how many errored rows have been successfully corrected by a user.
 	InitialErrorCount pgtype.Int4 `json:"initial_error_count"`
 	ResolvedRowsCount pgtype.Int4 `json:"resolved_rows_count"`
+	// Set when the share of rows sent to triage exceeded the report type's alert threshold.
+	Degraded bool `json:"degraded"`
+}
+
+type IngestionJobStat struct {
+	JobID      pgtype.UUID `json:"job_id"`
+	ReportType string      `json:"report_type"`
+	Status     string      `json:"status"`
+	// Every data row read from the file: upserted, triaged and blank rows.
+	RowsTotal int32 `json:"rows_total"`
+	// Rows saved to items, including rows that were unchanged in a delta ingestion.
+	RowsUpserted int32              `json:"rows_upserted"`
+	RowsTriaged  int32              `json:"rows_triaged"`
+	RowsBlank    int32              `json:"rows_blank"`
+	DurationMs   int64              `json:"duration_ms"`
+	RecordedAt   pgtype.Timestamptz `json:"recorded_at"`
 }
 
 type Item struct {
@@ -193,6 +228,9 @@ type Item struct {
 	Embedding        pgvector.Vector    `json:"embedding"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ContentHash      pgtype.Text        `json:"content_hash"`
+	TitleEmbedding   pgvector.Vector    `json:"title_embedding"`
+	BodyEmbedding    pgvector.Vector    `json:"body_embedding"`
 }
 
 type ItemAssignment struct {
@@ -266,6 +304,7 @@ type TempItemsStaging struct {
 	Embedding        pgvector.Vector    `json:"embedding"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ContentHash      pgtype.Text        `json:"content_hash"`
 }
 
 type User struct {
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// ParseNumeric parses s as an exact decimal for a NUMERIC parameter. A blank string yields a NULL
+// numeric, so optional filters can be passed straight through; a value that is not a number is an
+// error rather than a silent NULL.
+func ParseNumeric(s string) (pgtype.Numeric, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return pgtype.Numeric{}, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return pgtype.Numeric{}, fmt.Errorf("could not parse '%s' as a number", s)
+	}
+	var num pgtype.Numeric
+	if err := num.Scan(d.String()); err != nil {
+		return pgtype.Numeric{}, fmt.Errorf("could not parse '%s' as a number: %w", s, err)
+	}
+	return num, nil
+}
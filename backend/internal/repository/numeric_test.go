@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumeric(t *testing.T) {
+	t.Run("Parses exact decimals", func(t *testing.T) {
+		num, err := ParseNumeric(" 1250.10 ")
+		require.NoError(t, err)
+		require.True(t, num.Valid)
+		value, err := num.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "1250.1", value)
+	})
+
+	t.Run("Returns NULL when not provided", func(t *testing.T) {
+		num, err := ParseNumeric("")
+		require.NoError(t, err)
+		assert.False(t, num.Valid)
+	})
+
+	t.Run("Rejects values that are not numbers", func(t *testing.T) {
+		_, err := ParseNumeric("12,000")
+		assert.ErrorContains(t, err, "could not parse '12,000' as a number")
+	})
+}
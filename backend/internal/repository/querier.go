@@ -11,16 +11,40 @@ import (
 )
 
 type Querier interface {
+	// Appends value to the custom_properties array under key in a single statement, creating the array
+	// when the key is absent. A value already in the array isn't added again, so retried requests don't
+	// duplicate it. Matches no row when the key holds something other than an array
+	AddItemPropertyArrayValue(ctx context.Context, arg AddItemPropertyArrayValueParams) (Item, error)
 	AddMentionToComment(ctx context.Context, arg AddMentionToCommentParams) error
+	// Archives active items of the type that are absent from the staging table, for delta
+	// ingestion of files that carry the full current set of records
+	ArchiveMissingItems(ctx context.Context, itemType ItemType) (int64, error)
+	// Archives items for documents present in the staging table that were not re-staged,
+	// so re-ingesting a document replaces its previous chunks. They are archived rather than
+	// deleted because their items_events rows must keep pointing at them
+	ArchiveStaleDocumentItems(ctx context.Context, itemType ItemType) (int64, error)
 	// Assign a specific role to a user
 	AssignRoleToUser(ctx context.Context, arg AssignRoleToUserParams) error
 	// Grants a user access to a specific scope
 	AssignScopeToUser(ctx context.Context, arg AssignScopeToUserParams) error
+	// Clears the embeddings of an item whose content changed, so they are no longer matched against
+	// the new content and the embedding backfill picks the item up again
+	ClearItemEmbeddings(ctx context.Context, id int64) (Item, error)
+	// Counts the live comments on an item, for paginating ListCommentsForItem
+	CountCommentsForItem(ctx context.Context, itemID int64) (int64, error)
+	// Counts the items ListItemsInScope pages through, with the same filters
+	CountItemsInScope(ctx context.Context, arg CountItemsInScopeParams) (int64, error)
+	// Counts ingestion jobs, for paginating ListIngestionJobs
+	CountIngestionJobs(ctx context.Context) (int64, error)
 	CreateComment(ctx context.Context, arg CreateCommentParams) (CreateCommentRow, error)
+	// Records one answered RAG query together with the context it was answered from and its LLM usage
+	CreateConversationTurn(ctx context.Context, arg CreateConversationTurnParams) (ConversationTurn, error)
 	// Inserts a new ingestion error record for a row that failed processing.
 	CreateIngestionError(ctx context.Context, arg CreateIngestionErrorParams) (IngestionError, error)
 	// Inserts a new file ingestion job record.
 	CreateIngestionJob(ctx context.Context, arg CreateIngestionJobParams) (IngestionJob, error)
+	// Records the aggregate counters of a processed ingestion job
+	CreateIngestionJobStats(ctx context.Context, arg CreateIngestionJobStatsParams) error
 	// Inserts a new item record into database
 	// Go is responsible for constructing the custom_properties JSONB
 	CreateItem(ctx context.Context, arg CreateItemParams) (Item, error)
@@ -32,35 +56,86 @@ type Querier interface {
 	// Creates a new user record from the authentication provider's details
 	CreateUserFromAuthProvider(ctx context.Context, arg CreateUserFromAuthProviderParams) (User, error)
 	DeactivateItemsBySource(ctx context.Context, arg DeactivateItemsBySourceParams) error
+	// Drops staged rows whose content already matches the stored active item, so a delta
+	// ingestion only upserts new and changed rows. Upserts merge properties, so a row is
+	// unchanged when merging it would leave the stored content hash as it is
+	DiscardUnchangedStagedItems(ctx context.Context) (int64, error)
+	// Returns the author of a live comment, so edits and deletes can be limited to them
+	GetCommentAuthor(ctx context.Context, arg GetCommentAuthorParams) (int64, error)
+	// Returns the user who started a conversation, so only they can continue it
+	GetConversationOwner(ctx context.Context, conversationID pgtype.UUID) (pgtype.Int8, error)
+	// Totals the LLM usage of every turn of a conversation
+	GetConversationUsage(ctx context.Context, conversationID pgtype.UUID) (GetConversationUsageRow, error)
 	// Fetch the event history for a specific item, newest first
 	GetEventsForItem(ctx context.Context, itemID int64) ([]ItemsEvent, error)
 	// Retrieves ingestion errors associated with a specific job ID, with pagination support
 	GetIngestionErrorsByJobID(ctx context.Context, jobID pgtype.UUID) ([]IngestionError, error)
+	// Fetches a single ingestion job by ID
+	GetIngestionJob(ctx context.Context, id pgtype.UUID) (IngestionJob, error)
 	// Fetch a single item for update
 	GetItemForUpdate(ctx context.Context, id int64) (Item, error)
+	// Fetch a single item if it falls within the caller's scopes; view_all skips the scope check.
+	// A scope also covers the composite scopes nested under it, e.g. WEST covers WEST/BR01.
+	GetItemInScope(ctx context.Context, arg GetItemInScopeParams) (GetItemInScopeRow, error)
 	// Fetch a single user by their external auth provider ID
 	GetUserByAuthProviderSubject(ctx context.Context, authProviderSubject string) (User, error)
+	// Fetch a single user by their internal ID
+	GetUserByID(ctx context.Context, id int64) (User, error)
+	// Resolves which items a user may view: every scope for admins and holders of items:view_all,
+	// otherwise the scopes granted in user_scope_access
+	GetUserItemScope(ctx context.Context, id int64) (GetUserItemScopeRow, error)
 	IncrementIngestionJobResolvedRows(ctx context.Context, id pgtype.UUID) error
 	// Checks for the existence of an item by its type and business key. Returns 1 if it exists, 0 otherwise.
 	ItemExistsByBusinessKey(ctx context.Context, arg ItemExistsByBusinessKeyParams) (int32, error)
-	ListCommentsForItem(ctx context.Context, itemID int64) ([]ListCommentsForItemRow, error)
+	// Lists the live comments on an item, newest first, one page at a time. A page after the first
+	// starts below before_id, the oldest comment of the page before it
+	ListCommentsForItem(ctx context.Context, arg ListCommentsForItemParams) ([]ListCommentsForItemRow, error)
+	// Lists the turns of a conversation in the order they were asked
+	ListConversationTurns(ctx context.Context, conversationID pgtype.UUID) ([]ConversationTurn, error)
+	// Lists job stats recorded since a point in time, optionally for one report type,
+	// ordered as a time series per report type
+	ListIngestionJobStats(ctx context.Context, arg ListIngestionJobStatsParams) ([]ListIngestionJobStatsRow, error)
 	// Lists ingestion jobs with pagination support
 	ListIngestionJobs(ctx context.Context, arg ListIngestionJobsParams) ([]ListIngestionJobsRow, error)
+	// Lists items of one type within the caller's scopes, most recently updated first.
+	// filters is a JSON array of {field, op, value, values, number} conditions on custom_properties,
+	// built by the API's filter parser; an item must match all of them. number is set for numeric
+	// filter values so numeric properties, and string properties holding a number, compare as numbers.
+	// Each condition is evaluated by the item_filter_matches SQL function
+	ListItemsInScope(ctx context.Context, arg ListItemsInScopeParams) ([]ListItemsInScopeRow, error)
+	// Lists items of one type that have no embedding, in id order after after_id, so an embedding
+	// backfill can resume from the last item a previous run processed. with_title and with_body also
+	// list items missing that named embedding: delta ingestion skips unchanged rows, so items ingested
+	// before the named embedding was configured never get one otherwise
+	ListItemsMissingEmbedding(ctx context.Context, arg ListItemsMissingEmbeddingParams) ([]ListItemsMissingEmbeddingRow, error)
 	// Fetch all available roles in system
 	ListRoles(ctx context.Context) ([]Role, error)
+	// Flags a job whose triage rate exceeded its report type's alert threshold
+	MarkIngestionJobDegraded(ctx context.Context, id pgtype.UUID) error
 	// Removes all roles from a user. Useful when completely re-assigning roles
 	RemoveAllRolesFromUser(ctx context.Context, userID int64) error
 	// Removes all scope access from a user
 	RemoveAllScopesFromUser(ctx context.Context, userID int64) error
+	// Removes every element equal to value from the custom_properties array under key in a single
+	// statement, leaving an absent key absent. Matches no row when the key holds something other than an array
+	RemoveItemPropertyArrayValue(ctx context.Context, arg RemoveItemPropertyArrayValueParams) (Item, error)
 	// Removes a specific role from a user
 	RemoveRoleFromUser(ctx context.Context, arg RemoveRoleFromUserParams) error
 	//Revokes a user's access from a specific scope.
 	RemoveScopeFromUser(ctx context.Context, arg RemoveScopeFromUserParams) error
 	// Sets the embedding for a specific comment after its been created
 	SetCommentEmbedding(ctx context.Context, arg SetCommentEmbeddingParams) error
+	// Stores embeddings generated after ingestion. The named embeddings are passed as vector text so
+	// an empty vector, from a field with no text to embed, is stored as NULL
+	SetItemEmbeddings(ctx context.Context, arg SetItemEmbeddingsParams) error
 	// Updates only the is_admin status of a specific user
 	// This is a priviliged action and should be protected at API layer
 	SetUserAdminStatus(ctx context.Context, arg SetUserAdminStatusParams) (User, error)
+	// Hides a comment by its author from listings and search while keeping the row for the audit trail
+	SoftDeleteComment(ctx context.Context, arg SoftDeleteCommentParams) (int64, error)
+	// Replaces the text of a live comment by its author and returns the previous text for the audit trail.
+	// The embedding of the old text is cleared so search never matches it against the new text
+	UpdateComment(ctx context.Context, arg UpdateCommentParams) (UpdateCommentRow, error)
 	UpdateIngestionErrorWithCorrection(ctx context.Context, arg UpdateIngestionErrorWithCorrectionParams) (IngestionError, error)
 	// Updates the status and details of an ingestion job
 	UpdateIngestionJobStatus(ctx context.Context, arg UpdateIngestionJobStatusParams) error
@@ -68,8 +143,11 @@ type Querier interface {
 	UpdateItem(ctx context.Context, arg UpdateItemParams) (Item, error)
 	// Updates a user's mutable details
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
-	//Insert new records from staging, or update existing ones based on business key
-	UpsertItems(ctx context.Context) (int64, error)
+	//Insert new records from staging, or update existing ones based on business key.
+	//Returns how many rows were inserted vs updated (xmax is 0 only for freshly inserted rows)
+	UpsertItems(ctx context.Context) (UpsertItemsRow, error)
+	// Reports whether a user is an admin or holds the given permission through one of their roles
+	UserHasPermission(ctx context.Context, arg UserHasPermissionParams) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
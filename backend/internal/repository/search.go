@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pgvector/pgvector-go"
+)
+
+// SearchEverything is written by hand rather than generated by sqlc. An HNSW index only serves an
+// ORDER BY on its own column and distance operator, so both are part of the query text instead of
+// being picked by a CASE, which would force a full scan. They come from searchEmbeddingColumns and
+// searchDistanceOperators, never from input.
+
+// searchEmbeddingColumns maps the embedding fields SearchEverything can rank items by to their
+// columns. The empty field is the combined embedding.
+var searchEmbeddingColumns = map[string]string{
+	"":      "embedding",
+	"title": "title_embedding",
+	"body":  "body_embedding",
+}
+
+// searchDistanceOperators maps the distance metrics SearchEverything can rank by to their pgvector
+// operators. The empty metric is cosine.
+var searchDistanceOperators = map[string]string{
+	"":              "<=>",
+	"cosine":        "<=>",
+	"l2":            "<->",
+	"inner_product": "<#>",
+}
+
+const searchEverything = `-- name: SearchEverything :many
+WITH viewer AS (
+	SELECT
+		u.is_admin OR EXISTS (
+			SELECT 1
+			FROM user_roles ur
+			JOIN role_permissions rp ON rp.role_id = ur.role_id
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE ur.user_id = u.id AND p.action = 'items:view_all'
+		) AS view_all
+	FROM users u
+	WHERE u.id = $1
+),
+visible_scopes AS (
+	SELECT scope FROM user_scope_access WHERE user_id = $1
+),
+item_hits AS (
+	SELECT
+		'item'::TEXT AS source_type,
+		i.id,
+		i.id AS item_id,
+		i.item_type::TEXT AS item_type,
+		i.scope,
+		i.business_key,
+		i.custom_properties AS properties,
+		(i.{{column}} {{operator}} $2::vector)::FLOAT8 AS distance
+	FROM items i
+	WHERE
+		i.{{column}} IS NOT NULL
+		AND ($3::TEXT[] IS NULL OR i.item_type::TEXT = ANY($3::TEXT[]))
+		AND (COALESCE((SELECT view_all FROM viewer), FALSE) OR EXISTS (
+			SELECT 1 FROM visible_scopes vs
+			WHERE i.scope = vs.scope OR starts_with(i.scope, vs.scope || '/')
+		))
+	ORDER BY i.{{column}} {{operator}} $2::vector
+	LIMIT $4
+),
+comment_hits AS (
+	SELECT
+		'comment'::TEXT AS source_type,
+		c.id,
+		c.item_id,
+		i.item_type::TEXT AS item_type,
+		i.scope,
+		i.business_key,
+		jsonb_build_object('comment', c.comment) AS properties,
+		(c.embedding {{operator}} $2::vector)::FLOAT8 AS distance
+	FROM comments c
+	JOIN items i ON i.id = c.item_id
+	WHERE
+		c.embedding IS NOT NULL
+		AND c.deleted_at IS NULL
+		AND ($3::TEXT[] IS NULL OR i.item_type::TEXT = ANY($3::TEXT[]))
+		AND (COALESCE((SELECT view_all FROM viewer), FALSE) OR EXISTS (
+			SELECT 1 FROM visible_scopes vs
+			WHERE i.scope = vs.scope OR starts_with(i.scope, vs.scope || '/')
+		))
+	ORDER BY c.embedding {{operator}} $2::vector
+	LIMIT $4
+)
+SELECT source_type, id, item_id, item_type, scope, business_key, properties, distance
+FROM (
+	SELECT * FROM item_hits
+	UNION ALL
+	SELECT * FROM comment_hits
+) hits
+ORDER BY distance
+LIMIT $4
+`
+
+type SearchEverythingParams struct {
+	UserID      int64           `json:"user_id"`
+	Embedding   pgvector.Vector `json:"embedding"`
+	ItemTypes   []string        `json:"item_types"`
+	ResultLimit int32           `json:"result_limit"`
+	// EmbeddingField picks the item embedding to search: "title", "body", or "" for the combined one.
+	EmbeddingField string `json:"embedding_field"`
+	// Metric picks the distance operator: "cosine" (<=>), "l2" (<->) or "inner_product" (<#>); ""
+	// is cosine.
+	Metric string `json:"metric"`
+}
+
+type SearchEverythingRow struct {
+	SourceType  string      `json:"source_type"`
+	ID          int64       `json:"id"`
+	ItemID      int64       `json:"item_id"`
+	ItemType    string      `json:"item_type"`
+	Scope       pgtype.Text `json:"scope"`
+	BusinessKey pgtype.Text `json:"business_key"`
+	Properties  []byte      `json:"properties"`
+	Distance    float64     `json:"distance"`
+}
+
+// searchEverythingQuery returns the search query for the given embedding field and metric.
+func searchEverythingQuery(embeddingField, metric string) (string, error) {
+	column, ok := searchEmbeddingColumns[embeddingField]
+	if !ok {
+		return "", fmt.Errorf("unknown embedding field '%s'", embeddingField)
+	}
+	operator, ok := searchDistanceOperators[metric]
+	if !ok {
+		return "", fmt.Errorf("unknown distance metric '%s'", metric)
+	}
+	return strings.NewReplacer("{{column}}", column, "{{operator}}", operator).Replace(searchEverything), nil
+}
+
+// Runs one vector search across every embedded item and live comment, limited to the scopes the
+// user may view. Admins and holders of items:view_all see every scope; comments inherit their item's scope.
+// A granted scope also covers the composite scopes nested under it, e.g. WEST covers WEST/BR01.
+func (q *Queries) SearchEverything(ctx context.Context, arg SearchEverythingParams) ([]SearchEverythingRow, error) {
+	query, err := searchEverythingQuery(arg.EmbeddingField, arg.Metric)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.db.Query(ctx, query,
+		arg.UserID,
+		arg.Embedding,
+		arg.ItemTypes,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchEverythingRow
+	for rows.Next() {
+		var i SearchEverythingRow
+		if err := rows.Scan(
+			&i.SourceType,
+			&i.ID,
+			&i.ItemID,
+			&i.ItemType,
+			&i.Scope,
+			&i.BusinessKey,
+			&i.Properties,
+			&i.Distance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
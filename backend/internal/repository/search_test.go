@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchEverythingQuery(t *testing.T) {
+	t.Run("Orders by the field's column", func(t *testing.T) {
+		for field, column := range map[string]string{"": "embedding", "title": "title_embedding", "body": "body_embedding"} {
+			query, err := searchEverythingQuery(field, "cosine")
+			require.NoError(t, err)
+			assert.Contains(t, query, "ORDER BY i."+column+" <=> $2::vector", field)
+			assert.NotContains(t, query, "{{", field)
+		}
+	})
+
+	t.Run("Orders by the metric's operator", func(t *testing.T) {
+		for metric, operator := range map[string]string{"": "<=>", "cosine": "<=>", "l2": "<->", "inner_product": "<#>"} {
+			query, err := searchEverythingQuery("", metric)
+			require.NoError(t, err)
+			assert.Contains(t, query, "ORDER BY i.embedding "+operator+" $2::vector", metric)
+			assert.Contains(t, query, "ORDER BY c.embedding "+operator+" $2::vector", metric)
+			assert.NotContains(t, query, "CASE", metric)
+		}
+	})
+
+	t.Run("Rejects names outside the whitelists", func(t *testing.T) {
+		_, err := searchEverythingQuery("summary; DROP TABLE items", "cosine")
+		assert.ErrorContains(t, err, "unknown embedding field")
+		_, err = searchEverythingQuery("", "<=> NULL;")
+		assert.ErrorContains(t, err, "unknown distance metric")
+	})
+}
+
+// TestSearchEverythingScopes checks that items and comments are only found within the scopes the
+// user is granted, including the scopes nested under a grant. It needs a Postgres with the platform
+// migrations applied and is skipped unless TEST_DATABASE_URL points at it.
+func TestSearchEverythingScopes(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	q := New(tx)
+
+	run := uuid.NewString()
+	region, otherRegion := "search-scope-"+run, "other-"+run
+	values := make([]float32, 384)
+	values[0] = 1
+	embedding := pgvector.NewVector(values)
+	var userID int64
+	require.NoError(t, tx.QueryRow(ctx, `INSERT INTO users (auth_provider_subject, email) VALUES ($1, $1 || '@example.com') RETURNING id`, run).Scan(&userID))
+	_, err = tx.Exec(ctx, `INSERT INTO user_scope_access (user_id, scope) VALUES ($1, $2)`, userID, region)
+	require.NoError(t, err)
+	for _, scope := range []string{region, region + "/BR01", region + "ERN", otherRegion} {
+		var itemID int64
+		require.NoError(t, tx.QueryRow(ctx, `INSERT INTO items (item_type, scope, business_key, status, custom_properties, embedding) VALUES ('INSURANCE_CLAIM', $1, $1, 'active', '{}', $2) RETURNING id`,
+			scope, embedding).Scan(&itemID))
+		_, err := tx.Exec(ctx, `INSERT INTO comments (item_id, comment, user_id, embedding) VALUES ($1, $2, $3, $4)`, itemID, "Comment in "+scope, userID, embedding)
+		require.NoError(t, err)
+	}
+
+	rows, err := q.SearchEverything(ctx, SearchEverythingParams{UserID: userID, Embedding: embedding, ResultLimit: 20})
+	require.NoError(t, err)
+	found := map[string][]string{}
+	for _, row := range rows {
+		found[row.SourceType] = append(found[row.SourceType], row.Scope.String)
+	}
+	assert.ElementsMatch(t, []string{region, region + "/BR01"}, found["item"], "a grant covers its own level and the levels nested under it")
+	assert.ElementsMatch(t, []string{region, region + "/BR01"}, found["comment"], "comments inherit their item's scope")
+}
@@ -11,6 +11,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countIngestionJobs = `-- name: CountIngestionJobs :one
+SELECT COUNT(*)
+FROM ingestion_jobs
+`
+
+// Counts ingestion jobs, for paginating ListIngestionJobs
+func (q *Queries) CountIngestionJobs(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countIngestionJobs)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createIngestionError = `-- name: CreateIngestionError :one
 INSERT INTO ingestion_errors (
     id,
@@ -65,7 +78,7 @@ INSERT INTO ingestion_jobs (
 ) VALUES (
 	$1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, source_type, source_details, item_type, status, started_at, completed_at, error_details, user_id, source_uri, total_rows, processed_rows, initial_error_count, resolved_rows_count
+RETURNING id, source_type, source_details, item_type, status, started_at, completed_at, error_details, user_id, source_uri, total_rows, processed_rows, initial_error_count, resolved_rows_count, degraded
 `
 
 type CreateIngestionJobParams struct {
@@ -105,6 +118,7 @@ func (q *Queries) CreateIngestionJob(ctx context.Context, arg CreateIngestionJob
 		&i.ProcessedRows,
 		&i.InitialErrorCount,
 		&i.ResolvedRowsCount,
+		&i.Degraded,
 	)
 	return i, err
 }
@@ -170,6 +184,35 @@ func (q *Queries) GetIngestionErrorsByJobID(ctx context.Context, jobID pgtype.UU
 	return items, nil
 }
 
+const getIngestionJob = `-- name: GetIngestionJob :one
+SELECT id, source_type, source_details, item_type, status, started_at, completed_at, error_details, user_id, source_uri, total_rows, processed_rows, initial_error_count, resolved_rows_count, degraded FROM ingestion_jobs
+WHERE id = $1
+`
+
+// Fetches a single ingestion job by ID
+func (q *Queries) GetIngestionJob(ctx context.Context, id pgtype.UUID) (IngestionJob, error) {
+	row := q.db.QueryRow(ctx, getIngestionJob, id)
+	var i IngestionJob
+	err := row.Scan(
+		&i.ID,
+		&i.SourceType,
+		&i.SourceDetails,
+		&i.ItemType,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.ErrorDetails,
+		&i.UserID,
+		&i.SourceUri,
+		&i.TotalRows,
+		&i.ProcessedRows,
+		&i.InitialErrorCount,
+		&i.ResolvedRowsCount,
+		&i.Degraded,
+	)
+	return i, err
+}
+
 const incrementIngestionJobResolvedRows = `-- name: IncrementIngestionJobResolvedRows :exec
 UPDATE ingestion_jobs
 SET
@@ -215,7 +258,8 @@ SELECT
 	processed_rows,
 	initial_error_count,
 	resolved_rows_count,
-	total_rows
+	total_rows,
+	degraded
 FROM 
 	ingestion_jobs
 ORDER BY 
@@ -243,6 +287,7 @@ type ListIngestionJobsRow struct {
 	InitialErrorCount pgtype.Int4        `json:"initial_error_count"`
 	ResolvedRowsCount pgtype.Int4        `json:"resolved_rows_count"`
 	TotalRows         pgtype.Int4        `json:"total_rows"`
+	Degraded          bool               `json:"degraded"`
 }
 
 // Lists ingestion jobs with pagination support
@@ -270,6 +315,7 @@ func (q *Queries) ListIngestionJobs(ctx context.Context, arg ListIngestionJobsPa
 			&i.InitialErrorCount,
 			&i.ResolvedRowsCount,
 			&i.TotalRows,
+			&i.Degraded,
 		); err != nil {
 			return nil, err
 		}
@@ -281,6 +327,20 @@ func (q *Queries) ListIngestionJobs(ctx context.Context, arg ListIngestionJobsPa
 	return items, nil
 }
 
+const markIngestionJobDegraded = `-- name: MarkIngestionJobDegraded :exec
+UPDATE ingestion_jobs
+SET
+	degraded = TRUE
+WHERE
+	id = $1
+`
+
+// Flags a job whose triage rate exceeded its report type's alert threshold
+func (q *Queries) MarkIngestionJobDegraded(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markIngestionJobDegraded, id)
+	return err
+}
+
 const updateIngestionErrorWithCorrection = `-- name: UpdateIngestionErrorWithCorrection :one
 UPDATE ingestion_errors
 SET
@@ -100,6 +100,57 @@ func (q *Queries) GetUserByAuthProviderSubject(ctx context.Context, authProvider
 	return i, err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, auth_provider_subject, email, display_name, is_active, is_admin, updated_at, created_at FROM "users" WHERE id = $1
+`
+
+// Fetch a single user by their internal ID
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.AuthProviderSubject,
+		&i.Email,
+		&i.DisplayName,
+		&i.IsActive,
+		&i.IsAdmin,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserItemScope = `-- name: GetUserItemScope :one
+SELECT
+	(u.is_admin OR EXISTS (
+		SELECT 1
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = u.id AND p.action = 'items:view_all'
+	))::BOOLEAN AS view_all,
+	ARRAY(
+		SELECT usa.scope::TEXT FROM user_scope_access usa WHERE usa.user_id = u.id ORDER BY usa.scope
+	)::TEXT[] AS scopes
+FROM users u
+WHERE u.id = $1
+`
+
+type GetUserItemScopeRow struct {
+	ViewAll bool     `json:"view_all"`
+	Scopes  []string `json:"scopes"`
+}
+
+// Resolves which items a user may view: every scope for admins and holders of items:view_all,
+// otherwise the scopes granted in user_scope_access
+func (q *Queries) GetUserItemScope(ctx context.Context, id int64) (GetUserItemScopeRow, error) {
+	row := q.db.QueryRow(ctx, getUserItemScope, id)
+	var i GetUserItemScopeRow
+	err := row.Scan(&i.ViewAll, &i.Scopes)
+	return i, err
+}
+
 const listRoles = `-- name: ListRoles :many
 SELECT id, name, description FROM "roles" ORDER BY id
 `
@@ -239,3 +290,29 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 	)
 	return i, err
 }
+
+const userHasPermission = `-- name: UserHasPermission :one
+SELECT
+	(u.is_admin OR EXISTS (
+		SELECT 1
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = u.id AND p.action = $1
+	))::BOOLEAN AS has_permission
+FROM users u
+WHERE u.id = $2
+`
+
+type UserHasPermissionParams struct {
+	Action string `json:"action"`
+	UserID int64  `json:"user_id"`
+}
+
+// Reports whether a user is an admin or holds the given permission through one of their roles
+func (q *Queries) UserHasPermission(ctx context.Context, arg UserHasPermissionParams) (bool, error) {
+	row := q.db.QueryRow(ctx, userHasPermission, arg.Action, arg.UserID)
+	var has_permission bool
+	err := row.Scan(&has_permission)
+	return has_permission, err
+}
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Comments are soft deleted so the audit trail in items_events keeps pointing at real rows.
+ALTER TABLE "comments" ADD COLUMN "deleted_at" TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "deleted_at";
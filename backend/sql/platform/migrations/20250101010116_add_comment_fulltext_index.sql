@@ -0,0 +1,6 @@
+-- +goose Up
+-- Supports keyword search over comments (e.g. ticket numbers) alongside the vector search.
+CREATE INDEX IF NOT EXISTS "idx_comments_comment_fts" ON "comments" USING GIN (to_tsvector('english', "comment"));
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_comments_comment_fts";
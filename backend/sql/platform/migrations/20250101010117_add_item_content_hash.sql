@@ -0,0 +1,7 @@
+-- +goose Up
+-- jsonb renders with sorted keys and canonical whitespace, so hashing its text form gives a
+-- stable fingerprint of the properties regardless of how they were written.
+ALTER TABLE "items" ADD COLUMN "content_hash" TEXT GENERATED ALWAYS AS (md5("custom_properties"::text)) STORED;
+
+-- +goose Down
+ALTER TABLE "items" DROP COLUMN IF EXISTS "content_hash";
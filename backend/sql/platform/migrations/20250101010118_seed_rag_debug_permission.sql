@@ -0,0 +1,12 @@
+-- +goose Up
+-- Lets prompt engineers preview RAG planner output without running tools.
+INSERT INTO "permissions" (action, description) VALUES
+('rag:debug', 'Ability to preview RAG query plans without executing tools.');
+
+INSERT INTO "role_permissions" (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('super_admin', 'admin') AND p.action = 'rag:debug';
+
+-- +goose Down
+DELETE FROM "role_permissions" WHERE permission_id = (SELECT id FROM permissions WHERE action = 'rag:debug');
+DELETE FROM "permissions" WHERE action = 'rag:debug';
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Persist each RAG query turn with the context retrieved for it, so past conversations can be
+-- replayed faithfully against new synthesizer templates.
+CREATE TABLE "conversation_turns" (
+	"id" BIGSERIAL PRIMARY KEY,
+	"conversation_id" UUID NOT NULL,
+	"rag_context" VARCHAR(100) NOT NULL,
+	"user_id" BIGINT REFERENCES "users"("id"),
+	"question" TEXT NOT NULL,
+	"history" JSONB NOT NULL DEFAULT '[]',
+	"retrieved_context" JSONB NOT NULL,
+	"answer" JSONB NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "idx_conversation_turns_conversation" ON "conversation_turns" ("conversation_id", "id");
+
+-- +goose Down
+DROP TABLE IF EXISTS "conversation_turns";
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Aggregate counters for each processed ingestion job, kept so ingestion quality (e.g. triage
+-- rate) can be tracked per report type over time.
+CREATE TABLE "ingestion_job_stats" (
+	"job_id" UUID PRIMARY KEY REFERENCES "ingestion_jobs"("id") ON DELETE CASCADE,
+	"report_type" TEXT NOT NULL,
+	"status" VARCHAR(50) NOT NULL,
+	"rows_total" INTEGER NOT NULL,
+	"rows_upserted" INTEGER NOT NULL,
+	"rows_triaged" INTEGER NOT NULL,
+	"rows_blank" INTEGER NOT NULL,
+	"duration_ms" BIGINT NOT NULL,
+	"recorded_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN "ingestion_job_stats"."rows_total" IS 'Every data row read from the file: upserted, triaged and blank rows.';
+COMMENT ON COLUMN "ingestion_job_stats"."rows_upserted" IS 'Rows saved to items, including rows that were unchanged in a delta ingestion.';
+
+CREATE INDEX "idx_ingestion_job_stats_report_type" ON "ingestion_job_stats" ("report_type", "recorded_at");
+
+-- +goose Down
+DROP TABLE IF EXISTS "ingestion_job_stats";
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE "ingestion_jobs" ADD COLUMN "degraded" BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN "ingestion_jobs"."degraded" IS 'Set when the share of rows sent to triage exceeded the report type''s alert threshold.';
+
+-- +goose Down
+ALTER TABLE "ingestion_jobs" DROP COLUMN IF EXISTS "degraded";
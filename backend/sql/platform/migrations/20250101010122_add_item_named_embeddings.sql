@@ -0,0 +1,14 @@
+-- +goose Up
+-- Named embeddings let documents be searched by title and body separately. The unnamed
+-- "embedding" column keeps holding the combined embedding built from embed_content.source_columns.
+ALTER TABLE "items" ADD COLUMN "title_embedding" vector(384);
+ALTER TABLE "items" ADD COLUMN "body_embedding" vector(384);
+
+CREATE INDEX idx_items_title_embedding ON items USING HNSW (title_embedding vector_cosine_ops);
+CREATE INDEX idx_items_body_embedding ON items USING HNSW (body_embedding vector_cosine_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_items_body_embedding;
+DROP INDEX IF EXISTS idx_items_title_embedding;
+ALTER TABLE "items" DROP COLUMN IF EXISTS "body_embedding";
+ALTER TABLE "items" DROP COLUMN IF EXISTS "title_embedding";
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Search can rank by cosine (<=>), L2 (<->) or inner product (<#>) distance. An HNSW index only
+-- serves the operator its opclass was built for, so the embeddings that support every metric get
+-- an index per opclass. The named title/body embeddings are cosine-only.
+CREATE INDEX idx_items_embedding_l2 ON items USING HNSW (embedding vector_l2_ops);
+CREATE INDEX idx_items_embedding_ip ON items USING HNSW (embedding vector_ip_ops);
+CREATE INDEX idx_comments_embedding_l2 ON comments USING HNSW (embedding vector_l2_ops);
+CREATE INDEX idx_comments_embedding_ip ON comments USING HNSW (embedding vector_ip_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_embedding_ip;
+DROP INDEX IF EXISTS idx_comments_embedding_l2;
+DROP INDEX IF EXISTS idx_items_embedding_ip;
+DROP INDEX IF EXISTS idx_items_embedding_l2;
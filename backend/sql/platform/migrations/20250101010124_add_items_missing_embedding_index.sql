@@ -0,0 +1,7 @@
+-- +goose Up
+-- The embedding backfill pages through items without an embedding in id order. A partial index keeps
+-- each page cheap while millions of items are still unembedded, and shrinks as they are filled in.
+CREATE INDEX idx_items_missing_embedding ON items (item_type, id) WHERE embedding IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_items_missing_embedding;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Gates the /admin endpoints: runtime log level, config status, ingestion pauses, conversation
+-- replay and template reloads.
+INSERT INTO "permissions" (action, description) VALUES
+('admin:access', 'Ability to use the administrative endpoints.');
+
+INSERT INTO "role_permissions" (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('super_admin', 'admin') AND p.action = 'admin:access';
+
+-- +goose Down
+DELETE FROM "role_permissions" WHERE permission_id = (SELECT id FROM permissions WHERE action = 'admin:access');
+DELETE FROM "permissions" WHERE action = 'admin:access';
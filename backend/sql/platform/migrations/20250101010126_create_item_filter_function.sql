@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Reports whether an item's custom_properties match one condition of an item filter, as built by
+-- the API's filter parser. ListItemsInScope and CountItemsInScope share it so they can't drift.
+-- A numeric filter value compares numerically with number properties and with string properties
+-- holding a plain decimal, since ingestion stores many numeric columns as text.
+
+CREATE OR REPLACE FUNCTION item_filter_matches(
+	properties JSONB,
+	filter_field TEXT,
+	filter_op TEXT,
+	filter_value TEXT,
+	filter_values TEXT[],
+	filter_number NUMERIC
+) RETURNS BOOLEAN AS $$
+	SELECT COALESCE(CASE
+		WHEN filter_number IS NOT NULL AND filter_op IN ('=', '!=', '>', '<') AND (
+			jsonb_typeof(properties->filter_field) = 'number'
+			OR (jsonb_typeof(properties->filter_field) = 'string'
+				AND properties->>filter_field ~ '^\s*[+-]?(\d+\.?\d*|\.\d+)\s*$')
+		) THEN CASE filter_op
+			WHEN '=' THEN (properties->>filter_field)::NUMERIC = filter_number
+			WHEN '!=' THEN (properties->>filter_field)::NUMERIC <> filter_number
+			WHEN '>' THEN (properties->>filter_field)::NUMERIC > filter_number
+			WHEN '<' THEN (properties->>filter_field)::NUMERIC < filter_number
+		END
+		ELSE CASE filter_op
+			WHEN '=' THEN properties->>filter_field = filter_value
+			WHEN '!=' THEN properties->>filter_field IS DISTINCT FROM filter_value
+			WHEN '>' THEN filter_number IS NULL AND properties->>filter_field > filter_value
+			WHEN '<' THEN filter_number IS NULL AND properties->>filter_field < filter_value
+			WHEN 'in' THEN properties->>filter_field = ANY(filter_values)
+			WHEN 'contains' THEN CASE jsonb_typeof(properties->filter_field)
+				WHEN 'array' THEN properties->filter_field @> jsonb_build_array(filter_value)
+					OR (filter_number IS NOT NULL AND properties->filter_field @> jsonb_build_array(filter_number))
+				WHEN 'string' THEN strpos(properties->>filter_field, filter_value) > 0
+			END
+		END
+	END, FALSE)
+$$
+LANGUAGE sql
+IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS item_filter_matches(JSONB, TEXT, TEXT, TEXT, TEXT[], NUMERIC);
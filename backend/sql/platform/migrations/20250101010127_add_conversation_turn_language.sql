@@ -0,0 +1,7 @@
+-- +goose Up
+-- Store the language each turn was answered in, so a replay answers in the same language.
+-- Turns recorded before this were all answered in the default language.
+ALTER TABLE "conversation_turns" ADD COLUMN "language" VARCHAR(50) NOT NULL DEFAULT 'English';
+
+-- +goose Down
+ALTER TABLE "conversation_turns" DROP COLUMN IF EXISTS "language";
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Store the LLM usage of each turn, so the tokens and cost of a conversation can be totalled.
+-- Turns recorded before this count as free.
+ALTER TABLE "conversation_turns"
+	ADD COLUMN "llm_calls" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "prompt_tokens" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "completion_tokens" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "total_tokens" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "estimated_cost_usd" DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE "conversation_turns"
+	DROP COLUMN IF EXISTS "llm_calls",
+	DROP COLUMN IF EXISTS "prompt_tokens",
+	DROP COLUMN IF EXISTS "completion_tokens",
+	DROP COLUMN IF EXISTS "total_tokens",
+	DROP COLUMN IF EXISTS "estimated_cost_usd";
@@ -18,6 +18,8 @@ INSERT INTO comment_mentions (
 ) ON CONFLICT DO NOTHING;
 
 -- name: ListCommentsForItem :many
+-- Lists the live comments on an item, newest first, one page at a time. A page after the first
+-- starts below before_id, the oldest comment of the page before it
 SELECT
 	c.id,
 	c.comment,
@@ -35,9 +37,24 @@ FROM
 JOIN
 	users u ON c.user_id = u.id
 WHERE
-	c.item_id = $1
+	c.item_id = @item_id
+	AND c.deleted_at IS NULL
+	AND (
+		sqlc.narg('before_id')::BIGINT IS NULL
+		OR (c.created_at, c.id) < (SELECT b.created_at, b.id FROM comments b WHERE b.id = sqlc.narg('before_id'))
+	)
 ORDER BY
-	c.created_at ASC;
+	c.created_at DESC, c.id DESC
+LIMIT @page_limit;
+
+
+-- name: CountCommentsForItem :one
+-- Counts the live comments on an item, for paginating ListCommentsForItem
+SELECT COUNT(*)
+FROM comments
+WHERE
+	item_id = $1
+	AND deleted_at IS NULL;
 
 
 -- name: SetCommentEmbedding :exec
@@ -48,3 +65,40 @@ SET
 WHERE
 	id = $1;
 
+
+-- name: GetCommentAuthor :one
+-- Returns the author of a live comment, so edits and deletes can be limited to them
+SELECT user_id
+FROM comments
+WHERE
+	id = $1 AND item_id = $2 AND deleted_at IS NULL;
+
+
+-- name: UpdateComment :one
+-- Replaces the text of a live comment by its author and returns the previous text for the audit trail.
+-- The embedding of the old text is cleared so search never matches it against the new text
+WITH previous AS (
+	SELECT id, comment
+	FROM comments
+	WHERE id = $1 AND item_id = $2 AND user_id = $4 AND deleted_at IS NULL
+	FOR UPDATE
+)
+UPDATE comments c
+SET
+	comment = $3,
+	embedding = NULL,
+	updated_at = NOW()
+FROM previous p
+WHERE
+	c.id = p.id
+RETURNING c.id, c.item_id, c.comment, c.user_id, c.created_at, c.updated_at, p.comment AS previous_comment;
+
+
+-- name: SoftDeleteComment :execrows
+-- Hides a comment by its author from listings and search while keeping the row for the audit trail
+UPDATE comments
+SET
+	deleted_at = NOW(),
+	updated_at = NOW()
+WHERE
+	id = $1 AND item_id = $2 AND user_id = $3 AND deleted_at IS NULL;
@@ -0,0 +1,45 @@
+-- name: CreateConversationTurn :one
+-- Records one answered RAG query together with the context it was answered from and its LLM usage
+INSERT INTO conversation_turns (
+	conversation_id,
+	rag_context,
+	user_id,
+	question,
+	history,
+	retrieved_context,
+	answer,
+	language,
+	llm_calls,
+	prompt_tokens,
+	completion_tokens,
+	total_tokens,
+	estimated_cost_usd
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+)
+RETURNING *;
+
+-- name: GetConversationOwner :one
+-- Returns the user who started a conversation, so only they can continue it
+SELECT user_id FROM conversation_turns
+WHERE conversation_id = $1
+ORDER BY id
+LIMIT 1;
+
+-- name: GetConversationUsage :one
+-- Totals the LLM usage of every turn of a conversation
+SELECT
+	COUNT(*) AS turns,
+	COALESCE(SUM(llm_calls), 0)::INTEGER AS llm_calls,
+	COALESCE(SUM(prompt_tokens), 0)::INTEGER AS prompt_tokens,
+	COALESCE(SUM(completion_tokens), 0)::INTEGER AS completion_tokens,
+	COALESCE(SUM(total_tokens), 0)::INTEGER AS total_tokens,
+	COALESCE(SUM(estimated_cost_usd), 0)::FLOAT8 AS estimated_cost_usd
+FROM conversation_turns
+WHERE conversation_id = $1;
+
+-- name: ListConversationTurns :many
+-- Lists the turns of a conversation in the order they were asked
+SELECT * FROM conversation_turns
+WHERE conversation_id = $1
+ORDER BY id;
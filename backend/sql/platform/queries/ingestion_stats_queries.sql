@@ -0,0 +1,44 @@
+-- name: CreateIngestionJobStats :exec
+-- Records the aggregate counters of a processed ingestion job
+INSERT INTO ingestion_job_stats (
+	job_id,
+	report_type,
+	status,
+	rows_total,
+	rows_upserted,
+	rows_triaged,
+	rows_blank,
+	duration_ms
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (job_id) DO UPDATE SET
+	status = EXCLUDED.status,
+	rows_total = EXCLUDED.rows_total,
+	rows_upserted = EXCLUDED.rows_upserted,
+	rows_triaged = EXCLUDED.rows_triaged,
+	rows_blank = EXCLUDED.rows_blank,
+	duration_ms = EXCLUDED.duration_ms,
+	recorded_at = NOW();
+
+-- name: ListIngestionJobStats :many
+-- Lists job stats recorded since a point in time, optionally for one report type,
+-- ordered as a time series per report type
+SELECT
+	job_id,
+	report_type,
+	status,
+	rows_total,
+	rows_upserted,
+	rows_triaged,
+	rows_blank,
+	duration_ms,
+	recorded_at,
+	(CASE WHEN rows_total > 0 THEN rows_triaged::FLOAT8 / rows_total ELSE 0 END)::FLOAT8 AS triage_rate
+FROM
+	ingestion_job_stats
+WHERE
+	recorded_at >= sqlc.arg(since)
+	AND (sqlc.narg(report_type)::TEXT IS NULL OR report_type = sqlc.narg(report_type)::TEXT)
+ORDER BY
+	report_type, recorded_at;
@@ -25,29 +25,79 @@ INSERT INTO items_events (
 )
 RETURNING *;
 
+-- name: ArchiveMissingItems :execrows
+-- Archives active items of the type that are absent from the staging table, for delta
+-- ingestion of files that carry the full current set of records
+UPDATE items SET status = 'archived', updated_at = NOW()
+WHERE items.item_type = $1
+AND items.status = 'active'
+AND NOT EXISTS (
+	SELECT 1 FROM temp_items_staging s
+	WHERE s.item_type = items.item_type AND s.business_key = items.business_key
+);
+
 -- name: DeactivateItemsBySource :exec
 UPDATE items SET status = 'inactive'
 WHERE item_type= $1 AND custom_properties->>'reporting_source' = $2;
 
--- name: UpsertItems :execrows
---Insert new records from staging, or update existing ones based on business key
-INSERT INTO items (
-	item_type, scope, business_key, status, custom_properties, embedding
+-- name: ArchiveStaleDocumentItems :execrows
+-- Archives items for documents present in the staging table that were not re-staged,
+-- so re-ingesting a document replaces its previous chunks. They are archived rather than
+-- deleted because their items_events rows must keep pointing at them
+UPDATE items SET status = 'archived', updated_at = NOW()
+WHERE items.item_type = $1
+AND items.status = 'active'
+AND items.custom_properties->>'metadata.document_id' IN (
+	SELECT custom_properties->>'metadata.document_id' FROM temp_items_staging
+)
+AND NOT EXISTS (
+	SELECT 1 FROM temp_items_staging s
+	WHERE s.item_type = items.item_type AND s.business_key = items.business_key
+);
+
+-- name: DiscardUnchangedStagedItems :execrows
+-- Drops staged rows whose content already matches the stored active item, so a delta
+-- ingestion only upserts new and changed rows. Upserts merge properties, so a row is
+-- unchanged when merging it would leave the stored content hash as it is
+DELETE FROM temp_items_staging s
+USING items i
+WHERE i.item_type = s.item_type
+AND i.business_key = s.business_key
+AND i.status = 'active'
+AND i.scope IS NOT DISTINCT FROM s.scope
+AND i.content_hash = md5((i.custom_properties || s.custom_properties)::text);
+
+-- name: UpsertItems :one
+--Insert new records from staging, or update existing ones based on business key.
+--Returns how many rows were inserted vs updated (xmax is 0 only for freshly inserted rows)
+WITH upserted AS (
+	INSERT INTO items (
+		item_type, scope, business_key, status, custom_properties, embedding, title_embedding, body_embedding
+	)
+	SELECT
+		item_type,
+		scope,
+		business_key,
+		'active',
+		custom_properties,
+		embedding,
+		title_embedding,
+		body_embedding
+	FROM temp_items_staging
+	ON CONFLICT (item_type, business_key) DO UPDATE SET
+		status = EXCLUDED.status,
+		scope = EXCLUDED.scope,
+		custom_properties = items.custom_properties || EXCLUDED.custom_properties,
+		embedding = EXCLUDED.embedding,
+		title_embedding = EXCLUDED.title_embedding,
+		body_embedding = EXCLUDED.body_embedding,
+		updated_at = NOW()
+	RETURNING (xmax = 0) AS inserted
 )
 SELECT
-	item_type,
-	scope, 
-	business_key,
-	'active',
-	custom_properties,
-	embedding
-FROM temp_items_staging
-ON CONFLICT (item_type, business_key) DO UPDATE SET 
-	status = EXCLUDED.status,
-	scope = EXCLUDED.scope,
-	custom_properties = items.custom_properties || EXCLUDED.custom_properties,
-	embedding = EXCLUDED.embedding,
-	updated_at = NOW();
+	COUNT(*) FILTER (WHERE inserted)::bigint AS inserted_count,
+	COUNT(*) FILTER (WHERE NOT inserted)::bigint AS updated_count
+FROM upserted;
 
 
 -- name: GetEventsForItem :many
@@ -57,9 +107,10 @@ WHERE item_id = $1
 ORDER BY created_at DESC;
 
 -- name: GetItemForUpdate :one
--- Fetch a single item for update
+-- Fetch a single item for update, locking its row until the transaction ends
 SELECT * FROM "items"
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
 
 -- name: UpdateItem :one
 -- Updates the mutable fields of a specific item
@@ -73,4 +124,139 @@ WHERE
 	id = $1
 RETURNING *;
 
+-- name: ClearItemEmbeddings :one
+-- Clears the embeddings of an item whose content changed, so they are no longer matched against
+-- the new content and the embedding backfill picks the item up again
+UPDATE items
+SET
+	embedding = NULL,
+	title_embedding = NULL,
+	body_embedding = NULL
+WHERE
+	id = $1
+RETURNING *;
+
+
+
+-- name: GetItemInScope :one
+-- Fetch a single item if it falls within the caller's scopes; view_all skips the scope check.
+-- A scope also covers the composite scopes nested under it, e.g. WEST covers WEST/BR01.
+SELECT id, item_type, scope, business_key, status, custom_properties, created_at, updated_at, content_hash
+FROM items
+WHERE
+	id = sqlc.arg(id)
+	AND (sqlc.arg(view_all)::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest(sqlc.arg(scopes)::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	));
+
+-- name: ListItemsInScope :many
+-- Lists items of one type within the caller's scopes, most recently updated first.
+-- filters is a JSON array of {field, op, value, values, number} conditions on custom_properties,
+-- built by the API's filter parser; an item must match all of them. number is set for numeric
+-- filter values so numeric properties, and string properties holding a number, compare as numbers.
+-- Each condition is evaluated by the item_filter_matches SQL function
+SELECT id, item_type, scope, business_key, status, custom_properties, created_at, updated_at, content_hash
+FROM items
+WHERE
+	item_type::TEXT = sqlc.arg(item_type)::TEXT
+	AND (sqlc.arg(view_all)::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest(sqlc.arg(scopes)::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	))
+	AND NOT EXISTS (
+		SELECT 1
+		FROM jsonb_to_recordset(sqlc.arg(filters)::JSONB) AS f(field TEXT, op TEXT, value TEXT, "values" TEXT[], number NUMERIC)
+		WHERE NOT item_filter_matches(items.custom_properties, f.field, f.op, f.value, f."values", f.number)
+	)
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
 
+-- name: CountItemsInScope :one
+-- Counts the items ListItemsInScope pages through, with the same filters
+SELECT COUNT(*)
+FROM items
+WHERE
+	item_type::TEXT = sqlc.arg(item_type)::TEXT
+	AND (sqlc.arg(view_all)::BOOLEAN OR EXISTS (
+		SELECT 1 FROM unnest(sqlc.arg(scopes)::TEXT[]) AS s(scope)
+		WHERE items.scope = s.scope OR starts_with(items.scope, s.scope || '/')
+	))
+	AND NOT EXISTS (
+		SELECT 1
+		FROM jsonb_to_recordset(sqlc.arg(filters)::JSONB) AS f(field TEXT, op TEXT, value TEXT, "values" TEXT[], number NUMERIC)
+		WHERE NOT item_filter_matches(items.custom_properties, f.field, f.op, f.value, f."values", f.number)
+	);
+
+-- name: ListItemsMissingEmbedding :many
+-- Lists items of one type that have no embedding, in id order after after_id, so an embedding
+-- backfill can resume from the last item a previous run processed. with_title and with_body also
+-- list items missing that named embedding: delta ingestion skips unchanged rows, so items ingested
+-- before the named embedding was configured never get one otherwise
+SELECT id, custom_properties
+FROM items
+WHERE item_type = @item_type AND id > @after_id
+	AND (
+		embedding IS NULL
+		OR (@with_title::BOOLEAN AND title_embedding IS NULL)
+		OR (@with_body::BOOLEAN AND body_embedding IS NULL)
+	)
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: SetItemEmbeddings :exec
+-- Stores embeddings generated after ingestion. The named embeddings are passed as vector text so
+-- an empty vector, from a field with no text to embed, is stored as NULL
+UPDATE items
+SET
+	embedding = @embedding,
+	title_embedding = NULLIF(@title_embedding::TEXT, '[]')::vector,
+	body_embedding = NULLIF(@body_embedding::TEXT, '[]')::vector
+WHERE
+	id = @id;
+
+-- name: AddItemPropertyArrayValue :one
+-- Appends value to the custom_properties array under key in a single statement, creating the array
+-- when the key is absent. A value already in the array isn't added again, so retried requests don't
+-- duplicate it. Matches no row when the key holds something other than an array
+UPDATE items
+SET
+	custom_properties = jsonb_set(
+		custom_properties,
+		ARRAY[@key::TEXT],
+		CASE
+			WHEN EXISTS (
+				SELECT 1 FROM jsonb_array_elements(COALESCE(custom_properties->@key::TEXT, '[]'::JSONB)) AS e(value)
+				WHERE e.value = @value::JSONB
+			) THEN custom_properties->@key::TEXT
+			ELSE COALESCE(custom_properties->@key::TEXT, '[]'::JSONB) || jsonb_build_array(@value::JSONB)
+		END
+	),
+	updated_at = NOW()
+WHERE
+	id = @id
+	AND jsonb_typeof(COALESCE(custom_properties->@key::TEXT, '[]'::JSONB)) = 'array'
+RETURNING *;
+
+-- name: RemoveItemPropertyArrayValue :one
+-- Removes every element equal to value from the custom_properties array under key in a single
+-- statement, leaving an absent key absent. Matches no row when the key holds something other than an array
+UPDATE items
+SET
+	custom_properties = CASE
+		WHEN custom_properties->@key::TEXT IS NULL THEN custom_properties
+		ELSE jsonb_set(
+			custom_properties,
+			ARRAY[@key::TEXT],
+			COALESCE((
+				SELECT jsonb_agg(e.value ORDER BY e.position)
+				FROM jsonb_array_elements(custom_properties->@key::TEXT) WITH ORDINALITY AS e(value, position)
+				WHERE e.value <> @value::JSONB
+			), '[]'::JSONB)
+		)
+	END,
+	updated_at = NOW()
+WHERE
+	id = @id
+	AND jsonb_typeof(COALESCE(custom_properties->@key::TEXT, '[]'::JSONB)) = 'array'
+RETURNING *;
@@ -46,6 +46,14 @@ SET
 WHERE
 	id = $1;
 
+-- name: MarkIngestionJobDegraded :exec
+-- Flags a job whose triage rate exceeded its report type's alert threshold
+UPDATE ingestion_jobs
+SET
+	degraded = TRUE
+WHERE
+	id = $1;
+
 -- name: IncrementIngestionJobResolvedRows :exec
 UPDATE ingestion_jobs
 SET
@@ -53,6 +61,11 @@ SET
 WHERE
 	id = (SELECT job_id FROM ingestion_errors WHERE ingestion_errors.id = $1);
 
+-- name: CountIngestionJobs :one
+-- Counts ingestion jobs, for paginating ListIngestionJobs
+SELECT COUNT(*)
+FROM ingestion_jobs;
+
 -- name: ListIngestionJobs :many
 -- Lists ingestion jobs with pagination support
 SELECT 
@@ -69,7 +82,8 @@ SELECT
 	processed_rows,
 	initial_error_count,
 	resolved_rows_count,
-	total_rows
+	total_rows,
+	degraded
 FROM 
 	ingestion_jobs
 ORDER BY 
@@ -95,6 +109,11 @@ WHERE
 ORDER BY
 	"timestamp" ASC;
 
+-- name: GetIngestionJob :one
+-- Fetches a single ingestion job by ID
+SELECT * FROM ingestion_jobs
+WHERE id = $1;
+
 -- name: UpdateIngestionErrorWithCorrection :one
 UPDATE ingestion_errors
 SET
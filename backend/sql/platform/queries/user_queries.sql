@@ -57,6 +57,27 @@ RETURNING *;
 -- Fetch a single user by their external auth provider ID
 SELECT * FROM "users" WHERE auth_provider_subject = $1;
 
+-- name: GetUserByID :one
+-- Fetch a single user by their internal ID
+SELECT * FROM "users" WHERE id = $1;
+
+-- name: GetUserItemScope :one
+-- Resolves which items a user may view: every scope for admins and holders of items:view_all,
+-- otherwise the scopes granted in user_scope_access
+SELECT
+	(u.is_admin OR EXISTS (
+		SELECT 1
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = u.id AND p.action = 'items:view_all'
+	))::BOOLEAN AS view_all,
+	ARRAY(
+		SELECT usa.scope::TEXT FROM user_scope_access usa WHERE usa.user_id = u.id ORDER BY usa.scope
+	)::TEXT[] AS scopes
+FROM users u
+WHERE u.id = $1;
+
 -- name: UpdateUser :one
 -- Updates a user's mutable details
 UPDATE "users"
@@ -68,3 +89,16 @@ WHERE
 RETURNING *;
 
 
+
+-- name: UserHasPermission :one
+-- Reports whether a user is an admin or holds the given permission through one of their roles
+SELECT
+	(u.is_admin OR EXISTS (
+		SELECT 1
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = u.id AND p.action = sqlc.arg(action)
+	))::BOOLEAN AS has_permission
+FROM users u
+WHERE u.id = sqlc.arg(user_id);
@@ -6,10 +6,12 @@ import { apiClient } from '@/lib/api';
 
 interface StatusHistoryEvent {
   ID: number;
+  event_type: 'CLAIM_STATUS_CHANGED' | 'CLAIM_STATUS_TRANSITION_REJECTED';
   event_timestamp: string;
   event_data: {
     old_status: string;
-    new_status: string;
+    new_status?: string;
+    attempted_status?: string;
   };
   user_name: string;
 }
@@ -58,9 +60,15 @@ export function StatusHistory({ id }: StatusHistoryProps) {
     <div className="space-y-4">
       {history.map((event) => (
         <div key={event.ID} className="p-3 border rounded-md text-sm">
-          <p>
-            Status changed from <strong>{event.event_data.old_status || 'N/A'}</strong> to <strong>{event.event_data.new_status}</strong>
-          </p>
+          {event.event_type === 'CLAIM_STATUS_TRANSITION_REJECTED' ? (
+            <p>
+              Rejected change from <strong>{event.event_data.old_status || 'N/A'}</strong> to <strong>{event.event_data.attempted_status}</strong>
+            </p>
+          ) : (
+            <p>
+              Status changed from <strong>{event.event_data.old_status || 'N/A'}</strong> to <strong>{event.event_data.new_status}</strong>
+            </p>
+          )}
           <p className="text-sm text-muted-foreground">
             by {event.user_name} on {new Date(event.event_timestamp).toLocaleString()}
           </p>
@@ -23,6 +23,9 @@ export function Comments({ itemId }: CommentsProps) {
   const [comments, setComments] = useState<Comment[]>([]);
   const [newComment, setNewComment] = useState("");
   const [isLoading, setIsLoading] = useState(true);
+  const [totalCount, setTotalCount] = useState(0);
+  const [nextCursor, setNextCursor] = useState<number | null>(null);
+  const [isLoadingOlder, setIsLoadingOlder] = useState(false);
   const { getAccessTokenSilently } = useAuth();
 
   const fetchComments = async () => {
@@ -31,7 +34,9 @@ export function Comments({ itemId }: CommentsProps) {
       const token = await getAccessTokenSilently();
       const url = `/api/insurance/claims/${itemId}/comments`;
       const data = await apiClient.get(url, token);
-      setComments(data || []);
+      setComments(data?.data || []);
+      setTotalCount(data?.total_count || 0);
+      setNextCursor(data?.next_cursor ?? null);
     } catch (error) {
       console.error('Comments: Failed to fetch comments:', error);
     } finally {
@@ -39,6 +44,24 @@ export function Comments({ itemId }: CommentsProps) {
     }
   };
 
+  // Each page comes back oldest first, so older pages go in front of the ones already shown.
+  const fetchOlderComments = async () => {
+    if (nextCursor === null) return;
+    try {
+      setIsLoadingOlder(true);
+      const token = await getAccessTokenSilently();
+      const url = `/api/insurance/claims/${itemId}/comments?before=${nextCursor}`;
+      const data = await apiClient.get(url, token);
+      setComments((current) => [...(data?.data || []), ...current]);
+      setTotalCount(data?.total_count || 0);
+      setNextCursor(data?.next_cursor ?? null);
+    } catch (error) {
+      console.error('Comments: Failed to fetch older comments:', error);
+    } finally {
+      setIsLoadingOlder(false);
+    }
+  };
+
   useEffect(() => {
     if (itemId) {
       fetchComments();
@@ -84,14 +107,21 @@ export function Comments({ itemId }: CommentsProps) {
         {isLoading ? (
           <p className="text-sm text-muted-foreground">Loading comments...</p>
         ) : comments.length > 0 ? (
-          comments.map((comment) => (
-            <div key={comment.id} className="p-3 border rounded-md text-sm">
-              <p>{comment.comment}</p>
-              <p className="text-xs text-muted-foreground mt-2">
-                - {comment.display_name} on {new Date(comment.created_at).toLocaleString()}
-              </p>
-            </div>
-          ))
+          <>
+            {nextCursor !== null && (
+              <Button variant="outline" size="sm" onClick={fetchOlderComments} disabled={isLoadingOlder}>
+                {isLoadingOlder ? "Loading..." : `Load older comments (${totalCount - comments.length} more)`}
+              </Button>
+            )}
+            {comments.map((comment) => (
+              <div key={comment.id} className="p-3 border rounded-md text-sm">
+                <p>{comment.comment}</p>
+                <p className="text-xs text-muted-foreground mt-2">
+                  - {comment.display_name} on {new Date(comment.created_at).toLocaleString()}
+                </p>
+              </div>
+            ))}
+          </>
         ) : (
           <p className="text-sm text-muted-foreground">No comments yet.</p>
         )}
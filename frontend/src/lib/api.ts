@@ -13,6 +13,15 @@ export interface IngestionJob {
   user_id: number | null;
 }
 
+// Paginated list endpoints wrap one page of results with the total number of matches
+export interface PaginatedResponse<T> {
+  total_count: number;
+  data: T[];
+  // The page size this request got, and the most any request to the endpoint can get.
+  limit: number;
+  max_limit: number;
+}
+
 // This type represents a single errored row that needs triage (the detail view)
 export interface IngestionError {
   id: string;
@@ -81,7 +90,7 @@ export const apiClient = {
  /**
    * Fetches a paginated list of all ingestion jobs.
    */
-  getIngestionJobs: async (token: string, limit = 20, offset = 0): Promise<IngestionJob[]> => {
+  getIngestionJobs: async (token: string, limit = 20, offset = 0): Promise<PaginatedResponse<IngestionJob>> => {
     return apiClient.get(`/api/ingestion-jobs?limit=${limit}&offset=${offset}`, token);
   },
 
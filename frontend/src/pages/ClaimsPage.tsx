@@ -19,6 +19,24 @@ interface Message {
   content: string;
 }
 
+// Payload of a render_table action: the first page of claims and the GET /claims query for more.
+interface RenderTablePayload {
+  claims: Claim[];
+  page: number;
+  page_size: number;
+  has_more: boolean;
+  query?: string;
+  next_query?: string;
+}
+
+// Paging state for a table the AI rendered, fetched lazily from GET /claims.
+interface AiTablePaging {
+  query: string;
+  page: number;
+  pageSize: number;
+  hasMore: boolean;
+}
+
 // Type for the AI's response structure
 interface AiResponse {
   answer?: {
@@ -41,6 +59,8 @@ export default function ClaimsPage() {
   const [messages, setMessages] = useState<Message[]>([]);
   const [input, setInput] = useState("");
   const [isAiLoading, setIsAiLoading] = useState(false);
+  const [conversationId, setConversationId] = useState<string | null>(null);
+  const [aiTable, setAiTable] = useState<AiTablePaging | null>(null);
 
   // --- DATA FETCHING AND HANDLERS ---
 
@@ -49,7 +69,7 @@ export default function ClaimsPage() {
     try {
       const token = await getAccessTokenSilently();
       const data = await apiClient.get('/api/insurance/claims', token);
-      setClaims(data || []);
+      setClaims(data?.data || []);
     } catch (error) {
       console.error("Failed to fetch claims:", error);
       toast.error("Failed to fetch claims data.");
@@ -73,6 +93,22 @@ export default function ClaimsPage() {
     }
   };
 
+  const fetchAiTablePage = async (page: number) => {
+    if (!aiTable) return;
+    try {
+      const token = await getAccessTokenSilently();
+      const query = new URLSearchParams(aiTable.query);
+      query.set('page', String(page));
+      query.set('limit', String(aiTable.pageSize));
+      const data = await apiClient.get(`/api/insurance/claims?${query.toString()}`, token);
+      setClaims(data?.data || []);
+      setAiTable({ ...aiTable, page, hasMore: page * aiTable.pageSize < (data?.total_count ?? 0) });
+    } catch (error) {
+      toast.error("Failed to fetch more claims.");
+      console.error("Failed to fetch AI table page:", error);
+    }
+  };
+
   const handleRowClick = (claim: Claim) => {
     fetchClaimDetails(claim.id);
   };
@@ -119,14 +155,23 @@ export default function ClaimsPage() {
         case 'text_response':
           setMessages(prev => [...prev, { sender: 'ai', content: action.payload }]);
           break;
-        case 'render_table':
+        case 'render_table': {
+          const table = action.payload as RenderTablePayload;
           toast.success("AI has updated the claims table for you.");
-          setClaims(action.payload);
+          setClaims(table.claims);
+          // Later pages can only be fetched when the response says how to query them.
+          setAiTable(table.next_query
+            ? { query: table.query ?? '', page: table.page, pageSize: table.page_size, hasMore: table.has_more }
+            : null);
           break;
+        }
         case 'open_detail_drawer':
           toast.success(`AI is showing details for claim ${action.payload.claim_id}`);
           setSelectedClaim(action.payload);
           break;
+        case 'clarify':
+          setMessages(prev => [...prev, { sender: 'ai', content: action.payload }]);
+          break;
         default:
           console.warn("Received unknown AI action type:", action.type);
       }
@@ -151,6 +196,7 @@ export default function ClaimsPage() {
       const requestBody = {
         question: input,
         history: messages, // Send the history *before* the new message
+        ...(conversationId && { conversation_id: conversationId }),
       };
 
       const response = await fetch(`${import.meta.env.VITE_API_BASE_URL}/api/insurance/query`, {
@@ -165,6 +211,7 @@ export default function ClaimsPage() {
       if (!response.ok) { throw new Error(`API Error: ${response.statusText}`); }
       
       const data = await response.json();
+      if (data.conversation_id) { setConversationId(data.conversation_id); }
       handleAiResponse(data);
     } catch (error) {
       toast.error("An error occurred while talking to the AI assistant.");
@@ -228,9 +275,9 @@ export default function ClaimsPage() {
           data={claims}
           title="General Securities Assurance - Policy Claims"
           description="Browse and manage all insurance claims."
-          page={1}
-          setPage={() => {}}
-          hasMore={false}
+          page={aiTable?.page ?? 1}
+          setPage={fetchAiTablePage}
+          hasMore={aiTable?.hasMore ?? false}
           onRowClick={handleRowClick}
         />
       </div>
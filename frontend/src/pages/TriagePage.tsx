@@ -20,7 +20,7 @@ export default function TriagePage() {
     try {
       const token = await getAccessTokenSilently();
       const data = await apiClient.getIngestionJobs(token);
-      setJobs(data || []);
+      setJobs(data?.data || []);
     } catch (error) {
       console.error("Failed to fetch ingestion jobs:", error);
       toast.error("Failed to fetch ingestion jobs.");